@@ -4,36 +4,57 @@
 //
 // When the user runs a shimmed command, this binary:
 //  1. Logs the execution to ~/.brewprune/usage.log (best-effort, non-blocking)
-//  2. Execs the real binary at the stable brew prefix path, replacing this process
+//  2. Execs the real binary in the command's Homebrew prefix, replacing this process
 //
 // The shim must NOT import any internal brewprune packages — it is a standalone
 // binary compiled and deployed separately from the main CLI.
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
+
+	"github.com/mattn/go-isatty"
 )
 
 // shimVersion is set at build time via -ldflags "-X main.shimVersion=x.y.z"
 var shimVersion = "dev"
 
+// shimIndexHeader starts the shim index written by brewprune scan; the number
+// is the format version. Keep in sync with internal/shim/index.go.
+const shimIndexHeader = "brewprune-shim-index 1\t"
+
 func main() {
 	// Determine which command was invoked via the symlink name.
 	cmdName := filepath.Base(os.Args[0])
 
+	// Fast path: ~/.brewprune/shim.index holds the real binary of every
+	// shimmed command and the expected shim version, so one read replaces
+	// the version file read and the prefix search below.
+	var index []byte
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		index, _ = os.ReadFile(filepath.Join(homeDir, ".brewprune", "shim.index"))
+	}
+
 	// Warn if this shim binary is stale relative to the installed brewprune version.
 	// Best-effort: failures are silently ignored so the user's command always proceeds.
-	checkShimVersion()
+	checkShimVersion(index)
 
 	// Log execution to ~/.brewprune/usage.log (best-effort: never fail the user's command).
-	logExecution(cmdName)
+	logExecution(cmdName, shimIndexOption(index, "cwd"))
 
-	// Find the real binary at the stable brew prefix.
+	// Replace this process with the indexed binary. An entry gone stale since
+	// the last scan fails to exec and falls through to the full search.
+	if realBin := lookupShimIndex(index, cmdName); realBin != "" {
+		syscall.Exec(realBin, os.Args, os.Environ()) //nolint:errcheck
+	}
+
+	// Find the real binary in the command's Homebrew prefix.
 	realBin := findRealBinary(cmdName)
 	if realBin == "" {
 		fmt.Fprintf(os.Stderr, "brewprune-shim: cannot find real binary for %q in Homebrew prefix\n", cmdName)
@@ -47,27 +68,119 @@ func main() {
 	}
 }
 
+// shimIndexVersion returns the expected shim version recorded in the header
+// of a shim index, and false when index is empty or not a known format.
+func shimIndexVersion(index []byte) (string, bool) {
+	fields, ok := shimIndexHeaderFields(index)
+	if !ok {
+		return "", false
+	}
+	if tab := bytes.IndexByte(fields, '\t'); tab >= 0 {
+		fields = fields[:tab]
+	}
+	return string(fields), true
+}
+
+// shimIndexOption reports whether option is in the comma-separated option
+// list that follows the version in the shim index header.
+func shimIndexOption(index []byte, option string) bool {
+	fields, ok := shimIndexHeaderFields(index)
+	if !ok {
+		return false
+	}
+	tab := bytes.IndexByte(fields, '\t')
+	if tab < 0 {
+		return false
+	}
+	for _, opt := range bytes.Split(fields[tab+1:], []byte(",")) {
+		if string(opt) == option {
+			return true
+		}
+	}
+	return false
+}
+
+// shimIndexHeaderFields returns the rest of the shim index header line after
+// the format marker: the version, then any options.
+func shimIndexHeaderFields(index []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(index, []byte(shimIndexHeader)) {
+		return nil, false
+	}
+	line := index[len(shimIndexHeader):]
+	if nl := bytes.IndexByte(line, '\n'); nl >= 0 {
+		line = line[:nl]
+	}
+	return line, true
+}
+
+// lookupShimIndex returns the real binary recorded for name in a shim index,
+// or "" when there is none. Entries are "name\tpath" lines sorted by name;
+// they are binary searched in place, without splitting the index.
+func lookupShimIndex(index []byte, name string) string {
+	if !bytes.HasPrefix(index, []byte(shimIndexHeader)) {
+		return ""
+	}
+	nl := bytes.IndexByte(index, '\n')
+	if nl < 0 {
+		return ""
+	}
+	body := index[nl+1:]
+	key := []byte(name)
+
+	// lo and hi are always line starts (or len(body)).
+	lo, hi := 0, len(body)
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start := bytes.LastIndexByte(body[:mid], '\n') + 1
+		end := bytes.IndexByte(body[start:hi], '\n')
+		if end < 0 {
+			end = hi
+		} else {
+			end += start
+		}
+		line := body[start:end]
+		tab := bytes.IndexByte(line, '\t')
+		if tab < 0 {
+			return "" // malformed index — use the slow path
+		}
+		switch c := bytes.Compare(line[:tab], key); {
+		case c == 0:
+			return string(line[tab+1:])
+		case c < 0:
+			lo = end + 1
+		default:
+			hi = start
+		}
+	}
+	return ""
+}
+
 // checkShimVersion compares this binary's embedded shimVersion against the
-// expected version stored in ~/.brewprune/shim.version (written by brewprune
-// scan). When they differ it emits a one-line warning to stderr, rate-limited
-// to once per calendar day via ~/.brewprune/shim.version.warned.
+// expected version written by brewprune scan: the one in the shim index
+// header, or ~/.brewprune/shim.version when there is no index. When they
+// differ it emits a one-line warning to stderr, rate-limited to once per
+// calendar day via ~/.brewprune/shim.version.warned.
 //
 // All errors are silently swallowed — the version check must never prevent
 // the user's command from running.
-func checkShimVersion() {
+func checkShimVersion(index []byte) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return
 	}
 
-	// Read the expected version written by `brewprune scan`.
-	versionPath := filepath.Join(homeDir, ".brewprune", "shim.version")
-	data, err := os.ReadFile(versionPath)
-	if err != nil {
-		// File absent means scan hasn't run yet — no warning before first scan.
-		return
+	expected, ok := shimIndexVersion(index)
+	if !ok {
+		// Read the expected version written by `brewprune scan`.
+		versionPath := filepath.Join(homeDir, ".brewprune", "shim.version")
+		data, err := os.ReadFile(versionPath)
+		if err != nil {
+			// File absent means scan hasn't run yet — no warning before first scan.
+			return
+		}
+		expected = string(data)
 	}
-	expected := strings.TrimSpace(string(data))
+	expected = strings.TrimSpace(expected)
 	if expected == "" || expected == shimVersion {
 		return
 	}
@@ -137,9 +250,10 @@ func isPromptGitCall() bool {
 	return false
 }
 
-// logExecution appends a usage record to ~/.brewprune/usage.log.
+// logExecution appends a usage record to ~/.brewprune/usage.log, with the
+// working directory when recordCwd is set (the projects.track_cwd setting).
 // Failures are silently ignored so the user's command always proceeds.
-func logExecution(cmdName string) {
+func logExecution(cmdName string, recordCwd bool) {
 	// Skip tracking git calls from shell prompt daemons (gitstatusd, etc.)
 	// to avoid inflating git usage with thousands of prompt-driven git status checks.
 	if cmdName == "git" && isPromptGitCall() {
@@ -160,15 +274,56 @@ func logExecution(cmdName string) {
 	}
 	defer f.Close()
 
-	// Format: "<unix_nano>,<argv0>\n"
-	// argv0 is the shim symlink path, e.g. /Users/alice/.brewprune/bin/git
-	fmt.Fprintf(f, "%d,%s\n", time.Now().UnixNano(), os.Args[0])
+	var cwd string
+	if recordCwd {
+		cwd, _ = os.Getwd()
+	}
+
+	// One write per record keeps concurrent shims from interleaving lines.
+	f.WriteString(formatLogRecord(time.Now().UnixNano(), os.Args[0], cwd, stdinIsTerminal())) //nolint:errcheck
+}
+
+// formatLogRecord returns a v2 usage log line (see internal/watcher/shimlog.go,
+// which parses it):
+//
+//	2<TAB><unix_nano><TAB><pid><TAB><ppid><TAB><tty><TAB><exit><TAB><argv0><TAB><cwd>
+//
+// argv0 is the shim symlink path, e.g. /Users/alice/.brewprune/bin/git. The
+// exit code is left empty: the shim execs the real binary and never sees it.
+// cwd is empty when not recorded.
+func formatLogRecord(tsNano int64, argv0, cwd string, tty bool) string {
+	ttyFlag := "0"
+	if tty {
+		ttyFlag = "1"
+	}
+	return fmt.Sprintf("2\t%d\t%d\t%d\t%s\t\t%s\t%s\n",
+		tsNano, os.Getpid(), os.Getppid(), ttyFlag, escapeLogField(argv0), escapeLogField(cwd))
+}
+
+// logFieldEscaper escapes the bytes that would break a tab-separated log line.
+var logFieldEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// escapeLogField escapes backslash, tab, newline and carriage return in a
+// usage log field.
+func escapeLogField(s string) string {
+	if !strings.ContainsAny(s, "\\\t\n\r") {
+		return s
+	}
+	return logFieldEscaper.Replace(s)
+}
+
+// stdinIsTerminal reports whether stdin is a terminal, i.e. the command was
+// run interactively rather than from a script, pipe or service.
+func stdinIsTerminal() bool {
+	return isatty.IsTerminal(os.Stdin.Fd())
 }
 
-// findRealBinary locates the actual Homebrew binary at the stable opt prefix.
-// Tries /opt/homebrew (Apple Silicon), /usr/local (Intel), and
-// /home/linuxbrew/.linuxbrew (Linux) as primary candidates, then falls back
-// to any executable on PATH outside the shim directory (e.g. /bin/cat).
+// findRealBinary locates the actual Homebrew binary for name. Prefixes are
+// tried in order: the prefix the scan recorded for this command in the prefix
+// manifest (~/.brewprune/prefixes), the other scanned prefixes, then
+// $HOMEBREW_PREFIX, /opt/homebrew (Apple Silicon), /usr/local (Intel) and
+// /home/linuxbrew/.linuxbrew (Linux). It then falls back to any executable on
+// PATH outside the shim directory (e.g. /bin/cat).
 // Returns "" if the binary would resolve back to the shim itself (infinite exec loop guard).
 func findRealBinary(name string) string {
 	// Prevent infinite exec loop: brewprune-shim must never exec itself.
@@ -176,8 +331,19 @@ func findRealBinary(name string) string {
 		return ""
 	}
 
-	// Primary: stable Homebrew prefix paths.
-	prefixes := []string{"/opt/homebrew", "/usr/local", "/home/linuxbrew/.linuxbrew"}
+	homeDir, _ := os.UserHomeDir()
+
+	// Primary: the command's own prefix, then every other known prefix.
+	own, scanned := readPrefixManifest(filepath.Join(homeDir, ".brewprune", "prefixes"), name)
+	var prefixes []string
+	if own != "" {
+		prefixes = append(prefixes, own)
+	}
+	prefixes = append(prefixes, scanned...)
+	if env := os.Getenv("HOMEBREW_PREFIX"); env != "" {
+		prefixes = append(prefixes, env)
+	}
+	prefixes = append(prefixes, "/opt/homebrew", "/usr/local", "/home/linuxbrew/.linuxbrew")
 	for _, prefix := range prefixes {
 		p := filepath.Join(prefix, "bin", name)
 		if _, err := os.Stat(p); err == nil {
@@ -186,7 +352,6 @@ func findRealBinary(name string) string {
 	}
 
 	// Fallback: search PATH entries, skipping the shim directory to prevent loops.
-	homeDir, _ := os.UserHomeDir()
 	shimDir := filepath.Join(homeDir, ".brewprune", "bin")
 	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
 		if dir == shimDir {
@@ -200,3 +365,24 @@ func findRealBinary(name string) string {
 
 	return ""
 }
+
+// readPrefixManifest returns the prefix recorded for name in the prefix
+// manifest written by brewprune scan, and the scanned prefixes in priority
+// order. Lines are "prefix\t<path>" or "bin\t<name>\t<prefix>". A missing or
+// unreadable manifest yields nothing.
+func readPrefixManifest(path, name string) (own string, prefixes []string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Split(line, "\t")
+		switch {
+		case len(fields) == 2 && fields[0] == "prefix":
+			prefixes = append(prefixes, fields[1])
+		case len(fields) == 3 && fields[0] == "bin" && fields[1] == name:
+			own = fields[2]
+		}
+	}
+	return own, prefixes
+}
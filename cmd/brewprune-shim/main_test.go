@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blackwell-systems/brewprune/internal/shim"
+)
+
+// testIndex encodes a shim index with n entries cmd0000..cmd<n-1> using the
+// CLI's encoder, so the two sides of the format are tested together.
+func testIndex(n int) []byte {
+	entries := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("cmd%04d", i)
+		entries[name] = "/opt/homebrew/bin/" + name
+	}
+	return shim.EncodeShimIndex("1.0.0", shim.ShimIndexOptions{}, entries)
+}
+
+func TestLookupShimIndex(t *testing.T) {
+	index := shim.EncodeShimIndex("1.0.0", shim.ShimIndexOptions{}, map[string]string{
+		"gcc":    "/usr/local/bin/gcc",
+		"gcc-14": "/usr/local/bin/gcc-14",
+		"git":    "/opt/homebrew/bin/git",
+		"jq":     "/opt/homebrew/bin/jq",
+		"wget":   "/usr/local/bin/wget",
+	})
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"gcc", "/usr/local/bin/gcc"}, // first entry
+		{"gcc-14", "/usr/local/bin/gcc-14"},
+		{"git", "/opt/homebrew/bin/git"},
+		{"jq", "/opt/homebrew/bin/jq"},
+		{"wget", "/usr/local/bin/wget"}, // last entry
+		{"aaa", ""},                     // before the first entry
+		{"gc", ""},                      // prefix of an entry
+		{"gitk", ""},
+		{"zzz", ""}, // after the last entry
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := lookupShimIndex(index, tt.name); got != tt.want {
+			t.Errorf("lookupShimIndex(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLookupShimIndex_Large(t *testing.T) {
+	index := testIndex(1000)
+	for _, i := range []int{0, 1, 499, 500, 998, 999} {
+		name := fmt.Sprintf("cmd%04d", i)
+		if got, want := lookupShimIndex(index, name), "/opt/homebrew/bin/"+name; got != want {
+			t.Errorf("lookupShimIndex(%q) = %q, want %q", name, got, want)
+		}
+	}
+	if got := lookupShimIndex(index, "cmd1000"); got != "" {
+		t.Errorf("lookupShimIndex(cmd1000) = %q, want empty", got)
+	}
+}
+
+func TestLookupShimIndex_Unusable(t *testing.T) {
+	tests := map[string]string{
+		"empty":          "",
+		"unknown format": "brewprune-shim-index 2\t1.0.0\ngit\t/opt/homebrew/bin/git\n",
+		"no header":      "git\t/opt/homebrew/bin/git\n",
+		"header only":    "brewprune-shim-index 1\t1.0.0",
+		"malformed line": "brewprune-shim-index 1\t1.0.0\ngit /opt/homebrew/bin/git\n",
+	}
+	for desc, index := range tests {
+		if got := lookupShimIndex([]byte(index), "git"); got != "" {
+			t.Errorf("%s: lookupShimIndex(git) = %q, want empty", desc, got)
+		}
+	}
+
+	// A final line without its newline is still an entry.
+	index := "brewprune-shim-index 1\t1.0.0\ngit\t/opt/homebrew/bin/git"
+	if got := lookupShimIndex([]byte(index), "git"); got != "/opt/homebrew/bin/git" {
+		t.Errorf("lookupShimIndex(git) without trailing newline = %q", got)
+	}
+}
+
+func TestShimIndexVersion(t *testing.T) {
+	if v, ok := shimIndexVersion(testIndex(3)); !ok || v != "1.0.0" {
+		t.Errorf("shimIndexVersion() = %q, %v; want 1.0.0, true", v, ok)
+	}
+	if _, ok := shimIndexVersion(nil); ok {
+		t.Error("shimIndexVersion(nil) ok = true, want false")
+	}
+}
+
+func TestShimIndexOption(t *testing.T) {
+	index := shim.EncodeShimIndex("1.0.0", shim.ShimIndexOptions{RecordCwd: true}, map[string]string{"git": "/opt/homebrew/bin/git"})
+	if v, ok := shimIndexVersion(index); !ok || v != "1.0.0" {
+		t.Errorf("shimIndexVersion() with options = %q, %v; want 1.0.0, true", v, ok)
+	}
+	if !shimIndexOption(index, "cwd") {
+		t.Error(`shimIndexOption(cwd) = false, want true`)
+	}
+	if shimIndexOption(index, "cw") {
+		t.Error(`shimIndexOption(cw) = true, want false`)
+	}
+	if got := lookupShimIndex(index, "git"); got != "/opt/homebrew/bin/git" {
+		t.Errorf("lookupShimIndex(git) = %q", got)
+	}
+
+	for _, index := range [][]byte{
+		testIndex(3), // no options
+		[]byte("brewprune-shim-index 1\t1.0.0\n"), // index written before options existed
+		nil,
+	} {
+		if shimIndexOption(index, "cwd") {
+			t.Errorf("shimIndexOption(%q, cwd) = true, want false", index)
+		}
+	}
+}
+
+// The fast path runs on every shimmed exec; it must not allocate beyond the
+// returned string.
+func TestLookupShimIndex_Allocs(t *testing.T) {
+	index := testIndex(1000)
+	allocs := testing.AllocsPerRun(100, func() {
+		lookupShimIndex(index, "cmd0777")
+	})
+	if allocs > 1 {
+		t.Errorf("lookupShimIndex allocated %.0f times per call, want at most 1", allocs)
+	}
+}
+
+func BenchmarkLookupShimIndex(b *testing.B) {
+	index := testIndex(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if lookupShimIndex(index, "cmd0777") == "" {
+			b.Fatal("entry not found")
+		}
+	}
+}
+
+// BenchmarkFindRealBinary measures the slow path the shim index replaces, for
+// a command outside every default prefix that is found on PATH.
+func BenchmarkFindRealBinary(b *testing.B) {
+	home := b.TempDir()
+	binDir := filepath.Join(b.TempDir(), "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		b.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, "cmd0777"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		b.Fatal(err)
+	}
+	b.Setenv("HOME", home)
+	b.Setenv("HOMEBREW_PREFIX", "")
+	b.Setenv("PATH", binDir)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if findRealBinary("cmd0777") == "" {
+			b.Fatal("binary not found")
+		}
+	}
+}
+
+func TestFormatLogRecord(t *testing.T) {
+	got := formatLogRecord(1709012345678901234, "/Users/alice/.brewprune/bin/git", "", true)
+	want := fmt.Sprintf("2\t1709012345678901234\t%d\t%d\t1\t\t/Users/alice/.brewprune/bin/git\t\n", os.Getpid(), os.Getppid())
+	if got != want {
+		t.Errorf("formatLogRecord() = %q, want %q", got, want)
+	}
+
+	got = formatLogRecord(1, "/tmp/a\tb", "/src/x\\y\nz", false)
+	want = fmt.Sprintf("2\t1\t%d\t%d\t0\t\t/tmp/a\\tb\t/src/x\\\\y\\nz\n", os.Getpid(), os.Getppid())
+	if got != want {
+		t.Errorf("formatLogRecord() with special bytes = %q, want %q", got, want)
+	}
+}
@@ -194,8 +194,50 @@ separator "Step 11: scan --refresh-shims"
 REFRESH_OUT=$(brewprune scan --refresh-shims 2>&1)
 assert_contains "refresh-shims runs cleanly" "Refreshed\|shim" "$REFRESH_OUT"
 
-# ── 12. Cleanup ──────────────────────────────────────────────────────────────
-separator "Step 12: cleanup"
+# ── 12. Pinned packages and services ────────────────────────────────────────
+separator "Step 12: brew pin and brew services"
+
+brew pin tree >/dev/null
+brew services start htop >/dev/null
+brewprune scan >/dev/null 2>&1 || fail "rescan after pin" "brewprune scan failed"
+
+TREE_OUT=$(brewprune explain tree 2>&1 | sed 's/\x1b\[[0-9;]*m//g')
+assert_contains "pinned package is labelled"      "PINNED"                 "$TREE_OUT"
+HTOP_OUT=$(brewprune explain htop 2>&1 | sed 's/\x1b\[[0-9;]*m//g')
+assert_contains "running service is labelled"     "SERVICE"                "$HTOP_OUT"
+assert_contains "running service is protected"    "running brew service"   "$HTOP_OUT"
+
+REMOVE_OUT=$(brewprune remove --risky --dry-run 2>&1 || true)
+assert_contains "tier removal skips pinned package"   "tree (pinned)"          "$REMOVE_OUT"
+assert_contains "tier removal skips running service"  "htop (running service)" "$REMOVE_OUT"
+
+brew unpin tree
+brew services stop htop >/dev/null
+brewprune scan >/dev/null 2>&1 || true
+
+# ── 13. Taps ─────────────────────────────────────────────────────────────────
+separator "Step 13: taps and remove --tap"
+
+brew tap mock/unused >/dev/null
+
+TAPS_OUT=$(brewprune taps 2>&1)
+assert_contains "taps lists homebrew/core"        "homebrew/core"                    "$TAPS_OUT"
+assert_contains "empty tap is recommended"        "untap: no packages installed"     "$TAPS_OUT"
+
+UNTAP_OUT=$(brewprune remove --tap mock/unused --yes 2>&1 || true)
+assert_contains "remove --tap untaps"             "Untapped mock/unused"             "$UNTAP_OUT"
+if brew tap | grep -q "mock/unused"; then
+  fail "tap is gone" "mock/unused still listed by brew tap"
+else
+  ok "tap is gone"
+fi
+
+UNDO_OUT=$(brewprune undo latest --yes 2>&1 || true)
+assert_contains "undo taps it again"              "Restored tap mock/unused"         "$UNDO_OUT"
+brew untap mock/unused >/dev/null 2>&1 || true
+
+# ── 14. Cleanup ──────────────────────────────────────────────────────────────
+separator "Step 14: cleanup"
 
 if [[ -n "$DAEMON_PID" ]]; then
   kill "$DAEMON_PID" 2>/dev/null && ok "daemon stopped" || ok "daemon already stopped"
@@ -1,13 +1,49 @@
 package analyzer
 
-import "github.com/blackwell-systems/brewprune/internal/store"
+import (
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/config"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
 
 // Analyzer computes confidence scores and usage statistics for packages.
 type Analyzer struct {
 	store *store.Store
+
+	// model selects the usage scoring model (config.ScoringModelBuckets or
+	// config.ScoringModelDecay); halfLife applies to the decay model only.
+	model    string
+	halfLife time.Duration
+
+	// projectDirs are the configured directories holding the user's
+	// projects, used to attribute usage to projects.
+	projectDirs []string
+
+	// cache memoizes usage lookups across the packages of one analysis
+	// pass; nil outside a pass. See pass.
+	cache *usageCache
 }
 
 // New creates a new Analyzer instance with the given store.
 func New(store *store.Store) *Analyzer {
-	return &Analyzer{store: store}
+	return &Analyzer{
+		store:    store,
+		model:    config.ScoringModelBuckets,
+		halfLife: time.Duration(config.DefaultHalfLifeDays * 24 * float64(time.Hour)),
+	}
+}
+
+// Configure applies user settings such as the usage scoring model.
+func (a *Analyzer) Configure(settings *config.Settings) {
+	if settings == nil {
+		return
+	}
+	if settings.ScoringModel != "" {
+		a.model = settings.ScoringModel
+	}
+	if settings.HalfLifeDays > 0 {
+		a.halfLife = time.Duration(settings.HalfLifeDays * 24 * float64(time.Hour))
+	}
+	a.projectDirs = settings.ProjectDirs
 }
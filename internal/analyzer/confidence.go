@@ -2,9 +2,12 @@ package analyzer
 
 import (
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
-	"github.com/blackwell-systems/brewprune/internal/scanner"
+	"github.com/blackwell-systems/brewprune/internal/brew"
 )
 
 // Data quality thresholds for tracking confidence.
@@ -44,14 +47,21 @@ func ClassifyConfidence(trackingDays int) string {
 	return "READY"
 }
 
+// serviceScoreCap is the highest score of a package running as a brew
+// service, which keeps it in the risky tier.
+const serviceScoreCap = 49
+
 // ComputeScore calculates the confidence score for removing a package.
 // Score components:
 //   - Usage (40 points): Last 7d=0, 30d=10, 90d=20, 1yr=30, never=40
-//     0 = recently used (keep), 40 = never used (safe to remove)
+//     0 = recently used (keep), 40 = never used (safe to remove).
+//     With the decay model configured, see decayUsageScore instead.
 //   - Dependencies (30 points): No deps=30, 1-3 unused=20, 1-3 used=10, 4+=0
 //   - Age (20 points): >180d=20, >90d=15, >30d=10, <30d=0
 //   - Type (10 points): Leaf with bins=10, lib no bins=5, core=0
 func (a *Analyzer) ComputeScore(pkg string) (*ConfidenceScore, error) {
+	a = a.pass()
+
 	// Get package info
 	pkgInfo, err := a.store.GetPackage(pkg)
 	if err != nil {
@@ -68,28 +78,55 @@ func (a *Analyzer) ComputeScore(pkg string) (*ConfidenceScore, error) {
 	// 1. Usage Score (40 points)
 	score.UsageScore = a.computeUsageScore(pkg)
 
+	// A runtime that virtualenvs, scripts or version managers point at is
+	// in use whether or not its binaries run through a shim.
+	runtimeRefs, err := a.runtimeReferences(pkg)
+	if err != nil {
+		return nil, err
+	}
+	if len(runtimeRefs) > 0 {
+		score.RuntimeReferences = runtimeRefs
+		score.UsageScore = 0
+		score.Labels = append(score.Labels, "IN USE — "+describeRuntimeReferences(runtimeRefs))
+	}
+
 	// 2. Dependencies Score (30 points)
 	dependents, err := a.store.GetDependents(pkg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get dependents: %w", err)
 	}
-	score.DepsScore = a.computeDepsScore(dependents)
+	depUsage, err := a.assessDependents(pkg, dependents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assess dependents: %w", err)
+	}
+	score.DepsScore = a.computeDepsScore(dependents, depUsage)
 
 	// 3. Age Score (20 points)
 	score.AgeScore = a.computeAgeScore(pkgInfo.InstalledAt)
 
 	// 4. Type Score (10 points)
-	score.TypeScore = a.computeTypeScore(pkg, pkgInfo.HasBinary, len(dependents))
+	// Dependents that have all gone unused for a year do not hold the
+	// package in place, so it is typed like a leaf.
+	numDependents := len(dependents)
+	if depUsage.stale {
+		numDependents = 0
+	}
+	score.IsCritical = a.IsCoreDependency(pkg)
+	score.TypeScore = a.computeTypeScore(score.IsCritical, pkgInfo.HasBinary, numDependents)
 
 	// Total score
 	score.Score = score.UsageScore + score.DepsScore + score.AgeScore + score.TypeScore
 
 	// Apply criticality penalty: cap critical packages at 70 (medium tier max)
-	if scanner.IsCoreDependency(pkg) {
-		score.IsCritical = true
-		if score.Score > 70 {
-			score.Score = 70
-		}
+	if score.IsCritical {
+		score.capAt(70, "capped at 70 (core system dependency)")
+	}
+
+	// A running brew service is in use even though its daemon never passes
+	// through a shim: cap it in the risky tier.
+	if brew.IsRunningService(pkgInfo.Service) {
+		score.IsService = true
+		score.capAt(serviceScoreCap, "capped at 49 (running brew service)")
 	}
 
 	// Determine tier
@@ -101,9 +138,86 @@ func (a *Analyzer) ComputeScore(pkg string) (*ConfidenceScore, error) {
 		score.Tier = "risky"
 	}
 
+	if score.IsService {
+		score.Labels = append(score.Labels, fmt.Sprintf("SERVICE — %s by brew services", pkgInfo.Service))
+	}
+	if pkgInfo.Pinned {
+		score.IsPinned = true
+		score.Labels = append(score.Labels, "PINNED — excluded from tier removal")
+	}
+
+	// Hold periodically used packages in medium tier while their next
+	// expected use is due, until it passes without one.
+	periodic, err := a.GetPeriodicPattern(pkg)
+	if err != nil {
+		return nil, err
+	}
+	if periodic.Holds(time.Now()) {
+		score.Periodic = periodic
+		score.Labels = append(score.Labels, periodic.Label())
+		if score.Tier == "safe" {
+			score.capAt(79, "held at 79 (periodic use due soon)")
+			score.Tier = "medium"
+		}
+	}
+
+	// Usage that bypasses the shims is undercounted; do not call the
+	// package safe until that usage is being recorded.
+	bypassed, err := a.bypassedBinaries(pkg)
+	if err != nil {
+		return nil, err
+	}
+	if len(bypassed) > 0 {
+		score.Bypassed = bypassed
+		score.Labels = append(score.Labels, fmt.Sprintf("BYPASS — usage undercounted (%s)", strings.Join(bypassed, ", ")))
+		if score.Tier == "safe" {
+			score.capAt(79, "held at 79 (shim bypass)")
+			score.Tier = "medium"
+		}
+	}
+
+	// Projects that declare they need the package may run it only in CI
+	// or on release day, where no usage is recorded.
+	declaredBy, err := a.declaringProjects(pkg)
+	if err != nil {
+		return nil, err
+	}
+	if len(declaredBy) > 0 {
+		score.DeclaredBy = declaredBy
+		score.Labels = append(score.Labels, "DECLARED — "+declaredNeededBy(declaredBy))
+		if score.Tier == "safe" {
+			score.capAt(79, "held at 79 (declared by a project)")
+			score.Tier = "medium"
+		}
+	}
+
+	// Casks are mostly used through their apps, which the shims never see.
+	// Without launch data a cask with an app looks unused whether or not it
+	// is, even when it also ships shimmed command-line tools, so it is not
+	// called safe until its launches are tracked. A cask of command-line
+	// tools only is tracked by its shims.
+	if pkgInfo.IsCask {
+		tracked, err := a.store.IsCaskLaunchTracked(pkg)
+		if err != nil {
+			return nil, err
+		}
+		hasApps, err := a.store.HasCaskApps(pkg)
+		if err != nil {
+			return nil, err
+		}
+		score.UsageTracked = tracked || (pkgInfo.HasBinary && !hasApps)
+		if !score.UsageTracked {
+			score.Labels = append(score.Labels, "NO LAUNCH DATA — cask usage not tracked")
+			if score.Tier == "safe" {
+				score.capAt(79, "held at 79 (no cask launch data)")
+				score.Tier = "medium"
+			}
+		}
+	}
+
 	// Generate reason and explanation
 	score.Reason = a.generateReason(score, dependents, pkgInfo.HasBinary)
-	score.Explanation = a.generateExplanation(score, pkg, dependents, &struct {
+	score.Explanation = a.generateExplanation(score, pkg, dependents, depUsage, &struct {
 		InstalledAt time.Time
 		HasBinary   bool
 	}{
@@ -114,11 +228,146 @@ func (a *Analyzer) ComputeScore(pkg string) (*ConfidenceScore, error) {
 	return score, nil
 }
 
-// computeUsageScore calculates usage score based on last use time.
+// capAt lowers the score to limit, recording the difference as an
+// adjustment so that the components and adjustments add up to the score.
+func (s *ConfidenceScore) capAt(limit int, reason string) {
+	if s.Score <= limit {
+		return
+	}
+	s.Adjustments = append(s.Adjustments, ScoreAdjustment{Points: limit - s.Score, Reason: reason})
+	s.Score = limit
+}
+
+// bypassedBinaries returns the sorted, de-duplicated names of pkg's binaries
+// with uncovered shim-bypass findings.
+func (a *Analyzer) bypassedBinaries(pkg string) ([]string, error) {
+	findings, err := a.store.GetBypassFindings(pkg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bypass findings: %w", err)
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, f := range findings {
+		if !seen[f.BinaryName] {
+			seen[f.BinaryName] = true
+			names = append(names, f.BinaryName)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// runtimeReferences returns the paths that reach pkg as a language runtime.
+func (a *Analyzer) runtimeReferences(pkg string) ([]RuntimeReference, error) {
+	stored, err := a.store.GetRuntimeReferences(pkg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get runtime references: %w", err)
+	}
+
+	refs := make([]RuntimeReference, 0, len(stored))
+	for _, r := range stored {
+		refs = append(refs, RuntimeReference{Kind: r.Kind, Path: r.Path, Target: r.Target})
+	}
+	return refs, nil
+}
+
+// runtimeReferenceNouns name each kind of runtime reference, in the order
+// describeRuntimeReferences lists them.
+var runtimeReferenceNouns = []struct{ kind, noun string }{
+	{"venv", "virtualenv"},
+	{"shebang", "script"},
+	{"symlink", "symlink"},
+}
+
+// describeRuntimeReferences summarises references by kind, e.g.
+// "referenced by 2 virtualenvs, 1 script".
+func describeRuntimeReferences(refs []RuntimeReference) string {
+	counts := make(map[string]int)
+	for _, r := range refs {
+		counts[r.Kind]++
+	}
+	var parts []string
+	for _, k := range runtimeReferenceNouns {
+		switch n := counts[k.kind]; n {
+		case 0:
+		case 1:
+			parts = append(parts, "1 "+k.noun)
+		default:
+			parts = append(parts, fmt.Sprintf("%d %ss", n, k.noun))
+		}
+	}
+	return "referenced by " + strings.Join(parts, ", ")
+}
+
+// declaringProjects returns the sorted, de-duplicated projects that declare
+// they need pkg.
+func (a *Analyzer) declaringProjects(pkg string) ([]string, error) {
+	reqs, err := a.store.GetDeclaredRequirements(pkg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get declared requirements: %w", err)
+	}
+
+	var projects []string
+	seen := make(map[string]bool)
+	for _, r := range reqs {
+		if !seen[r.Project] {
+			seen[r.Project] = true
+			projects = append(projects, r.Project)
+		}
+	}
+	sort.Strings(projects)
+	return projects, nil
+}
+
+// declaredNeededBy formats the projects declaring a package by name, e.g.
+// "needed by api, web and 2 more".
+func declaredNeededBy(projects []string) string {
+	const maxNames = 2
+	names := make([]string, 0, maxNames)
+	for i, p := range projects {
+		if i == maxNames {
+			return fmt.Sprintf("needed by %s and %d more", strings.Join(names, ", "), len(projects)-maxNames)
+		}
+		names = append(names, filepath.Base(p))
+	}
+	return "needed by " + strings.Join(names, ", ")
+}
+
+// lastUsage returns the most recent time pkg was used, either directly or
+// through an executed binary of another package that loads its shared
+// libraries. via names that package when the indirect use is the more recent
+// one, and is empty otherwise. a must be a pass.
+func (a *Analyzer) lastUsage(pkg string) (lastUsed *time.Time, via string) {
+	if cached, ok := a.cache.lastUse[pkg]; ok {
+		return cached.lastUsed, cached.via
+	}
+
+	lastUsed, err := a.store.GetLastUsage(pkg)
+	if err != nil {
+		lastUsed = nil
+	}
+
+	indirect := a.getIndirectUses(pkg)
+	if len(indirect) > 0 && (lastUsed == nil || indirect[0].LastUsed.After(*lastUsed)) {
+		t := indirect[0].LastUsed
+		lastUsed, via = &t, indirect[0].Via
+	}
+
+	a.cache.lastUse[pkg] = cachedLastUse{lastUsed: lastUsed, via: via}
+	return lastUsed, via
+}
+
+// computeUsageScore calculates usage score based on last use time, or on
+// decayed usage intensity when the decay model is configured.
 // 0 = recently used (keep), 40 = never used (safe to remove)
 func (a *Analyzer) computeUsageScore(pkg string) int {
-	lastUsed, err := a.store.GetLastUsage(pkg)
-	if err != nil || lastUsed == nil {
+	if a.usesDecayModel() {
+		return a.computeDecayUsageScore(pkg)
+	}
+
+	lastUsed, _ := a.lastUsage(pkg)
+	if lastUsed == nil {
 		// Never used or error
 		return 40
 	}
@@ -137,27 +386,17 @@ func (a *Analyzer) computeUsageScore(pkg string) int {
 	return 40
 }
 
-// computeDepsScore calculates dependency score based on dependents.
-func (a *Analyzer) computeDepsScore(dependents []string) int {
+// computeDepsScore calculates dependency score based on dependents. A
+// dependent counts as used when it, or anything depending on it, was used in
+// the last 30 days. When every dependent has been unused for a year the
+// package scores like one with no dependents.
+func (a *Analyzer) computeDepsScore(dependents []string, usage *dependentUsage) int {
 	numDependents := len(dependents)
 
-	if numDependents == 0 {
+	if numDependents == 0 || usage.stale {
 		return 30
 	} else if numDependents <= 3 {
-		// Check if dependents are used
-		usedCount := 0
-		for _, dep := range dependents {
-			lastUsed, err := a.store.GetLastUsage(dep)
-			if err == nil && lastUsed != nil {
-				// Check if used in last 30 days
-				daysSince := int(time.Since(*lastUsed).Hours() / 24)
-				if daysSince <= 30 {
-					usedCount++
-				}
-			}
-		}
-
-		if usedCount == 0 {
+		if usage.usedCount == 0 {
 			// All dependents are unused
 			return 20
 		}
@@ -184,9 +423,9 @@ func (a *Analyzer) computeAgeScore(installedAt time.Time) int {
 }
 
 // computeTypeScore calculates type score based on package characteristics.
-func (a *Analyzer) computeTypeScore(pkg string, hasBinary bool, numDependents int) int {
+func (a *Analyzer) computeTypeScore(critical, hasBinary bool, numDependents int) int {
 	// Core dependency check
-	if scanner.IsCoreDependency(pkg) {
+	if critical {
 		return 0
 	}
 
@@ -216,6 +455,21 @@ func (a *Analyzer) generateReason(score *ConfidenceScore, dependents []string, h
 	}
 
 	if score.Tier == "medium" {
+		if len(score.RuntimeReferences) > 0 {
+			return "runtime " + describeRuntimeReferences(score.RuntimeReferences)
+		}
+		if score.Periodic != nil {
+			return fmt.Sprintf("used periodically, next expected ~%s", score.Periodic.NextExpected.Format("2006-01-02"))
+		}
+		if len(score.DeclaredBy) > 0 {
+			return "declared-" + declaredNeededBy(score.DeclaredBy)
+		}
+		if len(score.Bypassed) > 0 {
+			return "runs outside the shims, usage undercounted"
+		}
+		if score.IsCask && !score.UsageTracked {
+			return "cask app launches not tracked, review manually"
+		}
 		if len(dependents) > 0 && len(dependents) <= 3 {
 			return "has few dependents, check before removing"
 		}
@@ -226,28 +480,36 @@ func (a *Analyzer) generateReason(score *ConfidenceScore, dependents []string, h
 	}
 
 	// Risky
+	if score.IsService {
+		return "running brew service, keep"
+	}
+	if len(score.RuntimeReferences) > 0 {
+		return "runtime in use, keep"
+	}
 	if score.UsageScore == 0 {
 		return "recently used, keep"
 	}
 	if len(dependents) >= 4 {
 		return fmt.Sprintf("has %d dependents, keep", len(dependents))
 	}
-	if scanner.IsCoreDependency(score.Package) {
+	if score.IsCritical {
 		return "core system dependency, keep"
 	}
 	return "low confidence for removal"
 }
 
 // generateExplanation creates detailed component breakdown for the score.
-func (a *Analyzer) generateExplanation(score *ConfidenceScore, pkg string, dependents []string, pkgInfo *struct {
+func (a *Analyzer) generateExplanation(score *ConfidenceScore, pkg string, dependents []string, depUsage *dependentUsage, pkgInfo *struct {
 	InstalledAt time.Time
 	HasBinary   bool
 }) ScoreExplanation {
 	explanation := ScoreExplanation{}
 
 	// Usage detail
-	lastUsed, err := a.store.GetLastUsage(pkg)
-	if err != nil || lastUsed == nil {
+	lastUsed, via := a.lastUsage(pkg)
+	if len(score.RuntimeReferences) > 0 {
+		explanation.UsageDetail = describeRuntimeReferences(score.RuntimeReferences)
+	} else if lastUsed == nil {
 		explanation.UsageDetail = "never observed execution"
 	} else {
 		daysSince := int(time.Since(*lastUsed).Hours() / 24)
@@ -259,25 +521,23 @@ func (a *Analyzer) generateExplanation(score *ConfidenceScore, pkg string, depen
 		default:
 			explanation.UsageDetail = fmt.Sprintf("last used %d days ago", daysSince)
 		}
+		if via != "" {
+			explanation.UsageDetail = fmt.Sprintf("indirectly used via %s, %s", via, strings.TrimPrefix(explanation.UsageDetail, "last "))
+		}
 	}
 
 	// Dependencies detail
 	numDependents := len(dependents)
 	if numDependents == 0 {
 		explanation.DepsDetail = "no dependents"
-	} else {
-		// Count used dependents
-		usedCount := 0
-		for _, dep := range dependents {
-			lastUsed, err := a.store.GetLastUsage(dep)
-			if err == nil && lastUsed != nil {
-				daysSince := int(time.Since(*lastUsed).Hours() / 24)
-				if daysSince <= 30 {
-					usedCount++
-				}
-			}
+	} else if depUsage.stale {
+		if numDependents == 1 {
+			explanation.DepsDetail = "1 dependent, unused for over a year"
+		} else {
+			explanation.DepsDetail = fmt.Sprintf("%d dependents, none used for over a year", numDependents)
 		}
-
+	} else {
+		usedCount := depUsage.usedCount
 		unusedCount := numDependents - usedCount
 		if usedCount == 0 {
 			if numDependents == 1 {
@@ -294,6 +554,11 @@ func (a *Analyzer) generateExplanation(score *ConfidenceScore, pkg string, depen
 		} else {
 			explanation.DepsDetail = fmt.Sprintf("%d used, %d unused dependents", usedCount, unusedCount)
 		}
+
+		// Show the dependency path that justified the most recent use.
+		if path := formatUsePath(depUsage.latest); path != "" {
+			explanation.DepsDetail += " (" + path + ")"
+		}
 	}
 
 	// Age detail
@@ -321,8 +586,26 @@ func (a *Analyzer) generateExplanation(score *ConfidenceScore, pkg string, depen
 	return explanation
 }
 
+// ComputeScores scores each of pkgs in one analysis pass, so the usage and
+// dependents they share are looked up once. Packages that cannot be scored
+// are left out and passed to skip with the error.
+func (a *Analyzer) ComputeScores(pkgs []string, skip func(pkg string, err error)) []*ConfidenceScore {
+	a = a.pass()
+	scores := make([]*ConfidenceScore, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		score, err := a.ComputeScore(pkg)
+		if err != nil {
+			skip(pkg, err)
+			continue
+		}
+		scores = append(scores, score)
+	}
+	return scores
+}
+
 // GetPackagesByTier returns all packages with scores in the specified tier.
 func (a *Analyzer) GetPackagesByTier(tier string) ([]*ConfidenceScore, error) {
+	a = a.pass()
 	// Validate tier
 	if tier != "safe" && tier != "medium" && tier != "risky" {
 		return nil, fmt.Errorf("invalid tier: %s (must be safe, medium, or risky)", tier)
@@ -2,6 +2,7 @@ package analyzer
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -756,3 +757,477 @@ func TestClassifyConfidenceTransition(t *testing.T) {
 		t.Errorf("ClassifyConfidence(%d) should be READY, got %q", MinimumTrackingDays+1, afterThreshold)
 	}
 }
+
+func TestComputeScore_IndirectUsageViaLinkage(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	for _, pkg := range []*brew.Package{
+		{Name: "git", InstalledAt: time.Now().AddDate(0, 0, -200), InstallType: "explicit", HasBinary: true},
+		{Name: "pcre2", InstalledAt: time.Now().AddDate(0, 0, -200), InstallType: "dependency"},
+	} {
+		if err := s.InsertPackage(pkg); err != nil {
+			t.Fatalf("failed to insert package: %v", err)
+		}
+	}
+
+	if err := s.ReplaceLinkages("git", []*store.Linkage{
+		{Package: "git", BinaryName: "git", LibraryPackage: "pcre2", Library: "libpcre2-8.0.dylib"},
+	}); err != nil {
+		t.Fatalf("ReplaceLinkages failed: %v", err)
+	}
+
+	analyzer := New(s)
+	before, err := analyzer.ComputeScore("pcre2")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if before.UsageScore != 40 {
+		t.Fatalf("expected UsageScore 40 before git runs, got %d", before.UsageScore)
+	}
+
+	if err := s.InsertUsageEvent(&store.UsageEvent{
+		Package:    "git",
+		EventType:  "exec",
+		BinaryName: "git",
+		Timestamp:  time.Now().AddDate(0, 0, -2),
+	}); err != nil {
+		t.Fatalf("failed to insert usage event: %v", err)
+	}
+
+	after, err := analyzer.ComputeScore("pcre2")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if after.UsageScore != 0 {
+		t.Errorf("expected UsageScore 0 when linked binary used 2 days ago, got %d", after.UsageScore)
+	}
+	if !strings.Contains(after.Explanation.UsageDetail, "indirectly used via git") {
+		t.Errorf("UsageDetail = %q, want mention of indirect use via git", after.Explanation.UsageDetail)
+	}
+
+	stats, err := analyzer.GetUsageStats("pcre2")
+	if err != nil {
+		t.Fatalf("GetUsageStats failed: %v", err)
+	}
+	if len(stats.IndirectUses) != 1 || stats.IndirectUses[0].Via != "git" {
+		t.Errorf("IndirectUses = %+v, want one entry via git", stats.IndirectUses)
+	}
+}
+
+func TestComputeScore_BypassHeldInMedium(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	if err := s.InsertPackage(&brew.Package{
+		Name:        "fzf",
+		Version:     "0.54.0",
+		InstalledAt: time.Now().AddDate(-2, 0, 0),
+		InstallType: "explicit",
+		HasBinary:   true,
+		BinaryPaths: []string{"/opt/homebrew/bin/fzf"},
+	}); err != nil {
+		t.Fatalf("failed to insert package: %v", err)
+	}
+
+	a := New(s)
+	score, err := a.ComputeScore("fzf")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.Tier != "safe" {
+		t.Fatalf("expected safe tier without bypass evidence, got %s (%d)", score.Tier, score.Score)
+	}
+
+	// ~/.zshrc sources /opt/homebrew/opt/fzf/bin/fzf directly.
+	if err := s.ReplaceBypassFindings([]*store.BypassFinding{{
+		Package:    "fzf",
+		BinaryName: "fzf",
+		Kind:       store.BypassReference,
+		Location:   "/home/u/.zshrc:12",
+		EvidenceAt: time.Now().AddDate(0, -1, 0),
+		DetectedAt: time.Now(),
+	}}); err != nil {
+		t.Fatalf("ReplaceBypassFindings failed: %v", err)
+	}
+
+	score, err = a.ComputeScore("fzf")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.Tier != "medium" || score.Score != 79 {
+		t.Errorf("expected bypassed package held at 79 (medium), got %d (%s)", score.Score, score.Tier)
+	}
+	if len(score.Bypassed) != 1 || score.Bypassed[0] != "fzf" {
+		t.Errorf("Bypassed = %v, want [fzf]", score.Bypassed)
+	}
+	if len(score.Labels) != 1 || !strings.HasPrefix(score.Labels[0], "BYPASS") {
+		t.Errorf("Labels = %v, want BYPASS label", score.Labels)
+	}
+}
+
+func TestComputeScore_RunningServiceIsRisky(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	for _, pkg := range []*brew.Package{
+		{Name: "postgresql@16", Service: "started"},
+		{Name: "redis", Service: "stopped"},
+	} {
+		pkg.InstalledAt = time.Now().AddDate(-2, 0, 0)
+		pkg.InstallType = "explicit"
+		pkg.HasBinary = true
+		if err := s.InsertPackage(pkg); err != nil {
+			t.Fatalf("failed to insert package: %v", err)
+		}
+	}
+
+	a := New(s)
+	score, err := a.ComputeScore("postgresql@16")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if !score.IsService || score.Tier != "risky" || score.Score != serviceScoreCap {
+		t.Errorf("running service scored %d (%s, service %v), want %d risky", score.Score, score.Tier, score.IsService, serviceScoreCap)
+	}
+	if len(score.Labels) != 1 || score.Labels[0] != "SERVICE — started by brew services" {
+		t.Errorf("Labels = %v, want SERVICE label", score.Labels)
+	}
+	if score.Reason != "running brew service, keep" {
+		t.Errorf("Reason = %q", score.Reason)
+	}
+
+	// A stopped service gives no protection.
+	score, err = a.ComputeScore("redis")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.IsService || score.Tier != "safe" {
+		t.Errorf("stopped service scored %s (service %v), want safe", score.Tier, score.IsService)
+	}
+}
+
+func TestComputeScore_PinnedPackageLabelled(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	if err := s.InsertPackage(&brew.Package{
+		Name:        "terraform",
+		InstalledAt: time.Now().AddDate(-2, 0, 0),
+		InstallType: "explicit",
+		HasBinary:   true,
+		SizeBytes:   80 << 20,
+		Pinned:      true,
+	}); err != nil {
+		t.Fatalf("failed to insert package: %v", err)
+	}
+
+	a := New(s)
+	score, err := a.ComputeScore("terraform")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	// The score is unchanged; pinning only keeps it out of tier removal.
+	if !score.IsPinned || score.Tier != "safe" {
+		t.Errorf("pinned package scored %s (pinned %v), want safe and pinned", score.Tier, score.IsPinned)
+	}
+	if len(score.Labels) != 1 || score.Labels[0] != "PINNED — excluded from tier removal" {
+		t.Errorf("Labels = %v, want PINNED label", score.Labels)
+	}
+
+	rec, err := a.GetRecommendations()
+	if err != nil {
+		t.Fatalf("GetRecommendations failed: %v", err)
+	}
+	if len(rec.Packages) != 0 {
+		t.Errorf("GetRecommendations() = %v, want pinned package left out", rec.Packages)
+	}
+	warnings, err := a.ValidateRemoval([]string{"terraform"})
+	if err != nil {
+		t.Fatalf("ValidateRemoval failed: %v", err)
+	}
+	found := false
+	for _, w := range warnings {
+		found = found || w == "terraform: pinned with 'brew pin'"
+	}
+	if !found {
+		t.Errorf("ValidateRemoval() = %v, want pinned warning", warnings)
+	}
+}
+
+func TestComputeScore_ReferencedRuntimeIsInUse(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	if err := s.InsertPackage(&brew.Package{
+		Name:        "node@16",
+		Version:     "16.20.2",
+		InstalledAt: time.Now().AddDate(-2, 0, 0),
+		InstallType: "explicit",
+		HasBinary:   true,
+		BinaryPaths: []string{"/opt/homebrew/bin/node"},
+	}); err != nil {
+		t.Fatalf("failed to insert package: %v", err)
+	}
+
+	a := New(s)
+	score, err := a.ComputeScore("node@16")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.Tier != "safe" || score.UsageScore != 40 {
+		t.Fatalf("expected never-used safe package, got %s (usage %d)", score.Tier, score.UsageScore)
+	}
+
+	// Two version-manager links and a script run it without touching the
+	// shims.
+	now := time.Now()
+	if err := s.ReplaceRuntimeReferences([]*store.RuntimeReference{
+		{Package: "node@16", Kind: store.RuntimeSymlink, Path: "/u/.nodenv/versions/16", Target: "/opt/homebrew/opt/node@16", DetectedAt: now},
+		{Package: "node@16", Kind: store.RuntimeSymlink, Path: "/u/.asdf/installs/nodejs/16", Target: "/opt/homebrew/opt/node@16", DetectedAt: now},
+		{Package: "node@16", Kind: store.RuntimeShebang, Path: "/u/bin/sync", Target: "/opt/homebrew/bin/node", DetectedAt: now},
+	}); err != nil {
+		t.Fatalf("ReplaceRuntimeReferences failed: %v", err)
+	}
+
+	score, err = a.ComputeScore("node@16")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.UsageScore != 0 {
+		t.Errorf("UsageScore = %d, want 0 for a referenced runtime", score.UsageScore)
+	}
+	if score.Tier == "safe" {
+		t.Errorf("referenced runtime scored safe (%d)", score.Score)
+	}
+	if len(score.RuntimeReferences) != 3 {
+		t.Errorf("RuntimeReferences = %+v, want 3", score.RuntimeReferences)
+	}
+	const summary = "referenced by 1 script, 2 symlinks"
+	if len(score.Labels) != 1 || score.Labels[0] != "IN USE — "+summary {
+		t.Errorf("Labels = %v, want IN USE label", score.Labels)
+	}
+	if score.Explanation.UsageDetail != summary {
+		t.Errorf("UsageDetail = %q, want %q", score.Explanation.UsageDetail, summary)
+	}
+	if score.Reason != "runtime "+summary {
+		t.Errorf("Reason = %q, want %q", score.Reason, "runtime "+summary)
+	}
+}
+
+func TestComputeScore_DeclaredRequirementHoldsSafePackage(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	if err := s.InsertPackage(&brew.Package{
+		Name:        "goreleaser",
+		Version:     "2.0.0",
+		InstalledAt: time.Now().AddDate(-2, 0, 0),
+		InstallType: "explicit",
+		HasBinary:   true,
+		BinaryPaths: []string{"/opt/homebrew/bin/goreleaser"},
+	}); err != nil {
+		t.Fatalf("failed to insert package: %v", err)
+	}
+
+	a := New(s)
+	score, err := a.ComputeScore("goreleaser")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.Tier != "safe" {
+		t.Fatalf("expected safe tier without declarations, got %s (%d)", score.Tier, score.Score)
+	}
+
+	// Two projects run it on release day only.
+	var reqs []*store.DeclaredRequirement
+	for _, project := range []string{"/src/web", "/src/api", "/src/api"} {
+		reqs = append(reqs, &store.DeclaredRequirement{
+			Package:    "goreleaser",
+			Project:    project,
+			Name:       "goreleaser",
+			Source:     "Makefile",
+			Location:   project + "/Makefile:" + fmt.Sprint(len(reqs)+1),
+			DetectedAt: time.Now(),
+		})
+	}
+	if err := s.ReplaceDeclaredRequirements([]string{"/src"}, reqs); err != nil {
+		t.Fatalf("ReplaceDeclaredRequirements failed: %v", err)
+	}
+
+	score, err = a.ComputeScore("goreleaser")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.Tier != "medium" || score.Score != 79 {
+		t.Errorf("expected declared package held at 79 (medium), got %d (%s)", score.Score, score.Tier)
+	}
+	if len(score.DeclaredBy) != 2 || score.DeclaredBy[0] != "/src/api" || score.DeclaredBy[1] != "/src/web" {
+		t.Errorf("DeclaredBy = %v, want [/src/api /src/web]", score.DeclaredBy)
+	}
+	if len(score.Labels) != 1 || score.Labels[0] != "DECLARED — needed by api, web" {
+		t.Errorf("Labels = %v, want DECLARED label", score.Labels)
+	}
+	if score.Reason != "declared-needed by api, web" {
+		t.Errorf("Reason = %q, want declared-needed", score.Reason)
+	}
+}
+
+func TestDeclaredNeededBy(t *testing.T) {
+	tests := map[string][]string{
+		"needed by app":                 {"/src/app"},
+		"needed by api, web":            {"/src/api", "/src/web"},
+		"needed by api, cli and 2 more": {"/src/api", "/src/cli", "/src/docs", "/src/web"},
+	}
+	for want, projects := range tests {
+		if got := declaredNeededBy(projects); got != want {
+			t.Errorf("declaredNeededBy(%v) = %q, want %q", projects, got, want)
+		}
+	}
+}
+
+func TestComputeScore_CaskHeldUntilLaunchTracked(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	if err := s.InsertPackage(&brew.Package{
+		Name:        "firefox",
+		Version:     "130.0",
+		InstalledAt: time.Now().AddDate(-2, 0, 0),
+		InstallType: "explicit",
+		IsCask:      true,
+	}); err != nil {
+		t.Fatalf("failed to insert package: %v", err)
+	}
+
+	a := New(s)
+	score, err := a.ComputeScore("firefox")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.Tier != "medium" || score.Score != 79 {
+		t.Errorf("expected untracked cask held at 79 (medium), got %d (%s)", score.Score, score.Tier)
+	}
+	if score.UsageTracked {
+		t.Error("UsageTracked = true before any launch check")
+	}
+	if len(score.Labels) != 1 || !strings.HasPrefix(score.Labels[0], "NO LAUNCH DATA") {
+		t.Errorf("Labels = %v, want NO LAUNCH DATA label", score.Labels)
+	}
+
+	// A launch source has checked the app and found no launches.
+	if err := s.ReplaceCaskApps([]*store.CaskApp{
+		{Package: "firefox", Name: "Firefox.app", Path: "/Applications/Firefox.app"},
+	}); err != nil {
+		t.Fatalf("ReplaceCaskApps failed: %v", err)
+	}
+	if _, err := s.DB().Exec(`UPDATE cask_apps SET checked_at = ?`, time.Now().Format(time.RFC3339)); err != nil {
+		t.Fatalf("failed to mark app checked: %v", err)
+	}
+
+	score, err = a.ComputeScore("firefox")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.Tier != "safe" || !score.UsageTracked {
+		t.Errorf("expected tracked, unlaunched cask to be safe, got %d (%s), tracked=%v", score.Score, score.Tier, score.UsageTracked)
+	}
+
+	// A recent launch keeps it.
+	if err := s.InsertUsageEvent(&store.UsageEvent{
+		Package:    "firefox",
+		EventType:  "app_launch",
+		BinaryPath: "/Applications/Firefox.app",
+		BinaryName: "Firefox.app",
+		Timestamp:  time.Now().Add(-time.Hour),
+	}); err != nil {
+		t.Fatalf("InsertUsageEvent failed: %v", err)
+	}
+	score, err = a.ComputeScore("firefox")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.Tier == "safe" {
+		t.Errorf("expected recently launched cask not to be safe, got %d (%s)", score.Score, score.Tier)
+	}
+}
+
+func TestComputeScore_CaskWithBinariesTracked(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	if err := s.InsertPackage(&brew.Package{
+		Name:        "1password-cli",
+		Version:     "2.30.0",
+		InstalledAt: time.Now().AddDate(-2, 0, 0),
+		InstallType: "explicit",
+		IsCask:      true,
+		HasBinary:   true,
+		BinaryPaths: []string{"/opt/homebrew/bin/op"},
+	}); err != nil {
+		t.Fatalf("failed to insert package: %v", err)
+	}
+
+	a := New(s)
+	score, err := a.ComputeScore("1password-cli")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if !score.UsageTracked || score.Tier != "safe" {
+		t.Errorf("expected cask with shimmed binaries to be tracked and safe, got %d (%s), tracked=%v", score.Score, score.Tier, score.UsageTracked)
+	}
+	for _, l := range score.Labels {
+		if strings.HasPrefix(l, "NO LAUNCH DATA") {
+			t.Errorf("unexpected label %q for cask with binaries", l)
+		}
+	}
+
+	// An exec event through the op shim counts as cask usage.
+	if err := s.InsertUsageEvent(&store.UsageEvent{
+		Package:    "1password-cli",
+		EventType:  "exec",
+		BinaryPath: "/opt/homebrew/bin/op",
+		BinaryName: "op",
+		Timestamp:  time.Now().Add(-time.Hour),
+	}); err != nil {
+		t.Fatalf("InsertUsageEvent failed: %v", err)
+	}
+	score, err = a.ComputeScore("1password-cli")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.Tier == "safe" {
+		t.Errorf("expected recently used cask not to be safe, got %d (%s)", score.Score, score.Tier)
+	}
+}
+
+func TestComputeScore_CaskWithAppAndBinariesNeedsLaunchData(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	if err := s.InsertPackage(&brew.Package{
+		Name:        "visual-studio-code",
+		Version:     "1.94.0",
+		InstalledAt: time.Now().AddDate(-2, 0, 0),
+		InstallType: "explicit",
+		IsCask:      true,
+		HasBinary:   true,
+		BinaryPaths: []string{"/opt/homebrew/bin/code"},
+	}); err != nil {
+		t.Fatalf("failed to insert package: %v", err)
+	}
+	if err := s.ReplaceCaskApps([]*store.CaskApp{
+		{Package: "visual-studio-code", Name: "Visual Studio Code.app", Path: "/Applications/Visual Studio Code.app"},
+	}); err != nil {
+		t.Fatalf("ReplaceCaskApps failed: %v", err)
+	}
+
+	// The app may be used daily without anyone running the code shim.
+	score, err := New(s).ComputeScore("visual-studio-code")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.UsageTracked || score.Tier != "medium" {
+		t.Errorf("expected GUI cask with a CLI held without launch data, got %d (%s), tracked=%v", score.Score, score.Tier, score.UsageTracked)
+	}
+}
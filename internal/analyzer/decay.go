@@ -0,0 +1,102 @@
+package analyzer
+
+import (
+	"math"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/config"
+)
+
+// frequencyUsageScore maps a computeFrequency classification onto the 0-40
+// usage scale: habitual use pulls the score down even after a quiet spell.
+var frequencyUsageScore = map[string]float64{
+	"daily":   0,
+	"weekly":  10,
+	"monthly": 20,
+	"never":   40,
+}
+
+// Weights of the decayed-intensity and frequency components in the decay
+// model's usage score. They sum to 1.
+const (
+	decayWeight     = 0.75
+	frequencyWeight = 0.25
+)
+
+// usageIntensity returns the exponentially decayed number of uses at now:
+// each use contributes 2^(-age/halfLife), so a use one half-life ago counts
+// 0.5 and two hundred uses last week outweigh one.
+func usageIntensity(uses []time.Time, now time.Time, halfLife time.Duration) float64 {
+	var intensity float64
+	for _, t := range uses {
+		age := now.Sub(t)
+		if age < 0 {
+			age = 0
+		}
+		intensity += math.Exp2(-float64(age) / float64(halfLife))
+	}
+	return intensity
+}
+
+// decayUsageScore converts decayed intensity and a frequency classification
+// into a 0-40 usage score (0 = heavily used, keep; 40 = unused).
+//
+// The intensity term is 40/(1+4I): no uses give 40, a single use right now
+// gives 8, and sustained daily use drives it to ~0. It is blended with the
+// frequency classification so that a package used daily for months is not
+// scored as abandoned after a few idle weeks.
+func decayUsageScore(intensity float64, frequency string) int {
+	decayed := 40 / (1 + 4*intensity)
+
+	freqScore, ok := frequencyUsageScore[frequency]
+	if !ok {
+		freqScore = 30
+	}
+
+	return int(math.Round(decayWeight*decayed + frequencyWeight*freqScore))
+}
+
+// computeDecayUsageScore scores usage under config.ScoringModelDecay. Probe
+// events are ignored; indirect use through library linkage contributes one
+// use at its most recent time per linking package.
+func (a *Analyzer) computeDecayUsageScore(pkg string) int {
+	events, err := a.store.GetUsageEvents(pkg, time.Time{})
+	if err != nil {
+		return 40
+	}
+
+	var uses []time.Time
+	for _, e := range events {
+		if e.EventType == "probe" {
+			continue
+		}
+		uses = append(uses, e.Timestamp)
+	}
+	for _, u := range a.getIndirectUses(pkg) {
+		uses = append(uses, u.LastUsed)
+	}
+
+	if len(uses) == 0 {
+		return 40
+	}
+
+	var lastUsed time.Time
+	for _, t := range uses {
+		if t.After(lastUsed) {
+			lastUsed = t
+		}
+	}
+
+	installedAt := time.Now()
+	if pkgInfo, err := a.store.GetPackage(pkg); err == nil {
+		installedAt = pkgInfo.InstalledAt
+	}
+
+	frequency := a.computeFrequency(&lastUsed, len(uses), installedAt)
+	return decayUsageScore(usageIntensity(uses, time.Now(), a.halfLife), frequency)
+}
+
+// usesDecayModel reports whether the decay scoring model is selected.
+func (a *Analyzer) usesDecayModel() bool {
+	return a.model == config.ScoringModelDecay
+}
@@ -0,0 +1,204 @@
+package analyzer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/config"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// usageHistory returns timestamps for count uses, the most recent daysAgo
+// days in the past and each subsequent one everyDays earlier.
+func usageHistory(count, daysAgo, everyDays int) []time.Time {
+	now := time.Now()
+	uses := make([]time.Time, 0, count)
+	for i := 0; i < count; i++ {
+		uses = append(uses, now.AddDate(0, 0, -(daysAgo+i*everyDays)))
+	}
+	return uses
+}
+
+// setupDecayFixture inserts pkg (installed 200 days ago, with binaries) and
+// its usage history. When hub is true the package gets four never-used
+// dependents, so its dependency score is 0 and usage decides the tier.
+func setupDecayFixture(t *testing.T, s *store.Store, pkg string, hub bool, uses []time.Time) {
+	t.Helper()
+
+	installedAt := time.Now().AddDate(0, 0, -200)
+	if err := s.InsertPackage(&brew.Package{
+		Name:        pkg,
+		Version:     "1.0.0",
+		InstalledAt: installedAt,
+		InstallType: "explicit",
+		HasBinary:   true,
+	}); err != nil {
+		t.Fatalf("failed to insert package: %v", err)
+	}
+
+	if hub {
+		for i := 0; i < 4; i++ {
+			dep := fmt.Sprintf("%s-dependent-%d", pkg, i)
+			if err := s.InsertPackage(&brew.Package{Name: dep, InstalledAt: installedAt}); err != nil {
+				t.Fatalf("failed to insert dependent: %v", err)
+			}
+			if err := s.InsertDependency(dep, pkg); err != nil {
+				t.Fatalf("failed to insert dependency: %v", err)
+			}
+		}
+	}
+
+	for _, ts := range uses {
+		if err := s.InsertUsageEvent(&store.UsageEvent{Package: pkg, EventType: "exec", Timestamp: ts}); err != nil {
+			t.Fatalf("failed to insert usage event: %v", err)
+		}
+	}
+}
+
+func newDecayAnalyzer(s *store.Store, halfLifeDays float64) *Analyzer {
+	a := New(s)
+	a.Configure(&config.Settings{ScoringModel: config.ScoringModelDecay, HalfLifeDays: halfLifeDays})
+	return a
+}
+
+// TestDecayModel_Calibration pins the tiers that representative usage
+// histories land in under the decay model with the default 14-day half-life.
+// A leaf package installed 200 days ago scores 60 before usage, so its tier
+// is safe once the usage component reaches 20; a hub package with four
+// dependents scores 20 before usage and is risky below 30.
+func TestDecayModel_Calibration(t *testing.T) {
+	tests := []struct {
+		name     string
+		hub      bool
+		uses     []time.Time
+		wantTier string
+	}{
+		{"never used", false, nil, "safe"},
+		{"single use yesterday", false, usageHistory(1, 1, 0), "medium"},
+		{"single use 20 days ago", false, usageHistory(1, 20, 0), "medium"},
+		{"single use 40 days ago", false, usageHistory(1, 40, 0), "safe"},
+		{"single use 120 days ago", false, usageHistory(1, 120, 0), "safe"},
+		{"daily for two months", false, usageHistory(60, 0, 1), "medium"},
+		{"daily for two months, stopped half a year ago", false, usageHistory(60, 180, 1), "safe"},
+		{"hub used daily", true, usageHistory(60, 0, 1), "risky"},
+		{"hub used weekly", true, usageHistory(12, 3, 7), "risky"},
+		{"hub never used", true, nil, "medium"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := setupTestStore(t)
+			defer s.Close()
+			setupDecayFixture(t, s, "pkg", tt.hub, tt.uses)
+
+			score, err := newDecayAnalyzer(s, config.DefaultHalfLifeDays).ComputeScore("pkg")
+			if err != nil {
+				t.Fatalf("ComputeScore failed: %v", err)
+			}
+			if score.Tier != tt.wantTier {
+				t.Errorf("tier = %s (score %d, usage %d), want %s",
+					score.Tier, score.Score, score.UsageScore, tt.wantTier)
+			}
+		})
+	}
+}
+
+// TestDecayModel_IntensityDistinguishesVolume checks the motivating case: 200
+// uses eight days ago must look more "in use" than a single use eight days
+// ago, which the bucket model scores identically.
+func TestDecayModel_IntensityDistinguishesVolume(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	setupDecayFixture(t, s, "heavy", false, usageHistory(200, 8, 0))
+	setupDecayFixture(t, s, "light", false, usageHistory(1, 8, 0))
+
+	decay := newDecayAnalyzer(s, config.DefaultHalfLifeDays)
+	heavy, err := decay.ComputeScore("heavy")
+	if err != nil {
+		t.Fatalf("ComputeScore(heavy) failed: %v", err)
+	}
+	light, err := decay.ComputeScore("light")
+	if err != nil {
+		t.Fatalf("ComputeScore(light) failed: %v", err)
+	}
+	if heavy.UsageScore >= light.UsageScore {
+		t.Errorf("heavy usage score %d should be below light usage score %d", heavy.UsageScore, light.UsageScore)
+	}
+
+	buckets := New(s)
+	heavyB, _ := buckets.ComputeScore("heavy")
+	lightB, _ := buckets.ComputeScore("light")
+	if heavyB.UsageScore != lightB.UsageScore {
+		t.Errorf("bucket model should not distinguish volume, got %d vs %d", heavyB.UsageScore, lightB.UsageScore)
+	}
+}
+
+// TestDecayModel_HalfLife checks that a longer half-life keeps old uses
+// relevant for longer.
+func TestDecayModel_HalfLife(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+	setupDecayFixture(t, s, "pkg", false, usageHistory(1, 20, 0))
+
+	short, err := newDecayAnalyzer(s, 3).ComputeScore("pkg")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	long, err := newDecayAnalyzer(s, 90).ComputeScore("pkg")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if long.UsageScore >= short.UsageScore {
+		t.Errorf("90-day half-life usage score %d should be below 3-day half-life score %d",
+			long.UsageScore, short.UsageScore)
+	}
+}
+
+func TestDecayModel_IgnoresProbeEvents(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+	setupDecayFixture(t, s, "pkg", false, nil)
+
+	for i := 0; i < 50; i++ {
+		if err := s.InsertUsageEvent(&store.UsageEvent{Package: "pkg", EventType: "probe", Timestamp: time.Now()}); err != nil {
+			t.Fatalf("failed to insert probe event: %v", err)
+		}
+	}
+
+	score, err := newDecayAnalyzer(s, config.DefaultHalfLifeDays).ComputeScore("pkg")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.UsageScore != 40 {
+		t.Errorf("expected UsageScore 40 with only probe events, got %d", score.UsageScore)
+	}
+}
+
+func TestUsageIntensity(t *testing.T) {
+	now := time.Now()
+	halfLife := 14 * 24 * time.Hour
+
+	tests := []struct {
+		name string
+		uses []time.Time
+		want float64
+	}{
+		{"no uses", nil, 0},
+		{"one use now", []time.Time{now}, 1},
+		{"one use one half-life ago", []time.Time{now.Add(-halfLife)}, 0.5},
+		{"two uses two half-lives ago", []time.Time{now.Add(-2 * halfLife), now.Add(-2 * halfLife)}, 0.5},
+		{"future timestamp clamps to now", []time.Time{now.Add(time.Hour)}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := usageIntensity(tt.uses, now, halfLife)
+			if diff := got - tt.want; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("usageIntensity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
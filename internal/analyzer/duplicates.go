@@ -0,0 +1,250 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/scanner"
+)
+
+// supersededUnusedDays is how long a version must have gone unused, directly
+// or through its dependents, before a newer version in use supersedes it.
+const supersededUnusedDays = 90
+
+// DuplicateGroup is a set of installed formulae that are versions of the
+// same software, such as node, node@20 and node@18.
+type DuplicateGroup struct {
+	Base     string
+	Versions []*DuplicateVersion // newest first; the unversioned formula leads
+}
+
+// DuplicateVersion is one installed formula of a DuplicateGroup.
+type DuplicateVersion struct {
+	Package   string
+	Version   string     // "" for the unversioned formula
+	LastUsed  *time.Time // latest use of the package or a dependent; nil if never
+	SizeBytes int64
+
+	// InUse is set when the version was used in the last
+	// supersededUnusedDays or, failing that, is Referenced by a virtualenv,
+	// script, symlink or project declaration.
+	InUse      bool
+	Referenced bool
+
+	// SupersededBy names the newest version in use when this one is unused
+	// and has no dependents; "" otherwise.
+	SupersededBy string
+}
+
+// Reclaimable returns the combined size of the group's superseded versions.
+func (g *DuplicateGroup) Reclaimable() int64 {
+	var total int64
+	for _, v := range g.Versions {
+		if v.SupersededBy != "" {
+			total += v.SizeBytes
+		}
+	}
+	return total
+}
+
+// FindDuplicates groups installed formulae by base name (node@18 and node
+// both belong to "node") and compares the usage of each group's versions.
+// Only groups of two or more formulae are returned, sorted by base name.
+func (a *Analyzer) FindDuplicates() ([]*DuplicateGroup, error) {
+	a = a.pass()
+	packages, err := a.store.ListPackages()
+	if err != nil {
+		return nil, err
+	}
+
+	byBase := make(map[string][]string)
+	for _, pkg := range packages {
+		if pkg.IsCask {
+			continue
+		}
+		base, _ := splitVersionedName(pkg.Name)
+		byBase[base] = append(byBase[base], pkg.Name)
+	}
+
+	var groups []*DuplicateGroup
+	for base, names := range byBase {
+		if len(names) < 2 {
+			continue
+		}
+		group, err := a.duplicateGroup(base, names)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Base < groups[j].Base })
+	return groups, nil
+}
+
+// IsCoreDependency reports whether pkg is a core dependency that must not be
+// removed (see scanner.IsCoreDependency). A core runtime version such as
+// python@3.9 is only protected until a newer version in use supersedes it.
+func (a *Analyzer) IsCoreDependency(pkg string) bool {
+	if !scanner.IsCoreDependency(pkg) {
+		return false
+	}
+	if !scanner.IsCoreRuntimeVersion(pkg) {
+		return true
+	}
+	version, err := a.duplicateVersion(pkg)
+	return err != nil || version == nil || version.SupersededBy == ""
+}
+
+// duplicateVersion returns pkg's entry in its group of versions, or nil
+// when no other version is installed.
+func (a *Analyzer) duplicateVersion(pkg string) (*DuplicateVersion, error) {
+	packages, err := a.store.ListPackages()
+	if err != nil {
+		return nil, err
+	}
+	base, _ := splitVersionedName(pkg)
+	var names []string
+	for _, p := range packages {
+		if b, _ := splitVersionedName(p.Name); b == base && !p.IsCask {
+			names = append(names, p.Name)
+		}
+	}
+	if len(names) < 2 {
+		return nil, nil
+	}
+
+	group, err := a.duplicateGroup(base, names)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range group.Versions {
+		if v.Package == pkg {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
+
+// duplicateGroup assesses the usage of each of names, the installed
+// formulae sharing base, and marks the superseded ones.
+func (a *Analyzer) duplicateGroup(base string, names []string) (*DuplicateGroup, error) {
+	group := &DuplicateGroup{Base: base}
+	for _, name := range names {
+		v, err := a.assessVersion(name)
+		if err != nil {
+			return nil, err
+		}
+		group.Versions = append(group.Versions, v)
+	}
+	sort.Slice(group.Versions, func(i, j int) bool {
+		return compareVersions(group.Versions[i].Version, group.Versions[j].Version) > 0
+	})
+
+	newestInUse := ""
+	for _, v := range group.Versions {
+		if v.InUse {
+			if newestInUse == "" {
+				newestInUse = v.Package
+			}
+			continue
+		}
+		if newestInUse == "" {
+			continue // No newer version in use
+		}
+		dependents, err := a.store.GetDependents(v.Package)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dependents of %s: %w", v.Package, err)
+		}
+		if len(dependents) == 0 {
+			v.SupersededBy = newestInUse
+		}
+	}
+	return group, nil
+}
+
+// assessVersion records when pkg, or anything depending on it, was last
+// used and whether it counts as in use. References are only looked up when
+// there is no recent use.
+func (a *Analyzer) assessVersion(pkg string) (*DuplicateVersion, error) {
+	info, err := a.store.GetPackage(pkg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package: %w", err)
+	}
+	use, err := a.EffectiveLastUse(pkg)
+	if err != nil {
+		return nil, err
+	}
+	_, version := splitVersionedName(pkg)
+	v := &DuplicateVersion{
+		Package:   pkg,
+		Version:   version,
+		LastUsed:  use.LastUsed,
+		SizeBytes: info.SizeBytes,
+	}
+	if use.LastUsed != nil && daysSince(*use.LastUsed) < supersededUnusedDays {
+		v.InUse = true
+		return v, nil
+	}
+
+	refs, err := a.runtimeReferences(pkg)
+	if err != nil {
+		return nil, err
+	}
+	declared, err := a.declaringProjects(pkg)
+	if err != nil {
+		return nil, err
+	}
+	v.Referenced = len(refs) > 0 || len(declared) > 0
+	v.InUse = v.Referenced
+	return v, nil
+}
+
+// splitVersionedName splits a versioned formula name such as "node@18" into
+// its base name and version. Unversioned names have an empty version.
+func splitVersionedName(name string) (base, version string) {
+	base, version, _ = strings.Cut(name, "@")
+	return base, version
+}
+
+// compareVersions orders formula versions such as "3.9" and "3.12"
+// numerically, field by field. The empty version of an unversioned formula
+// tracks the latest release, so it is newer than any other. Non-numeric
+// fields compare as strings.
+func compareVersions(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	af, bf := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(af) && i < len(bf); i++ {
+		an, aErr := strconv.Atoi(af[i])
+		bn, bErr := strconv.Atoi(bf[i])
+		if aErr != nil || bErr != nil {
+			if c := strings.Compare(af[i], bf[i]); c != 0 {
+				return c
+			}
+			continue
+		}
+		if an != bn {
+			if an < bn {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(af) < len(bf):
+		return -1
+	case len(af) > len(bf):
+		return 1
+	}
+	return 0
+}
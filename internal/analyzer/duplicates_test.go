@@ -0,0 +1,178 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// insertVersions installs each package with the given size and records a
+// use daysAgo days ago; a negative daysAgo records none.
+func insertVersions(t *testing.T, s *store.Store, daysAgo map[string]int) {
+	t.Helper()
+	for name, days := range daysAgo {
+		if err := s.InsertPackage(&brew.Package{
+			Name:        name,
+			InstalledAt: time.Now().AddDate(-2, 0, 0),
+			InstallType: "explicit",
+			HasBinary:   true,
+			SizeBytes:   10 << 20,
+		}); err != nil {
+			t.Fatalf("failed to insert package %s: %v", name, err)
+		}
+		if days < 0 {
+			continue
+		}
+		if err := s.InsertUsageEvent(&store.UsageEvent{
+			Package:   name,
+			EventType: "exec",
+			Timestamp: time.Now().AddDate(0, 0, -days),
+		}); err != nil {
+			t.Fatalf("failed to insert usage event: %v", err)
+		}
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	insertVersions(t, s, map[string]int{
+		"node":        -1,
+		"node@20":     2,
+		"node@18":     200,
+		"node@16":     -1,
+		"node@14":     -1,
+		"python@3.12": 1,
+		"python@3.9":  -1,
+		"ruby@3.1":    5,
+		"ruby@3.3":    -1,
+		"jq":          1,
+	})
+	// An unused dependent keeps node@14 installed.
+	insertVersions(t, s, map[string]int{"legacy-cli": -1})
+	if err := s.InsertDependency("legacy-cli", "node@14"); err != nil {
+		t.Fatalf("failed to insert dependency: %v", err)
+	}
+
+	groups, err := New(s).FindDuplicates()
+	if err != nil {
+		t.Fatalf("FindDuplicates failed: %v", err)
+	}
+
+	var got []string
+	for _, g := range groups {
+		var versions []string
+		for _, v := range g.Versions {
+			if v.SupersededBy != "" {
+				versions = append(versions, v.Package+">"+v.SupersededBy)
+			} else {
+				versions = append(versions, v.Package)
+			}
+		}
+		got = append(got, g.Base+": "+strings.Join(versions, " "))
+	}
+	// The unused unversioned node supersedes nothing; ruby@3.3 is newer
+	// than the version in use.
+	want := []string{
+		"node: node node@20 node@18>node@20 node@16>node@20 node@14",
+		"python: python@3.12 python@3.9>python@3.12",
+		"ruby: ruby@3.3 ruby@3.1",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("FindDuplicates() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if groups[0].Reclaimable() != 20<<20 {
+		t.Errorf("node Reclaimable() = %d, want %d", groups[0].Reclaimable(), 20<<20)
+	}
+}
+
+func TestFindDuplicates_RuntimeReferenceKeepsVersion(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	insertVersions(t, s, map[string]int{"node@20": 1, "node@16": -1})
+	if err := s.ReplaceRuntimeReferences([]*store.RuntimeReference{{
+		Package:    "node@16",
+		Kind:       store.RuntimeSymlink,
+		Path:       "/u/.nodenv/versions/16",
+		Target:     "/opt/homebrew/opt/node@16",
+		DetectedAt: time.Now(),
+	}}); err != nil {
+		t.Fatalf("ReplaceRuntimeReferences failed: %v", err)
+	}
+
+	groups, err := New(s).FindDuplicates()
+	if err != nil {
+		t.Fatalf("FindDuplicates failed: %v", err)
+	}
+	if len(groups) != 1 || len(groups[0].Versions) != 2 {
+		t.Fatalf("FindDuplicates() = %+v, want one group of two", groups)
+	}
+	old := groups[0].Versions[1]
+	if old.Package != "node@16" || !old.InUse || !old.Referenced || old.SupersededBy != "" {
+		t.Errorf("node@16 = %+v, want referenced and not superseded", old)
+	}
+}
+
+func TestIsCoreDependency_OnlyUsedPythonProtected(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	insertVersions(t, s, map[string]int{"python@3.12": 1, "python@3.9": -1, "python@3.8": 400})
+	a := New(s)
+
+	for pkg, want := range map[string]bool{
+		"python@3.12": true,
+		"python@3.9":  false,
+		"python@3.8":  false,
+		"openssl@3":   true,
+		"jq":          false,
+	} {
+		if got := a.IsCoreDependency(pkg); got != want {
+			t.Errorf("IsCoreDependency(%q) = %v, want %v", pkg, got, want)
+		}
+	}
+
+	score, err := a.ComputeScore("python@3.9")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.IsCritical || score.Tier != "safe" {
+		t.Errorf("superseded python@3.9 scored %s (critical %v), want safe", score.Tier, score.IsCritical)
+	}
+}
+
+func TestIsCoreDependency_SinglePythonProtected(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	insertVersions(t, s, map[string]int{"python@3.11": -1})
+	if !New(s).IsCoreDependency("python@3.11") {
+		t.Error("the only installed python@3.x should stay protected")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"3.12", "3.9", 1},
+		{"3.9", "3.12", -1},
+		{"16", "18", -1},
+		{"", "20", 1},
+		{"20", "", -1},
+		{"3.1", "3.1", 0},
+		{"3", "3.1", -1},
+		{"1.1", "3", -1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
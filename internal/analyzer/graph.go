@@ -0,0 +1,188 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// staleDependentDays is how long every dependent of a package must have gone
+// unused before the package is scored as if it had no dependents at all.
+const staleDependentDays = 365
+
+// usageCache holds the usage of packages looked up during one analysis
+// pass, keyed by package, so that scoring every package reads each
+// package's usage and walks each part of the dependency graph only once.
+type usageCache struct {
+	lastUse    map[string]cachedLastUse
+	dependents map[string][]string
+	effective  map[string]*EffectiveUse
+}
+
+// cachedLastUse is a memoized result of Analyzer.lastUsage.
+type cachedLastUse struct {
+	lastUsed *time.Time
+	via      string
+}
+
+// pass returns an Analyzer for one analysis pass: a copy of a whose usage
+// lookups are memoized until the copy is dropped, or a itself when it
+// already is one. Entry points that score or walk several packages start a
+// pass so they read each package's usage once, and no lookup outlives the
+// call that made it, so changes to the store are seen by the next call.
+func (a *Analyzer) pass() *Analyzer {
+	if a.cache != nil {
+		return a
+	}
+	p := *a
+	p.cache = &usageCache{
+		lastUse:    make(map[string]cachedLastUse),
+		dependents: make(map[string][]string),
+		effective:  make(map[string]*EffectiveUse),
+	}
+	return &p
+}
+
+// dependentsOf returns the packages that directly depend on pkg. a must be
+// a pass.
+func (a *Analyzer) dependentsOf(pkg string) ([]string, error) {
+	if dependents, ok := a.cache.dependents[pkg]; ok {
+		return dependents, nil
+	}
+	dependents, err := a.store.GetDependents(pkg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dependents of %s: %w", pkg, err)
+	}
+	a.cache.dependents[pkg] = dependents
+	return dependents, nil
+}
+
+// EffectiveLastUse returns the latest usage among pkg and every package that
+// transitively depends on it. A library is effectively in use for as long as
+// anything built on top of it is, so the most recent use of any dependent
+// counts, and the path to the package that used it is recorded. Results are
+// memoized for the analysis pass, so scoring every package visits each
+// package of the reverse dependency graph once.
+func (a *Analyzer) EffectiveLastUse(pkg string) (*EffectiveUse, error) {
+	use, _, err := a.pass().effectiveLastUse(pkg, make(map[string]bool))
+	return use, err
+}
+
+// effectiveLastUse computes EffectiveLastUse depth-first within pass a.
+// visiting holds the packages on the current path; a dependent already on
+// it closes a cycle and is skipped, and the results depending on that skip
+// are reported as partial and not memoized.
+func (a *Analyzer) effectiveLastUse(pkg string, visiting map[string]bool) (use *EffectiveUse, partial bool, err error) {
+	cache := a.cache
+	if use, ok := cache.effective[pkg]; ok {
+		return use, false, nil
+	}
+
+	use = &EffectiveUse{}
+	if lastUsed, _ := a.lastUsage(pkg); lastUsed != nil {
+		use.LastUsed = lastUsed
+		use.Path = []string{pkg}
+	}
+
+	dependents, err := a.dependentsOf(pkg)
+	if err != nil {
+		return nil, false, err
+	}
+	visiting[pkg] = true
+	defer delete(visiting, pkg)
+	for _, dep := range dependents {
+		if visiting[dep] {
+			partial = true // Cycle in the graph
+			continue
+		}
+		depUse, depPartial, err := a.effectiveLastUse(dep, visiting)
+		if err != nil {
+			return nil, false, err
+		}
+		partial = partial || depPartial
+		if depUse.LastUsed != nil && (use.LastUsed == nil || depUse.LastUsed.After(*use.LastUsed)) {
+			use = &EffectiveUse{
+				LastUsed: depUse.LastUsed,
+				Path:     append([]string{pkg}, depUse.Path...),
+			}
+		}
+	}
+
+	if !partial {
+		cache.effective[pkg] = use
+	}
+	return use, partial, nil
+}
+
+// dependentUsage summarises how a package's direct dependents are used once
+// usage is propagated through the dependency graph.
+type dependentUsage struct {
+	usedCount int           // dependents effectively used in the last 30 days
+	stale     bool          // every dependent unused for at least staleDependentDays
+	latest    *EffectiveUse // most recent effective use among dependents; nil if none
+}
+
+// assessDependents computes the effective usage of each direct dependent of
+// pkg. The returned path in latest starts at pkg.
+func (a *Analyzer) assessDependents(pkg string, dependents []string) (*dependentUsage, error) {
+	result := &dependentUsage{stale: len(dependents) > 0}
+
+	for _, dep := range dependents {
+		use, err := a.EffectiveLastUse(dep)
+		if err != nil {
+			return nil, err
+		}
+
+		if use.LastUsed == nil {
+			// Never used: only stale once it has been installed long enough
+			// to have had the chance.
+			depInfo, err := a.store.GetPackage(dep)
+			if err != nil || daysSince(depInfo.InstalledAt) < staleDependentDays {
+				result.stale = false
+			}
+			continue
+		}
+
+		days := daysSince(*use.LastUsed)
+		if days <= 30 {
+			result.usedCount++
+		}
+		if days < staleDependentDays {
+			result.stale = false
+		}
+		if result.latest == nil || use.LastUsed.After(*result.latest.LastUsed) {
+			result.latest = &EffectiveUse{
+				LastUsed: use.LastUsed,
+				Path:     append([]string{pkg}, use.Path...),
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// formatUsePath renders the dependents in an effective-use path, e.g.
+// "via python@3.12 → poetry, 3 days ago". The first element (the scored
+// package itself) is omitted.
+func formatUsePath(use *EffectiveUse) string {
+	if use == nil || use.LastUsed == nil || len(use.Path) < 2 {
+		return ""
+	}
+
+	var ago string
+	switch days := daysSince(*use.LastUsed); days {
+	case 0:
+		ago = "today"
+	case 1:
+		ago = "1 day ago"
+	default:
+		ago = fmt.Sprintf("%d days ago", days)
+	}
+
+	return fmt.Sprintf("via %s, %s", strings.Join(use.Path[1:], " → "), ago)
+}
+
+// daysSince returns the number of whole days elapsed since t.
+func daysSince(t time.Time) int {
+	return int(time.Since(t).Hours() / 24)
+}
@@ -0,0 +1,216 @@
+package analyzer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// insertChain inserts packages where each one depends on the previous one,
+// e.g. insertChain("openssl@3", "python@3.12", "poetry").
+func insertChain(t *testing.T, s *store.Store, installedAt time.Time, names ...string) {
+	t.Helper()
+	for i, name := range names {
+		pkg := &brew.Package{
+			Name:        name,
+			Version:     "1.0.0",
+			InstalledAt: installedAt,
+			InstallType: "dependency",
+			HasBinary:   i == len(names)-1,
+		}
+		if err := s.InsertPackage(pkg); err != nil {
+			t.Fatalf("failed to insert package %s: %v", name, err)
+		}
+		if i > 0 {
+			if err := s.InsertDependency(name, names[i-1]); err != nil {
+				t.Fatalf("failed to insert dependency %s -> %s: %v", name, names[i-1], err)
+			}
+		}
+	}
+}
+
+func TestEffectiveLastUse_TransitiveDependent(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	insertChain(t, s, time.Now().AddDate(0, 0, -200), "openssl@3", "python@3.12", "poetry")
+	if err := s.InsertUsageEvent(&store.UsageEvent{
+		Package:   "poetry",
+		EventType: "exec",
+		Timestamp: time.Now().AddDate(0, 0, -3),
+	}); err != nil {
+		t.Fatalf("failed to insert usage event: %v", err)
+	}
+
+	use, err := New(s).EffectiveLastUse("openssl@3")
+	if err != nil {
+		t.Fatalf("EffectiveLastUse failed: %v", err)
+	}
+	if use.LastUsed == nil {
+		t.Fatal("expected effective use through poetry")
+	}
+	want := []string{"openssl@3", "python@3.12", "poetry"}
+	if !reflect.DeepEqual(use.Path, want) {
+		t.Errorf("Path = %v, want %v", use.Path, want)
+	}
+}
+
+func TestEffectiveLastUse_NeverUsed(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	insertChain(t, s, time.Now().AddDate(0, 0, -200), "libyaml", "ruby")
+
+	use, err := New(s).EffectiveLastUse("libyaml")
+	if err != nil {
+		t.Fatalf("EffectiveLastUse failed: %v", err)
+	}
+	if use.LastUsed != nil || len(use.Path) != 0 {
+		t.Errorf("expected no effective use, got %+v", use)
+	}
+}
+
+func TestEffectiveLastUse_MemoizedWithinPass(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	insertChain(t, s, time.Now().AddDate(0, 0, -200), "libyaml", "ruby", "rubocop")
+	a := New(s)
+	p := a.pass()
+	if use, err := p.EffectiveLastUse("libyaml"); err != nil || use.LastUsed != nil {
+		t.Fatalf("EffectiveLastUse = %+v, %v; want no use", use, err)
+	}
+
+	if err := s.InsertUsageEvent(&store.UsageEvent{
+		Package:   "rubocop",
+		EventType: "exec",
+		Timestamp: time.Now().AddDate(0, 0, -1),
+	}); err != nil {
+		t.Fatalf("failed to insert usage event: %v", err)
+	}
+	if use, _ := p.EffectiveLastUse("libyaml"); use.LastUsed != nil {
+		t.Error("expected the analysis pass to keep the memoized result")
+	}
+
+	// The Analyzer itself keeps nothing between calls.
+	use, err := a.EffectiveLastUse("libyaml")
+	if err != nil {
+		t.Fatalf("EffectiveLastUse failed: %v", err)
+	}
+	if want := []string{"libyaml", "ruby", "rubocop"}; !reflect.DeepEqual(use.Path, want) {
+		t.Errorf("Path after new usage = %v, want %v", use.Path, want)
+	}
+}
+
+func TestEffectiveLastUse_Cycle(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	insertChain(t, s, time.Now().AddDate(0, 0, -200), "a", "b", "c")
+	if err := s.InsertDependency("a", "c"); err != nil {
+		t.Fatalf("failed to insert dependency: %v", err)
+	}
+	if err := s.InsertUsageEvent(&store.UsageEvent{
+		Package:   "a",
+		EventType: "exec",
+		Timestamp: time.Now().AddDate(0, 0, -1),
+	}); err != nil {
+		t.Fatalf("failed to insert usage event: %v", err)
+	}
+
+	an := New(s)
+	for _, pkg := range []string{"b", "c", "a"} {
+		use, err := an.EffectiveLastUse(pkg)
+		if err != nil {
+			t.Fatalf("EffectiveLastUse(%s) failed: %v", pkg, err)
+		}
+		if use.LastUsed == nil || use.Path[len(use.Path)-1] != "a" {
+			t.Errorf("EffectiveLastUse(%s) = %+v, want use through a", pkg, use)
+		}
+	}
+}
+
+func TestComputeScore_DepsDetailShowsPath(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	insertChain(t, s, time.Now().AddDate(0, 0, -200), "openssl@3", "python@3.12", "poetry")
+	if err := s.InsertUsageEvent(&store.UsageEvent{
+		Package:   "poetry",
+		EventType: "exec",
+		Timestamp: time.Now().AddDate(0, 0, -3),
+	}); err != nil {
+		t.Fatalf("failed to insert usage event: %v", err)
+	}
+
+	score, err := New(s).ComputeScore("openssl@3")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+
+	// python@3.12 is never run directly but poetry is, so it counts as used.
+	if score.DepsScore != 10 {
+		t.Errorf("expected DepsScore 10 (used dependent), got %d", score.DepsScore)
+	}
+	want := "1 used dependent (via python@3.12 → poetry, 3 days ago)"
+	if score.Explanation.DepsDetail != want {
+		t.Errorf("DepsDetail = %q, want %q", score.Explanation.DepsDetail, want)
+	}
+}
+
+func TestComputeScore_StaleDependentsScoreLikeLeaf(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	// Everything installed 2 years ago; the only dependent was last used
+	// 400 days ago.
+	insertChain(t, s, time.Now().AddDate(-2, 0, 0), "libfoo", "foo-cli")
+	if err := s.InsertUsageEvent(&store.UsageEvent{
+		Package:   "foo-cli",
+		EventType: "exec",
+		Timestamp: time.Now().AddDate(0, 0, -400),
+	}); err != nil {
+		t.Fatalf("failed to insert usage event: %v", err)
+	}
+
+	score, err := New(s).ComputeScore("libfoo")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+
+	// Usage 40 + Deps 30 (stale) + Age 20 + Type 5 (library, typed as leaf)
+	if score.DepsScore != 30 {
+		t.Errorf("expected DepsScore 30 for stale dependents, got %d", score.DepsScore)
+	}
+	if score.Score != 95 || score.Tier != "safe" {
+		t.Errorf("expected score 95 (safe), got %d (%s)", score.Score, score.Tier)
+	}
+	if !strings.Contains(score.Explanation.DepsDetail, "unused for over a year") {
+		t.Errorf("DepsDetail = %q, want stale wording", score.Explanation.DepsDetail)
+	}
+}
+
+func TestComputeScore_RecentlyInstalledDependentNotStale(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	insertChain(t, s, time.Now().AddDate(-2, 0, 0), "libbar")
+	insertChain(t, s, time.Now().AddDate(0, 0, -20), "bar-cli")
+	if err := s.InsertDependency("bar-cli", "libbar"); err != nil {
+		t.Fatalf("failed to insert dependency: %v", err)
+	}
+
+	score, err := New(s).ComputeScore("libbar")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+
+	// A dependent installed 20 days ago has not had a year to be used.
+	if score.DepsScore != 20 {
+		t.Errorf("expected DepsScore 20 for one unused (not stale) dependent, got %d", score.DepsScore)
+	}
+}
@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Periodic-usage detection thresholds.
+const (
+	// minPeriodicSessions is the number of separate usage sessions needed
+	// before a cadence is trusted (two intervals).
+	minPeriodicSessions = 3
+
+	// minPeriodicInterval excludes ordinary frequent use: a tool run every
+	// few days is simply in use, not periodic.
+	minPeriodicInterval = 7 * 24 * time.Hour
+
+	// maxPeriodicVariation is the largest coefficient of variation
+	// (stddev/mean) of the intervals that still counts as regular.
+	maxPeriodicVariation = 0.35
+
+	// sessionGap separates usage sessions: uses closer together than this
+	// (e.g. twenty invocations on tax day) are one occurrence.
+	sessionGap = 24 * time.Hour
+
+	// minPeriodicGrace is the smallest tolerance granted after the predicted
+	// date before a missed use releases the hold.
+	minPeriodicGrace = 7 * 24 * time.Hour
+)
+
+// detectPeriodic looks for a regular cadence in a package's usage history
+// and predicts the next use. It returns nil when there are too few usage
+// sessions or the intervals between them are irregular.
+func detectPeriodic(uses []time.Time) *PeriodicPattern {
+	if len(uses) < minPeriodicSessions {
+		return nil
+	}
+
+	sorted := make([]time.Time, len(uses))
+	copy(sorted, uses)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	// Collapse bursts into sessions, keyed by their first use.
+	sessions := []time.Time{sorted[0]}
+	last := sorted[0]
+	for _, t := range sorted[1:] {
+		if t.Sub(last) > sessionGap {
+			sessions = append(sessions, t)
+		}
+		last = t
+	}
+	if len(sessions) < minPeriodicSessions {
+		return nil
+	}
+
+	intervals := make([]float64, 0, len(sessions)-1)
+	var sum float64
+	for i := 1; i < len(sessions); i++ {
+		d := float64(sessions[i].Sub(sessions[i-1]))
+		intervals = append(intervals, d)
+		sum += d
+	}
+	mean := sum / float64(len(intervals))
+	if mean < float64(minPeriodicInterval) {
+		return nil
+	}
+
+	var variance float64
+	for _, d := range intervals {
+		variance += (d - mean) * (d - mean)
+	}
+	variance /= float64(len(intervals))
+	if math.Sqrt(variance)/mean > maxPeriodicVariation {
+		return nil
+	}
+
+	interval := time.Duration(mean)
+	grace := interval / 4
+	if grace < minPeriodicGrace {
+		grace = minPeriodicGrace
+	}
+
+	lastSession := sessions[len(sessions)-1]
+	return &PeriodicPattern{
+		Interval:     interval,
+		Occurrences:  len(sessions),
+		LastUse:      lastSession,
+		NextExpected: lastSession.Add(interval),
+		Grace:        grace,
+	}
+}
+
+// GetPeriodicPattern returns the detected usage cadence of a package, or nil
+// if its usage is not periodic. Probe events are ignored.
+func (a *Analyzer) GetPeriodicPattern(pkg string) (*PeriodicPattern, error) {
+	events, err := a.store.GetUsageEvents(pkg, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage events: %w", err)
+	}
+
+	uses := make([]time.Time, 0, len(events))
+	for _, e := range events {
+		if e.EventType == "probe" {
+			continue
+		}
+		uses = append(uses, e.Timestamp)
+	}
+
+	return detectPeriodic(uses), nil
+}
+
+// Holds reports whether the pattern still protects the package at now: from
+// the last use until the predicted next use (plus grace) passes without one.
+func (p *PeriodicPattern) Holds(now time.Time) bool {
+	return p != nil && !now.After(p.NextExpected.Add(p.Grace))
+}
+
+// Label returns the status label shown for a held periodic package, e.g.
+// "PERIODIC — next expected ~2026-12-01".
+func (p *PeriodicPattern) Label() string {
+	return fmt.Sprintf("PERIODIC — next expected ~%s", p.NextExpected.Format("2006-01-02"))
+}
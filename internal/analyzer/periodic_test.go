@@ -0,0 +1,172 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// daysAgo returns timestamps the given number of days in the past.
+func daysAgo(days ...int) []time.Time {
+	now := time.Now()
+	uses := make([]time.Time, 0, len(days))
+	for _, d := range days {
+		uses = append(uses, now.AddDate(0, 0, -d))
+	}
+	return uses
+}
+
+func TestDetectPeriodic(t *testing.T) {
+	tests := []struct {
+		name         string
+		uses         []time.Time
+		wantPeriodic bool
+		wantDays     int
+	}{
+		{"quarterly", daysAgo(270, 180, 90), true, 90},
+		{"monthly with jitter", daysAgo(95, 62, 33, 2), true, 31},
+		{"bursts count once per session", append(daysAgo(180, 180, 180, 90, 90), daysAgo(0)...), true, 90},
+		{"too few sessions", daysAgo(180, 90), false, 0},
+		{"irregular", daysAgo(300, 290, 100), false, 0},
+		{"frequent use is not periodic", daysAgo(6, 4, 2, 0), false, 0},
+		{"no uses", nil, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := detectPeriodic(tt.uses)
+			if (p != nil) != tt.wantPeriodic {
+				t.Fatalf("detectPeriodic() = %+v, wantPeriodic %v", p, tt.wantPeriodic)
+			}
+			if p == nil {
+				return
+			}
+			if days := int(p.Interval.Hours()/24 + 0.5); days != tt.wantDays {
+				t.Errorf("interval = %d days, want %d", days, tt.wantDays)
+			}
+			if !p.NextExpected.Equal(p.LastUse.Add(p.Interval)) {
+				t.Errorf("NextExpected = %v, want LastUse + Interval", p.NextExpected)
+			}
+		})
+	}
+}
+
+func TestPeriodicPattern_Holds(t *testing.T) {
+	now := time.Now()
+	p := &PeriodicPattern{
+		Interval:     90 * 24 * time.Hour,
+		NextExpected: now.AddDate(0, 0, 5),
+		Grace:        22 * 24 * time.Hour,
+	}
+
+	if !p.Holds(now) {
+		t.Error("pattern should hold before the next expected date")
+	}
+	if !p.Holds(now.AddDate(0, 0, 20)) {
+		t.Error("pattern should hold within the grace period")
+	}
+	if p.Holds(now.AddDate(0, 0, 30)) {
+		t.Error("pattern should lapse once next expected + grace has passed")
+	}
+	if !p.Holds(now.AddDate(0, 0, -60)) {
+		t.Error("pattern should hold between uses, long before the next expected date")
+	}
+
+	var nilPattern *PeriodicPattern
+	if nilPattern.Holds(now) {
+		t.Error("nil pattern should never hold")
+	}
+}
+
+func insertPeriodicPackage(t *testing.T, s *store.Store, uses []time.Time) {
+	t.Helper()
+	if err := s.InsertPackage(&brew.Package{
+		Name:        "certbot",
+		Version:     "2.9.0",
+		InstalledAt: time.Now().AddDate(-2, 0, 0),
+		InstallType: "explicit",
+		HasBinary:   true,
+	}); err != nil {
+		t.Fatalf("failed to insert package: %v", err)
+	}
+	for _, ts := range uses {
+		if err := s.InsertUsageEvent(&store.UsageEvent{Package: "certbot", EventType: "exec", Timestamp: ts}); err != nil {
+			t.Fatalf("failed to insert usage event: %v", err)
+		}
+	}
+}
+
+func TestComputeScore_PeriodicHeldInMedium(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	// Quarterly renewals, last one 85 days ago: next expected in ~5 days.
+	// Without the hold: usage 20 + deps 30 + age 20 + type 10 = 80 (safe).
+	insertPeriodicPackage(t, s, daysAgo(355, 265, 175, 85))
+
+	score, err := New(s).ComputeScore("certbot")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.Tier != "medium" || score.Score != 79 {
+		t.Errorf("expected periodic package held at 79 (medium), got %d (%s)", score.Score, score.Tier)
+	}
+	if score.Periodic == nil {
+		t.Fatal("expected Periodic to be set")
+	}
+	if len(score.Labels) != 1 || !strings.HasPrefix(score.Labels[0], "PERIODIC — next expected ~") {
+		t.Errorf("Labels = %v, want PERIODIC label", score.Labels)
+	}
+	if !strings.Contains(score.Reason, "periodically") {
+		t.Errorf("Reason = %q, want periodic wording", score.Reason)
+	}
+	if len(score.Adjustments) != 1 || score.Adjustments[0].Points != -1 {
+		t.Fatalf("Adjustments = %+v, want one -1 hold", score.Adjustments)
+	}
+	total := score.UsageScore + score.DepsScore + score.AgeScore + score.TypeScore + score.Adjustments[0].Points
+	if total != score.Score {
+		t.Errorf("components and adjustments add up to %d, want %d", total, score.Score)
+	}
+}
+
+func TestComputeScore_PeriodicHeldBetweenUses(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	// Yearly use, last one 120 days ago: the next is ~245 days away, and the
+	// package must not look safe to remove in the meantime.
+	insertPeriodicPackage(t, s, daysAgo(850, 485, 120))
+
+	score, err := New(s).ComputeScore("certbot")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.Periodic == nil {
+		t.Fatal("expected a periodic hold between uses")
+	}
+	if score.Tier == "safe" {
+		t.Errorf("expected periodic package kept out of the safe tier, got %s (%d)", score.Tier, score.Score)
+	}
+}
+
+func TestComputeScore_PeriodicHoldLapses(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	// Monthly use that stopped 200 days ago: the expected date is long past.
+	insertPeriodicPackage(t, s, daysAgo(290, 260, 230, 200))
+
+	score, err := New(s).ComputeScore("certbot")
+	if err != nil {
+		t.Fatalf("ComputeScore failed: %v", err)
+	}
+	if score.Periodic != nil || len(score.Labels) != 0 {
+		t.Errorf("expected no periodic hold after a missed use, got %+v %v", score.Periodic, score.Labels)
+	}
+	if score.Tier != "safe" {
+		t.Errorf("expected safe tier once the hold lapses, got %s (%d)", score.Tier, score.Score)
+	}
+}
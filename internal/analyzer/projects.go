@@ -0,0 +1,83 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/projects"
+)
+
+// Project is a directory tree, normally a repository, that shimmed commands
+// were run in, with the packages used there.
+type Project struct {
+	Path     string
+	Tools    []ProjectTool // most recently used first
+	LastUsed time.Time
+}
+
+// ProjectTool is one package's usage within a project.
+type ProjectTool struct {
+	Package  string
+	Runs     int
+	LastUsed time.Time
+}
+
+// Projects groups usage events that recorded a working directory by project
+// (see projects.Root), most recently used project first. pkg limits the
+// result to projects that use pkg, listing only that package; "" lists all
+// projects and tools. Directories outside any project are left out.
+func (a *Analyzer) Projects(pkg string) ([]*Project, error) {
+	usage, err := a.store.GetDirUsage(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := projects.NewResolver(a.projectDirs)
+	byPath := make(map[string]*Project)
+	tools := make(map[string]map[string]*ProjectTool) // project -> package -> tool
+	for _, u := range usage {
+		root := resolver.Root(u.Cwd)
+		if root == "" {
+			continue
+		}
+		p, ok := byPath[root]
+		if !ok {
+			p = &Project{Path: root}
+			byPath[root] = p
+			tools[root] = make(map[string]*ProjectTool)
+		}
+		t, ok := tools[root][u.Package]
+		if !ok {
+			t = &ProjectTool{Package: u.Package}
+			tools[root][u.Package] = t
+		}
+		t.Runs += u.Runs
+		if u.LastUsed.After(t.LastUsed) {
+			t.LastUsed = u.LastUsed
+		}
+		if u.LastUsed.After(p.LastUsed) {
+			p.LastUsed = u.LastUsed
+		}
+	}
+
+	result := make([]*Project, 0, len(byPath))
+	for root, p := range byPath {
+		for _, t := range tools[root] {
+			p.Tools = append(p.Tools, *t)
+		}
+		sort.Slice(p.Tools, func(i, j int) bool {
+			if !p.Tools[i].LastUsed.Equal(p.Tools[j].LastUsed) {
+				return p.Tools[i].LastUsed.After(p.Tools[j].LastUsed)
+			}
+			return p.Tools[i].Package < p.Tools[j].Package
+		})
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].LastUsed.Equal(result[j].LastUsed) {
+			return result[i].LastUsed.After(result[j].LastUsed)
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result, nil
+}
@@ -0,0 +1,87 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/config"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+func TestProjects(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	src := filepath.Join(home, "src")
+	app := filepath.Join(src, "app")
+	api := filepath.Join(home, "work", "api")
+	for _, dir := range []string{filepath.Join(app, "web"), filepath.Join(api, ".git"), filepath.Join(home, "Downloads")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := setupTestStore(t)
+	defer s.Close()
+	for _, name := range []string{"jq", "node", "terraform"} {
+		if err := s.InsertPackage(&brew.Package{Name: name, InstalledAt: time.Now(), InstallType: "explicit"}); err != nil {
+			t.Fatalf("failed to insert package: %v", err)
+		}
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, e := range []struct {
+		pkg, cwd, eventType string
+		ago                 time.Duration
+	}{
+		{"node", filepath.Join(app, "web"), "exec", time.Hour},
+		{"node", app, "exec", 2 * time.Hour},
+		{"jq", app, "exec", 3 * time.Hour},
+		{"jq", app, "probe", 0}, // probes are not uses
+		{"terraform", api, "exec", 30 * time.Minute},
+		{"jq", api, "exec", 48 * time.Hour},
+		{"jq", filepath.Join(home, "Downloads"), "exec", 0}, // no project
+		{"jq", "", "exec", 0},                               // cwd not recorded
+	} {
+		event := &store.UsageEvent{Package: e.pkg, EventType: e.eventType, Cwd: e.cwd, Timestamp: now.Add(-e.ago)}
+		if err := s.InsertUsageEvent(event); err != nil {
+			t.Fatalf("failed to insert usage event: %v", err)
+		}
+	}
+
+	a := New(s)
+	a.Configure(&config.Settings{ProjectDirs: []string{src}})
+
+	got, err := a.Projects("")
+	if err != nil {
+		t.Fatalf("Projects() error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Projects() returned %d projects, want 2: %+v", len(got), got)
+	}
+	if got[0].Path != api || got[1].Path != app {
+		t.Errorf("project order = %s, %s; want %s, %s", got[0].Path, got[1].Path, api, app)
+	}
+	appTools := got[1].Tools
+	if len(appTools) != 2 || appTools[0].Package != "node" || appTools[0].Runs != 2 || appTools[1].Package != "jq" || appTools[1].Runs != 1 {
+		t.Errorf("app tools = %+v, want node (2 runs) then jq (1 run)", appTools)
+	}
+	if !got[1].LastUsed.Equal(now.Add(-time.Hour)) {
+		t.Errorf("app LastUsed = %v, want %v", got[1].LastUsed, now.Add(-time.Hour))
+	}
+
+	got, err = a.Projects("jq")
+	if err != nil {
+		t.Fatalf("Projects(jq) error: %v", err)
+	}
+	if len(got) != 2 || got[0].Path != app || got[1].Path != api {
+		t.Fatalf("Projects(jq) = %+v, want app then api", got)
+	}
+	for _, p := range got {
+		if len(p.Tools) != 1 || p.Tools[0].Package != "jq" {
+			t.Errorf("Projects(jq) tools for %s = %+v, want only jq", p.Path, p.Tools)
+		}
+	}
+}
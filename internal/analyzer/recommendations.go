@@ -3,11 +3,14 @@ package analyzer
 import (
 	"fmt"
 	"sort"
+
+	"github.com/blackwell-systems/brewprune/internal/brew"
 )
 
 // GetRecommendations returns packages recommended for removal based on safe tier.
-// Returns safe packages sorted by size (largest first).
+// Returns safe packages, other than pinned ones, sorted by size (largest first).
 func (a *Analyzer) GetRecommendations() (*Recommendation, error) {
+	a = a.pass()
 	safePackages, err := a.GetPackagesByTier("safe")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get safe packages: %w", err)
@@ -34,6 +37,9 @@ func (a *Analyzer) GetRecommendations() (*Recommendation, error) {
 	var pkgSizes []pkgSize
 
 	for _, score := range safePackages {
+		if score.IsPinned {
+			continue // Pinned packages are kept on purpose
+		}
 		pkgInfo, err := a.store.GetPackage(score.Package)
 		if err != nil {
 			// Skip packages we can't get info for
@@ -68,6 +74,7 @@ func (a *Analyzer) GetRecommendations() (*Recommendation, error) {
 // ValidateRemoval validates that a list of packages can be safely removed.
 // Returns a list of warnings for packages that may cause issues.
 func (a *Analyzer) ValidateRemoval(packages []string) ([]string, error) {
+	a = a.pass()
 	var warnings []string
 
 	for _, pkg := range packages {
@@ -132,6 +139,15 @@ func (a *Analyzer) ValidateRemoval(packages []string) ([]string, error) {
 			}
 		}
 
+		if pkgInfo.Pinned {
+			warnings = append(warnings,
+				fmt.Sprintf("%s: pinned with 'brew pin'", pkg))
+		}
+		if brew.IsRunningService(pkgInfo.Service) {
+			warnings = append(warnings,
+				fmt.Sprintf("%s: running as a brew service (%s)", pkg, pkgInfo.Service))
+		}
+
 		// Check if it's explicitly installed
 		if pkgInfo.InstallType == "explicit" {
 			warnings = append(warnings,
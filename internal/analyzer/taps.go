@@ -0,0 +1,107 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/blackwell-systems/brewprune/internal/brew"
+)
+
+// TapUsage is the usage of the installed packages of one tap.
+type TapUsage struct {
+	Name      string
+	Path      string // git clone; "" when the tap is not cloned
+	SizeBytes int64  // size of the clone
+	Tapped    bool   // listed by `brew tap`
+
+	// Prefixes are the Homebrew prefixes whose brew lists the tap, in the
+	// order the taps were given.
+	Prefixes []string
+
+	// Packages holds every installed package from the tap. Outside the
+	// official taps, each is also in exactly one of Used (outside the safe
+	// tier: recently used, referenced, held or running as a service), Kept
+	// (safe but pinned) and Unused (safe to remove).
+	Packages []string
+	Used     []string
+	Kept     []string
+	Unused   []string
+
+	UnusedSizeBytes int64 // combined size of the Unused packages
+}
+
+// ShouldUntap reports whether the tap can be untapped: it is tapped, is not
+// one of Homebrew's official taps, and none of its installed packages is
+// used or pinned.
+func (t *TapUsage) ShouldUntap() bool {
+	return t.Tapped && !brew.IsOfficialTap(t.Name) && len(t.Used) == 0 && len(t.Kept) == 0
+}
+
+// AnalyzeTaps returns the usage of each of taps, plus the taps installed
+// packages came from that are no longer tapped, sorted by name. Packages are
+// classified by their confidence tier, so the holds of ComputeScore (shim
+// bypass, periodic use, declared by a project, no cask launch data) keep a
+// tap. Packages of the official taps, which are never untapped, are listed
+// but not scored.
+func (a *Analyzer) AnalyzeTaps(taps []*brew.Tap) ([]*TapUsage, error) {
+	a = a.pass()
+	byName := make(map[string]*TapUsage)
+	for _, tap := range taps {
+		if u := byName[tap.Name]; u != nil {
+			// Tapped in more than one prefix
+			u.SizeBytes += tap.SizeBytes
+			u.Prefixes = append(u.Prefixes, tap.Prefix)
+			continue
+		}
+		byName[tap.Name] = &TapUsage{
+			Name:      tap.Name,
+			Path:      tap.Path,
+			SizeBytes: tap.SizeBytes,
+			Tapped:    true,
+			Prefixes:  []string{tap.Prefix},
+		}
+	}
+
+	packages, err := a.store.ListPackages()
+	if err != nil {
+		return nil, err
+	}
+	for _, pkg := range packages {
+		if pkg.Tap == "" {
+			continue
+		}
+		u := byName[pkg.Tap]
+		if u == nil {
+			u = &TapUsage{Name: pkg.Tap}
+			byName[pkg.Tap] = u
+		}
+		u.Packages = append(u.Packages, pkg.Name)
+		if brew.IsOfficialTap(pkg.Tap) {
+			continue
+		}
+
+		score, err := a.ComputeScore(pkg.Name)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case score.Tier != "safe":
+			u.Used = append(u.Used, pkg.Name)
+		case pkg.Pinned:
+			u.Kept = append(u.Kept, pkg.Name)
+		default:
+			u.Unused = append(u.Unused, pkg.Name)
+			u.UnusedSizeBytes += pkg.SizeBytes
+		}
+	}
+
+	usage := make([]*TapUsage, 0, len(byName))
+	for _, u := range byName {
+		sort.Strings(u.Packages)
+		sort.Strings(u.Used)
+		sort.Strings(u.Kept)
+		sort.Strings(u.Unused)
+		usage = append(usage, u)
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Name < usage[j].Name })
+	return usage, nil
+}
@@ -0,0 +1,108 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+func TestAnalyzeTaps(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	for _, pkg := range []*brew.Package{
+		{Name: "jq", Tap: "homebrew/core", SizeBytes: 1 << 20},
+		{Name: "tool", Tap: "user/tools", SizeBytes: 5 << 20},
+		{Name: "tool-extras", Tap: "user/tools", SizeBytes: 2 << 20},
+		{Name: "daemon", Tap: "user/daemons", Service: brew.ServiceStarted},
+		{Name: "frozen", Tap: "user/frozen", Pinned: true},
+		{Name: "legacy", Tap: "gone/tap", SizeBytes: 3 << 20},
+		{Name: "recent", Tap: "user/recent"},
+		{Name: "hooked", Tap: "user/held"},
+	} {
+		pkg.InstalledAt = time.Now().AddDate(-1, 0, 0)
+		pkg.InstallType = "explicit"
+		if err := s.InsertPackage(pkg); err != nil {
+			t.Fatalf("failed to insert package %s: %v", pkg.Name, err)
+		}
+	}
+	for _, pkg := range []string{"jq", "recent"} {
+		if err := s.InsertUsageEvent(&store.UsageEvent{
+			Package:   pkg,
+			EventType: "exec",
+			Timestamp: time.Now().AddDate(0, 0, -3),
+		}); err != nil {
+			t.Fatalf("failed to insert usage event: %v", err)
+		}
+	}
+	// Unused, but sourced from ~/.zshrc, so held out of the safe tier
+	if err := s.ReplaceBypassFindings([]*store.BypassFinding{{
+		Package:    "hooked",
+		BinaryName: "hooked",
+		Kind:       store.BypassReference,
+		Location:   "/home/u/.zshrc:3",
+		EvidenceAt: time.Now().AddDate(0, -1, 0),
+		DetectedAt: time.Now(),
+	}}); err != nil {
+		t.Fatalf("ReplaceBypassFindings failed: %v", err)
+	}
+
+	usage, err := New(s).AnalyzeTaps([]*brew.Tap{
+		{Name: "homebrew/core"},
+		{Name: "old/empty", Path: "/h/Library/Taps/old/homebrew-empty", SizeBytes: 4 << 20},
+		{Name: "user/daemons", SizeBytes: 1 << 20},
+		{Name: "user/frozen", SizeBytes: 1 << 20},
+		{Name: "user/held", SizeBytes: 1 << 20},
+		{Name: "user/recent", SizeBytes: 1 << 20},
+		{Name: "user/tools", SizeBytes: 6 << 20, Prefix: "/opt/homebrew"},
+		{Name: "user/tools", SizeBytes: 2 << 20, Prefix: "/usr/local"},
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeTaps failed: %v", err)
+	}
+
+	var got []string
+	for _, u := range usage {
+		got = append(got, strings.Join([]string{
+			u.Name,
+			"used=" + strings.Join(u.Used, ","),
+			"kept=" + strings.Join(u.Kept, ","),
+			"unused=" + strings.Join(u.Unused, ","),
+		}, " "))
+	}
+	want := []string{
+		"gone/tap used= kept= unused=legacy",
+		"homebrew/core used= kept= unused=", // Official taps are not scored
+		"old/empty used= kept= unused=",
+		"user/daemons used=daemon kept= unused=",
+		"user/frozen used= kept=frozen unused=",
+		"user/held used=hooked kept= unused=",
+		"user/recent used=recent kept= unused=",
+		"user/tools used= kept= unused=tool,tool-extras",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("AnalyzeTaps() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	untap := map[string]bool{"old/empty": true, "user/tools": true}
+	for _, u := range usage {
+		if u.ShouldUntap() != untap[u.Name] {
+			t.Errorf("%s ShouldUntap() = %v, want %v", u.Name, u.ShouldUntap(), untap[u.Name])
+		}
+	}
+	if core := usage[1]; len(core.Packages) != 1 || core.ShouldUntap() {
+		t.Errorf("homebrew/core = %+v, want jq listed and never untapped", core)
+	}
+	if tools := usage[7]; tools.UnusedSizeBytes != 7<<20 || len(tools.Packages) != 2 {
+		t.Errorf("user/tools = %+v, want 2 packages with 7 MB unused", tools)
+	}
+	if tools := usage[7]; tools.SizeBytes != 8<<20 || strings.Join(tools.Prefixes, ",") != "/opt/homebrew,/usr/local" {
+		t.Errorf("user/tools = %+v, want 8 MB tapped in /opt/homebrew and /usr/local", tools)
+	}
+	if usage[0].Tapped {
+		t.Error("gone/tap is only known from its packages and should not be Tapped")
+	}
+}
@@ -13,16 +13,61 @@ type ConfidenceScore struct {
 	TypeScore   int       // 0-10 points
 	Reason      string    // Human-readable explanation
 	IsCritical  bool      // True if package is a core dependency
+	IsService   bool      // True if package runs as a brew service
+	IsPinned    bool      // True if package is pinned with `brew pin`
 	IsCask      bool      // True if package is a cask (GUI app)
 	SizeBytes   int64     // Package size in bytes (for sorting)
 	InstalledAt time.Time // Installation date (for sorting)
 	Explanation ScoreExplanation
+
+	// Periodic is the detected usage cadence while it holds the package
+	// out of the safe tier; nil otherwise.
+	Periodic *PeriodicPattern
+
+	// Bypassed lists binaries that appear to run without passing through
+	// their shims (see scanner.DetectBypass). While any remain uncovered the
+	// package is held out of the safe tier.
+	Bypassed []string
+
+	// RuntimeReferences lists the virtualenvs, scripts and symlinks that
+	// reach the package as a language runtime (see
+	// scanner.DetectRuntimeReferences). A referenced runtime is in use, so
+	// its usage component scores 0.
+	RuntimeReferences []RuntimeReference
+
+	// DeclaredBy lists the projects whose manifests or scripts declare they
+	// need the package (see scanner.DetectDeclaredRequirements). A declared
+	// package is held out of the safe tier, since CI or release-day runs
+	// may never reach the shims.
+	DeclaredBy []string
+
+	// UsageTracked is true for a cask whose usage is recorded: its
+	// application launches (see watcher.IngestAppLaunches), or, for a cask
+	// without app bundles, its shimmed command-line binaries. An untracked
+	// cask is held out of the safe tier, since its lack of usage means
+	// nothing.
+	UsageTracked bool
+
+	// Adjustments lists the caps and holds applied after the components
+	// were summed; the components plus the adjustments equal Score.
+	Adjustments []ScoreAdjustment
+
+	// Labels are short status markers shown next to the tier, e.g.
+	// "PERIODIC — next expected ~2026-12-01".
+	Labels []string
+}
+
+// ScoreAdjustment is a change to the summed score components, such as the
+// critical cap or a hold in the medium tier.
+type ScoreAdjustment struct {
+	Points int    // negative: the score only ever moves down
+	Reason string // "capped at 70 (core system dependency)"
 }
 
 // ScoreExplanation provides detailed breakdown of score components.
 type ScoreExplanation struct {
 	UsageDetail string // "never observed execution" / "last used 45 days ago"
-	DepsDetail  string // "no dependents" / "3 used dependents" / "1 unused dependent"
+	DepsDetail  string // "no dependents" / "1 used dependent (via python@3.12 → poetry, 3 days ago)"
 	AgeDetail   string // "installed 240 days ago"
 	TypeDetail  string // "leaf package with binaries" / "library-only (low confidence)" / "core dependency"
 }
@@ -35,6 +80,25 @@ type UsageStats struct {
 	FirstSeen time.Time
 	DaysSince int    // Days since last used, -1 if never used
 	Frequency string // "daily", "weekly", "monthly", "never"
+
+	// IndirectUses lists packages whose executed binaries load this
+	// package's shared libraries, most recent first.
+	IndirectUses []IndirectUse
+}
+
+// IndirectUse records that a package's libraries were loaded by an executed
+// binary of another package.
+type IndirectUse struct {
+	Via      string // Package whose binary was executed
+	Binary   string // Executed binary basename
+	LastUsed time.Time
+}
+
+// RuntimeReference is a path that reaches a package as a language runtime.
+type RuntimeReference struct {
+	Kind   string // "venv", "shebang" or "symlink"
+	Path   string // Virtualenv directory, script or symlink
+	Target string // Referenced path inside the brew prefix
 }
 
 // Recommendation represents a set of packages recommended for removal.
@@ -44,3 +108,28 @@ type Recommendation struct {
 	Tier            string
 	ExpectedSavings int64
 }
+
+// BinaryStats represents usage statistics for a single binary of a package.
+type BinaryStats struct {
+	Name      string
+	Path      string
+	TotalUses int
+	LastUsed  *time.Time
+}
+
+// EffectiveUse is the most recent usage of a package or of any package that
+// transitively depends on it.
+type EffectiveUse struct {
+	LastUsed *time.Time // nil if neither the package nor any dependent was used
+	Path     []string   // Package first, then dependents down to the one that was used
+}
+
+// PeriodicPattern describes a regular cadence in a package's usage, such as
+// a quarterly release script or a yearly tax tool.
+type PeriodicPattern struct {
+	Interval     time.Duration // Mean time between usage sessions
+	Occurrences  int           // Number of usage sessions observed
+	LastUse      time.Time     // Start of the most recent session
+	NextExpected time.Time     // LastUse + Interval
+	Grace        time.Duration // Window around NextExpected in which the hold applies
+}
@@ -2,7 +2,10 @@ package analyzer
 
 import (
 	"fmt"
+	"sort"
 	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/config"
 )
 
 // GetUsageStats returns usage statistics for a specific package.
@@ -39,9 +42,28 @@ func (a *Analyzer) GetUsageStats(pkg string) (*UsageStats, error) {
 	// Compute frequency
 	stats.Frequency = a.computeFrequency(stats.LastUsed, stats.TotalUses, pkgInfo.InstalledAt)
 
+	// Indirect usage through dynamic linkage (best effort — older databases
+	// may not have linkage data yet).
+	stats.IndirectUses = a.getIndirectUses(pkg)
+
 	return stats, nil
 }
 
+// getIndirectUses returns the executed binaries of other packages that load
+// pkg's shared libraries. Errors yield no indirect uses.
+func (a *Analyzer) getIndirectUses(pkg string) []IndirectUse {
+	usage, err := a.store.GetIndirectUsage(pkg)
+	if err != nil {
+		return nil
+	}
+
+	uses := make([]IndirectUse, 0, len(usage))
+	for _, u := range usage {
+		uses = append(uses, IndirectUse{Via: u.Via, Binary: u.Binary, LastUsed: u.LastUsed})
+	}
+	return uses
+}
+
 // computeFrequency determines usage frequency classification.
 func (a *Analyzer) computeFrequency(lastUsed *time.Time, totalUses int, installedAt time.Time) string {
 	if lastUsed == nil {
@@ -156,3 +178,68 @@ func (a *Analyzer) getUsageStatsInWindow(pkg string, days int) (*UsageStats, err
 
 	return stats, nil
 }
+
+// GetBinaryStats returns per-binary usage statistics for a package, ordered by
+// total uses (most used first). Binaries that were never executed are included
+// with zero uses so callers can see which parts of a package go unused.
+func (a *Analyzer) GetBinaryStats(pkg string) ([]*BinaryStats, error) {
+	usage, err := a.store.GetBinaryUsage(pkg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get binary usage: %w", err)
+	}
+
+	stats := make([]*BinaryStats, 0, len(usage))
+	for _, u := range usage {
+		stats = append(stats, &BinaryStats{
+			Name:      u.Name,
+			Path:      u.Path,
+			TotalUses: u.TotalUses,
+			LastUsed:  u.LastUsed,
+		})
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].TotalUses != stats[j].TotalUses {
+			return stats[i].TotalUses > stats[j].TotalUses
+		}
+		return stats[i].Name < stats[j].Name
+	})
+
+	return stats, nil
+}
+
+// SuggestAlternative returns a hint recommending a lighter formula when a
+// multi-binary package only ever has a single binary executed and that binary
+// is listed in the alternatives mapping. It returns an empty string when no
+// suggestion applies.
+func (a *Analyzer) SuggestAlternative(pkg string, alts *config.AlternativesConfig) (string, error) {
+	stats, err := a.GetBinaryStats(pkg)
+	if err != nil {
+		return "", err
+	}
+	if len(stats) < 2 {
+		return "", nil
+	}
+
+	var used *BinaryStats
+	for _, b := range stats {
+		if b.TotalUses == 0 {
+			continue
+		}
+		if used != nil {
+			return "", nil // more than one binary in use
+		}
+		used = b
+	}
+	if used == nil {
+		return "", nil
+	}
+
+	alt, ok := alts.Lookup(pkg, used.Name)
+	if !ok || alt == pkg {
+		return "", nil
+	}
+
+	return fmt.Sprintf("only %s is used (1 of %d binaries) — the lighter %s formula also provides it",
+		used.Name, len(stats), alt), nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/config"
 	"github.com/blackwell-systems/brewprune/internal/store"
 )
 
@@ -402,3 +403,107 @@ func TestComputeFrequency(t *testing.T) {
 		})
 	}
 }
+
+func insertMultiBinaryPackage(t *testing.T, s *store.Store) {
+	t.Helper()
+	pkg := &brew.Package{
+		Name:        "postgresql@16",
+		Version:     "16.2",
+		InstalledAt: time.Now().AddDate(0, 0, -60),
+		InstallType: "explicit",
+		HasBinary:   true,
+		BinaryPaths: []string{
+			"/opt/homebrew/bin/psql",
+			"/opt/homebrew/bin/pg_dump",
+			"/opt/homebrew/bin/postgres",
+		},
+	}
+	if err := s.InsertPackage(pkg); err != nil {
+		t.Fatalf("failed to insert package: %v", err)
+	}
+}
+
+func TestGetBinaryStats(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+	insertMultiBinaryPackage(t, s)
+
+	for i := 0; i < 3; i++ {
+		if err := s.InsertUsageEvent(&store.UsageEvent{
+			Package:    "postgresql@16",
+			EventType:  "exec",
+			BinaryPath: "/Users/alice/.brewprune/bin/psql",
+			BinaryName: "psql",
+			Timestamp:  time.Now().AddDate(0, 0, -i),
+		}); err != nil {
+			t.Fatalf("failed to insert usage event: %v", err)
+		}
+	}
+
+	stats, err := New(s).GetBinaryStats("postgresql@16")
+	if err != nil {
+		t.Fatalf("GetBinaryStats failed: %v", err)
+	}
+	if len(stats) != 3 {
+		t.Fatalf("expected 3 binaries, got %d", len(stats))
+	}
+	if stats[0].Name != "psql" || stats[0].TotalUses != 3 {
+		t.Errorf("expected psql with 3 uses first, got %s with %d", stats[0].Name, stats[0].TotalUses)
+	}
+	if stats[0].LastUsed == nil {
+		t.Error("expected LastUsed set for psql")
+	}
+	for _, b := range stats[1:] {
+		if b.TotalUses != 0 || b.LastUsed != nil {
+			t.Errorf("expected %s unused, got %d uses", b.Name, b.TotalUses)
+		}
+	}
+}
+
+func TestSuggestAlternative(t *testing.T) {
+	alts := &config.AlternativesConfig{
+		Alternatives: map[string]string{"postgresql@16/psql": "libpq"},
+	}
+
+	tests := []struct {
+		name     string
+		binaries []string
+		wantHint bool
+	}{
+		{"single mapped binary used", []string{"psql"}, true},
+		{"two binaries used", []string{"psql", "pg_dump"}, false},
+		{"unmapped binary used", []string{"pg_dump"}, false},
+		{"nothing used", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := setupTestStore(t)
+			defer s.Close()
+			insertMultiBinaryPackage(t, s)
+
+			for _, bin := range tt.binaries {
+				if err := s.InsertUsageEvent(&store.UsageEvent{
+					Package:    "postgresql@16",
+					EventType:  "exec",
+					BinaryPath: "/Users/alice/.brewprune/bin/" + bin,
+					BinaryName: bin,
+					Timestamp:  time.Now(),
+				}); err != nil {
+					t.Fatalf("failed to insert usage event: %v", err)
+				}
+			}
+
+			hint, err := New(s).SuggestAlternative("postgresql@16", alts)
+			if err != nil {
+				t.Fatalf("SuggestAlternative failed: %v", err)
+			}
+			if got := hint != ""; got != tt.wantHint {
+				t.Errorf("hint = %q, wantHint %v", hint, tt.wantHint)
+			}
+			if tt.wantHint && !strings.Contains(hint, "libpq") {
+				t.Errorf("hint %q does not mention libpq", hint)
+			}
+		})
+	}
+}
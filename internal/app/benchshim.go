@@ -0,0 +1,92 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/shim"
+	"github.com/spf13/cobra"
+)
+
+// defaultBenchCommand is benchmarked when bench-shim is given no command: it
+// does no work of its own, so its run time is all process startup.
+const defaultBenchCommand = "true"
+
+var benchShimRuns int
+
+var benchShimCmd = &cobra.Command{
+	Use:   "bench-shim [command [args...]]",
+	Short: "Measure the per-exec overhead of the PATH shims",
+	Long: `Measure how much time the brewprune shim adds to each run of a command.
+
+The command (default: true) is run directly and through the installed shim,
+once with the shim index that 'brewprune scan' writes and once without it, and
+the median of each is reported. Runs are interleaved so background load
+affects all three alike. The shim runs in a temporary home directory, so the
+benchmark is not recorded as usage.`,
+	Example: `  # Overhead of the shim itself
+  brewprune bench-shim
+
+  # Overhead for a real shimmed command
+  brewprune bench-shim --runs 500 git --version`,
+	Args: cobra.ArbitraryArgs,
+	RunE: runBenchShim,
+}
+
+func init() {
+	benchShimCmd.Flags().IntVar(&benchShimRuns, "runs", 200, "number of runs of each variant")
+	benchShimCmd.Flags().SetInterspersed(false)
+
+	RootCmd.AddCommand(benchShimCmd)
+}
+
+func runBenchShim(cmd *cobra.Command, args []string) error {
+	name := defaultBenchCommand
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	fmt.Printf("Running %s %d times each: directly, through the shim with and without its index...\n", name, benchShimRuns)
+	result, err := shim.BenchShim(name, args, benchShimRuns)
+	if err != nil {
+		return fmt.Errorf("failed to benchmark shim: %w", err)
+	}
+
+	fmt.Println()
+	fmt.Printf("Command: %s (%s)\n\n", result.Command, result.RealPath)
+	fmt.Print(renderBenchShim(result))
+	return nil
+}
+
+// renderBenchShim formats a bench-shim result as a small table of median run
+// times and the overhead each shim variant adds.
+func renderBenchShim(r *shim.BenchResult) string {
+	row := func(label string, d, overhead time.Duration, showOverhead bool) string {
+		line := fmt.Sprintf("  %-18s %10s", label, formatBenchDuration(d))
+		if showOverhead {
+			line += fmt.Sprintf("   %s", formatBenchOverhead(overhead))
+		}
+		return line + "\n"
+	}
+	return row("bare binary", r.Bare, 0, false) +
+		row("shim (indexed)", r.Indexed, r.Overhead(), true) +
+		row("shim (no index)", r.Fallback, r.FallbackOverhead(), true) +
+		fmt.Sprintf("\nShim overhead: %s per exec (median of %d runs)\n", formatBenchOverhead(r.Overhead()), r.Runs)
+}
+
+// formatBenchDuration formats d in microseconds or milliseconds.
+func formatBenchDuration(d time.Duration) string {
+	if d < time.Millisecond {
+		return fmt.Sprintf("%dµs", d.Microseconds())
+	}
+	return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+}
+
+// formatBenchOverhead formats a signed overhead; timing noise can make a
+// fast shim measure slightly faster than the bare binary.
+func formatBenchOverhead(d time.Duration) string {
+	if d < 0 {
+		return "-" + formatBenchDuration(-d)
+	}
+	return "+" + formatBenchDuration(d)
+}
@@ -4,8 +4,33 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/blackwell-systems/brewprune/internal/analyzer"
+	"github.com/blackwell-systems/brewprune/internal/config"
+	"github.com/blackwell-systems/brewprune/internal/store"
 )
 
+// loadSettings reads ~/.config/brewprune/config, falling back to defaults if
+// the config directory cannot be determined or the file is unreadable.
+func loadSettings() *config.Settings {
+	cfgDir, err := config.Dir()
+	if err != nil {
+		return config.DefaultSettings()
+	}
+	settings, err := config.LoadSettings(cfgDir)
+	if err != nil {
+		return config.DefaultSettings()
+	}
+	return settings
+}
+
+// newAnalyzer creates an analyzer configured from the user's settings.
+func newAnalyzer(st *store.Store) *analyzer.Analyzer {
+	a := analyzer.New(st)
+	a.Configure(loadSettings())
+	return a
+}
+
 // getSnapshotDir returns the directory for snapshot storage.
 // Uses $HOME/.brewprune/snapshots by default.
 func getSnapshotDir() string {
@@ -25,11 +25,25 @@ Checks:
   • Database exists and is accessible
   • Daemon is running
   • Usage events are being recorded
-  • Recommends next steps`,
+  • Binaries that run outside the shims (usage undercounted)
+  • Recommends next steps
+
+With --unresolved, lists the commands the shims logged that no package
+claims instead. These usually mean a missing alias or a renamed package;
+once fixed, 'brewprune scan' records the logged runs retroactively.`,
+	Example: `  brewprune doctor
+  brewprune doctor --unresolved`,
 	RunE: runDoctor,
 }
 
+var doctorUnresolved bool
+
+// maxUnresolvedLines caps the commands listed by doctor --unresolved.
+const maxUnresolvedLines = 20
+
 func init() {
+	doctorCmd.Flags().BoolVar(&doctorUnresolved, "unresolved", false, "List logged commands that do not map to any package")
+
 	RootCmd.AddCommand(doctorCmd)
 }
 
@@ -69,7 +83,55 @@ func detectShellConfig() string {
 	}
 }
 
+// maxBypassLines caps the bypassed binaries listed by doctor.
+const maxBypassLines = 10
+
+// bypassGroup is one bypassed binary with the evidence shown for it.
+type bypassGroup struct {
+	pkg      string
+	binary   string
+	evidence string
+}
+
+// groupBypassFindings collapses findings into one entry per package binary,
+// preferring a hardcoded reference (actionable) over an access time as the
+// evidence shown. Findings must be sorted by package and binary.
+func groupBypassFindings(findings []*store.BypassFinding) []bypassGroup {
+	var groups []bypassGroup
+	for _, f := range findings {
+		evidence := "keg binary accessed " + f.EvidenceAt.Format("2006-01-02") + " with no run recorded"
+		if f.Kind == store.BypassReference {
+			evidence = "hardcoded in " + shortenHome(f.Location)
+		}
+
+		if n := len(groups); n > 0 && groups[n-1].pkg == f.Package && groups[n-1].binary == f.BinaryName {
+			if f.Kind == store.BypassReference && !strings.HasPrefix(groups[n-1].evidence, "hardcoded") {
+				groups[n-1].evidence = evidence
+			}
+			continue
+		}
+		groups = append(groups, bypassGroup{pkg: f.Package, binary: f.BinaryName, evidence: evidence})
+	}
+	return groups
+}
+
+// shortenHome replaces the home directory prefix of path with "~".
+func shortenHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return "~/" + rest
+	}
+	return path
+}
+
 func runDoctor(cmd *cobra.Command, args []string) error {
+	if doctorUnresolved {
+		return runDoctorUnresolved()
+	}
+
 	fmt.Println("Running brewprune diagnostics...")
 	fmt.Println()
 
@@ -127,16 +189,36 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 			} else {
 				fmt.Println(colorize("32", "✓") + fmt.Sprintf(" %d usage events recorded", totalUsageEvents))
 			}
+
+			// Informational: logged commands no package claims.
+			if unresolved, err := db.GetUnresolvedEventCount(); err == nil && unresolved > 0 {
+				fmt.Printf("ℹ %d logged runs not mapped to any package — see 'brewprune doctor --unresolved'\n", unresolved)
+			}
 		}
 	}
 
 	// Check 5: Daemon running — warning only
 	// Track daemon status separately so we can skip/shorten pipeline test if not running.
 	daemonRunning := false
+	// The control socket is asked first; the PID file is the fallback for a
+	// daemon that is not listening on it.
 	pidFile, err := getDefaultPIDFile()
+	var daemonStatus *watcher.DaemonStatus
+	if err == nil {
+		daemonStatus = queryDaemonStatus(pidFile)
+	}
 	if err != nil {
 		fmt.Println(colorize("33", "⚠") + " Failed to get PID file path: " + fmt.Sprint(err))
 		warningIssues++
+	} else if daemonStatus != nil {
+		daemonRunning = true
+		fmt.Println(colorize("32", "✓") + fmt.Sprintf(" Daemon running (PID %d, %s)", daemonStatus.PID, describeDaemonActivity(daemonStatus)))
+		if daemonStatus.LastError != "" && daemonStatus.LastErrorAt != nil && daemonStatus.LastTick != nil &&
+			!daemonStatus.LastErrorAt.Before(*daemonStatus.LastTick) {
+			fmt.Println(colorize("33", "⚠") + " Daemon's last shim log run failed: " + daemonStatus.LastError)
+			fmt.Println("  Action: Check ~/.brewprune/watch.log")
+			warningIssues++
+		}
 	} else if _, err := os.Stat(pidFile); os.IsNotExist(err) {
 		fmt.Println(colorize("33", "⚠") + " Daemon not running (no PID file)")
 		fmt.Println("  Action: Run 'brewprune watch --daemon'")
@@ -218,6 +300,36 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Check 8: Shim bypass — warning only
+	// Binaries run by absolute path never reach their shims, so their usage
+	// is undercounted and they must not be recommended as safe. Doctor only
+	// reports the findings of the last scan; 'brewprune scan' refreshes them.
+	if criticalIssues == 0 {
+		if db, err := store.New(resolvedDBPath); err == nil {
+			bypassed, err := db.GetBypassFindings("")
+			db.Close()
+			if err != nil {
+				fmt.Println(colorize("33", "⚠") + " Shim bypass check failed: " + fmt.Sprint(err))
+				warningIssues++
+			} else if groups := groupBypassFindings(bypassed); len(groups) > 0 {
+				fmt.Println(colorize("33", "⚠") + fmt.Sprintf(" %d binaries likely undercounted (run outside the shims)", len(groups)))
+				for i, g := range groups {
+					if i == maxBypassLines {
+						fmt.Printf("  ... and %d more\n", len(groups)-maxBypassLines)
+						break
+					}
+					fmt.Printf("  %s (%s): %s\n", g.binary, g.pkg, g.evidence)
+				}
+				fmt.Println("  These packages are held out of the safe tier until their usage is recorded.")
+				fmt.Println("  Action: Call these commands by name so the shims see them,")
+				fmt.Println("  then run 'brewprune scan' to re-check")
+				warningIssues++
+			} else {
+				fmt.Println(colorize("32", "✓") + " No shim bypass detected (as of the last 'brewprune scan')")
+			}
+		}
+	}
+
 	// Tip: alias config file — only show when there are no critical issues and
 	// the daemon is not running (fresh setup) or total usage events are below
 	// threshold, since the tip is most useful early on before the user has
@@ -250,7 +362,7 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Check 8: End-to-end pipeline test (only when no critical issues)
+	// Check 9: End-to-end pipeline test (only when no critical issues)
 	if criticalIssues == 0 {
 		// Skip or shorten pipeline test if daemon is not running, since the test
 		// requires the daemon to record usage events.
@@ -299,7 +411,7 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 
 	// Compute total checks (approximation based on the structure above)
 	// Each check block increments either criticalIssues or warningIssues or is a pass
-	totalChecks := 8 // Database (3 checks), Events, Daemon, Shim binary, PATH, Pipeline
+	totalChecks := 9 // Database (3 checks), Events, Daemon, Shim binary, PATH, Bypass, Pipeline
 
 	fmt.Println()
 
@@ -337,3 +449,45 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	fmt.Println("System is functional but not fully configured. Address warnings above for best experience.")
 	return nil
 }
+
+// runDoctorUnresolved implements doctor --unresolved: it lists the logged
+// commands that no package claims, most frequent first.
+func runDoctorUnresolved() error {
+	resolvedDBPath, err := getDBPath()
+	if err != nil {
+		return fmt.Errorf("failed to get database path: %w", err)
+	}
+	db, err := store.New(resolvedDBPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.CreateSchema(); err != nil {
+		return fmt.Errorf("failed to create database schema: %w", err)
+	}
+
+	commands, err := db.GetUnresolvedCommands(0)
+	if err != nil {
+		return fmt.Errorf("failed to read unresolved commands: %w", err)
+	}
+	if len(commands) == 0 {
+		fmt.Println(colorize("32", "✓") + " Every logged command maps to a package")
+		return nil
+	}
+
+	fmt.Printf("%d commands were run through the shims but map to no package:\n\n", len(commands))
+	fmt.Printf("  %-20s %6s  %-12s %s\n", "COMMAND", "RUNS", "LAST RUN", "PATH")
+	for i, c := range commands {
+		if i == maxUnresolvedLines {
+			fmt.Printf("  ... and %d more\n", len(commands)-maxUnresolvedLines)
+			break
+		}
+		fmt.Printf("  %-20s %6d  %-12s %s\n", c.BinaryName, c.Count, formatAgo(c.LastSeen), shortenHome(c.BinaryPath))
+	}
+	fmt.Println()
+	fmt.Println("Usually this means a missing alias or a renamed package.")
+	fmt.Println("  Action: Map aliases in ~/.config/brewprune/aliases (e.g. ll=eza), then run")
+	fmt.Println("          'brewprune scan'. Runs logged so far are recorded once they resolve.")
+	return nil
+}
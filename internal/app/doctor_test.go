@@ -1008,3 +1008,70 @@ func TestDoctorPipelineFailureMessage_DaemonNotRunning(t *testing.T) {
 		t.Errorf("expected 'Pipeline test skipped' message when daemon not running, got:\n%s", out)
 	}
 }
+
+func TestGroupBypassFindings(t *testing.T) {
+	t.Setenv("HOME", "/home/tester")
+	accessed := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+
+	groups := groupBypassFindings([]*store.BypassFinding{
+		{Package: "fzf", BinaryName: "fzf", Kind: store.BypassAccessTime, EvidenceAt: accessed},
+		{Package: "fzf", BinaryName: "fzf", Kind: store.BypassReference, Location: "/home/tester/.zshrc:3"},
+		{Package: "libpq", BinaryName: "psql", Kind: store.BypassAccessTime, EvidenceAt: accessed},
+	})
+
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2: %+v", len(groups), groups)
+	}
+	if groups[0].evidence != "hardcoded in ~/.zshrc:3" {
+		t.Errorf("fzf evidence = %q, want the hardcoded reference", groups[0].evidence)
+	}
+	if want := "keg binary accessed 2026-10-12 with no run recorded"; groups[1].evidence != want {
+		t.Errorf("psql evidence = %q, want %q", groups[1].evidence, want)
+	}
+}
+
+// TestRunDoctor_UnresolvedListsCommands verifies that doctor --unresolved
+// lists logged commands that map to no package, most frequent first.
+func TestRunDoctor_UnresolvedListsCommands(t *testing.T) {
+	tmpDB := filepath.Join(t.TempDir(), "test.db")
+	st, err := store.New(tmpDB)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	if err := st.CreateSchema(); err != nil {
+		st.Close()
+		t.Fatalf("CreateSchema: %v", err)
+	}
+	now := time.Now()
+	for _, name := range []string{"gs", "ll", "ll", "ll"} {
+		if err := st.InsertUnresolvedEvent(name, "/home/u/.brewprune/bin/"+name, now); err != nil {
+			st.Close()
+			t.Fatalf("InsertUnresolvedEvent: %v", err)
+		}
+	}
+	st.Close()
+
+	oldDBPath := dbPath
+	dbPath = tmpDB
+	defer func() { dbPath = oldDBPath }()
+	doctorUnresolved = true
+	defer func() { doctorUnresolved = false }()
+
+	var runErr error
+	out := captureStdout(t, func() {
+		runErr = runDoctor(doctorCmd, nil)
+	})
+	if runErr != nil {
+		t.Fatalf("runDoctor --unresolved: %v", runErr)
+	}
+	if !strings.Contains(out, "2 commands") {
+		t.Errorf("expected command count in output, got:\n%s", out)
+	}
+	llAt, gsAt := strings.Index(out, "  ll "), strings.Index(out, "  gs ")
+	if llAt < 0 || gsAt < 0 || llAt > gsAt {
+		t.Errorf("expected ll (3 runs) listed before gs (1 run), got:\n%s", out)
+	}
+	if strings.Contains(out, "Running brewprune diagnostics") {
+		t.Errorf("--unresolved should not run the full diagnostics, got:\n%s", out)
+	}
+}
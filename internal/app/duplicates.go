@@ -0,0 +1,123 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/blackwell-systems/brewprune/internal/analyzer"
+	"github.com/blackwell-systems/brewprune/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var duplicatesCmd = &cobra.Command{
+	Use:   "duplicates",
+	Short: "List versioned formulae superseded by a newer version in use",
+	Long: `List installed formulae that are versions of the same software, such as
+node, node@20 and node@16, or several python@3.x, with the usage of each.
+
+A version is superseded when it has not been used for 90 days, directly or
+through a package depending on it, while a newer version in the group has.
+Virtualenvs, scripts and symlinks pointing at a runtime, and projects
+declaring it, count as use. Versions other packages depend on are never
+superseded. Superseded python@3.x versions lose the protection core
+dependencies otherwise get.
+
+Requires: run 'brewprune scan' first to initialize the database.`,
+	Example: `  # List version groups and the space superseded versions take
+  brewprune duplicates
+
+  # Remove the superseded versions it lists
+  brewprune remove node@16 python@3.9`,
+	Args: cobra.NoArgs,
+	RunE: runDuplicates,
+}
+
+func init() {
+	RootCmd.AddCommand(duplicatesCmd)
+}
+
+func runDuplicates(cmd *cobra.Command, args []string) error {
+	dbPath, err := getDBPath()
+	if err != nil {
+		return fmt.Errorf("failed to get database path: %w", err)
+	}
+	st, err := store.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer st.Close()
+
+	groups, err := newAnalyzer(st).FindDuplicates()
+	if errors.Is(err, store.ErrNotInitialized) {
+		return store.ErrNotInitialized
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find duplicates: %w", err)
+	}
+
+	if len(groups) == 0 {
+		fmt.Println("No formula has more than one version installed.")
+		return nil
+	}
+
+	fmt.Print(renderDuplicates(groups))
+	return nil
+}
+
+// renderDuplicates formats each group with its versions, newest first,
+// followed by the total reclaimable size and a removal hint.
+func renderDuplicates(groups []*analyzer.DuplicateGroup) string {
+	width := 0
+	for _, g := range groups {
+		for _, v := range g.Versions {
+			width = max(width, len(v.Package))
+		}
+	}
+
+	var b strings.Builder
+	var reclaimable int64
+	var superseded []string
+	for i, g := range groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s  (%s", g.Base, countNoun(len(g.Versions), "version"))
+		if size := g.Reclaimable(); size > 0 {
+			fmt.Fprintf(&b, ", %s reclaimable", formatSize(size))
+			reclaimable += size
+		}
+		b.WriteString(")\n")
+
+		for _, v := range g.Versions {
+			line := fmt.Sprintf("  %-*s  %7s  %s", width, v.Package, formatSize(v.SizeBytes), describeVersionUsage(v))
+			if v.SupersededBy != "" {
+				line += "  superseded by " + v.SupersededBy
+				superseded = append(superseded, v.Package)
+			}
+			b.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	if len(superseded) == 0 {
+		b.WriteString("No superseded versions.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Reclaimable: %s from %s\n", formatSize(reclaimable), countNoun(len(superseded), "superseded version"))
+	fmt.Fprintf(&b, "Remove them with: brewprune remove %s\n", strings.Join(superseded, " "))
+	return b.String()
+}
+
+// describeVersionUsage summarises when a version was last used, noting
+// versions held in use by references rather than recent runs.
+func describeVersionUsage(v *analyzer.DuplicateVersion) string {
+	usage := "never used"
+	if v.LastUsed != nil {
+		usage = "last used " + formatAgo(*v.LastUsed)
+	}
+	if v.Referenced {
+		usage += ", referenced"
+	}
+	return usage
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/analyzer"
+)
+
+func TestRenderDuplicates(t *testing.T) {
+	recent := time.Now().Add(-2 * time.Hour)
+	old := time.Now().AddDate(0, 0, -200)
+	groups := []*analyzer.DuplicateGroup{
+		{Base: "node", Versions: []*analyzer.DuplicateVersion{
+			{Package: "node@20", Version: "20", LastUsed: &recent, InUse: true, SizeBytes: 60 << 20},
+			{Package: "node@18", Version: "18", LastUsed: &old, SizeBytes: 50 << 20, SupersededBy: "node@20"},
+			{Package: "node@16", Version: "16", SizeBytes: 40 << 20, InUse: true, Referenced: true},
+		}},
+		{Base: "python", Versions: []*analyzer.DuplicateVersion{
+			{Package: "python@3.12", Version: "3.12", LastUsed: &recent, InUse: true, SizeBytes: 80 << 20},
+			{Package: "python@3.9", Version: "3.9", SizeBytes: 70 << 20, SupersededBy: "python@3.12"},
+		}},
+	}
+
+	output := renderDuplicates(groups)
+
+	for _, want := range []string{
+		"node  (3 versions, 50 MB reclaimable)\n",
+		"  node@20        60 MB  last used today\n",
+		"  node@18        50 MB  last used 6 months ago  superseded by node@20\n",
+		"  node@16        40 MB  never used, referenced\n",
+		"python  (2 versions, 70 MB reclaimable)\n",
+		"  python@3.9     70 MB  never used  superseded by python@3.12\n",
+		"Reclaimable: 120 MB from 2 superseded versions\n",
+		"Remove them with: brewprune remove node@18 python@3.9\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+}
+
+func TestRenderDuplicates_NoneSuperseded(t *testing.T) {
+	recent := time.Now()
+	output := renderDuplicates([]*analyzer.DuplicateGroup{
+		{Base: "ruby", Versions: []*analyzer.DuplicateVersion{
+			{Package: "ruby@3.3", Version: "3.3", SizeBytes: 30 << 20},
+			{Package: "ruby@3.1", Version: "3.1", LastUsed: &recent, InUse: true, SizeBytes: 30 << 20},
+		}},
+	})
+
+	if strings.Contains(output, "reclaimable") || !strings.HasSuffix(output, "\nNo superseded versions.\n") {
+		t.Errorf("expected no reclaimable space, got:\n%s", output)
+	}
+}
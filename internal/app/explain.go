@@ -55,7 +55,7 @@ func runExplain(cmd *cobra.Command, args []string) error {
 	defer st.Close()
 
 	// Create analyzer
-	a := analyzer.New(st)
+	a := newAnalyzer(st)
 
 	// Check if package exists
 	// [EXPLAIN-1] Print directly to stderr and call os.Exit(1) so main.go's
@@ -96,6 +96,29 @@ func runExplain(cmd *cobra.Command, args []string) error {
 	// Display detailed explanation
 	renderExplanation(score, installedDate, dependents, usageStats)
 
+	// Virtualenvs, scripts and symlinks that reach the package as a
+	// language runtime.
+	if len(score.RuntimeReferences) > 0 {
+		fmt.Printf("%s\n", renderRuntimeReferences(score.RuntimeReferences))
+	}
+
+	// Projects that would lose the package (best effort — only recorded
+	// when projects.track_cwd is on).
+	if projects, err := a.Projects(packageName); err == nil && len(projects) > 0 {
+		fmt.Printf("%s\n", renderPackageProjects(projects))
+	}
+
+	// Projects whose manifests or scripts declare the package (recorded by
+	// 'brewprune scan --projects').
+	if reqs, err := st.GetDeclaredRequirements(packageName); err == nil && len(reqs) > 0 {
+		fmt.Printf("%s\n", renderDeclaredRequirements(reqs))
+	}
+
+	// Suggest a lighter formula when only one of several binaries is used.
+	if hint := alternativeHint(a, packageName); hint != "" {
+		fmt.Printf("Hint: %s\n\n", hint)
+	}
+
 	return nil
 }
 
@@ -141,9 +164,13 @@ func renderExplanation(score *analyzer.ConfidenceScore, installedDate string, de
 
 	// Header
 	fmt.Printf("\n%sPackage: %s%s\n", colorBold, score.Package, colorReset)
-	fmt.Printf("Score:   %s%d%s (%s%s%s)\n",
+	labels := ""
+	if len(score.Labels) > 0 {
+		labels = "  " + strings.Join(score.Labels, "  ")
+	}
+	fmt.Printf("Score:   %s%d%s (%s%s%s)%s\n",
 		tierColor, score.Score, colorReset,
-		tierColor, strings.ToUpper(score.Tier), colorReset)
+		tierColor, strings.ToUpper(score.Tier), colorReset, labels)
 	fmt.Printf("Installed: %s\n", installedDate)
 
 	// Breakdown section — plain-text compact format matching showConfidenceAssessment.
@@ -160,8 +187,18 @@ func renderExplanation(score *analyzer.ConfidenceScore, installedDate string, de
 	} else if usageStats != nil {
 		fmt.Println("  Usage history: no recorded usage")
 	}
+	if usageStats != nil && len(usageStats.IndirectUses) > 0 {
+		fmt.Printf("  Indirectly used via: %s\n", formatIndirectUses(usageStats.IndirectUses))
+	}
+	if p := score.Periodic; p != nil {
+		fmt.Printf("  Periodic: used every ~%d days (%d times), next expected ~%s\n",
+			int(p.Interval.Hours()/24), p.Occurrences, p.NextExpected.Format("2006-01-02"))
+	}
+	if len(score.Bypassed) > 0 {
+		fmt.Printf("  Shim bypass: %s run outside the shims (see 'brewprune doctor')\n", strings.Join(score.Bypassed, ", "))
+	}
 
-	fmt.Printf("  %-13s %2d/30 pts - %s\n", "Dependencies:", score.DepsScore, truncateDetail(score.Explanation.DepsDetail, 50))
+	fmt.Printf("  %-13s %2d/30 pts - %s\n", "Dependencies:", score.DepsScore, truncateDetail(score.Explanation.DepsDetail, 80))
 	if len(dependents) > 0 {
 		const maxNames = 8
 		if len(dependents) <= maxNames {
@@ -178,6 +215,9 @@ func renderExplanation(score *analyzer.ConfidenceScore, installedDate string, de
 	if score.IsCritical {
 		fmt.Println("  Critical: YES - capped at 70 (core system dependency)")
 	}
+	for _, adj := range score.Adjustments {
+		fmt.Printf("  %-12s %+3d pts - %s\n", "Adjustment:", adj.Points, adj.Reason)
+	}
 
 	fmt.Printf("  Total: %s%d/100%s (%s%s%s)\n",
 		tierColor, score.Score, colorReset,
@@ -198,7 +238,10 @@ func renderExplanation(score *analyzer.ConfidenceScore, installedDate string, de
 		fmt.Println("If certain, run 'brewprune remove " + score.Package + " --dry-run' to preview, then without --dry-run to remove.")
 	case "risky":
 		fmt.Printf("%sDo not remove.%s ", colorRed, colorReset)
-		if score.IsCritical {
+		if score.IsService {
+			fmt.Println("This package runs as a brew service. Stop it with")
+			fmt.Println("'brew services stop " + score.Package + "' before removing it.")
+		} else if score.IsCritical {
 			fmt.Println("This is a foundational package that other tools may")
 			fmt.Println("depend on indirectly. Even though no direct usage has been recorded,")
 			fmt.Println("removing it could break your development environment.")
@@ -211,10 +254,83 @@ func renderExplanation(score *analyzer.ConfidenceScore, installedDate string, de
 	if score.IsCritical {
 		fmt.Printf("\n%sProtected:%s YES (core system dependency — kept even if unused)\n", colorBold, colorReset)
 	}
+	if score.IsService {
+		fmt.Printf("\n%sProtected:%s YES (running brew service — kept while it runs)\n", colorBold, colorReset)
+	}
+	if score.IsPinned {
+		fmt.Printf("\n%sPinned:%s YES (excluded from tier removal — 'brew unpin %s' to include it)\n", colorBold, colorReset, score.Package)
+	}
 
 	fmt.Println()
 }
 
+// runtimeReferenceKinds describe each kind of runtime reference in
+// 'brewprune explain'.
+var runtimeReferenceKinds = map[string]string{
+	"venv":    "virtualenv",
+	"shebang": "#! line",
+	"symlink": "symlink",
+}
+
+// maxRuntimeReferenceLines caps the runtime references explain lists.
+const maxRuntimeReferenceLines = 10
+
+// renderRuntimeReferences lists the paths that reach a package as a
+// language runtime, each with what it references.
+func renderRuntimeReferences(refs []analyzer.RuntimeReference) string {
+	shown := refs
+	if len(shown) > maxRuntimeReferenceLines {
+		shown = shown[:maxRuntimeReferenceLines]
+	}
+	width := 0
+	for _, r := range shown {
+		width = max(width, len(shortenHome(r.Path)))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Runtime in use by %s:\n", countNoun(len(refs), "path"))
+	for _, r := range shown {
+		fmt.Fprintf(&b, "  %-*s  %s → %s\n", width, shortenHome(r.Path), runtimeReferenceKinds[r.Kind], r.Target)
+	}
+	if len(refs) > len(shown) {
+		fmt.Fprintf(&b, "  ... and %d more\n", len(refs)-len(shown))
+	}
+	return b.String()
+}
+
+// formatIndirectUses renders up to three linking packages as
+// "git (2 days ago), node (today)", summarising the rest as "and N more".
+func formatIndirectUses(uses []analyzer.IndirectUse) string {
+	const maxShown = 3
+
+	parts := make([]string, 0, maxShown)
+	for i, u := range uses {
+		if i == maxShown {
+			break
+		}
+		name := u.Via
+		if u.Binary != "" && u.Binary != u.Via {
+			name = fmt.Sprintf("%s/%s", u.Via, u.Binary)
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", name, formatAgo(u.LastUsed)))
+	}
+
+	result := strings.Join(parts, ", ")
+	if len(uses) > maxShown {
+		result += fmt.Sprintf(", and %d more", len(uses)-maxShown)
+	}
+	return result
+}
+
+// formatAgo formats a timestamp relative to now, e.g. "today" or "3 days ago".
+func formatAgo(t time.Time) string {
+	d := time.Since(t)
+	if d < 24*time.Hour {
+		return "today"
+	}
+	return formatUsageDuration(d) + " ago"
+}
+
 // usageSignalLabel appends a parenthetical hint when the usage score is low,
 // clarifying that 0 pts means "actively used" (penalizes removal confidence),
 // not "zero usage detected."
@@ -385,6 +385,20 @@ func TestRenderExplanation_CriticalTerminology(t *testing.T) {
 	}
 }
 
+// TestRenderExplanation_AdjustmentLines verifies that caps and holds are shown
+// as adjustment lines so the breakdown adds up to the total.
+func TestRenderExplanation_AdjustmentLines(t *testing.T) {
+	score := makeTestScore("certbot", false)
+	score.Score = 79
+	score.Tier = "medium"
+	score.Adjustments = []analyzer.ScoreAdjustment{{Points: -16, Reason: "held at 79 (periodic use due soon)"}}
+	output := captureRenderExplanation(score, "2023-06-01")
+
+	if !strings.Contains(output, "Adjustment:  -16 pts - held at 79 (periodic use due soon)") {
+		t.Errorf("expected an adjustment line for the periodic hold, got: %q", output)
+	}
+}
+
 // TestRenderExplanation_NoANSIWhenPiped verifies that when stdout is not a TTY
 // (simulated by replacing it with a pipe), no ANSI escape sequences are emitted.
 // Since the test itself redirects os.Stdout to a pipe, isColor() inside
@@ -771,3 +785,95 @@ func TestExplain_NoUsageHistory(t *testing.T) {
 		t.Errorf("expected output to NOT contain 'total runs' when usage is zero, got: %q", output)
 	}
 }
+
+// TestExplain_ShowsIndirectUsage verifies that libraries loaded by executed
+// binaries report which package used them.
+func TestExplain_ShowsIndirectUsage(t *testing.T) {
+	score := makeTestScore("pcre2", false)
+	usageStats := &analyzer.UsageStats{
+		Package:   "pcre2",
+		Frequency: "never",
+		IndirectUses: []analyzer.IndirectUse{
+			{Via: "git", Binary: "git", LastUsed: time.Now()},
+			{Via: "grep", Binary: "ggrep", LastUsed: time.Now().Add(-72 * time.Hour)},
+		},
+	}
+
+	output := captureRenderExplanationWithDeps(score, "2024-01-01", nil, usageStats)
+
+	if !strings.Contains(output, "Indirectly used via: git (today), grep/ggrep (3 days ago)") {
+		t.Errorf("expected indirect usage line, got: %q", output)
+	}
+}
+
+// TestExplain_ShowsPeriodicHold verifies the PERIODIC label and cadence line.
+func TestExplain_ShowsPeriodicHold(t *testing.T) {
+	score := makeTestScore("certbot", false)
+	next := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	score.Periodic = &analyzer.PeriodicPattern{
+		Interval:     90 * 24 * time.Hour,
+		Occurrences:  4,
+		NextExpected: next,
+	}
+	score.Labels = []string{score.Periodic.Label()}
+
+	output := captureRenderExplanationWithDeps(score, "2024-01-01", nil, nil)
+
+	if !strings.Contains(output, "PERIODIC — next expected ~2026-11-01") {
+		t.Errorf("expected PERIODIC label, got: %q", output)
+	}
+	if !strings.Contains(output, "Periodic: used every ~90 days (4 times), next expected ~2026-11-01") {
+		t.Errorf("expected periodic cadence line, got: %q", output)
+	}
+}
+
+// TestRenderRuntimeReferences verifies the runtime block lists each path
+// with its kind and target, truncating long lists.
+func TestRenderRuntimeReferences(t *testing.T) {
+	t.Setenv("HOME", "/Users/me")
+
+	output := renderRuntimeReferences([]analyzer.RuntimeReference{
+		{Kind: "venv", Path: "/Users/me/src/app/.venv", Target: "/opt/homebrew/opt/python@3.9/bin"},
+		{Kind: "shebang", Path: "/Users/me/bin/sync", Target: "/opt/homebrew/bin/python3.9"},
+	})
+	want := "Runtime in use by 2 paths:\n" +
+		"  ~/src/app/.venv  virtualenv → /opt/homebrew/opt/python@3.9/bin\n" +
+		"  ~/bin/sync       #! line → /opt/homebrew/bin/python3.9\n"
+	if output != want {
+		t.Errorf("renderRuntimeReferences() =\n%s\nwant\n%s", output, want)
+	}
+
+	var many []analyzer.RuntimeReference
+	for i := 0; i < maxRuntimeReferenceLines+2; i++ {
+		many = append(many, analyzer.RuntimeReference{Kind: "symlink", Path: "/tmp/link", Target: "/opt/homebrew/opt/node@16"})
+	}
+	output = renderRuntimeReferences(many)
+	if !strings.Contains(output, "Runtime in use by 12 paths:") || !strings.HasSuffix(output, "  ... and 2 more\n") {
+		t.Errorf("expected truncated list, got: %q", output)
+	}
+}
+
+// TestExplain_ShowsServiceAndPinned verifies the protection lines of a
+// running, pinned service.
+func TestExplain_ShowsServiceAndPinned(t *testing.T) {
+	score := makeTestScore("postgresql@16", false)
+	score.Tier = "risky"
+	score.Score = 49
+	score.IsService = true
+	score.IsPinned = true
+
+	output := captureRenderExplanationWithDeps(score, "2024-01-01", nil, nil)
+
+	for _, want := range []string{
+		"'brew services stop postgresql@16' before removing it.",
+		"Protected: YES (running brew service — kept while it runs)",
+		"Pinned: YES (excluded from tier removal — 'brew unpin postgresql@16' to include it)",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q, got: %q", want, output)
+		}
+	}
+	if strings.Contains(output, "foundational package") {
+		t.Errorf("service described as a core dependency: %q", output)
+	}
+}
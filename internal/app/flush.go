@@ -0,0 +1,102 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/blackwell-systems/brewprune/internal/store"
+	"github.com/blackwell-systems/brewprune/internal/watcher"
+	"github.com/spf13/cobra"
+)
+
+var flushPIDFile string
+
+var flushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Process pending shim log entries now",
+	Long: `Record pending entries from ~/.brewprune/usage.log immediately instead of
+waiting for the watch daemon's next batch.
+
+When the daemon is running it is asked over its control socket to process
+the log, and flush returns once it has. A daemon too old to have a control
+socket must be restarted first. When no daemon is running the log is
+processed directly.`,
+	Example: `  # Record the commands you just ran before checking their scores
+  brewprune flush && brewprune explain jq`,
+	RunE: runFlush,
+}
+
+func init() {
+	flushCmd.Flags().StringVar(&flushPIDFile, "pid-file", "", "PID file path (default: ~/.brewprune/watch.pid)")
+
+	RootCmd.AddCommand(flushCmd)
+}
+
+func runFlush(cmd *cobra.Command, args []string) error {
+	if flushPIDFile == "" {
+		defaultPID, err := getDefaultPIDFile()
+		if err != nil {
+			return fmt.Errorf("failed to get default PID file path: %w", err)
+		}
+		flushPIDFile = defaultPID
+	}
+
+	_, err := watcher.CallControl(watcher.ControlSocketPath(flushPIDFile), watcher.ControlFlush)
+	if err == nil {
+		fmt.Println("✓ Usage log processed")
+		return nil
+	}
+	if !errors.Is(err, watcher.ErrControlUnavailable) {
+		return fmt.Errorf("failed to flush: %w", err)
+	}
+
+	running, err := watcher.IsDaemonRunning(flushPIDFile)
+	if err != nil {
+		return fmt.Errorf("failed to check daemon status: %w", err)
+	}
+	if running {
+		// Signalling a daemon that predates the control socket could kill
+		// it, and processing the log here would race with it.
+		return fmt.Errorf("the running watch daemon has no control socket\n\nRestart it with 'brewprune watch --stop && brewprune watch --daemon', then run flush again")
+	}
+	return flushInProcess()
+}
+
+// flushInProcess drains the shim log directly when no daemon is running.
+func flushInProcess() error {
+	dbPath, err := getDBPath()
+	if err != nil {
+		return fmt.Errorf("failed to get database path: %w", err)
+	}
+	db, err := store.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.CreateSchema(); err != nil {
+		return fmt.Errorf("failed to create database schema: %w", err)
+	}
+
+	var total watcher.ProcessingStats
+	for {
+		stats, err := watcher.ProcessUsageLog(db)
+		if err != nil {
+			return fmt.Errorf("failed to process usage log: %w", err)
+		}
+		total.LinesRead += stats.LinesRead
+		total.Inserted += stats.Inserted
+		total.SkippedNoIndex += stats.SkippedNoIndex
+		if stats.LinesRead == 0 || stats.SkippedNoIndex > 0 {
+			break
+		}
+	}
+
+	if total.SkippedNoIndex > 0 {
+		fmt.Println("No packages indexed yet; run 'brewprune scan' first. Entries were left in the log.")
+		return nil
+	}
+	fmt.Printf("✓ Processed %d log lines, recorded %d usage events\n", total.LinesRead, total.Inserted)
+	fmt.Println("  Daemon not running; start it with 'brewprune watch --daemon' to track usage continuously.")
+	return nil
+}
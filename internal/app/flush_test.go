@@ -0,0 +1,103 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// TestRunFlush_NoDaemonProcessesLog verifies that flush records pending shim
+// log entries itself when no daemon is running.
+func TestRunFlush_NoDaemonProcessesLog(t *testing.T) {
+	tmpHome := t.TempDir()
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpHome)
+	defer os.Setenv("HOME", origHome)
+
+	tmpDB := filepath.Join(t.TempDir(), "test.db")
+	st, err := store.New(tmpDB)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	if err := st.CreateSchema(); err != nil {
+		st.Close()
+		t.Fatalf("CreateSchema: %v", err)
+	}
+	if err := st.InsertPackage(&brew.Package{
+		Name:        "jq",
+		Version:     "1.7.1",
+		InstalledAt: time.Now(),
+		InstallType: "explicit",
+		HasBinary:   true,
+		BinaryPaths: []string{"/opt/homebrew/bin/jq"},
+	}); err != nil {
+		st.Close()
+		t.Fatalf("InsertPackage: %v", err)
+	}
+	st.Close()
+
+	oldDBPath := dbPath
+	dbPath = tmpDB
+	defer func() { dbPath = oldDBPath }()
+
+	oldPIDFile := flushPIDFile
+	flushPIDFile = filepath.Join(t.TempDir(), "watch.pid")
+	defer func() { flushPIDFile = oldPIDFile }()
+
+	logDir := filepath.Join(tmpHome, ".brewprune")
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	line := fmt.Sprintf("%d,%s\n", time.Now().UnixNano(), filepath.Join(logDir, "bin", "jq"))
+	if err := os.WriteFile(filepath.Join(logDir, "usage.log"), []byte(line+line), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	var runErr error
+	out := captureStdout(t, func() {
+		runErr = runFlush(flushCmd, nil)
+	})
+	if runErr != nil {
+		t.Fatalf("runFlush: %v", runErr)
+	}
+	if !strings.Contains(out, "recorded 2 usage events") {
+		t.Errorf("expected output to report 2 recorded events, got: %q", out)
+	}
+
+	st, err = store.New(tmpDB)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer st.Close()
+	events, err := st.GetUsageEvents("jq", time.Time{})
+	if err != nil {
+		t.Fatalf("GetUsageEvents: %v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("got %d usage events, want 2", len(events))
+	}
+}
+
+// TestRunFlush_DaemonWithoutControlSocket verifies that flush neither signals
+// nor races a running daemon that has no control socket.
+func TestRunFlush_DaemonWithoutControlSocket(t *testing.T) {
+	oldPIDFile := flushPIDFile
+	flushPIDFile = filepath.Join(t.TempDir(), "watch.pid")
+	defer func() { flushPIDFile = oldPIDFile }()
+
+	// The test process stands in for the running daemon.
+	if err := os.WriteFile(flushPIDFile, []byte(fmt.Sprintf("%d\n", os.Getpid())), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	err := runFlush(flushCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "no control socket") {
+		t.Errorf("runFlush() error = %v, want a request to restart the daemon", err)
+	}
+}
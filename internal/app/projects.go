@@ -0,0 +1,217 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blackwell-systems/brewprune/internal/analyzer"
+	"github.com/blackwell-systems/brewprune/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var projectsCmd = &cobra.Command{
+	Use:   "projects",
+	Short: "List projects and the Homebrew tools they use",
+	Long: `List the projects shimmed commands were run in, with the packages each one
+used, most recently active project first.
+
+Projects are found from the working directory the shims record when
+projects.track_cwd is enabled in ~/.config/brewprune/config (it is off by
+default). Each directory is attributed to its nearest enclosing version
+control root (.git, .hg, .svn, .jj, ...) or, failing that, to the directory
+directly inside one of the configured projects.dirs. Runs in the home
+directory or outside any project are not listed.
+
+Requires: run 'brewprune scan' first to initialize the database.`,
+	Example: `  # Enable recording, then refresh the shims
+  echo "projects.track_cwd = true" >> ~/.config/brewprune/config
+  brewprune scan --refresh-shims
+
+  # List projects with their tools
+  brewprune projects`,
+	Args: cobra.NoArgs,
+	RunE: runProjects,
+}
+
+func init() {
+	RootCmd.AddCommand(projectsCmd)
+}
+
+func runProjects(cmd *cobra.Command, args []string) error {
+	dbPath, err := getDBPath()
+	if err != nil {
+		return fmt.Errorf("failed to get database path: %w", err)
+	}
+	st, err := store.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer st.Close()
+
+	projects, err := newAnalyzer(st).Projects("")
+	if errors.Is(err, store.ErrNotInitialized) {
+		return store.ErrNotInitialized
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	if len(projects) == 0 {
+		fmt.Println("No project usage recorded yet.")
+		if !loadSettings().TrackCwd {
+			fmt.Println()
+			fmt.Println("Working-directory tracking is off. To enable it, add")
+			fmt.Println("  projects.track_cwd = true")
+			fmt.Println("to ~/.config/brewprune/config and run 'brewprune scan --refresh-shims'.")
+		}
+		return nil
+	}
+
+	fmt.Print(renderProjects(projects))
+	return nil
+}
+
+// renderProjects formats each project with its tools, one per line.
+func renderProjects(projects []*analyzer.Project) string {
+	width := 0
+	for _, p := range projects {
+		for _, t := range p.Tools {
+			width = max(width, len(t.Package))
+		}
+	}
+
+	var b strings.Builder
+	for i, p := range projects {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s  (%s, last used %s)\n", shortenHome(p.Path), countNoun(len(p.Tools), "tool"), formatAgo(p.LastUsed))
+		for _, t := range p.Tools {
+			fmt.Fprintf(&b, "  %-*s  %9s  %s\n", width, t.Package, countNoun(t.Runs, "run"), formatAgo(t.LastUsed))
+		}
+	}
+	return b.String()
+}
+
+// renderPackageProjects formats the projects a package is used in for
+// 'brewprune explain'. Each project lists only that package's usage.
+func renderPackageProjects(projects []*analyzer.Project) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Used in %s:\n", countNoun(len(projects), "project"))
+	for _, p := range projects {
+		runs := 0
+		for _, t := range p.Tools {
+			runs += t.Runs
+		}
+		fmt.Fprintf(&b, "  %s  (%s, last %s)\n", shortenHome(p.Path), countNoun(runs, "run"), formatAgo(p.LastUsed))
+	}
+	return b.String()
+}
+
+// projectRemovalWarnings returns a warning for each of packages that was used
+// in a project, naming the projects that may break once it is removed.
+// Lookup failures yield no warnings.
+func projectRemovalWarnings(a *analyzer.Analyzer, packages []string) []string {
+	projects, err := a.Projects("")
+	if err != nil {
+		return nil
+	}
+	usedIn := make(map[string][]string)
+	for _, p := range projects {
+		for _, t := range p.Tools {
+			usedIn[t.Package] = append(usedIn[t.Package], shortenHome(p.Path))
+		}
+	}
+
+	var warnings []string
+	for _, pkg := range packages {
+		paths := usedIn[pkg]
+		if len(paths) == 0 {
+			continue
+		}
+		sort.Strings(paths)
+		const maxNames = 3
+		names := strings.Join(paths, ", ")
+		if len(paths) > maxNames {
+			names = fmt.Sprintf("%s, and %d more", strings.Join(paths[:maxNames], ", "), len(paths)-maxNames)
+		}
+		warnings = append(warnings, fmt.Sprintf("%s: used in %s (%s), which may break without it",
+			pkg, countNoun(len(paths), "project"), names))
+	}
+	return warnings
+}
+
+// renderDeclaredProjects summarises the requirements found by
+// 'brewprune scan --projects': the installed packages each project declares,
+// one project per line.
+func renderDeclaredProjects(reqs []*store.DeclaredRequirement) string {
+	var order []string
+	declared := make(map[string][]string)
+	seen := make(map[[2]string]bool)
+	for _, r := range reqs {
+		if _, ok := declared[r.Project]; !ok {
+			order = append(order, r.Project)
+		}
+		if key := [2]string{r.Project, r.Package}; !seen[key] {
+			seen[key] = true
+			declared[r.Project] = append(declared[r.Project], r.Package)
+		}
+	}
+	if len(order) == 0 {
+		return "No installed packages are declared by these projects.\n"
+	}
+	sort.Strings(order)
+
+	width := 0
+	for _, project := range order {
+		width = max(width, len(shortenHome(project)))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "✓ %s declare installed packages:\n", countNoun(len(order), "project"))
+	for _, project := range order {
+		pkgs := declared[project]
+		sort.Strings(pkgs)
+		fmt.Fprintf(&b, "  %-*s  %s\n", width, shortenHome(project), strings.Join(pkgs, ", "))
+	}
+	return b.String()
+}
+
+// renderDeclaredRequirements formats the projects that declare they need a
+// package for 'brewprune explain', with up to three of the places each one
+// declares it.
+func renderDeclaredRequirements(reqs []*store.DeclaredRequirement) string {
+	var order []string
+	locations := make(map[string][]string)
+	for _, r := range reqs {
+		if _, ok := locations[r.Project]; !ok {
+			order = append(order, r.Project)
+		}
+		location := r.Location
+		if rel, err := filepath.Rel(r.Project, location); err == nil && !strings.HasPrefix(rel, "..") {
+			location = rel
+		}
+		if n := len(locations[r.Project]); n == 0 || locations[r.Project][n-1] != location {
+			locations[r.Project] = append(locations[r.Project], location)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Declared needed by %s:\n", countNoun(len(order), "project"))
+	for _, project := range order {
+		locs := locations[project]
+		const maxLocations = 3
+		if len(locs) > maxLocations {
+			locs = append(locs[:maxLocations:maxLocations], fmt.Sprintf("%d more", len(locations[project])-maxLocations))
+		}
+		fmt.Fprintf(&b, "  %s  (%s)\n", shortenHome(project), strings.Join(locs, ", "))
+	}
+	return b.String()
+}
+
+// countNoun formats n with noun, e.g. "1 run" or "3 runs".
+func countNoun(n int, noun string) string {
+	return fmt.Sprintf("%d %s", n, pluralize(n, noun, noun+"s"))
+}
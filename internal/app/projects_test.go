@@ -0,0 +1,135 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/analyzer"
+	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+func TestRenderProjects(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	now := time.Now()
+
+	got := renderProjects([]*analyzer.Project{
+		{
+			Path:     filepath.Join(home, "src", "app"),
+			LastUsed: now,
+			Tools: []analyzer.ProjectTool{
+				{Package: "node", Runs: 12, LastUsed: now},
+				{Package: "terraform", Runs: 1, LastUsed: now.Add(-72 * time.Hour)},
+			},
+		},
+		{
+			Path:     "/srv/build/api",
+			LastUsed: now.Add(-48 * time.Hour),
+			Tools:    []analyzer.ProjectTool{{Package: "jq", Runs: 3, LastUsed: now.Add(-48 * time.Hour)}},
+		},
+	})
+
+	want := "~/src/app  (2 tools, last used today)\n" +
+		"  node         12 runs  today\n" +
+		"  terraform      1 run  3 days ago\n" +
+		"\n" +
+		"/srv/build/api  (1 tool, last used 2 days ago)\n" +
+		"  jq            3 runs  2 days ago\n"
+	if got != want {
+		t.Errorf("renderProjects() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestProjectRemovalWarnings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	var repos []string
+	for _, name := range []string{"a", "b", "c", "d"} {
+		repo := filepath.Join(home, name)
+		if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		repos = append(repos, repo)
+	}
+
+	st, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer st.Close()
+	if err := st.CreateSchema(); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	for _, name := range []string{"jq", "node", "wget"} {
+		if err := st.InsertPackage(&brew.Package{Name: name, InstalledAt: time.Now(), InstallType: "explicit"}); err != nil {
+			t.Fatalf("failed to insert package: %v", err)
+		}
+	}
+	insert := func(pkg, cwd string) {
+		t.Helper()
+		if err := st.InsertUsageEvent(&store.UsageEvent{Package: pkg, EventType: "exec", Cwd: cwd, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("failed to insert usage event: %v", err)
+		}
+	}
+	insert("jq", repos[1])
+	for _, repo := range repos {
+		insert("node", repo)
+	}
+	insert("wget", home) // not a project
+
+	got := projectRemovalWarnings(analyzer.New(st), []string{"jq", "node", "wget"})
+	want := []string{
+		"jq: used in 1 project (~/b), which may break without it",
+		"node: used in 4 projects (~/a, ~/b, ~/c, and 1 more), which may break without it",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("projectRemovalWarnings() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRenderDeclaredProjects(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	app := filepath.Join(home, "src", "app")
+
+	got := renderDeclaredProjects([]*store.DeclaredRequirement{
+		{Package: "terraform", Project: app, Location: app + "/Brewfile:2"},
+		{Package: "jq", Project: app, Location: app + "/Makefile:4"},
+		{Package: "jq", Project: app, Location: app + "/Makefile:9"},
+		{Package: "gh", Project: "/srv/api", Location: "/srv/api/release.sh:3"},
+	})
+	want := "✓ 2 projects declare installed packages:\n" +
+		"  /srv/api   gh\n" +
+		"  ~/src/app  jq, terraform\n"
+	if got != want {
+		t.Errorf("renderDeclaredProjects() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := renderDeclaredProjects(nil); !strings.HasPrefix(got, "No installed packages") {
+		t.Errorf("renderDeclaredProjects(nil) = %q", got)
+	}
+}
+
+func TestRenderDeclaredRequirements(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	app := filepath.Join(home, "src", "app")
+
+	var reqs []*store.DeclaredRequirement
+	for _, loc := range []string{"Brewfile:2", "Makefile:4", "Makefile:4", "ci/deploy.sh:7", "ci/lint.sh:1"} {
+		reqs = append(reqs, &store.DeclaredRequirement{Package: "jq", Project: app, Location: filepath.Join(app, loc)})
+	}
+	reqs = append(reqs, &store.DeclaredRequirement{Package: "jq", Project: "/srv/api", Location: "/srv/api/.tool-versions:1"})
+
+	got := renderDeclaredRequirements(reqs)
+	want := "Declared needed by 2 projects:\n" +
+		"  ~/src/app  (Brewfile:2, Makefile:4, ci/deploy.sh:7, 1 more)\n" +
+		"  /srv/api  (.tool-versions:1)\n"
+	if got != want {
+		t.Errorf("renderDeclaredRequirements() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -11,7 +11,6 @@ import (
 	"github.com/blackwell-systems/brewprune/internal/analyzer"
 	"github.com/blackwell-systems/brewprune/internal/brew"
 	"github.com/blackwell-systems/brewprune/internal/output"
-	"github.com/blackwell-systems/brewprune/internal/scanner"
 	"github.com/blackwell-systems/brewprune/internal/snapshots"
 	"github.com/blackwell-systems/brewprune/internal/store"
 	"github.com/spf13/cobra"
@@ -25,6 +24,7 @@ var (
 	removeFlagYes        bool
 	removeFlagNoSnapshot bool
 	removeTierFlag       string
+	removeTapFlag        string
 )
 
 var removeCmd = &cobra.Command{
@@ -44,6 +44,12 @@ Tier shortcut flags (equivalent to --tier):
 
 If packages are specified, validates and removes those specific packages.
 
+With --tap, removes the safe-tier packages of a tap and then untaps it. The
+tap is kept when any of its packages is outside the safe tier (used,
+referenced or held), pinned or needed by a package from elsewhere; the safe
+ones are still removed. The snapshot records the tap and its prefix, so
+'brewprune undo' taps it again.
+
 Safety features:
   - Validates removal candidates before proceeding
   - Warns about dependent packages
@@ -63,6 +69,9 @@ Examples:
   # Remove medium-tier packages without snapshot (dangerous!)
   brewprune remove --medium --no-snapshot --yes
 
+  # Untap a tap along with its unused packages
+  brewprune remove --tap user/tools
+
 Requires: run 'brewprune scan' first to initialize the database.`,
 	RunE: runRemove,
 }
@@ -75,6 +84,7 @@ func init() {
 	removeCmd.Flags().BoolVar(&removeFlagYes, "yes", false, "Skip confirmation prompts")
 	removeCmd.Flags().BoolVar(&removeFlagNoSnapshot, "no-snapshot", false, "Skip automatic snapshot creation [WARNING: removal cannot be undone]")
 	removeCmd.Flags().StringVar(&removeTierFlag, "tier", "", "Remove packages of specified tier: safe, medium, risky (shortcut: --safe, --medium, --risky)")
+	removeCmd.Flags().StringVar(&removeTapFlag, "tap", "", "Remove the unused packages of a tap, then untap it")
 
 	RootCmd.AddCommand(removeCmd)
 }
@@ -92,16 +102,88 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	defer st.Close()
 
 	// Initialize components
-	anlzr := analyzer.New(st)
+	anlzr := newAnalyzer(st)
 	snapshotDir := getSnapshotDir()
 	snapMgr := snapshots.New(st, snapshotDir)
 
 	var packagesToRemove []string
 	var totalSize int64
-	var activeTier string // tracks the resolved tier for confirmation UX
+	var activeTier string      // tracks the resolved tier for confirmation UX
+	var untapName string       // tap to untap once its packages are removed
+	var untapPrefixes []string // prefixes whose brew has the tap
+	var untapSize int64
 
 	// Determine which packages to remove
-	if len(args) > 0 {
+	if removeTapFlag != "" {
+		if len(args) > 0 || removeTierFlag != "" || removeFlagSafe || removeFlagMedium || removeFlagRisky {
+			return fmt.Errorf("cannot combine --tap with package names or tier flags")
+		}
+		if brew.IsOfficialTap(removeTapFlag) {
+			return fmt.Errorf("refusing to remove %s: it is one of Homebrew's official taps", removeTapFlag)
+		}
+		usage, err := findTapUsage(anlzr, removeTapFlag)
+		if err != nil {
+			return err
+		}
+
+		// Unused packages another installed package depends on stay
+		var lockedPackages []string
+		packagesToRemove, lockedPackages = tapRemovalOrder(usage.Unused, brew.Uses)
+		for _, pkg := range packagesToRemove {
+			if pkgInfo, err := st.GetPackage(pkg); err == nil {
+				totalSize += pkgInfo.SizeBytes
+			}
+		}
+
+		var kept []string
+		for _, pkg := range usage.Used {
+			if score, scoreErr := anlzr.ComputeScore(pkg); scoreErr == nil {
+				pkg += " (" + score.Tier + ")"
+			}
+			kept = append(kept, pkg)
+		}
+		for _, pkg := range usage.Kept {
+			kept = append(kept, pkg+" (pinned)")
+		}
+		kept = append(kept, lockedPackages...)
+		if len(kept) == 0 {
+			untapName = usage.Name
+			untapPrefixes = usage.Prefixes
+			untapSize = usage.SizeBytes
+			totalSize += untapSize
+		} else if len(packagesToRemove) == 0 {
+			fmt.Printf("Nothing to remove from %s: %s kept (%s).\n", usage.Name, countNoun(len(kept), "package"), strings.Join(kept, ", "))
+			return nil
+		}
+
+		if warnings := projectRemovalWarnings(anlzr, packagesToRemove); len(warnings) > 0 {
+			fmt.Println()
+			for _, warning := range warnings {
+				fmt.Printf("  ⚠ %s\n", warning)
+			}
+		}
+
+		var tapScores []*analyzer.ConfidenceScore
+		for _, pkg := range packagesToRemove {
+			if score, scoreErr := anlzr.ComputeScore(pkg); scoreErr == nil {
+				tapScores = append(tapScores, score)
+			}
+		}
+		fmt.Printf("\nPackages to remove (tap %s):\n\n", usage.Name)
+		if removeFlagDryRun {
+			fmt.Println("  *** DRY RUN — NO CHANGES WILL BE MADE ***")
+			fmt.Println()
+		}
+		if len(tapScores) > 0 {
+			displayConfidenceScores(st, tapScores)
+		} else {
+			fmt.Println("  (none installed)")
+		}
+
+		if untapName == "" {
+			fmt.Fprintf(os.Stderr, "\n⚠  %s is kept: %s\n", usage.Name, strings.Join(kept, ", "))
+		}
+	} else if len(args) > 0 {
 		// User specified packages explicitly — pre-filter dep-locked packages
 		var lockedExplicit []string
 		var filteredArgs []string
@@ -136,6 +218,7 @@ func runRemove(cmd *cobra.Command, args []string) error {
 				warnings = append(warnings, w)
 			}
 		}
+		warnings = append(warnings, projectRemovalWarnings(anlzr, packagesToRemove)...)
 
 		// Compute scores for explicit packages to display the same table as tier-based removal
 		var explicitScores []*analyzer.ConfidenceScore
@@ -194,6 +277,25 @@ func runRemove(cmd *cobra.Command, args []string) error {
 			return nil
 		}
 
+		// Pinned packages and running services are never removed by tier
+		var heldPackages []string
+		var unheldScores []*analyzer.ConfidenceScore
+		for _, score := range scores {
+			switch {
+			case score.IsPinned:
+				heldPackages = append(heldPackages, score.Package+" (pinned)")
+			case score.IsService:
+				heldPackages = append(heldPackages, score.Package+" (running service)")
+			default:
+				unheldScores = append(unheldScores, score)
+			}
+		}
+		scores = unheldScores
+		if len(scores) == 0 {
+			fmt.Printf("No %s packages found for removal (%d pinned or running as a service).\n", tier, len(heldPackages))
+			return nil
+		}
+
 		// Pre-filter dep-locked packages
 		var lockedPackages []string
 		var filteredScores []*analyzer.ConfidenceScore
@@ -225,6 +327,14 @@ func runRemove(cmd *cobra.Command, args []string) error {
 			fmt.Println()
 		}
 
+		// Name the projects that used any of the packages
+		if warnings := projectRemovalWarnings(anlzr, packagesToRemove); len(warnings) > 0 {
+			fmt.Println()
+			for _, warning := range warnings {
+				fmt.Printf("  ⚠ %s\n", warning)
+			}
+		}
+
 		// Display table of packages to remove
 		fmt.Printf("\nPackages to remove (%s tier):\n\n", tier)
 		if removeFlagDryRun {
@@ -234,12 +344,15 @@ func runRemove(cmd *cobra.Command, args []string) error {
 		displayConfidenceScores(st, scores)
 
 		// Print skipped summary after the table
+		if len(heldPackages) > 0 {
+			fmt.Fprintf(os.Stderr, "\n⚠  %d packages skipped: %s\n", len(heldPackages), strings.Join(heldPackages, ", "))
+		}
 		if len(lockedPackages) > 0 {
 			fmt.Fprintf(os.Stderr, "\n⚠  %d packages skipped (have other packages depending on them) — remove their dependents first, or use --verbose to see details\n", len(lockedPackages))
 		}
 	}
 
-	if len(packagesToRemove) == 0 {
+	if len(packagesToRemove) == 0 && untapName == "" {
 		fmt.Println("No packages to remove.")
 		return fmt.Errorf("no packages removed: all candidates were locked by dependents")
 	}
@@ -247,6 +360,9 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	// Display summary
 	fmt.Printf("\nSummary:\n")
 	fmt.Printf("  Packages: %d\n", len(packagesToRemove))
+	if untapName != "" {
+		fmt.Printf("  Tap: %s (%s, untapped after the packages)\n", untapName, formatSize(untapSize))
+	}
 	fmt.Printf("  Disk space to free: %s\n", formatSize(totalSize))
 	if !removeFlagNoSnapshot {
 		fmt.Printf("  Snapshot: will be created\n")
@@ -272,7 +388,13 @@ func runRemove(cmd *cobra.Command, args []string) error {
 
 	// Confirm removal
 	if !removeFlagYes {
-		if !confirmRemoval(len(packagesToRemove), activeTier) {
+		var confirmed bool
+		if untapName != "" {
+			confirmed = confirmTapRemoval(len(packagesToRemove), untapName)
+		} else {
+			confirmed = confirmRemoval(len(packagesToRemove), activeTier)
+		}
+		if !confirmed {
 			fmt.Println("Removal cancelled.")
 			return nil
 		}
@@ -282,7 +404,15 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	var snapshotID int64
 	if !removeFlagNoSnapshot {
 		fmt.Println("Creating snapshot...")
-		snapshotID, err = snapMgr.CreateSnapshot(packagesToRemove, "before removal")
+		if untapName != "" {
+			var taps []*snapshots.TapSnapshot
+			for _, prefix := range untapPrefixes {
+				taps = append(taps, &snapshots.TapSnapshot{Name: untapName, Prefix: prefix})
+			}
+			snapshotID, err = snapMgr.CreateTapSnapshot(packagesToRemove, taps, "before removing tap "+untapName)
+		} else {
+			snapshotID, err = snapMgr.CreateSnapshot(packagesToRemove, "before removal")
+		}
 		if err != nil {
 			return fmt.Errorf("failed to create snapshot: %w", err)
 		}
@@ -290,7 +420,9 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	}
 
 	// Remove packages
-	fmt.Printf("Removing %d packages...\n", len(packagesToRemove))
+	if len(packagesToRemove) > 0 {
+		fmt.Printf("Removing %d packages...\n", len(packagesToRemove))
+	}
 	progress := output.NewProgress(len(packagesToRemove), "Removing packages")
 
 	successCount := 0
@@ -299,11 +431,16 @@ func runRemove(cmd *cobra.Command, args []string) error {
 
 	for _, pkg := range packagesToRemove {
 		// Check if it's a core dependency (safety check)
-		if scanner.IsCoreDependency(pkg) {
+		if anlzr.IsCoreDependency(pkg) {
 			failures = append(failures, fmt.Sprintf("%s: core dependency, skipped", pkg))
 			progress.Increment()
 			continue
 		}
+		if pkgInfo, err := st.GetPackage(pkg); err == nil && brew.IsRunningService(pkgInfo.Service) {
+			failures = append(failures, fmt.Sprintf("%s: running brew service, skipped (stop it with 'brew services stop %s')", pkg, pkg))
+			progress.Increment()
+			continue
+		}
 
 		// Capture package size before removal (DB entry is deleted after)
 		var pkgSize int64
@@ -318,7 +455,11 @@ func runRemove(cmd *cobra.Command, args []string) error {
 			continue
 		}
 
-		// Update database
+		// Update database. Record the package's commands first so the
+		// shell's command-not-found hook can explain them.
+		if err := st.RecordRemovedPackage(pkg, snapshotID, time.Now()); err != nil && !errors.Is(err, store.ErrNotInitialized) {
+			fmt.Fprintf(os.Stderr, "\nWarning: failed to record removed commands of %s: %v\n", pkg, err)
+		}
 		if err := st.DeletePackage(pkg); err != nil {
 			// Non-fatal - package was removed from system but not from DB
 			fmt.Fprintf(os.Stderr, "\nWarning: removed %s but failed to update database: %v\n", pkg, err)
@@ -331,6 +472,25 @@ func runRemove(cmd *cobra.Command, args []string) error {
 
 	progress.Finish()
 
+	// Untap only once every package of the tap is gone
+	untapped := false
+	if untapName != "" {
+		if remaining := len(packagesToRemove) - successCount; remaining > 0 {
+			failures = append(failures, fmt.Sprintf("%s: not untapped, %s still installed", untapName, countNoun(remaining, "package")))
+		} else {
+			untapped = true
+			for _, prefix := range untapPrefixes {
+				if err := brew.Untap(prefix, untapName); err != nil {
+					failures = append(failures, fmt.Sprintf("%s: %v", untapName, err))
+					untapped = false
+				}
+			}
+			if untapped {
+				freedSize += untapSize
+			}
+		}
+	}
+
 	// Display results
 	if successCount == 0 && len(failures) > 0 {
 		fmt.Printf("\n✗ Removed 0 packages, freed %s\n", formatSize(freedSize))
@@ -342,6 +502,9 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("\n✓ Removed %d packages, freed %s\n", successCount, formatSize(freedSize))
+	if untapped {
+		fmt.Printf("✓ Untapped %s\n", untapName)
+	}
 
 	if len(failures) > 0 {
 		fmt.Printf("\n⚠️  %d failures:\n", len(failures))
@@ -488,10 +651,12 @@ func displayConfidenceScores(st *store.Store, scores []*analyzer.ConfidenceScore
 		uses7d, _ := st.GetUsageEventCountSince(score.Package, sevenDaysAgo)
 		depCount, _ := st.GetReverseDependencyCount(score.Package)
 
-		// Look up IsCask from the store; default to false on error
+		// Look up IsCask and the prefix from the store; default to zero on error
 		var isCask bool
+		var prefix string
 		if pkgInfo, err := st.GetPackage(score.Package); err == nil {
 			isCask = pkgInfo.IsCask
+			prefix = pkgInfo.Prefix
 		}
 
 		outputScores[i] = output.ConfidenceScore{
@@ -505,6 +670,7 @@ func displayConfidenceScores(st *store.Store, scores []*analyzer.ConfidenceScore
 			DepCount:   depCount,
 			IsCritical: score.IsCritical,
 			IsCask:     isCask,
+			Prefix:     prefix,
 		}
 	}
 
@@ -539,3 +705,87 @@ func confirmRemoval(count int, tier string) bool {
 	response = strings.TrimSpace(strings.ToLower(response))
 	return response == "y" || response == "yes"
 }
+
+// confirmTapRemoval prompts the user to confirm removing count packages of
+// tap and untapping it. It accepts "y" or "yes".
+func confirmTapRemoval(count int, tap string) bool {
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Printf("Remove %d packages and untap %s? [y/N]: ", count, tap)
+
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes"
+}
+
+// tapRemovalOrder splits the unused packages of a tap into those that can be
+// removed, ordered so that dependents go before their dependencies, and
+// those locked by an installed dependent that stays ("pkg (required by:
+// ...)"). uses returns the installed dependents of a package, as brew.Uses
+// does; like the other removal paths, a package whose dependents cannot be
+// listed is treated as having none.
+func tapRemovalOrder(packages []string, uses func(string) ([]string, error)) (order, locked []string) {
+	dependents := make(map[string][]string)
+	removing := make(map[string]bool)
+	for _, pkg := range packages {
+		if deps, err := uses(pkg); err == nil {
+			dependents[pkg] = deps
+		}
+		removing[pkg] = true
+	}
+
+	// A package stays when any dependent stays, which can keep its own
+	// dependencies in turn
+	for changed := true; changed; {
+		changed = false
+		for _, pkg := range packages {
+			if !removing[pkg] {
+				continue
+			}
+			var staying []string
+			for _, dep := range dependents[pkg] {
+				if !removing[dep] {
+					staying = append(staying, dep)
+				}
+			}
+			if len(staying) > 0 {
+				removing[pkg] = false
+				locked = append(locked, fmt.Sprintf("%s (required by: %s)", pkg, strings.Join(staying, ", ")))
+				changed = true
+			}
+		}
+	}
+
+	// Emit packages once none of their dependents is left to remove
+	done := make(map[string]bool)
+	for progress := true; progress; {
+		progress = false
+		for _, pkg := range packages {
+			if !removing[pkg] || done[pkg] {
+				continue
+			}
+			ready := true
+			for _, dep := range dependents[pkg] {
+				if removing[dep] && !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				done[pkg] = true
+				order = append(order, pkg)
+				progress = true
+			}
+		}
+	}
+	// A dependency cycle, which brew does not allow, keeps the given order
+	for _, pkg := range packages {
+		if removing[pkg] && !done[pkg] {
+			order = append(order, pkg)
+		}
+	}
+	return order, locked
+}
@@ -1106,3 +1106,26 @@ func TestRemove_ExitCodeAllLocked(t *testing.T) {
 		t.Errorf("error should mention locked by dependents, got: %q", resultErr.Error())
 	}
 }
+
+func TestTapRemovalOrder(t *testing.T) {
+	// tool-extras depends on tool-lib; tool-lib is also needed by app,
+	// which is not being removed, and tool-core only by tool-lib.
+	dependents := map[string][]string{
+		"tool":        nil,
+		"tool-extras": nil,
+		"tool-lib":    {"tool-extras", "app"},
+		"tool-core":   {"tool-lib"},
+		"tool-base":   {"tool", "tool-extras"},
+	}
+	uses := func(pkg string) ([]string, error) { return dependents[pkg], nil }
+
+	order, locked := tapRemovalOrder([]string{"tool-base", "tool-core", "tool-extras", "tool-lib", "tool"}, uses)
+
+	if got, want := strings.Join(order, " "), "tool-extras tool tool-base"; got != want {
+		t.Errorf("order = %q, want %q", got, want)
+	}
+	wantLocked := []string{"tool-lib (required by: app)", "tool-core (required by: tool-lib)"}
+	if strings.Join(locked, "\n") != strings.Join(wantLocked, "\n") {
+		t.Errorf("locked = %q, want %q", locked, wantLocked)
+	}
+}
@@ -24,7 +24,7 @@ var (
 
 	// validCommandsList is the hardcoded list of valid subcommands shown in
 	// the unknown-command error message.
-	validCommandsList = "scan, unused, remove, undo, status, stats, explain, doctor, quickstart, watch, completion"
+	validCommandsList = "scan, unused, remove, undo, status, stats, explain, projects, duplicates, taps, doctor, quickstart, watch, flush, service, shell-init, bench-shim, teardown, completion"
 
 	// RootCmd is the root command for brewprune
 	RootCmd = &cobra.Command{
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"syscall"
 
 	"github.com/blackwell-systems/brewprune/internal/brew"
@@ -21,6 +22,8 @@ var (
 	scanRefreshBinaries bool
 	scanQuiet           bool
 	scanRefreshShims    bool
+	scanPrefixes        []string
+	scanProjects        []string
 
 	scanCmd = &cobra.Command{
 		Use:   "scan",
@@ -34,13 +37,35 @@ the database for fast access by other commands.
 The scan command should be run:
   • After installing brewprune for the first time
   • After installing or removing packages manually with brew
-  • Periodically to keep the database in sync with brew`,
+  • Periodically to keep the database in sync with brew
+
+By default the prefix of the brew on PATH (or $HOMEBREW_PREFIX) is scanned.
+Machines with several Homebrew installations, such as an ARM brew in
+/opt/homebrew next to an Intel brew in /usr/local, can inventory all of them
+with a repeated --prefix or the brew.prefixes config key. A package installed
+in several prefixes is recorded under the first, and each shim runs the binary
+from its own package's prefix.
+
+Tools run only in CI or on release day leave no usage behind. --projects
+scans the given directories for manifests and scripts that declare such
+tools instead of scanning packages: Brewfile entries, .tool-versions and
+mise.toml tools, and the commands run by Makefile recipes, package.json
+scripts, system pre-commit hooks and shell scripts. Commands are mapped to
+packages through the binary index, and each declared package is held out of
+the safe tier and shown as declared-needed in 'brewprune explain'. Run it
+after a package scan, and again when the manifests change.`,
 		Example: `  # Scan all packages
   brewprune scan
 
   # Scan without refreshing binary paths
   brewprune scan --refresh-binaries=false
 
+  # Inventory both an ARM and an Intel Homebrew
+  brewprune scan --prefix /opt/homebrew --prefix /usr/local
+
+  # Record the tools declared by the projects under ~/src
+  brewprune scan --projects ~/src
+
   # Fast path: refresh shims only
   brewprune scan --refresh-shims
 
@@ -54,6 +79,8 @@ func init() {
 	scanCmd.Flags().BoolVar(&scanRefreshBinaries, "refresh-binaries", true, "refresh binary path mappings")
 	scanCmd.Flags().BoolVar(&scanQuiet, "quiet", false, "suppress output")
 	scanCmd.Flags().BoolVar(&scanRefreshShims, "refresh-shims", false, "fast path: diff and update shims only, skip full dep tree rebuild")
+	scanCmd.Flags().StringSliceVar(&scanProjects, "projects", nil, "scan these project directories for declared tool requirements instead of packages")
+	scanCmd.Flags().StringArrayVar(&scanPrefixes, "prefix", nil, "Homebrew prefix to scan (repeatable; default: brew.prefixes config or the brew on PATH)")
 }
 
 func runScan(cmd *cobra.Command, args []string) error {
@@ -75,6 +102,12 @@ func runScan(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create database schema: %w", err)
 	}
 
+	// Project manifests are scanned on their own, against the stored
+	// packages.
+	if len(scanProjects) > 0 {
+		return runScanProjects(db, scanProjects)
+	}
+
 	// Fast path: refresh shims only without a full dep tree rebuild.
 	if scanRefreshShims {
 		return runRefreshShims(db)
@@ -89,6 +122,11 @@ func runScan(cmd *cobra.Command, args []string) error {
 
 	// Create scanner
 	s := scanner.New(db)
+	prefixes, err := resolveScanPrefixes()
+	if err != nil {
+		return err
+	}
+	s.SetPrefixes(prefixes)
 
 	// Scan packages quietly to check for changes first
 	isTTY := isatty.IsTerminal(os.Stdout.Fd())
@@ -130,9 +168,11 @@ func runScan(cmd *cobra.Command, args []string) error {
 	// Detect changes: compare package names
 	hasChanges := detectChanges(existingPackages, newPackages)
 
-	// If no changes on re-scan, still sync alias shims then exit
+	// If no changes on re-scan, still sync alias shims and the shim index
+	// then exit
 	if !isFirstScan && !hasChanges {
 		aliasCount, aliasErr := generateAliasShims(db)
+		manifestErr := writeShimIndexes(prefixes, newPackages)
 		if !scanQuiet {
 			if isTTY {
 				msg := fmt.Sprintf("✓ Database up to date (%d packages, 0 changes)", len(newPackages))
@@ -149,6 +189,9 @@ func runScan(cmd *cobra.Command, args []string) error {
 			if aliasErr != nil {
 				fmt.Printf("⚠ Alias shim generation incomplete: %v\n", aliasErr)
 			}
+			if manifestErr != nil {
+				fmt.Printf("⚠ Could not write shim index: %v\n", manifestErr)
+			}
 		}
 		return nil
 	}
@@ -187,6 +230,13 @@ func runScan(cmd *cobra.Command, args []string) error {
 
 	// Refresh binary paths if requested
 	if scanRefreshBinaries {
+		// Map cask application bundles to their casks and record their
+		// launches, so casks are scored on real usage. Runs first so cask
+		// binaries linking into an app bundle are attributed. Non-fatal.
+		if appErr := scanCaskApps(s, db); appErr != nil && !scanQuiet {
+			fmt.Printf("⚠ Cask app scan incomplete: %v\n", appErr)
+		}
+
 		if !scanQuiet {
 			if isTTY {
 				spinner = output.NewSpinner("Refreshing binary paths...")
@@ -204,6 +254,46 @@ func runScan(cmd *cobra.Command, args []string) error {
 		if !scanQuiet && isTTY {
 			spinner.StopWithMessage("✓ Binary paths refreshed")
 		}
+
+		// Record which libraries each binary loads so library-only packages
+		// inherit usage from the binaries that link them. Non-fatal.
+		if !scanQuiet {
+			if isTTY {
+				spinner = output.NewSpinner("Scanning library linkage...")
+				spinner.Start()
+			} else {
+				fmt.Println("Scanning library linkage...")
+			}
+		}
+		linkCount, linkErr := scanLibraryLinkage(s)
+		if !scanQuiet {
+			if linkErr != nil {
+				if isTTY {
+					spinner.Stop()
+				}
+				fmt.Printf("⚠ Library linkage scan incomplete: %v\n", linkErr)
+			} else if isTTY {
+				spinner.StopWithMessage(fmt.Sprintf("✓ Library linkage scanned (%d links)", linkCount))
+			}
+		}
+
+		// Look for binaries run around the shims, whose usage is therefore
+		// undercounted. Runs after the linkage scan, which preserves access
+		// times. Non-fatal.
+		bypassed, bypassErr := detectShimBypass(s, db)
+		if !scanQuiet {
+			if bypassErr != nil {
+				fmt.Printf("⚠ Shim bypass detection incomplete: %v\n", bypassErr)
+			} else if groups := groupBypassFindings(bypassed); len(groups) > 0 {
+				fmt.Printf("⚠ %d binaries appear to run outside the shims — see 'brewprune doctor'\n", len(groups))
+			}
+		}
+
+		// Find language runtimes reached through virtualenvs, "#!" lines and
+		// version-manager symlinks instead of the shims. Non-fatal.
+		if runtimeErr := detectRuntimeReferences(s, db); runtimeErr != nil && !scanQuiet {
+			fmt.Printf("⚠ Runtime reference scan incomplete: %v\n", runtimeErr)
+		}
 	}
 
 	// Re-fetch inventory after building dep graph and refreshing binaries
@@ -255,6 +345,9 @@ func runScan(cmd *cobra.Command, args []string) error {
 
 			var shimErr error
 			shimCount, shimErr = shim.GenerateShims(allBinaries)
+			if err := writeShimIndexes(prefixes, packages); err != nil && !scanQuiet {
+				fmt.Printf("⚠ Could not write shim index: %v\n", err)
+			}
 			if shimErr == nil {
 				// Generate alias shims from ~/.brewprune/aliases.
 				aliasCount, aliasErr := generateAliasShims(db)
@@ -292,9 +385,26 @@ func runScan(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Shim log entries that no package claimed may resolve now that the
+	// binary paths and aliases are up to date. Non-fatal.
+	replayed, replayErr := watcher.ReplayUnresolvedEvents(db)
+	if !scanQuiet {
+		if replayErr != nil {
+			fmt.Printf("⚠ Replaying unresolved usage incomplete: %v\n", replayErr)
+		} else if replayed.Replayed > 0 {
+			fmt.Printf("✓ Recorded %d earlier runs of %d commands that now map to packages\n", replayed.Replayed, replayed.Commands)
+		}
+	}
+
+	// Let a running daemon pick up the new package index right away.
+	notifyDaemonIndexChanged()
+
 	if !scanQuiet {
 		fmt.Println()
 		fmt.Printf("Scan complete: %d packages found (%s total)\n", len(packages), formatSize(totalSize))
+		if len(prefixes) > 1 {
+			fmt.Printf("Prefixes scanned: %s\n", strings.Join(prefixes, ", "))
+		}
 
 		// Show next-step guidance based on daemon state.
 		pidFile, pidErr := getDefaultPIDFile()
@@ -333,6 +443,17 @@ func runScan(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// notifyDaemonIndexChanged asks a running daemon to rebuild its cached package
+// index. Daemons without a control socket rebuild it periodically instead, so
+// failures are ignored.
+func notifyDaemonIndexChanged() {
+	pidFile, err := getDefaultPIDFile()
+	if err != nil {
+		return
+	}
+	watcher.CallControl(watcher.ControlSocketPath(pidFile), watcher.ControlRescanIndex) //nolint:errcheck
+}
+
 // runRefreshShims implements the --refresh-shims fast path.
 //
 // It reads the current binary list from the DB (no brew invocation or dep tree
@@ -388,6 +509,14 @@ func runRefreshShims(db *store.Store) error {
 		}
 	}
 
+	// Keep the shim's prefix manifest and index in step with the stored
+	// packages. The configured prefixes are optional here: the packages
+	// record their own.
+	prefixes, _ := resolveScanPrefixes()
+	if err := writeShimIndexes(prefixes, packages); err != nil && !scanQuiet {
+		fmt.Printf("warning: could not write shim index: %v\n", err)
+	}
+
 	if !scanQuiet {
 		fmt.Printf("Refreshed shims: +%d added, -%d removed\n", added, removed)
 	}
@@ -395,6 +524,208 @@ func runRefreshShims(db *store.Store) error {
 	return nil
 }
 
+// runScanProjects records the tool requirements declared by the manifests
+// and scripts under dirs, replacing those previously found there.
+func runScanProjects(db *store.Store, dirs []string) error {
+	packages, err := db.ListPackages()
+	if err != nil {
+		return fmt.Errorf("failed to list packages from database: %w", err)
+	}
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages in the database — run 'brewprune scan' before 'brewprune scan --projects'")
+	}
+
+	abs := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		path, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", dir, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("cannot scan project directory: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("cannot scan project directory: %s is not a directory", dir)
+		}
+		abs = append(abs, path)
+	}
+
+	reqs, err := scanner.New(db).DetectDeclaredRequirements(abs)
+	if err != nil {
+		return fmt.Errorf("failed to scan project manifests: %w", err)
+	}
+	if err := db.ReplaceDeclaredRequirements(abs, reqs); err != nil {
+		return fmt.Errorf("failed to store declared requirements: %w", err)
+	}
+
+	if !scanQuiet {
+		fmt.Print(renderDeclaredProjects(reqs))
+	}
+	return nil
+}
+
+// resolveScanPrefixes returns the Homebrew prefixes to scan: those given with
+// --prefix, else the brew.prefixes config key, else brew.DefaultPrefix.
+// Explicit prefixes must hold a Homebrew installation; duplicates are dropped.
+func resolveScanPrefixes() ([]string, error) {
+	prefixes := scanPrefixes
+	if len(prefixes) == 0 {
+		prefixes = loadSettings().BrewPrefixes
+	}
+	if len(prefixes) == 0 {
+		prefix, err := brew.DefaultPrefix()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get brew prefix: %w", err)
+		}
+		return []string{prefix}, nil
+	}
+
+	var resolved []string
+	seen := make(map[string]bool)
+	for _, prefix := range prefixes {
+		prefix = filepath.Clean(prefix)
+		if seen[prefix] {
+			continue
+		}
+		seen[prefix] = true
+		if err := brew.CheckPrefix(prefix); err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, prefix)
+	}
+	return resolved, nil
+}
+
+// writeShimIndexes records, for the shim binary, which prefix each shimmed
+// command runs from (the prefix manifest) and the real binary it execs, along
+// with the shim options set in the config (the shim index). Prefixes recorded on packages but no longer scanned are kept
+// after the scanned ones so their shims still resolve. Call it after
+// shim.WriteShimVersion, whose version the index embeds.
+func writeShimIndexes(prefixes []string, packages []*brew.Package) error {
+	known := make(map[string]bool, len(prefixes))
+	for _, prefix := range prefixes {
+		known[prefix] = true
+	}
+	var binaries []string
+	for _, pkg := range packages {
+		if pkg.Prefix != "" && !known[pkg.Prefix] {
+			known[pkg.Prefix] = true
+			prefixes = append(prefixes, pkg.Prefix)
+		}
+		binaries = append(binaries, pkg.BinaryPaths...)
+	}
+	m := shim.BuildPrefixManifest(prefixes, binaries)
+	if err := shim.WritePrefixManifest(m); err != nil {
+		return err
+	}
+	return shim.WriteShimIndex(m, shim.ShimIndexOptions{RecordCwd: loadSettings().TrackCwd})
+}
+
+// scanLibraryLinkage runs the linkage scanner against the Cellar of every
+// scanned brew prefix and returns the number of linkages recorded.
+func scanLibraryLinkage(s *scanner.Scanner) (int, error) {
+	prefixes, err := s.Prefixes()
+	if err != nil {
+		return 0, err
+	}
+	cellars := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		cellars = append(cellars, filepath.Join(prefix, "Cellar"))
+	}
+	return s.ScanLinkage(cellars...)
+}
+
+// detectShimBypass runs bypass detection against the scanned brew prefixes,
+// the user's home directory and the configured project directories, stores
+// the findings, and returns those not yet covered by recorded usage.
+func detectShimBypass(s *scanner.Scanner, db *store.Store) ([]*store.BypassFinding, error) {
+	opts := scanner.BypassOptions{ProjectDirs: loadSettings().ProjectDirs}
+	if prefixes, err := s.Prefixes(); err == nil {
+		opts.Prefixes = prefixes
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		opts.HomeDir = home
+	}
+
+	findings, err := s.DetectBypass(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.ReplaceBypassFindings(findings); err != nil {
+		return nil, err
+	}
+	return db.GetBypassFindings("")
+}
+
+// runtimeManagerDirs are the virtualenv and version-manager directories,
+// relative to the home directory, searched for runtime references when no
+// runtime roots are configured.
+var runtimeManagerDirs = []string{".virtualenvs", ".pyenv", ".nvm", ".rbenv", ".asdf"}
+
+// defaultRuntimeRoots returns the runtime roots used when none are
+// configured: the virtualenv and version-manager directories under home and
+// the project directories.
+func defaultRuntimeRoots(home string, projectDirs []string) []string {
+	var roots []string
+	if home != "" {
+		for _, dir := range runtimeManagerDirs {
+			roots = append(roots, filepath.Join(home, dir))
+		}
+	}
+	return append(roots, projectDirs...)
+}
+
+// detectRuntimeReferences searches the configured runtime roots (the
+// virtualenv and version-manager directories and the project directories by
+// default) and script directories (~/bin and ~/.local/bin by default) for
+// references to brew-installed language runtimes, and stores them.
+func detectRuntimeReferences(s *scanner.Scanner, db *store.Store) error {
+	settings := loadSettings()
+	opts := scanner.RuntimeOptions{
+		Roots:      settings.RuntimeRoots,
+		MaxDepth:   settings.RuntimeMaxDepth,
+		ScriptDirs: settings.RuntimeScriptDirs,
+	}
+	if prefixes, err := s.Prefixes(); err == nil {
+		opts.Prefixes = prefixes
+	}
+	home, _ := os.UserHomeDir()
+	if len(opts.Roots) == 0 {
+		opts.Roots = defaultRuntimeRoots(home, settings.ProjectDirs)
+	}
+	if home != "" && len(opts.ScriptDirs) == 0 {
+		opts.ScriptDirs = []string{filepath.Join(home, "bin"), filepath.Join(home, ".local", "bin")}
+	}
+
+	refs, err := s.DetectRuntimeReferences(opts)
+	if err != nil {
+		return err
+	}
+	return db.ReplaceRuntimeReferences(refs)
+}
+
+// scanCaskApps maps the application bundles of installed casks, found in the
+// Caskroom of each cask's prefix and the Applications folders, and records
+// their launches when the platform has a launch source.
+func scanCaskApps(s *scanner.Scanner, db *store.Store) error {
+	appDirs := []string{"/Applications"}
+	if home, err := os.UserHomeDir(); err == nil {
+		appDirs = append(appDirs, filepath.Join(home, "Applications"))
+	}
+
+	count, err := s.ScanCaskApps(appDirs)
+	if err != nil {
+		return err
+	}
+	if src := watcher.DefaultLaunchSource(); src != nil && count > 0 {
+		if _, err := watcher.IngestAppLaunches(db, src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // generateAliasShims reads the XDG config aliases file, creates a shim symlink
 // for each declared alias, and augments the target package's BinaryPaths in the
 // database so the shim processor can resolve the alias name to the canonical
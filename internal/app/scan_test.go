@@ -3,8 +3,10 @@ package app
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/blackwell-systems/brewprune/internal/brew"
 	"github.com/blackwell-systems/brewprune/internal/shim"
@@ -299,6 +301,49 @@ func TestRunRefreshShimsEmptyDB(t *testing.T) {
 	}
 }
 
+func TestRunScanProjects(t *testing.T) {
+	db, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer db.Close()
+	if err := db.CreateSchema(); err != nil {
+		t.Fatalf("CreateSchema: %v", err)
+	}
+
+	origQuiet := scanQuiet
+	scanQuiet = true
+	defer func() { scanQuiet = origQuiet }()
+
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Brewfile"), []byte("brew \"jq\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runScanProjects(db, []string{dir}); err == nil {
+		t.Error("runScanProjects with no packages should ask for a package scan first")
+	}
+
+	if err := db.InsertPackage(&brew.Package{Name: "jq", InstalledAt: time.Now()}); err != nil {
+		t.Fatalf("InsertPackage: %v", err)
+	}
+	if err := runScanProjects(db, []string{filepath.Join(dir, "missing")}); err == nil {
+		t.Error("runScanProjects with a missing directory should fail")
+	}
+	if err := runScanProjects(db, []string{dir}); err != nil {
+		t.Fatalf("runScanProjects: %v", err)
+	}
+
+	reqs, err := db.GetDeclaredRequirements("jq")
+	if err != nil {
+		t.Fatalf("GetDeclaredRequirements: %v", err)
+	}
+	if len(reqs) != 1 || reqs[0].Project != dir || reqs[0].Source != "Brewfile" {
+		t.Errorf("declared requirements = %+v, want jq from %s/Brewfile", reqs, dir)
+	}
+}
+
 // TestScanCommandFlagsIncludesRefreshShims extends the existing flag table
 // test to confirm --refresh-shims appears alongside the other flags.
 func TestScanCommandFlagsIncludesRefreshShims(t *testing.T) {
@@ -548,3 +593,23 @@ func TestRunScan_DaemonRunning_SuppressesWarning(t *testing.T) {
 		}
 	}
 }
+
+func TestDefaultRuntimeRoots(t *testing.T) {
+	got := defaultRuntimeRoots("/home/tester", []string{"/home/tester/src"})
+	want := []string{
+		"/home/tester/.virtualenvs",
+		"/home/tester/.pyenv",
+		"/home/tester/.nvm",
+		"/home/tester/.rbenv",
+		"/home/tester/.asdf",
+		"/home/tester/src",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("defaultRuntimeRoots() = %v, want %v", got, want)
+	}
+	for _, root := range got {
+		if root == "/home/tester" {
+			t.Error("home directory walked without being configured")
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/blackwell-systems/brewprune/internal/service"
+	"github.com/blackwell-systems/brewprune/internal/watcher"
+	"github.com/spf13/cobra"
+)
+
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Run the watch daemon as a launchd or systemd user service",
+	Long: `Install the usage tracking daemon as a per-user service so it starts at login
+and is restarted if it fails.
+
+On macOS a launchd agent is written to ~/Library/LaunchAgents; on Linux a
+systemd --user unit is written to ~/.config/systemd/user. The service runs
+the daemon in the foreground, logging to ~/.brewprune/watch.log.
+
+If you installed brewprune with Homebrew, 'brew services start brewprune'
+does the same job. The two are detected and never run side by side.`,
+	Example: `  # Start tracking at login
+  brewprune service install
+
+  # Check the service and daemon state
+  brewprune service status
+
+  # Stop and remove the service
+  brewprune service uninstall`,
+}
+
+var serviceInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Write the service definition and start the daemon",
+	Args:  cobra.NoArgs,
+	RunE:  runServiceInstall,
+}
+
+var serviceUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Stop the daemon and remove the service definition",
+	Args:  cobra.NoArgs,
+	RunE:  runServiceUninstall,
+}
+
+var serviceStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the service is installed and running",
+	Args:  cobra.NoArgs,
+	RunE:  runServiceStatus,
+}
+
+func init() {
+	serviceCmd.AddCommand(serviceInstallCmd, serviceUninstallCmd, serviceStatusCmd)
+	RootCmd.AddCommand(serviceCmd)
+}
+
+// newServiceManager returns the service manager for this platform.
+func newServiceManager() (*service.Manager, string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	m, err := service.NewManager(runtime.GOOS, home)
+	if err != nil {
+		return nil, "", err
+	}
+	return m, home, nil
+}
+
+func runServiceInstall(cmd *cobra.Command, args []string) error {
+	m, home, err := newServiceManager()
+	if err != nil {
+		return err
+	}
+
+	if path, ok := service.BrewServicesRegistration(runtime.GOOS, home); ok {
+		return fmt.Errorf("brewprune is already registered with brew services (%s)\n"+
+			"Keep using 'brew services', or run 'brew services stop brewprune' before installing this service", shortenHome(path))
+	}
+
+	pidFile, err := getDefaultPIDFile()
+	if err != nil {
+		return fmt.Errorf("failed to get default PID file path: %w", err)
+	}
+	logFile, err := getDefaultLogFile()
+	if err != nil {
+		return fmt.Errorf("failed to get default log file path: %w", err)
+	}
+
+	// A daemon started by 'watch --daemon' would run alongside the service.
+	// When the service is already installed the running daemon is its own
+	// and is restarted by Install.
+	if !m.Installed() {
+		if running, err := watcher.IsDaemonRunning(pidFile); err == nil && running {
+			return fmt.Errorf("a watch daemon is already running; stop it with 'brewprune watch --stop' first")
+		}
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+	spec := service.Spec{
+		Executable: stableExecutable(executable),
+		PIDFile:    pidFile,
+		LogFile:    logFile,
+	}
+	if err := m.Install(spec); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Installed %s service: %s\n", m.Name, shortenHome(m.Path))
+	fmt.Printf("  Runs: %s watch --daemon-child (restarted on failure, started at login)\n", spec.Executable)
+	fmt.Printf("  Log file: %s\n", shortenHome(logFile))
+	fmt.Println("\nTo remove: brewprune service uninstall")
+	return nil
+}
+
+func runServiceUninstall(cmd *cobra.Command, args []string) error {
+	m, _, err := newServiceManager()
+	if err != nil {
+		return err
+	}
+	if !m.Installed() {
+		fmt.Println("Service not installed")
+		return nil
+	}
+	if err := m.Uninstall(); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Removed %s service: %s\n", m.Name, shortenHome(m.Path))
+	fmt.Println("  Start tracking manually with 'brewprune watch --daemon'")
+	return nil
+}
+
+func runServiceStatus(cmd *cobra.Command, args []string) error {
+	m, home, err := newServiceManager()
+	if err != nil {
+		return err
+	}
+
+	label := "%-15s "
+	installed := m.Installed()
+	if installed {
+		fmt.Printf(label+"%s (installed)\n", "Service:", shortenHome(m.Path))
+		running, err := m.Running()
+		switch {
+		case err != nil:
+			fmt.Printf(label+"unknown (%v)\n", "State:", err)
+		case running:
+			fmt.Printf(label+"running under %s\n", "State:", m.Name)
+		default:
+			fmt.Printf(label+"stopped\n", "State:")
+		}
+	} else {
+		fmt.Printf(label+"not installed (run 'brewprune service install')\n", "Service:")
+	}
+
+	brewPath, brewRegistered := service.BrewServicesRegistration(runtime.GOOS, home)
+	if brewRegistered {
+		fmt.Printf(label+"registered (%s)\n", "brew services:", shortenHome(brewPath))
+	} else {
+		fmt.Printf(label+"not registered\n", "brew services:")
+	}
+
+	pidFile, err := getDefaultPIDFile()
+	if err != nil {
+		return fmt.Errorf("failed to get default PID file path: %w", err)
+	}
+	if status := queryDaemonStatus(pidFile); status != nil {
+		fmt.Printf(label+"running (PID %d, %s)\n", "Daemon:", status.PID, describeDaemonActivity(status))
+	} else if running, err := watcher.IsDaemonRunning(pidFile); err == nil && running {
+		fmt.Printf(label+"running\n", "Daemon:")
+	} else {
+		fmt.Printf(label+"not running\n", "Daemon:")
+	}
+
+	if installed && brewRegistered {
+		fmt.Println("\n⚠ Both this service and brew services start brewprune.")
+		fmt.Println("  Action: Run 'brewprune service uninstall' or 'brew services stop brewprune'")
+	}
+	return nil
+}
+
+// stableExecutable returns a path to exe that survives upgrades. Homebrew
+// runs brewprune from a versioned keg (…/Cellar/brewprune/<version>/bin),
+// which disappears on upgrade; the prefix bin symlink does not.
+func stableExecutable(exe string) string {
+	i := strings.Index(exe, string(filepath.Separator)+"Cellar"+string(filepath.Separator))
+	if i < 0 {
+		return exe
+	}
+	linked := filepath.Join(exe[:i], "bin", filepath.Base(exe))
+	if _, err := os.Stat(linked); err != nil {
+		return exe
+	}
+	return linked
+}
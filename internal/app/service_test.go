@@ -0,0 +1,71 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestStableExecutable(t *testing.T) {
+	prefix := t.TempDir()
+	keg := filepath.Join(prefix, "Cellar", "brewprune", "1.2.0", "bin", "brewprune")
+	linked := filepath.Join(prefix, "bin", "brewprune")
+
+	// Without the prefix link the keg path is kept.
+	if got := stableExecutable(keg); got != keg {
+		t.Errorf("stableExecutable without link = %q, want %q", got, keg)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(linked), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(linked, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got := stableExecutable(keg); got != linked {
+		t.Errorf("stableExecutable = %q, want %q", got, linked)
+	}
+
+	other := "/usr/local/go/bin/brewprune"
+	if got := stableExecutable(other); got != other {
+		t.Errorf("stableExecutable(%q) = %q, want unchanged", other, got)
+	}
+}
+
+func TestRunServiceInstall_RefusesWithBrewServices(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("services are only supported on linux and darwin")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	brewFile := filepath.Join(home, ".config", "systemd", "user", "homebrew.brewprune.service")
+	if runtime.GOOS == "darwin" {
+		brewFile = filepath.Join(home, "Library", "LaunchAgents", "homebrew.mxcl.brewprune.plist")
+	}
+	if err := os.MkdirAll(filepath.Dir(brewFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(brewFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runServiceInstall(serviceInstallCmd, nil)
+	if err == nil {
+		t.Fatal("expected an error when brew services is registered")
+	}
+	if !strings.Contains(err.Error(), "brew services stop brewprune") {
+		t.Errorf("error should explain how to resolve the conflict, got: %v", err)
+	}
+
+	m, _, err := newServiceManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Installed() {
+		t.Errorf("service file should not be written, found %s", m.Path)
+	}
+}
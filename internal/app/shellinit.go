@@ -0,0 +1,118 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blackwell-systems/brewprune/internal/shell"
+	"github.com/blackwell-systems/brewprune/internal/shim"
+	"github.com/blackwell-systems/brewprune/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var shellInitCmd = &cobra.Command{
+	Use:   "shell-init <shell>",
+	Short: "Print shell integration code (PATH, brew wrapper, command-not-found hook)",
+	Long: `Print a snippet that integrates brewprune with your shell. Evaluate it from
+your shell's startup file instead of adding the PATH line by hand.
+
+The snippet:
+  • Puts ~/.brewprune/bin on PATH (once, even if evaluated again)
+  • Wraps brew so that after install, upgrade, uninstall, reinstall, tap and
+    untap the shims are refreshed in the background ('brewprune scan --refresh-shims')
+  • Hooks command-not-found so running a command whose package brewprune
+    removed says which package it was and how to get it back. An existing
+    handler (e.g. Homebrew's command-not-found) still runs for other commands.
+
+Supported shells: bash, zsh, fish.`,
+	Example: `  # bash (~/.bash_profile)
+  eval "$(brewprune shell-init bash)"
+
+  # zsh (~/.zshrc)
+  eval "$(brewprune shell-init zsh)"
+
+  # fish (~/.config/fish/config.fish)
+  brewprune shell-init fish | source`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("requires a shell name (%s)\nExample: eval \"$(brewprune shell-init zsh)\"", strings.Join(shell.Shells, ", "))
+		}
+		return nil
+	},
+	ValidArgs: shell.Shells,
+	RunE:      runShellInit,
+}
+
+// commandNotFoundCmd is called by the shell-init hook. It prints an
+// explanation and exits 0 when brewprune removed the command's package, and
+// exits 1 silently otherwise so the shell falls back to its usual message.
+var commandNotFoundCmd = &cobra.Command{
+	Use:    "command-not-found <command>",
+	Short:  "Explain a missing command removed by brewprune (used by shell-init)",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	Run:    runCommandNotFound,
+}
+
+func init() {
+	RootCmd.AddCommand(shellInitCmd)
+	RootCmd.AddCommand(commandNotFoundCmd)
+}
+
+func runShellInit(cmd *cobra.Command, args []string) error {
+	shimDir, err := shim.GetShimDir()
+	if err != nil {
+		return err
+	}
+	script, err := shell.InitScript(filepath.Base(args[0]), shimDir)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(script)
+	return err
+}
+
+func runCommandNotFound(cmd *cobra.Command, args []string) {
+	removed := lookupRemovedCommand(args[0])
+	if removed == nil {
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "brewprune: %s was provided by %s, which brewprune removed %s.\n",
+		removed.Name, removed.Package, formatAgo(removed.RemovedAt))
+	fmt.Fprintf(os.Stderr, "  Reinstall: brew install %s\n", removed.Package)
+	if removed.SnapshotID != 0 {
+		fmt.Fprintf(os.Stderr, "  Or undo the removal: brewprune undo %d\n", removed.SnapshotID)
+	}
+}
+
+// lookupRemovedCommand returns the brewprune removal that took away name, or
+// nil when there is none or the database cannot be read. It never creates
+// the database.
+func lookupRemovedCommand(name string) *store.RemovedCommand {
+	path := dbPath
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		path = filepath.Join(home, ".brewprune", "brewprune.db")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+
+	st, err := store.New(path)
+	if err != nil {
+		return nil
+	}
+	defer st.Close()
+
+	removed, err := st.GetRemovedCommand(name)
+	if err != nil {
+		return nil
+	}
+	return removed
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+func TestRunShellInit_PrintsSnippet(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	var runErr error
+	out := captureStdout(t, func() {
+		runErr = runShellInit(shellInitCmd, []string{"/bin/zsh"})
+	})
+	if runErr != nil {
+		t.Fatalf("runShellInit: %v", runErr)
+	}
+	if !strings.Contains(out, filepath.Join(home, ".brewprune", "bin")) {
+		t.Errorf("snippet should put the shim dir on PATH, got:\n%s", out)
+	}
+	if !strings.Contains(out, "command_not_found_handler()") {
+		t.Errorf("zsh snippet should define command_not_found_handler, got:\n%s", out)
+	}
+
+	if err := runShellInit(shellInitCmd, []string{"tcsh"}); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
+
+func TestLookupRemovedCommand(t *testing.T) {
+	tmpDB := filepath.Join(t.TempDir(), "brewprune.db")
+	oldDBPath := dbPath
+	dbPath = tmpDB
+	defer func() { dbPath = oldDBPath }()
+
+	// No database yet: nothing to explain, and none is created.
+	if got := lookupRemovedCommand("jq"); got != nil {
+		t.Errorf("lookupRemovedCommand without a database = %+v, want nil", got)
+	}
+
+	st, err := store.New(tmpDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.CreateSchema(); err != nil {
+		t.Fatal(err)
+	}
+	pkg := &brew.Package{Name: "jq", Version: "1.7", InstalledAt: time.Now(), BinaryPaths: []string{"/opt/homebrew/bin/jq"}}
+	if err := st.InsertPackage(pkg); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.RecordRemovedPackage("jq", 3, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.DeletePackage("jq"); err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+
+	got := lookupRemovedCommand("jq")
+	if got == nil || got.Package != "jq" || got.SnapshotID != 3 {
+		t.Errorf("lookupRemovedCommand(jq) = %+v, want jq from snapshot 3", got)
+	}
+	if got := lookupRemovedCommand("git"); got != nil {
+		t.Errorf("lookupRemovedCommand(git) = %+v, want nil", got)
+	}
+}
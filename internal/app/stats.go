@@ -11,16 +11,18 @@ import (
 	isatty "github.com/mattn/go-isatty"
 
 	"github.com/blackwell-systems/brewprune/internal/analyzer"
+	"github.com/blackwell-systems/brewprune/internal/config"
 	"github.com/blackwell-systems/brewprune/internal/output"
 	"github.com/blackwell-systems/brewprune/internal/store"
 	"github.com/spf13/cobra"
 )
 
 var (
-	statsDays    int    // parsed value (used inside runStats)
-	statsDaysStr string // receives cobra flag value (string for clean error messages)
-	statsPackage string
-	statsAll     bool
+	statsDays     int    // parsed value (used inside runStats)
+	statsDaysStr  string // receives cobra flag value (string for clean error messages)
+	statsPackage  string
+	statsAll      bool
+	statsBinaries bool
 )
 
 var statsCmd = &cobra.Command{
@@ -31,6 +33,8 @@ var statsCmd = &cobra.Command{
 Without flags, shows usage trends for all packages in the last 30 days.
 Use --package to view detailed statistics for a specific package.
 Use --days to adjust the time window for analysis.
+Use --package with --binaries to break usage down by individual binary, which
+shows whether a large multi-binary package is only used for one command.
 
 Usage frequency is classified as:
   - daily: Used in last 7 days with high frequency
@@ -48,6 +52,9 @@ Requires: run 'brewprune scan' first to initialize the database.`,
   # Show detailed stats for a specific package
   brewprune stats --package git
 
+  # Show which binaries of a package are actually used
+  brewprune stats --package postgresql@16 --binaries
+
   # Show recent activity (last 7 days)
   brewprune stats --days 7`,
 	RunE: runStats,
@@ -57,6 +64,7 @@ func init() {
 	statsCmd.Flags().StringVar(&statsDaysStr, "days", "30", "Time window in days")
 	statsCmd.Flags().StringVar(&statsPackage, "package", "", "Show stats for specific package")
 	statsCmd.Flags().BoolVar(&statsAll, "all", false, "Show all packages including those with no usage")
+	statsCmd.Flags().BoolVar(&statsBinaries, "binaries", false, "Break down usage per binary (requires --package)")
 
 	// Register with root command
 	RootCmd.AddCommand(statsCmd)
@@ -78,6 +86,10 @@ func runStats(cmd *cobra.Command, args []string) error {
 	}
 	statsDays = days
 
+	if statsBinaries && statsPackage == "" {
+		return fmt.Errorf("--binaries requires --package")
+	}
+
 	// Get database path
 	dbPath, err := getDBPath()
 	if err != nil {
@@ -92,7 +104,7 @@ func runStats(cmd *cobra.Command, args []string) error {
 	defer st.Close()
 
 	// Create analyzer
-	a := analyzer.New(st)
+	a := newAnalyzer(st)
 
 	// Check if specific package requested
 	if statsPackage != "" {
@@ -167,6 +179,12 @@ func showPackageStats(a *analyzer.Analyzer, pkg string) error {
 	fmt.Printf("First Seen: %s\n", formatTime(stats.FirstSeen))
 	fmt.Printf("Frequency: %s\n", colorFreq(stats.Frequency))
 
+	if statsBinaries {
+		if err := showBinaryStats(a, pkg); err != nil {
+			return err
+		}
+	}
+
 	// Show explain hint for all packages
 	fmt.Println()
 	fmt.Printf("Tip: Run 'brewprune explain %s' for removal recommendation and scoring detail.\n", pkg)
@@ -174,6 +192,53 @@ func showPackageStats(a *analyzer.Analyzer, pkg string) error {
 	return nil
 }
 
+// showBinaryStats prints the per-binary usage table for a package, followed by
+// a lighter-alternative hint when only one binary of a multi-binary package has
+// been used.
+func showBinaryStats(a *analyzer.Analyzer, pkg string) error {
+	binaries, err := a.GetBinaryStats(pkg)
+	if err != nil {
+		return fmt.Errorf("failed to get binary stats for %s: %w", pkg, err)
+	}
+
+	rows := make([]output.BinaryStats, 0, len(binaries))
+	for _, b := range binaries {
+		row := output.BinaryStats{Name: b.Name, TotalRuns: b.TotalUses}
+		if b.LastUsed != nil {
+			row.LastUsed = *b.LastUsed
+		}
+		rows = append(rows, row)
+	}
+
+	fmt.Println()
+	fmt.Print(output.RenderBinaryTable(rows))
+
+	if hint := alternativeHint(a, pkg); hint != "" {
+		fmt.Println()
+		fmt.Printf("Hint: %s\n", hint)
+	}
+
+	return nil
+}
+
+// alternativeHint returns the lighter-formula suggestion for pkg, or "" if
+// none applies. Config and lookup errors are treated as "no hint".
+func alternativeHint(a *analyzer.Analyzer, pkg string) string {
+	cfgDir, err := config.Dir()
+	if err != nil {
+		return ""
+	}
+	alts, err := config.LoadAlternatives(cfgDir)
+	if err != nil {
+		return ""
+	}
+	hint, err := a.SuggestAlternative(pkg, alts)
+	if err != nil {
+		return ""
+	}
+	return hint
+}
+
 // showUsageTrends displays usage trends for all packages.
 // NOTE: RenderUsageTable is expected to sort by TotalRuns desc + LastUsed desc
 // as a secondary sort. Agent C (Wave 2) will add the secondary sort by LastUsed
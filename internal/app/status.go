@@ -48,19 +48,25 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get database path: %w", err)
 	}
 
-	// Check daemon status
-	daemonRunning, err := watcher.IsDaemonRunning(pidFile)
-	if err != nil {
-		return fmt.Errorf("failed to check daemon status: %w", err)
-	}
-
-	// Get PID if daemon is running
+	// Ask the daemon over its control socket; fall back to the PID file for
+	// a daemon that is not listening on it.
+	daemonStatus := queryDaemonStatus(pidFile)
+	daemonRunning := daemonStatus != nil
 	var pid int
 	if daemonRunning {
-		pidData, err := os.ReadFile(pidFile)
-		if err == nil {
-			pidStr := strings.TrimSpace(string(pidData))
-			pid, _ = strconv.Atoi(pidStr)
+		pid = daemonStatus.PID
+	} else {
+		daemonRunning, err = watcher.IsDaemonRunning(pidFile)
+		if err != nil {
+			return fmt.Errorf("failed to check daemon status: %w", err)
+		}
+		// Get PID if daemon is running
+		if daemonRunning {
+			pidData, err := os.ReadFile(pidFile)
+			if err == nil {
+				pidStr := strings.TrimSpace(string(pidData))
+				pid, _ = strconv.Atoi(pidStr)
+			}
 		}
 	}
 
@@ -133,7 +139,16 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	// Tracking line
 	if daemonRunning {
 		pidSince := daemonSince(pidFile)
+		if daemonStatus != nil {
+			pidSince = formatDuration(time.Since(daemonStatus.StartedAt))
+		}
 		fmt.Printf(label+"running (since %s, PID %d)\n", "Tracking:", pidSince, pid)
+		if daemonStatus != nil {
+			fmt.Printf(label+"%s\n", "Processing:", describeDaemonActivity(daemonStatus))
+			if daemonStatus.LastError != "" && daemonStatus.LastErrorAt != nil {
+				fmt.Printf("              ⚠ last error %s: %s\n", formatDuration(time.Since(*daemonStatus.LastErrorAt)), daemonStatus.LastError)
+			}
+		}
 	} else {
 		fmt.Printf(label+"stopped  (run 'brewprune watch --daemon')\n", "Tracking:")
 	}
@@ -144,6 +159,9 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	// Warn if daemon is running but no recent events
 	if daemonRunning && events24h == 0 && totalEvents <= 2 {
 		ageMin := daemonAgeMinutes(pidFile)
+		if daemonStatus != nil {
+			ageMin = int(time.Since(daemonStatus.StartedAt).Minutes())
+		}
 		if ageMin < 5 {
 			fmt.Printf("              (no events yet — daemon started just now, this is normal)\n")
 		} else {
@@ -319,6 +337,32 @@ func daemonAgeMinutes(pidFile string) int {
 	return int(time.Since(info.ModTime()).Minutes())
 }
 
+// queryDaemonStatus asks the daemon for its status over the control socket
+// next to pidFile. It returns nil when no daemon answers, in which case
+// callers fall back to the PID file.
+func queryDaemonStatus(pidFile string) *watcher.DaemonStatus {
+	resp, err := watcher.CallControl(watcher.ControlSocketPath(pidFile), watcher.ControlStatus)
+	if err != nil || resp.Status == nil {
+		return nil
+	}
+	return resp.Status
+}
+
+// describeDaemonActivity summarises what a running daemon has done, e.g.
+// "last run just now · 42 events this session · 0 B pending · file notifications".
+func describeDaemonActivity(status *watcher.DaemonStatus) string {
+	lastRun := "no run yet"
+	if status.LastTick != nil {
+		lastRun = "last run " + formatDuration(time.Since(*status.LastTick))
+	}
+	mode := "polling"
+	if status.Notifications {
+		mode = "file notifications"
+	}
+	return fmt.Sprintf("%s · %s events this session · %s pending · %s",
+		lastRun, formatNumber(int(status.EventsProcessed)), formatSize(status.BacklogBytes), mode)
+}
+
 // daemonSince returns a human-readable age of the PID file (proxy for daemon start time).
 func daemonSince(pidFile string) string {
 	fi, err := os.Stat(pidFile)
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/blackwell-systems/brewprune/internal/store"
+	"github.com/blackwell-systems/brewprune/internal/watcher"
 )
 
 // TestRunStatus_DaemonStoppedSuggestsWatchDaemon verifies that when the
@@ -1077,3 +1079,58 @@ func TestStatus_ShowsPackageCount(t *testing.T) {
 		t.Errorf("expected package count, got: %q", got)
 	}
 }
+
+// TestRunStatus_ReportsDaemonViaControlSocket verifies that status asks a
+// daemon listening on the control socket for its health, without a PID file.
+func TestRunStatus_ReportsDaemonViaControlSocket(t *testing.T) {
+	// A short HOME keeps the socket path within the sun_path limit.
+	tmpDir, err := os.MkdirTemp("", "bp")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", origHome)
+
+	brewpruneDir := filepath.Join(tmpDir, ".brewprune")
+	if err := os.MkdirAll(brewpruneDir, 0755); err != nil {
+		t.Fatalf("failed to create .brewprune dir: %v", err)
+	}
+	fakeDB := filepath.Join(brewpruneDir, "brewprune.db")
+	st, err := store.New(fakeDB)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer st.Close()
+	if err := st.CreateSchema(); err != nil {
+		t.Fatalf("CreateSchema: %v", err)
+	}
+
+	origDBPath := dbPath
+	dbPath = fakeDB
+	defer func() { dbPath = origDBPath }()
+
+	w, err := watcher.New(st)
+	if err != nil {
+		t.Fatalf("watcher.New: %v", err)
+	}
+	if err := w.ListenControl(filepath.Join(brewpruneDir, "watch.sock")); err != nil {
+		t.Fatalf("ListenControl: %v", err)
+	}
+	if err := w.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer w.Stop()
+
+	output := captureStdout(t, func() {
+		_ = runStatus(nil, nil)
+	})
+
+	if !strings.Contains(output, fmt.Sprintf("PID %d", os.Getpid())) {
+		t.Errorf("expected running tracking line with this process's PID, got output:\n%s", output)
+	}
+	if !strings.Contains(output, "Processing:") || !strings.Contains(output, "last run just now") {
+		t.Errorf("expected daemon activity from the control socket, got output:\n%s", output)
+	}
+}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blackwell-systems/brewprune/internal/analyzer"
+	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var tapsCmd = &cobra.Command{
+	Use:   "taps",
+	Short: "List taps with the usage of their packages",
+	Long: `List each Homebrew tap with how many of its packages are installed, how
+many of those are in use, and the disk size of its git clone under the
+Homebrew Library.
+
+Packages count as in use when their confidence score is below the safe
+tier: they were used recently, are referenced, run as a brew service, or are
+held for shim bypass, periodic use, a project declaration or missing cask
+launch data. Taps with no installed packages, or only safe, unpinned ones,
+are recommended for untapping. Homebrew's official taps (homebrew/core and
+homebrew/cask) are listed without scoring and never recommended.
+
+Requires: run 'brewprune scan' first to initialize the database.`,
+	Example: `  # List taps and the ones worth untapping
+  brewprune taps
+
+  # Remove a tap together with its unused packages
+  brewprune remove --tap user/tools --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: runTaps,
+}
+
+func init() {
+	RootCmd.AddCommand(tapsCmd)
+}
+
+func runTaps(cmd *cobra.Command, args []string) error {
+	dbPath, err := getDBPath()
+	if err != nil {
+		return fmt.Errorf("failed to get database path: %w", err)
+	}
+	st, err := store.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer st.Close()
+
+	usage, err := loadTapUsage(newAnalyzer(st))
+	if errors.Is(err, store.ErrNotInitialized) {
+		return store.ErrNotInitialized
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(usage) == 0 {
+		fmt.Println("No taps found.")
+		return nil
+	}
+
+	fmt.Print(renderTaps(usage))
+	return nil
+}
+
+// loadTapUsage lists the taps of the Homebrew prefixes brewprune scans and
+// analyzes the usage of their installed packages.
+func loadTapUsage(a *analyzer.Analyzer) ([]*analyzer.TapUsage, error) {
+	prefixes, err := resolveScanPrefixes()
+	if err != nil {
+		return nil, err
+	}
+	var taps []*brew.Tap
+	for _, prefix := range prefixes {
+		prefixTaps, err := brew.ListTapsIn(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list taps of %s: %w", prefix, err)
+		}
+		taps = append(taps, prefixTaps...)
+	}
+
+	usage, err := a.AnalyzeTaps(taps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze taps: %w", err)
+	}
+	return usage, nil
+}
+
+// findTapUsage returns the usage of tap, which must be tapped.
+func findTapUsage(a *analyzer.Analyzer, tap string) (*analyzer.TapUsage, error) {
+	usage, err := loadTapUsage(a)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range usage {
+		if u.Name == tap && u.Tapped {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("tap %s is not tapped\n\nRun 'brewprune taps' to list taps", tap)
+}
+
+// renderTaps formats one line per tap with its installed and used package
+// counts and clone size, marking the taps to untap, followed by the space
+// untapping them frees and the commands to do it.
+func renderTaps(usage []*analyzer.TapUsage) string {
+	width := 0
+	for _, u := range usage {
+		width = max(width, len(u.Name))
+	}
+
+	var b strings.Builder
+	var reclaimable int64
+	var untap []*analyzer.TapUsage
+	unusedPackages := 0
+	for _, u := range usage {
+		size := "-"
+		if u.Tapped {
+			size = formatSize(u.SizeBytes)
+		}
+		used := strconv.Itoa(len(u.Used))
+		if brew.IsOfficialTap(u.Name) {
+			used = "-" // Not scored
+		}
+		line := fmt.Sprintf("%-*s  %4d installed  %4s used  %7s  %s",
+			width, u.Name, len(u.Packages), used, size, describeTapUsage(u))
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+
+		if u.ShouldUntap() {
+			untap = append(untap, u)
+			reclaimable += u.SizeBytes + u.UnusedSizeBytes
+			unusedPackages += len(u.Unused)
+		}
+	}
+
+	b.WriteString("\n")
+	if len(untap) == 0 {
+		b.WriteString("No taps to untap.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Reclaimable: %s from %s", formatSize(reclaimable), countNoun(len(untap), "tap"))
+	if unusedPackages > 0 {
+		fmt.Fprintf(&b, " and %s", countNoun(unusedPackages, "unused package"))
+	}
+	b.WriteString("\nRemove them with:\n")
+	for _, u := range untap {
+		fmt.Fprintf(&b, "  brewprune remove --tap %s\n", u.Name)
+	}
+	return b.String()
+}
+
+// describeTapUsage explains why a tap should be untapped, or notes the
+// packages keeping an otherwise unused tap.
+func describeTapUsage(u *analyzer.TapUsage) string {
+	switch {
+	case !u.Tapped:
+		return "not tapped"
+	case brew.IsOfficialTap(u.Name):
+		return "official tap"
+	case u.ShouldUntap() && len(u.Packages) == 0:
+		return "untap: no packages installed"
+	case u.ShouldUntap():
+		return "untap: every package safe to remove"
+	case len(u.Used) == 0 && len(u.Kept) > 0:
+		return "pinned: " + strings.Join(u.Kept, ", ")
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/brewprune/internal/analyzer"
+)
+
+func TestRenderTaps(t *testing.T) {
+	output := renderTaps([]*analyzer.TapUsage{
+		{Name: "gone/tap", Packages: []string{"legacy"}, Unused: []string{"legacy"}},
+		{Name: "homebrew/core", Tapped: true, Packages: []string{"jq", "wget"}},
+		{Name: "old/empty", Tapped: true, SizeBytes: 4 << 20},
+		{Name: "user/frozen", Tapped: true, SizeBytes: 1 << 20, Packages: []string{"frozen"}, Kept: []string{"frozen"}},
+		{Name: "user/tools", Tapped: true, SizeBytes: 8 << 20, Packages: []string{"tool", "tool-extras"},
+			Unused: []string{"tool", "tool-extras"}, UnusedSizeBytes: 7 << 20},
+	})
+
+	for _, want := range []string{
+		"gone/tap          1 installed     0 used        -  not tapped\n",
+		"homebrew/core     2 installed     - used      0 B  official tap\n",
+		"old/empty         0 installed     0 used     4 MB  untap: no packages installed\n",
+		"user/frozen       1 installed     0 used     1 MB  pinned: frozen\n",
+		"user/tools        2 installed     0 used     8 MB  untap: every package safe to remove\n",
+		"Reclaimable: 19 MB from 2 taps and 2 unused packages\n",
+		"Remove them with:\n  brewprune remove --tap old/empty\n  brewprune remove --tap user/tools\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+}
+
+func TestRenderTaps_NoneToUntap(t *testing.T) {
+	output := renderTaps([]*analyzer.TapUsage{
+		{Name: "user/tools", Tapped: true, Packages: []string{"tool"}, Used: []string{"tool"}},
+	})
+
+	if strings.Contains(output, "Reclaimable") || !strings.HasSuffix(output, "\nNo taps to untap.\n") {
+		t.Errorf("expected no taps to untap, got:\n%s", output)
+	}
+}
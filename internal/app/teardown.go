@@ -0,0 +1,279 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/config"
+	"github.com/blackwell-systems/brewprune/internal/service"
+	"github.com/blackwell-systems/brewprune/internal/shell"
+	"github.com/blackwell-systems/brewprune/internal/shim"
+	"github.com/blackwell-systems/brewprune/internal/store"
+	"github.com/blackwell-systems/brewprune/internal/watcher"
+	"github.com/spf13/cobra"
+)
+
+var (
+	teardownKeepData  bool
+	teardownArchiveDB string
+	teardownYes       bool
+)
+
+var teardownCmd = &cobra.Command{
+	Use:   "teardown",
+	Short: "Remove shims, PATH entries, the daemon and all brewprune data",
+	Long: `Undo everything brewprune set up, so the brewprune package itself can be
+uninstalled cleanly:
+
+  1. Unregister the launchd/systemd service ('brewprune service install')
+  2. Stop the watch daemon
+  3. Remove the shims and ~/.brewprune/bin
+  4. Remove the "# brewprune shims" PATH block from shell config files
+     (each modified file is backed up to <file>.brewprune-backup)
+  5. Delete ~/.brewprune (database, snapshots, logs) and ~/.config/brewprune
+
+With --keep-data, step 5 is skipped so tracking can resume later with
+'brewprune scan'. With --archive-db, a copy of the database is written to the
+given path before anything is deleted.
+
+Finally teardown checks that no shell config file still references
+~/.brewprune/bin. Open a new terminal afterwards so the PATH change takes effect.`,
+	Example: `  # Remove everything
+  brewprune teardown
+
+  # Keep usage history and snapshots
+  brewprune teardown --keep-data
+
+  # Keep a copy of the database, then remove everything
+  brewprune teardown --archive-db ~/brewprune-backup.db --yes`,
+	Args: cobra.NoArgs,
+	RunE: runTeardown,
+}
+
+func init() {
+	teardownCmd.Flags().BoolVar(&teardownKeepData, "keep-data", false, "Keep the database, snapshots, logs and config")
+	teardownCmd.Flags().StringVar(&teardownArchiveDB, "archive-db", "", "Write a copy of the database to this path first")
+	teardownCmd.Flags().BoolVar(&teardownYes, "yes", false, "Skip the confirmation prompt")
+	RootCmd.AddCommand(teardownCmd)
+}
+
+func runTeardown(cmd *cobra.Command, args []string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	dataDir := filepath.Join(home, ".brewprune")
+
+	if teardownArchiveDB != "" {
+		if _, err := os.Stat(teardownArchiveDB); err == nil {
+			return fmt.Errorf("archive path %s already exists", teardownArchiveDB)
+		}
+	}
+
+	if !teardownYes && !confirmTeardown(dataDir) {
+		fmt.Println("Teardown cancelled")
+		return nil
+	}
+
+	// Archive first: if it fails nothing has been changed yet.
+	if teardownArchiveDB != "" {
+		if err := archiveDatabase(teardownArchiveDB); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Archived database to %s\n", shortenHome(teardownArchiveDB))
+	}
+
+	// Collect non-fatal problems and keep going: a half-finished teardown is
+	// worse than one that reports what it could not undo.
+	var problems []string
+
+	// 1. Service registration.
+	if m, err := service.NewManager(runtime.GOOS, home); err == nil && m.Installed() {
+		if err := m.Uninstall(); err != nil {
+			problems = append(problems, fmt.Sprintf("failed to remove %s service: %v", m.Name, err))
+		} else {
+			fmt.Printf("✓ Removed %s service: %s\n", m.Name, shortenHome(m.Path))
+		}
+	}
+	if path, ok := service.BrewServicesRegistration(runtime.GOOS, home); ok {
+		problems = append(problems, fmt.Sprintf("brew services still starts brewprune (%s); run 'brew services stop brewprune'", shortenHome(path)))
+	}
+
+	// 2. Daemon.
+	if err := teardownStopDaemon(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	// 3. Shims.
+	shimDir, err := shim.GetShimDir()
+	if err != nil {
+		return err
+	}
+	if err := shim.RemoveShims(); err != nil {
+		problems = append(problems, err.Error())
+	} else if err := shim.RemoveShimDir(); err != nil {
+		problems = append(problems, err.Error())
+	} else {
+		fmt.Printf("✓ Removed shims: %s\n", shortenHome(shimDir))
+	}
+
+	// 4. Shell config.
+	for _, configPath := range shell.ConfigFiles(home) {
+		removed, backupPath, err := shell.RemovePathEntry(configPath)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if removed {
+			fmt.Printf("✓ Removed PATH entry from %s (backup: %s)\n", shortenHome(configPath), shortenHome(backupPath))
+		}
+	}
+
+	for _, configPath := range shell.InitReferences(home) {
+		problems = append(problems, fmt.Sprintf("remove the 'brewprune shell-init' line from %s", shortenHome(configPath)))
+	}
+
+	// 5. Data.
+	if teardownKeepData {
+		fmt.Printf("  Kept data in %s\n", shortenHome(dataDir))
+	} else {
+		problems = append(problems, removeTeardownData(dataDir)...)
+	}
+
+	// Verify new shells will not put the shim directory back on PATH.
+	verified := verifyShimsGone(home)
+
+	if len(problems) > 0 {
+		fmt.Println("\n⚠ Some steps did not complete:")
+		for _, p := range problems {
+			fmt.Printf("  - %s\n", p)
+		}
+	}
+	if !verified || len(problems) > 0 {
+		return fmt.Errorf("teardown incomplete")
+	}
+
+	fmt.Println("\nbrewprune has been torn down. Open a new terminal so the PATH change takes effect.")
+	fmt.Println("To remove the program itself: brew uninstall brewprune")
+	return nil
+}
+
+// confirmTeardown prompts the user to confirm the teardown.
+func confirmTeardown(dataDir string) bool {
+	if teardownKeepData {
+		fmt.Println("This removes the brewprune shims, PATH entries, service and daemon.")
+	} else {
+		fmt.Printf("This removes the brewprune shims, PATH entries, service and daemon,\n"+
+			"and deletes all usage history and snapshots in %s.\n", shortenHome(dataDir))
+	}
+	fmt.Print("Continue? [y/N]: ")
+
+	response, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes"
+}
+
+// teardownStopDaemon stops a running watch daemon and waits for it to exit,
+// so it does not recreate files that are about to be deleted.
+func teardownStopDaemon() error {
+	pidFile, err := getDefaultPIDFile()
+	if err != nil {
+		return fmt.Errorf("failed to get default PID file path: %w", err)
+	}
+	running, err := watcher.IsDaemonRunning(pidFile)
+	if err != nil || !running {
+		return nil
+	}
+
+	socketPath := watcher.ControlSocketPath(pidFile)
+	if _, err := watcher.CallControl(socketPath, watcher.ControlStop); err == nil {
+		waitForDaemonExit(socketPath)
+	} else if err := watcher.StopDaemon(pidFile); err != nil {
+		return fmt.Errorf("failed to stop daemon: %w", err)
+	}
+
+	deadline := time.Now().Add(daemonStopWait)
+	for time.Now().Before(deadline) {
+		if running, _ := watcher.IsDaemonRunning(pidFile); !running {
+			fmt.Println("✓ Stopped watch daemon")
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return fmt.Errorf("watch daemon did not exit within %s; stop it with 'brewprune watch --stop'", daemonStopWait)
+}
+
+// archiveDatabase writes a consistent copy of the database to path.
+func archiveDatabase(path string) error {
+	resolvedDBPath, err := getDBPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(resolvedDBPath); err != nil {
+		return fmt.Errorf("no database to archive at %s", resolvedDBPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	db, err := store.New(resolvedDBPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+	return db.BackupTo(path)
+}
+
+// removeTeardownData deletes the database, the brewprune data directory and
+// the config directory, returning a description of each failure.
+func removeTeardownData(dataDir string) []string {
+	var problems []string
+
+	// A database set with --db may live outside the data directory.
+	if dbPath != "" {
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+				problems = append(problems, fmt.Sprintf("failed to remove %s: %v", dbPath+suffix, err))
+			}
+		}
+	}
+
+	if err := os.RemoveAll(dataDir); err != nil {
+		problems = append(problems, fmt.Sprintf("failed to remove %s: %v", dataDir, err))
+	} else {
+		fmt.Printf("✓ Deleted %s\n", shortenHome(dataDir))
+	}
+
+	if cfgDir, err := config.Dir(); err == nil {
+		if _, err := os.Stat(cfgDir); err == nil {
+			if err := os.RemoveAll(cfgDir); err != nil {
+				problems = append(problems, fmt.Sprintf("failed to remove %s: %v", cfgDir, err))
+			} else {
+				fmt.Printf("✓ Deleted %s\n", shortenHome(cfgDir))
+			}
+		}
+	}
+	return problems
+}
+
+// verifyShimsGone checks that no shell startup file under home still puts
+// the shim directory on PATH and reports the result.
+func verifyShimsGone(home string) bool {
+	refs := shell.ShimDirReferences(home)
+	for _, configPath := range refs {
+		fmt.Printf("✗ %s still references ~/.brewprune/bin\n", shortenHome(configPath))
+	}
+	if len(refs) > 0 {
+		return false
+	}
+	fmt.Println("✓ No shell config file references ~/.brewprune/bin")
+	return true
+}
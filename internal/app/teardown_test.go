@@ -0,0 +1,146 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// setupTeardownHome creates a home directory with shims, a shell config
+// PATH block, a database and a config file, and returns it.
+func setupTeardownHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	shimDir := filepath.Join(home, ".brewprune", "bin")
+	for _, dir := range []string{shimDir, filepath.Join(home, ".config", "brewprune")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	shimBin := filepath.Join(shimDir, "brewprune-shim")
+	if err := os.WriteFile(shimBin, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(shimBin, filepath.Join(shimDir, "git")); err != nil {
+		t.Fatal(err)
+	}
+
+	profile := "umask 022\n\n# brewprune shims\nexport PATH=\"" + shimDir + "\":$PATH\n"
+	if err := os.WriteFile(filepath.Join(home, ".zprofile"), []byte(profile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".config", "brewprune", "config"), []byte("score.safe_threshold = 80\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := store.New(filepath.Join(home, ".brewprune", "brewprune.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.CreateSchema(); err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+
+	oldDBPath := dbPath
+	dbPath = ""
+	t.Cleanup(func() {
+		dbPath = oldDBPath
+		teardownKeepData = false
+		teardownArchiveDB = ""
+		teardownYes = false
+	})
+	teardownYes = true
+	return home
+}
+
+func TestRunTeardown_RemovesEverything(t *testing.T) {
+	home := setupTeardownHome(t)
+	archive := filepath.Join(t.TempDir(), "archive.db")
+	teardownArchiveDB = archive
+
+	var runErr error
+	out := captureStdout(t, func() {
+		runErr = runTeardown(teardownCmd, nil)
+	})
+	if runErr != nil {
+		t.Fatalf("runTeardown: %v\n%s", runErr, out)
+	}
+
+	for _, path := range []string{
+		filepath.Join(home, ".brewprune"),
+		filepath.Join(home, ".config", "brewprune"),
+	} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed, stat err = %v", path, err)
+		}
+	}
+
+	profile, err := os.ReadFile(filepath.Join(home, ".zprofile"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(profile) != "umask 022\n" {
+		t.Errorf(".zprofile = %q, want the PATH block removed", profile)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".zprofile.brewprune-backup")); err != nil {
+		t.Errorf("expected a .zprofile backup: %v", err)
+	}
+
+	if _, err := os.Stat(archive); err != nil {
+		t.Errorf("expected archived database at %s: %v", archive, err)
+	}
+	if !strings.Contains(out, "No shell config file references ~/.brewprune/bin") {
+		t.Errorf("expected shim directory verification in output, got:\n%s", out)
+	}
+}
+
+func TestRunTeardown_KeepData(t *testing.T) {
+	home := setupTeardownHome(t)
+	teardownKeepData = true
+
+	var runErr error
+	out := captureStdout(t, func() {
+		runErr = runTeardown(teardownCmd, nil)
+	})
+	if runErr != nil {
+		t.Fatalf("runTeardown: %v\n%s", runErr, out)
+	}
+
+	if _, err := os.Stat(filepath.Join(home, ".brewprune", "bin")); !os.IsNotExist(err) {
+		t.Errorf("shim dir should be removed, stat err = %v", err)
+	}
+	for _, path := range []string{
+		filepath.Join(home, ".brewprune", "brewprune.db"),
+		filepath.Join(home, ".config", "brewprune", "config"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s should be kept: %v", path, err)
+		}
+	}
+}
+
+func TestRunTeardown_ReportsLeftoverShimDirReference(t *testing.T) {
+	home := setupTeardownHome(t)
+	bashrc := filepath.Join(home, ".bashrc")
+	if err := os.WriteFile(bashrc, []byte("export PATH=\"$HOME/.brewprune/bin:$PATH\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var runErr error
+	out := captureStdout(t, func() {
+		runErr = runTeardown(teardownCmd, nil)
+	})
+	if runErr == nil {
+		t.Fatalf("runTeardown should fail while .bashrc references the shim directory\n%s", out)
+	}
+	if !strings.Contains(out, "~/.bashrc still references ~/.brewprune/bin") {
+		t.Errorf("expected the .bashrc reference in output, got:\n%s", out)
+	}
+}
@@ -135,7 +135,7 @@ func runUnused(cmd *cobra.Command, args []string) error {
 	checkUsageWarning(st, showRiskyImplicit, unusedCasks)
 
 	// Create analyzer
-	a := analyzer.New(st)
+	a := newAnalyzer(st)
 
 	// Get all packages
 	packages, err := st.ListPackages()
@@ -164,21 +164,17 @@ func runUnused(cmd *cobra.Command, args []string) error {
 
 	// Build IsCask lookup from packages list
 	isCaskMap := make(map[string]bool, len(packages))
+	prefixMap := make(map[string]string, len(packages))
 	for _, pkg := range packages {
 		isCaskMap[pkg.Name] = pkg.IsCask
+		prefixMap[pkg.Name] = pkg.Prefix
 	}
 
 	// Compute scores for all packages (before filtering)
-	var allScores []*analyzer.ConfidenceScore
-	for _, pkg := range packages {
-		score, err := a.ComputeScore(pkg.Name)
-		if err != nil {
-			// Skip packages with errors but log warning
-			fmt.Fprintf(os.Stderr, "Warning: failed to score %s: %v\n", pkg.Name, err)
-			continue
-		}
-		allScores = append(allScores, score)
-	}
+	allScores := a.ComputeScores(pkgNames, func(pkg string, err error) {
+		// Skip packages with errors but log warning
+		fmt.Fprintf(os.Stderr, "Warning: failed to score %s: %v\n", pkg, err)
+	})
 
 	// Compute tier stats from all scores (before any filtering)
 	var safeTier, mediumTier, riskyTier output.TierStats
@@ -338,6 +334,10 @@ func runUnused(cmd *cobra.Command, args []string) error {
 					TypeDetail:  s.Explanation.TypeDetail,
 				},
 			}
+			for _, adj := range s.Adjustments {
+				verboseScores[i].Adjustments = append(verboseScores[i].Adjustments,
+					output.VerboseAdjustment{Points: adj.Points, Reason: adj.Reason})
+			}
 		}
 		table := output.RenderConfidenceTableVerbose(verboseScores)
 		fmt.Print(table)
@@ -369,17 +369,20 @@ func runUnused(cmd *cobra.Command, args []string) error {
 			}
 
 			outputScores[i] = output.ConfidenceScore{
-				Package:     s.Package,
-				Score:       s.Score,
-				Tier:        s.Tier,
-				LastUsed:    lastUsed,
-				Reason:      s.Reason,
-				SizeBytes:   s.SizeBytes,
-				Uses7d:      uses7d,
-				DepCount:    depCount,
-				IsCritical:  s.IsCritical,
-				IsCask:      isCaskMap[s.Package],
-				InstalledAt: installedAt,
+				Package:      s.Package,
+				Score:        s.Score,
+				Tier:         s.Tier,
+				LastUsed:     lastUsed,
+				Reason:       s.Reason,
+				SizeBytes:    s.SizeBytes,
+				Uses7d:       uses7d,
+				DepCount:     depCount,
+				IsCritical:   s.IsCritical,
+				IsCask:       isCaskMap[s.Package],
+				UsageTracked: s.UsageTracked,
+				InstalledAt:  installedAt,
+				Labels:       s.Labels,
+				Prefix:       prefixMap[s.Package],
 			}
 		}
 		table := output.RenderConfidenceTable(outputScores, hasUsageData)
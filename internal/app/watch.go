@@ -1,37 +1,60 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/blackwell-systems/brewprune/internal/config"
 	"github.com/blackwell-systems/brewprune/internal/output"
 	"github.com/blackwell-systems/brewprune/internal/store"
 	"github.com/blackwell-systems/brewprune/internal/watcher"
 	"github.com/spf13/cobra"
 )
 
+// daemonStopWait bounds how long 'watch --stop' waits for the daemon to exit.
+const daemonStopWait = 5 * time.Second
+
 var (
 	watchDaemon      bool
 	watchDaemonChild bool
 	watchPIDFile     string
 	watchLogFile     string
 	watchStop        bool
+	watchReload      bool
+	watchInterval    time.Duration
 
 	watchCmd = &cobra.Command{
 		Use:   "watch",
 		Short: "Process shim log and record package usage",
 		Long: `Process the PATH shim usage log to track which Homebrew packages you use.
-Usage data is written every 30 seconds to minimise I/O overhead.
+Usage data is recorded within a second or two of a command running, or every
+30 seconds where file notifications are unavailable (see --interval).
 
 When you run a shimmed command (e.g. git, gh, jq), the shim binary appends
-an entry to ~/.brewprune/usage.log. The watch daemon reads that log every 30
-seconds and records resolved usage events in the database. This data drives
-the confidence scores shown by 'brewprune unused'.
+an entry to ~/.brewprune/usage.log. The watch daemon is notified when that
+log changes (inotify on Linux, kqueue on macOS), waits briefly so a burst of
+commands is recorded as one batch, and records resolved usage events in the
+database. This data drives the confidence scores shown by 'brewprune unused'.
+
+Use 'brewprune flush' to have a running daemon process the log immediately.
+The daemon is controlled through a socket next to its PID file
+(~/.brewprune/watch.sock); 'brewprune status' reports its health from it.
+
+On Linux the watcher can also sample /proc for running Homebrew executables,
+so binaries started by absolute path or with a pinned PATH are recorded too.
+It is off by default; set "watch.proc_sampling = true" in
+~/.config/brewprune/config to enable it.
+
+On macOS the watcher also reads the last-used dates Spotlight keeps for
+application bundles, so casks are scored on when their apps were opened.
 
 Run 'brewprune scan' first to build the shim binary and create symlinks.
 Then add ~/.brewprune/bin to the front of your PATH.
@@ -49,6 +72,12 @@ Watch modes:
   # Stop running daemon
   brewprune watch --stop
 
+  # Apply edits to ~/.config/brewprune/config without restarting
+  brewprune watch --reload
+
+  # Poll every 5 seconds where file notifications are unavailable
+  brewprune watch --daemon --interval 5s
+
   # Use custom PID and log files
   brewprune watch --daemon --pid-file /tmp/watch.pid --log-file /tmp/watch.log`,
 		RunE: runWatch,
@@ -61,6 +90,8 @@ func init() {
 	watchCmd.Flags().StringVar(&watchPIDFile, "pid-file", "", "PID file path (default: ~/.brewprune/watch.pid)")
 	watchCmd.Flags().StringVar(&watchLogFile, "log-file", "", "log file path (default: ~/.brewprune/watch.log)")
 	watchCmd.Flags().BoolVar(&watchStop, "stop", false, "stop running daemon")
+	watchCmd.Flags().BoolVar(&watchReload, "reload", false, "make the running daemon re-read its config file")
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", watcher.DefaultPollInterval, "shim log poll interval when file notifications are unavailable")
 
 	// Hide the internal daemon-child flag from help
 	watchCmd.Flags().MarkHidden("daemon-child")
@@ -89,10 +120,20 @@ func runWatch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("--daemon and --stop are mutually exclusive: use one or the other")
 	}
 
-	// Handle stop command (no database needed)
+	if watchReload && (watchDaemon || watchStop) {
+		return fmt.Errorf("--reload cannot be combined with --daemon or --stop")
+	}
+	if watchInterval <= 0 {
+		return fmt.Errorf("--interval must be positive, got %v", watchInterval)
+	}
+
+	// Handle stop and reload commands (no database needed)
 	if watchStop {
 		return stopWatchDaemon()
 	}
+	if watchReload {
+		return reloadWatchDaemon()
+	}
 
 	// Handle daemon mode: spawn child without opening the database in the parent.
 	// The daemon child opens its own connection after exec; opening the DB here
@@ -125,6 +166,12 @@ func runWatch(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create watcher: %w", err)
 	}
+	w.SetInterval(watchInterval)
+	settings := loadSettings()
+	enableProcSampling(w, settings)
+	w.SetLaunchSource(watcher.DefaultLaunchSource(), watcher.DefaultLaunchCheckInterval)
+	applyLogRotation(w, settings)
+	listenControl(w)
 
 	// Handle daemon child process
 	if watchDaemonChild {
@@ -136,6 +183,20 @@ func runWatch(cmd *cobra.Command, args []string) error {
 }
 
 func stopWatchDaemon() error {
+	// Prefer the control socket; fall back to signalling the PID from the
+	// PID file for daemons that are not listening on it.
+	socketPath := watcher.ControlSocketPath(watchPIDFile)
+	if _, err := watcher.CallControl(socketPath, watcher.ControlStop); err == nil {
+		spinner := output.NewSpinner("Stopping daemon...")
+		spinner.Start()
+		waitForDaemonExit(socketPath)
+		spinner.StopWithMessage("✓ Daemon stopped")
+		logDaemonStopped()
+		return nil
+	} else if !errors.Is(err, watcher.ErrControlUnavailable) {
+		fmt.Fprintf(os.Stderr, "Warning: %v; falling back to PID file\n", err)
+	}
+
 	// Check if daemon is running
 	running, err := watcher.IsDaemonRunning(watchPIDFile)
 	if err != nil {
@@ -154,15 +215,46 @@ func stopWatchDaemon() error {
 		return fmt.Errorf("failed to stop daemon: %w", err)
 	}
 	spinner.StopWithMessage("✓ Daemon stopped")
+	logDaemonStopped()
+
+	return nil
+}
+
+// reloadWatchDaemon asks the running daemon to re-read the config file.
+func reloadWatchDaemon() error {
+	_, err := watcher.CallControl(watcher.ControlSocketPath(watchPIDFile), watcher.ControlReloadConfig)
+	if errors.Is(err, watcher.ErrControlUnavailable) {
+		fmt.Println("No daemon is listening on the control socket.")
+		fmt.Println("If an older daemon is running, restart it: brewprune watch --stop && brewprune watch --daemon")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reload daemon config: %w", err)
+	}
+	fmt.Println("✓ Daemon reloaded ~/.config/brewprune/config")
+	return nil
+}
+
+// waitForDaemonExit waits, up to daemonStopWait, for a daemon asked to stop
+// over the control socket to finish its final flush and remove the socket.
+func waitForDaemonExit(socketPath string) {
+	deadline := time.Now().Add(daemonStopWait)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(socketPath); os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
 
+// logDaemonStopped appends a stop line to the watch log.
+func logDaemonStopped() {
 	if watchLogFile != "" {
 		if f, err := os.OpenFile(watchLogFile, os.O_APPEND|os.O_WRONLY, 0644); err == nil {
 			fmt.Fprintf(f, "%s brewprune-watch: daemon stopped\n", time.Now().Format(time.RFC3339))
 			f.Close()
 		}
 	}
-
-	return nil
 }
 
 func startWatchDaemon() error {
@@ -180,7 +272,11 @@ func startWatchDaemon() error {
 
 	spinner := output.NewSpinner("Starting daemon...")
 	spinner.Start()
-	if err := watcher.LaunchDaemon(watchPIDFile, watchLogFile); err != nil {
+	var extraArgs []string
+	if watchInterval != watcher.DefaultPollInterval {
+		extraArgs = append(extraArgs, "--interval", watchInterval.String())
+	}
+	if err := watcher.LaunchDaemon(watchPIDFile, watchLogFile, extraArgs...); err != nil {
 		spinner.Stop()
 		return fmt.Errorf("failed to start daemon: %w", err)
 	}
@@ -207,6 +303,70 @@ func runWatchDaemonChild(w *watcher.Watcher) error {
 	return w.RunDaemon(watchPIDFile)
 }
 
+// enableProcSampling turns on the /proc usage source on Linux unless it is
+// disabled in the config file. A missing proc filesystem is reported but is
+// not fatal: the shim log remains the primary source.
+func enableProcSampling(w *watcher.Watcher, settings *config.Settings) {
+	if err := applyProcSettings(w, settings); err != nil {
+		fmt.Fprintf(os.Stderr, "watcher: proc sampling disabled: %v\n", err)
+	}
+}
+
+// applyProcSettings enables or disables the /proc usage source to match
+// settings. It is also used when a running daemon reloads its config.
+func applyProcSettings(w *watcher.Watcher, settings *config.Settings) error {
+	if runtime.GOOS != "linux" || !settings.ProcSampling {
+		w.DisableProcSampling()
+		return nil
+	}
+	return w.EnableProcSampling("/proc", settings.ProcSampleInterval)
+}
+
+// applyLogRotation sets when the watcher rotates the shim usage log, and
+// whether drained segments are archived under ~/.brewprune/archive.
+func applyLogRotation(w *watcher.Watcher, settings *config.Settings) {
+	rotation := watcher.LogRotation{
+		MaxSize: settings.LogMaxSize,
+		MaxAge:  time.Duration(settings.LogMaxAgeDays) * 24 * time.Hour,
+	}
+	if settings.LogArchive {
+		if home, err := os.UserHomeDir(); err == nil {
+			rotation.ArchiveDir = filepath.Join(home, ".brewprune", "archive")
+		}
+	}
+	w.SetLogRotation(rotation)
+}
+
+// reloadWatchConfig re-reads ~/.config/brewprune/config for a running
+// watcher (the control socket's reload-config command). Unlike
+// loadSettings, an unreadable file is reported rather than replaced by the
+// defaults.
+func reloadWatchConfig(w *watcher.Watcher) error {
+	cfgDir, err := config.Dir()
+	if err != nil {
+		return fmt.Errorf("failed to get config directory: %w", err)
+	}
+	settings, err := config.LoadSettings(cfgDir)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := applyProcSettings(w, settings); err != nil {
+		return fmt.Errorf("failed to apply proc sampling settings: %w", err)
+	}
+	applyLogRotation(w, settings)
+	fmt.Fprintf(os.Stderr, "%s brewprune-watch: config reloaded\n", time.Now().UTC().Format(time.RFC3339))
+	return nil
+}
+
+// listenControl starts the watcher's control socket next to the PID file.
+// Failure is not fatal: clients fall back to the PID file.
+func listenControl(w *watcher.Watcher) {
+	w.SetReloadFunc(func() error { return reloadWatchConfig(w) })
+	if err := w.ListenControl(watcher.ControlSocketPath(watchPIDFile)); err != nil {
+		fmt.Fprintf(os.Stderr, "watcher: control socket disabled: %v\n", err)
+	}
+}
+
 // readPIDFromFile reads and parses the PID from a PID file, returning 0 on error.
 func readPIDFromFile(path string) int {
 	data, err := os.ReadFile(path)
@@ -235,7 +395,7 @@ func runWatchForeground(w *watcher.Watcher) error {
 	spinner.StopWithMessage("✓ Shim log processor started")
 
 	fmt.Println()
-	fmt.Println("Processing ~/.brewprune/usage.log every 30 seconds.")
+	fmt.Println("Processing ~/.brewprune/usage.log as it changes.")
 	fmt.Println("Press Ctrl+C to stop.")
 	fmt.Println()
 
@@ -243,9 +403,13 @@ func runWatchForeground(w *watcher.Watcher) error {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
 
-	// Wait for shutdown signal
-	sig := <-sigCh
-	fmt.Printf("\nReceived signal %v, shutting down...\n", sig)
+	// Wait for shutdown signal or a stop request on the control socket
+	select {
+	case sig := <-sigCh:
+		fmt.Printf("\nReceived signal %v, shutting down...\n", sig)
+	case <-w.StopRequested():
+		fmt.Printf("\nStop requested, shutting down...\n")
+	}
 
 	// Stop the watcher
 	spinner = output.NewSpinner("Stopping watcher...")
@@ -3,8 +3,12 @@ package app
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/blackwell-systems/brewprune/internal/store"
+	"github.com/blackwell-systems/brewprune/internal/watcher"
 )
 
 func TestWatchCommand(t *testing.T) {
@@ -423,3 +427,53 @@ func TestWatchLogStartup(t *testing.T) {
 		t.Errorf("expected log file to contain 'brewprune-watch: daemon started', got: %q", content)
 	}
 }
+
+// TestStopWatchDaemon_UsesControlSocket verifies that 'watch --stop' asks a
+// daemon listening on the control socket to stop, without needing a PID file.
+func TestStopWatchDaemon_UsesControlSocket(t *testing.T) {
+	// A short dir keeps the socket path within the sun_path limit.
+	tmpDir, err := os.MkdirTemp("", "bp")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	origPIDFile, origLogFile := watchPIDFile, watchLogFile
+	watchPIDFile = filepath.Join(tmpDir, "watch.pid")
+	watchLogFile = filepath.Join(tmpDir, "watch.log")
+	defer func() { watchPIDFile, watchLogFile = origPIDFile, origLogFile }()
+
+	st, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer st.Close()
+	if err := st.CreateSchema(); err != nil {
+		t.Fatalf("CreateSchema: %v", err)
+	}
+	w, err := watcher.New(st)
+	if err != nil {
+		t.Fatalf("watcher.New: %v", err)
+	}
+	if err := w.ListenControl(watcher.ControlSocketPath(watchPIDFile)); err != nil {
+		t.Fatalf("ListenControl: %v", err)
+	}
+	// Act as the daemon: shut down once a stop is requested.
+	go func() {
+		<-w.StopRequested()
+		w.Stop()
+	}()
+	defer w.Stop()
+
+	if err := stopWatchDaemon(); err != nil {
+		t.Fatalf("stopWatchDaemon: %v", err)
+	}
+	select {
+	case <-w.StopRequested():
+	default:
+		t.Fatal("expected stop to be requested over the control socket")
+	}
+	if _, err := os.Stat(watcher.ControlSocketPath(watchPIDFile)); !os.IsNotExist(err) {
+		t.Errorf("expected control socket to be removed after stop, stat err = %v", err)
+	}
+}
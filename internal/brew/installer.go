@@ -62,10 +62,22 @@ func Uses(pkgName string) ([]string, error) {
 	return deps, nil
 }
 
-// AddTap adds a Homebrew tap if not already present
-func AddTap(tap string) error {
+// Untap removes a Homebrew tap via the brew of prefix ("" for the brew on
+// PATH)
+func Untap(prefix, tap string) error {
+	cmd := exec.Command(Command(prefix), "untap", tap)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("brew untap %s failed: %w (output: %s)", tap, err, string(output))
+	}
+	return nil
+}
+
+// AddTap adds a Homebrew tap to the brew of prefix ("" for the brew on
+// PATH) if not already present
+func AddTap(prefix, tap string) error {
 	// Check if tap already exists first
-	exists, err := TapExists(tap)
+	exists, err := TapExists(prefix, tap)
 	if err != nil {
 		return fmt.Errorf("failed to check if tap exists: %w", err)
 	}
@@ -74,7 +86,7 @@ func AddTap(tap string) error {
 		return nil // Already tapped, nothing to do
 	}
 
-	cmd := exec.Command("brew", "tap", tap)
+	cmd := exec.Command(Command(prefix), "tap", tap)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("brew tap %s failed: %w (output: %s)", tap, err, string(output))
@@ -82,9 +94,9 @@ func AddTap(tap string) error {
 	return nil
 }
 
-// TapExists checks if a tap is already added
-func TapExists(tap string) (bool, error) {
-	cmd := exec.Command("brew", "tap")
+// TapExists checks if a tap is already added to the brew of prefix
+func TapExists(prefix, tap string) (bool, error) {
+	cmd := exec.Command(Command(prefix), "tap")
 	output, err := cmd.Output()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
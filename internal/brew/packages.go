@@ -3,7 +3,9 @@ package brew
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -23,6 +25,7 @@ type brewFormula struct {
 	Version   string                 `json:"version"`
 	Installed []brewInstalledVersion `json:"installed"`
 	LinkedKeg string                 `json:"linked_keg,omitempty"`
+	Pinned    bool                   `json:"pinned,omitempty"`
 }
 
 // brewInstalledVersion represents an installed version
@@ -55,6 +58,7 @@ type brewFormulaInfo struct {
 	Version   string                 `json:"version"`
 	Installed []brewInstalledVersion `json:"installed"`
 	LinkedKeg string                 `json:"linked_keg,omitempty"`
+	Pinned    bool                   `json:"pinned,omitempty"`
 }
 
 // brewCaskInfo represents detailed cask information
@@ -65,9 +69,26 @@ type brewCaskInfo struct {
 	Version   string `json:"version"`
 }
 
+// Command returns the brew executable of prefix, or "brew" (resolved on PATH)
+// when prefix is empty.
+func Command(prefix string) string {
+	if prefix == "" {
+		return "brew"
+	}
+	return filepath.Join(prefix, "bin", "brew")
+}
+
 // ListInstalled returns all installed Homebrew packages (formulae and casks)
+// of the brew on PATH.
 func ListInstalled() ([]*Package, error) {
-	cmd := exec.Command("brew", "info", "--json=v2", "--installed")
+	return ListInstalledIn("")
+}
+
+// ListInstalledIn returns all packages installed in the Homebrew prefix,
+// using that prefix's brew. Each package's Prefix is set to prefix. An empty
+// prefix uses the brew on PATH.
+func ListInstalledIn(prefix string) ([]*Package, error) {
+	cmd := exec.Command(Command(prefix), "info", "--json=v2", "--installed")
 	output, err := cmd.Output()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
@@ -92,9 +113,11 @@ func ListInstalled() ([]*Package, error) {
 			InstallType: "explicit", // Default, needs to be determined from deps
 			Tap:         formula.Tap,
 			IsCask:      false,
-			SizeBytes:   calculatePackageSize(formula.Name, false),
+			SizeBytes:   calculatePackageSize(prefix, formula.Name, false),
 			HasBinary:   true, // Assume formulae have binaries
 			BinaryPaths: []string{},
+			Prefix:      prefix,
+			Pinned:      formula.Pinned,
 		}
 
 		// If we have installed info, use that timestamp
@@ -114,9 +137,10 @@ func ListInstalled() ([]*Package, error) {
 			InstallType: "explicit",
 			Tap:         cask.Tap,
 			IsCask:      true,
-			SizeBytes:   calculatePackageSize(cask.Token, true),
+			SizeBytes:   calculatePackageSize(prefix, cask.Token, true),
 			HasBinary:   false, // Casks typically don't install to bin
 			BinaryPaths: []string{},
+			Prefix:      prefix,
 		}
 
 		// Try to parse installed time if available
@@ -156,9 +180,10 @@ func GetPackageInfo(name string) (*Package, error) {
 			InstallType: "explicit",
 			Tap:         formula.Tap,
 			IsCask:      false,
-			SizeBytes:   calculatePackageSize(formula.Name, false),
+			SizeBytes:   calculatePackageSize("", formula.Name, false),
 			HasBinary:   true,
 			BinaryPaths: []string{},
+			Pinned:      formula.Pinned,
 		}
 
 		if len(formula.Installed) > 0 && formula.Installed[0].Time > 0 {
@@ -178,7 +203,7 @@ func GetPackageInfo(name string) (*Package, error) {
 			InstallType: "explicit",
 			Tap:         cask.Tap,
 			IsCask:      true,
-			SizeBytes:   calculatePackageSize(cask.Token, true),
+			SizeBytes:   calculatePackageSize("", cask.Token, true),
 			HasBinary:   false,
 			BinaryPaths: []string{},
 		}
@@ -193,7 +218,13 @@ func GetPackageInfo(name string) (*Package, error) {
 // This is much faster than calling GetDependencyTree for each package individually
 // Returns a map where keys are package names and values are their direct dependencies
 func GetAllDependencies() (map[string][]string, error) {
-	cmd := exec.Command("brew", "deps", "--installed", "--tree")
+	return GetAllDependenciesIn("")
+}
+
+// GetAllDependenciesIn is GetAllDependencies for the packages installed in
+// prefix. An empty prefix uses the brew on PATH.
+func GetAllDependenciesIn(prefix string) (map[string][]string, error) {
+	cmd := exec.Command(Command(prefix), "deps", "--installed", "--tree")
 	output, err := cmd.Output()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
@@ -319,10 +350,18 @@ func parseDependencyTree(output string) (map[string][]string, error) {
 
 // calculatePackageSize calculates the disk size of a package in bytes
 // Returns 0 if size cannot be determined (non-fatal error)
-func calculatePackageSize(name string, isCask bool) int64 {
+// With a prefix the package is looked up in <prefix>/Cellar or
+// <prefix>/Caskroom; otherwise the brew on PATH is asked for those paths.
+func calculatePackageSize(prefix, name string, isCask bool) int64 {
 	var path string
 
-	if isCask {
+	if prefix != "" {
+		dir := "Cellar"
+		if isCask {
+			dir = "Caskroom"
+		}
+		path = filepath.Join(prefix, dir, name)
+	} else if isCask {
 		// Get caskroom path
 		cmd := exec.Command("brew", "--caskroom")
 		output, err := cmd.Output()
@@ -340,11 +379,17 @@ func calculatePackageSize(name string, isCask bool) int64 {
 		path = strings.TrimSpace(string(output)) + "/" + name
 	}
 
+	return diskUsage(path)
+}
+
+// diskUsage returns the disk size of path in bytes as reported by du, or 0
+// when it cannot be determined.
+func diskUsage(path string) int64 {
 	// Run du -sk to get size in KB
 	cmd := exec.Command("du", "-sk", path)
 	output, err := cmd.Output()
 	if err != nil {
-		// Package directory might not exist or be accessible
+		// Directory might not exist or be accessible
 		return 0
 	}
 
@@ -377,6 +422,29 @@ func GetBrewPrefix() (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
+// DefaultPrefix returns the Homebrew prefix to use when none is configured:
+// $HOMEBREW_PREFIX when it holds a Homebrew installation, otherwise the
+// prefix of the brew on PATH.
+func DefaultPrefix() (string, error) {
+	if prefix := os.Getenv("HOMEBREW_PREFIX"); prefix != "" && CheckPrefix(prefix) == nil {
+		return filepath.Clean(prefix), nil
+	}
+	return GetBrewPrefix()
+}
+
+// CheckPrefix returns an error unless prefix is an absolute path holding a
+// Homebrew installation (an executable bin/brew).
+func CheckPrefix(prefix string) error {
+	if !filepath.IsAbs(prefix) {
+		return fmt.Errorf("brew prefix %q must be an absolute path", prefix)
+	}
+	info, err := os.Stat(Command(prefix))
+	if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+		return fmt.Errorf("no Homebrew installation at %s (missing %s)", prefix, Command(prefix))
+	}
+	return nil
+}
+
 // PackageExists checks if a package exists in available formulae/casks
 func PackageExists(name string) (bool, error) {
 	// Try as formula first
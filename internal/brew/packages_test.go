@@ -2,7 +2,10 @@ package brew
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
 	"testing"
 )
 
@@ -15,7 +18,8 @@ const mockBrewListJSON = `{
       "tap": "homebrew/core",
       "version": "20.10.0",
       "installed": [{"version": "20.10.0", "installed_on_request": true, "time": 1704067200}],
-      "linked_keg": "20.10.0"
+      "linked_keg": "20.10.0",
+      "pinned": true
     },
     {
       "name": "git",
@@ -567,3 +571,88 @@ func TestCompareDepTreeResults(t *testing.T) {
 		t.Error("parseDependencyTree should produce consistent results")
 	}
 }
+
+// fakePrefix creates a Homebrew prefix whose bin/brew prints brewOutput.
+func fakePrefix(t *testing.T, brewOutput string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	prefix := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(prefix, "bin"), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	dataPath := filepath.Join(prefix, "brew-output.json")
+	if err := os.WriteFile(dataPath, []byte(brewOutput), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	script := "#!/bin/sh\ncat '" + dataPath + "'\n"
+	if err := os.WriteFile(Command(prefix), []byte(script), 0755); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return prefix
+}
+
+func TestCommand(t *testing.T) {
+	if got := Command(""); got != "brew" {
+		t.Errorf("Command(\"\") = %q, want brew", got)
+	}
+	if got := Command("/usr/local"); got != filepath.Join("/usr/local", "bin", "brew") {
+		t.Errorf("Command(/usr/local) = %q", got)
+	}
+}
+
+func TestCheckPrefix(t *testing.T) {
+	prefix := fakePrefix(t, "{}")
+	if err := CheckPrefix(prefix); err != nil {
+		t.Errorf("CheckPrefix(%s) = %v, want nil", prefix, err)
+	}
+	if err := CheckPrefix(t.TempDir()); err == nil {
+		t.Error("CheckPrefix(empty dir) = nil, want error")
+	}
+	if err := CheckPrefix("relative/prefix"); err == nil {
+		t.Error("CheckPrefix(relative) = nil, want error")
+	}
+}
+
+func TestListInstalledIn_SetsPrefix(t *testing.T) {
+	prefix := fakePrefix(t, mockBrewListJSON)
+
+	packages, err := ListInstalledIn(prefix)
+	if err != nil {
+		t.Fatalf("ListInstalledIn: %v", err)
+	}
+	if len(packages) == 0 {
+		t.Fatal("ListInstalledIn returned no packages")
+	}
+	for _, pkg := range packages {
+		if pkg.Prefix != prefix {
+			t.Errorf("%s: Prefix = %q, want %q", pkg.Name, pkg.Prefix, prefix)
+		}
+	}
+}
+
+func TestListInstalledIn_Pinned(t *testing.T) {
+	packages, err := ListInstalledIn(fakePrefix(t, mockBrewListJSON))
+	if err != nil {
+		t.Fatalf("ListInstalledIn: %v", err)
+	}
+	for _, pkg := range packages {
+		if want := pkg.Name == "node"; pkg.Pinned != want {
+			t.Errorf("%s: Pinned = %v, want %v", pkg.Name, pkg.Pinned, want)
+		}
+	}
+}
+
+func TestDefaultPrefix_HomebrewPrefixEnv(t *testing.T) {
+	prefix := fakePrefix(t, "{}")
+	t.Setenv("HOMEBREW_PREFIX", prefix)
+
+	got, err := DefaultPrefix()
+	if err != nil {
+		t.Fatalf("DefaultPrefix: %v", err)
+	}
+	if got != prefix {
+		t.Errorf("DefaultPrefix() = %q, want %q", got, prefix)
+	}
+}
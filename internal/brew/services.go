@@ -0,0 +1,171 @@
+package brew
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Service statuses reported by `brew services list`. A service whose plist
+// or unit file is installed but that brew could not be asked about is
+// reported as ServiceStarted, since the file makes it start at login or
+// boot.
+const (
+	ServiceStarted   = "started"
+	ServiceScheduled = "scheduled"
+)
+
+// brewService is one entry of `brew services list --json`.
+type brewService struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// IsRunningService reports whether a service status means the service runs,
+// continuously or on a schedule.
+func IsRunningService(status string) bool {
+	return status == ServiceStarted || status == ServiceScheduled
+}
+
+// ListServicesIn returns the `brew services` status of each formula of
+// prefix that has a service, keyed by formula name. Formulae whose service
+// was never started ("none") are left out. When `brew services` fails, as
+// it does without the services command installed, the service files under
+// the launchd and systemd directories brew writes to are read instead; only
+// those whose program lies under prefix count, so files of another prefix's
+// formulae are not attributed to this one.
+func ListServicesIn(prefix string) map[string]string {
+	if services, err := listBrewServices(prefix); err == nil {
+		return services
+	}
+
+	services := make(map[string]string)
+	for name, program := range serviceFiles(serviceDirs()) {
+		if underPrefix(program, prefix) {
+			services[name] = ServiceStarted
+		}
+	}
+	return services
+}
+
+// listBrewServices runs `brew services list --json` with the brew of prefix.
+func listBrewServices(prefix string) (map[string]string, error) {
+	out, err := exec.Command(Command(prefix), "services", "list", "--json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("brew services list failed: %w", err)
+	}
+	var list []brewService
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse brew services list: %w", err)
+	}
+
+	services := make(map[string]string)
+	for _, svc := range list {
+		if svc.Status != "" && svc.Status != "none" {
+			services[svc.Name] = svc.Status
+		}
+	}
+	return services, nil
+}
+
+// underPrefix reports whether path lies inside prefix. Nothing lies inside
+// the unknown prefix "" of the brew on PATH.
+func underPrefix(path, prefix string) bool {
+	if prefix == "" || path == "" {
+		return false
+	}
+	return strings.HasPrefix(filepath.Clean(path), filepath.Clean(prefix)+string(filepath.Separator))
+}
+
+// serviceDirs returns the directories `brew services` installs launchd
+// plists (macOS) and systemd units (Linux) into, for the user and for root.
+func serviceDirs() []string {
+	dirs := []string{"/Library/LaunchDaemons", "/usr/lib/systemd/system"}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs,
+			filepath.Join(home, "Library", "LaunchAgents"),
+			filepath.Join(home, ".config", "systemd", "user"))
+	}
+	return dirs
+}
+
+// serviceFiles returns the formulae with a brew service file in dirs:
+// "homebrew.mxcl.<formula>.plist" for launchd, and
+// "homebrew.<formula>.service" or ".timer" for systemd. Each maps to the
+// program the file runs, or "" when it names none.
+func serviceFiles(dirs []string) map[string]string {
+	programs := make(map[string]string)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := serviceFileFormula(entry.Name())
+			if name == "" {
+				continue
+			}
+			// A systemd timer names no program; its .service does
+			if program := serviceProgram(filepath.Join(dir, entry.Name())); program != "" || programs[name] == "" {
+				programs[name] = program
+			}
+		}
+	}
+	return programs
+}
+
+// plistProgram matches the program of a launchd plist: the Program key, or
+// the first of its ProgramArguments.
+var plistProgram = regexp.MustCompile(`<key>Program(?:Arguments)?</key>\s*(?:<array>\s*)?<string>([^<]+)</string>`)
+
+// serviceProgram returns the program a launchd plist or systemd unit runs,
+// or "" when it cannot be read or names none.
+func serviceProgram(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	if strings.HasSuffix(path, ".plist") {
+		if m := plistProgram.FindSubmatch(data); m != nil {
+			return strings.TrimSpace(string(m[1]))
+		}
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "ExecStart=")
+		if !ok {
+			continue
+		}
+		// Strip the special executable prefixes systemd allows ("-", "@", ...)
+		fields := strings.Fields(strings.TrimLeft(value, "-@:+!"))
+		if len(fields) > 0 {
+			return fields[0]
+		}
+	}
+	return ""
+}
+
+// serviceFileFormula returns the formula a brew service file belongs to, or
+// "" when file is not one.
+func serviceFileFormula(file string) string {
+	if rest, ok := strings.CutPrefix(file, "homebrew.mxcl."); ok {
+		if name, ok := strings.CutSuffix(rest, ".plist"); ok {
+			return name
+		}
+		return ""
+	}
+	rest, ok := strings.CutPrefix(file, "homebrew.")
+	if !ok {
+		return ""
+	}
+	for _, suffix := range []string{".service", ".timer"} {
+		if name, ok := strings.CutSuffix(rest, suffix); ok {
+			return name
+		}
+	}
+	return ""
+}
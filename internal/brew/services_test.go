@@ -0,0 +1,140 @@
+package brew
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeServiceFiles writes service files, keyed by their path, with the
+// given contents.
+func writeServiceFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+}
+
+// launchdPlist returns a brew-style launchd plist running program.
+func launchdPlist(program string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>homebrew.mxcl.x</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>` + program + `</string>
+		<string>--daemon</string>
+	</array>
+</dict>
+</plist>
+`
+}
+
+func TestListServicesIn(t *testing.T) {
+	prefix := fakePrefix(t, `[
+  {"name": "postgresql@16", "status": "started", "user": "me", "file": "/x/homebrew.mxcl.postgresql@16.plist", "exit_code": 0},
+  {"name": "redis", "status": "none", "user": null, "file": "/x/homebrew.mxcl.redis.plist", "exit_code": null},
+  {"name": "unbound", "status": "error", "user": "root", "file": "/x/homebrew.mxcl.unbound.plist", "exit_code": 78}
+]`)
+
+	// Service files are ignored while brew can be asked: a formula brew
+	// does not list may belong to another prefix or be a leftover.
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeServiceFiles(t, map[string]string{
+		filepath.Join(home, "Library", "LaunchAgents", "homebrew.mxcl.redis.plist"):   launchdPlist(prefix + "/opt/redis/bin/redis-server"),
+		filepath.Join(home, "Library", "LaunchAgents", "homebrew.mxcl.dnsmasq.plist"): launchdPlist(prefix + "/opt/dnsmasq/sbin/dnsmasq"),
+	})
+
+	got := ListServicesIn(prefix)
+	want := map[string]string{
+		"postgresql@16": "started",
+		"unbound":       "error",
+	}
+	if len(got) != len(want) {
+		t.Errorf("ListServicesIn() = %v, want %v", got, want)
+	}
+	for name, status := range want {
+		if got[name] != status {
+			t.Errorf("ListServicesIn()[%q] = %q, want %q", name, got[name], status)
+		}
+	}
+}
+
+func TestListServicesIn_FileFallback(t *testing.T) {
+	prefix := fakePrefix(t, "")
+	// brew services is not installed
+	if err := os.WriteFile(Command(prefix), []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	agents := filepath.Join(home, "Library", "LaunchAgents")
+	units := filepath.Join(home, ".config", "systemd", "user")
+	writeServiceFiles(t, map[string]string{
+		filepath.Join(agents, "homebrew.mxcl.dnsmasq.plist"):  launchdPlist(prefix + "/opt/dnsmasq/sbin/dnsmasq"),
+		filepath.Join(agents, "homebrew.mxcl.redis.plist"):    launchdPlist("/usr/local/opt/redis/bin/redis-server"),
+		filepath.Join(agents, "homebrew.mxcl.empty.plist"):    "",
+		filepath.Join(agents, "com.example.agent.plist"):      launchdPlist(prefix + "/bin/agent"),
+		filepath.Join(units, "homebrew.syncthing.service"):    "[Service]\nExecStart=-" + prefix + "/opt/syncthing/bin/syncthing serve\n",
+		filepath.Join(units, "homebrew.restic.service"):       "[Service]\nExecStart=" + prefix + "/opt/restic/bin/restic backup\n",
+		filepath.Join(units, "homebrew.restic.timer"):         "[Timer]\nOnCalendar=daily\n",
+		filepath.Join(units, "homebrew.other-prefix.service"): "[Service]\nExecStart=/home/linuxbrew/.linuxbrew/opt/x/bin/x\n",
+	})
+
+	got := ListServicesIn(prefix)
+	want := map[string]string{
+		"dnsmasq":   "started",
+		"syncthing": "started",
+		"restic":    "started",
+	}
+	if len(got) != len(want) {
+		t.Errorf("ListServicesIn() = %v, want %v", got, want)
+	}
+	for name, status := range want {
+		if got[name] != status {
+			t.Errorf("ListServicesIn()[%q] = %q, want %q", name, got[name], status)
+		}
+	}
+}
+
+func TestServiceFileFormula(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"homebrew.mxcl.postgresql@16.plist", "postgresql@16"},
+		{"homebrew.mxcl.redis.plist.bak", ""},
+		{"homebrew.syncthing.service", "syncthing"},
+		{"homebrew.restic.timer", "restic"},
+		{"homebrew.conf", ""},
+		{"org.nixos.nix-daemon.plist", ""},
+	}
+	for _, tt := range tests {
+		if got := serviceFileFormula(tt.file); got != tt.want {
+			t.Errorf("serviceFileFormula(%q) = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestIsRunningService(t *testing.T) {
+	for status, want := range map[string]bool{
+		"started":   true,
+		"scheduled": true,
+		"stopped":   false,
+		"error":     false,
+		"":          false,
+	} {
+		if got := IsRunningService(status); got != want {
+			t.Errorf("IsRunningService(%q) = %v, want %v", status, got, want)
+		}
+	}
+}
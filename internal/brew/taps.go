@@ -0,0 +1,66 @@
+package brew
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Tap is a tapped formula repository, cloned under the Homebrew Library.
+type Tap struct {
+	Name      string // e.g., "user/tools"
+	Path      string // git clone, e.g. <repository>/Library/Taps/user/homebrew-tools
+	SizeBytes int64
+	Prefix    string // Homebrew prefix whose brew lists the tap
+}
+
+// IsOfficialTap reports whether tap is one of Homebrew's own default taps,
+// which most installed packages come from.
+func IsOfficialTap(tap string) bool {
+	return tap == "homebrew/core" || tap == "homebrew/cask"
+}
+
+// ListTapsIn returns the taps of the brew of prefix ("" for the brew on
+// PATH), sorted by name, with the disk size of each git clone. A tap whose
+// clone is missing, as homebrew/core is when formulae come from the JSON
+// API, has a size of 0.
+func ListTapsIn(prefix string) ([]*Tap, error) {
+	output, err := exec.Command(Command(prefix), "tap").Output()
+	if err != nil {
+		return nil, fmt.Errorf("brew tap failed: %w", err)
+	}
+	repoOutput, err := exec.Command(Command(prefix), "--repository").Output()
+	if err != nil {
+		return nil, fmt.Errorf("brew --repository failed: %w", err)
+	}
+	repository := strings.TrimSpace(string(repoOutput))
+
+	var taps []*Tap
+	for _, line := range strings.Split(string(output), "\n") {
+		name := strings.TrimSpace(line)
+		if name == "" {
+			continue
+		}
+		path := tapPath(repository, name)
+		taps = append(taps, &Tap{
+			Name:      name,
+			Path:      path,
+			SizeBytes: diskUsage(path),
+			Prefix:    prefix,
+		})
+	}
+	sort.Slice(taps, func(i, j int) bool { return taps[i].Name < taps[j].Name })
+	return taps, nil
+}
+
+// tapPath returns where brew clones tap "user/repo" under repository:
+// Library/Taps/user/homebrew-repo.
+func tapPath(repository, tap string) string {
+	user, repo, _ := strings.Cut(tap, "/")
+	if !strings.HasPrefix(repo, "homebrew-") {
+		repo = "homebrew-" + repo
+	}
+	return filepath.Join(repository, "Library", "Taps", user, repo)
+}
@@ -0,0 +1,66 @@
+package brew
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListTapsIn(t *testing.T) {
+	prefix := fakePrefix(t, "")
+	repository := filepath.Join(prefix, "Homebrew")
+	script := "#!/bin/sh\ncase \"$1\" in\n" +
+		"tap) printf 'user/tools\\nhomebrew/core\\nold/archive\\n' ;;\n" +
+		"--repository) echo '" + repository + "' ;;\n" +
+		"esac\n"
+	if err := os.WriteFile(Command(prefix), []byte(script), 0755); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	formula := filepath.Join(repository, "Library", "Taps", "user", "homebrew-tools", "Formula", "tool.rb")
+	if err := os.MkdirAll(filepath.Dir(formula), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(formula, make([]byte, 64<<10), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	taps, err := ListTapsIn(prefix)
+	if err != nil {
+		t.Fatalf("ListTapsIn failed: %v", err)
+	}
+	if len(taps) != 3 {
+		t.Fatalf("ListTapsIn() returned %d taps, want 3", len(taps))
+	}
+	for i, name := range []string{"homebrew/core", "old/archive", "user/tools"} {
+		if taps[i].Name != name {
+			t.Errorf("taps[%d].Name = %q, want %q", i, taps[i].Name, name)
+		}
+		if taps[i].Prefix != prefix {
+			t.Errorf("taps[%d].Prefix = %q, want %q", i, taps[i].Prefix, prefix)
+		}
+	}
+
+	tools := taps[2]
+	if want := filepath.Dir(filepath.Dir(formula)); tools.Path != want {
+		t.Errorf("user/tools Path = %q, want %q", tools.Path, want)
+	}
+	if tools.SizeBytes < 64<<10 {
+		t.Errorf("user/tools SizeBytes = %d, want at least %d", tools.SizeBytes, 64<<10)
+	}
+	if taps[0].SizeBytes != 0 {
+		t.Errorf("homebrew/core without a clone has SizeBytes = %d, want 0", taps[0].SizeBytes)
+	}
+}
+
+func TestIsOfficialTap(t *testing.T) {
+	for tap, want := range map[string]bool{
+		"homebrew/core":     true,
+		"homebrew/cask":     true,
+		"homebrew/services": false,
+		"user/tools":        false,
+	} {
+		if got := IsOfficialTap(tap); got != want {
+			t.Errorf("IsOfficialTap(%q) = %v, want %v", tap, got, want)
+		}
+	}
+}
@@ -13,6 +13,9 @@ type Package struct {
 	SizeBytes   int64
 	HasBinary   bool
 	BinaryPaths []string
+	Prefix      string // Homebrew prefix it is installed in, e.g. "/opt/homebrew"; empty if unknown
+	Pinned      bool   // held at its version with `brew pin`
+	Service     string // `brew services` status, e.g. "started"; empty if it has no service
 }
 
 // Dependency represents a package dependency relationship.
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultAlternatives lists well-known heavyweight formulae whose commonly used
+// binary is also shipped by a much smaller formula. Keys are
+// "<package>/<binary>" and values are the lighter formula.
+var defaultAlternatives = map[string]string{
+	"postgresql@14/psql": "libpq",
+	"postgresql@15/psql": "libpq",
+	"postgresql@16/psql": "libpq",
+	"postgresql@17/psql": "libpq",
+	"mysql/mysql":        "mysql-client",
+	"llvm/clang-format":  "clang-format",
+}
+
+// AlternativesConfig maps a single binary of a package to a lighter formula
+// that provides the same command. It is consulted when a multi-binary package
+// only ever has one of its binaries executed.
+type AlternativesConfig struct {
+	Alternatives map[string]string
+}
+
+// Lookup returns the lighter formula for the given package binary, if any.
+func (c *AlternativesConfig) Lookup(pkg, binary string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	alt, ok := c.Alternatives[pkg+"/"+binary]
+	return alt, ok
+}
+
+// LoadAlternatives reads the alternatives file at {dir}/alternatives and merges
+// it over the built-in defaults. Each line has the form
+// "<package>/<binary>=<formula>"; an empty right-hand side disables a default
+// mapping. If the file does not exist, only the defaults are returned.
+// Invalid or malformed lines are silently skipped.
+func LoadAlternatives(dir string) (*AlternativesConfig, error) {
+	cfg := &AlternativesConfig{
+		Alternatives: make(map[string]string, len(defaultAlternatives)),
+	}
+	for k, v := range defaultAlternatives {
+		cfg.Alternatives[k] = v
+	}
+
+	path := filepath.Join(dir, "alternatives")
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip blank lines and comments.
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		idx := strings.IndexByte(line, '=')
+		if idx <= 0 {
+			continue
+		}
+
+		key := strings.TrimSpace(line[:idx])
+		alt := strings.TrimSpace(line[idx+1:])
+
+		// The key must name both a package and one of its binaries.
+		slash := strings.LastIndexByte(key, '/')
+		if slash <= 0 || slash == len(key)-1 {
+			continue
+		}
+
+		if alt == "" {
+			delete(cfg.Alternatives, key)
+			continue
+		}
+
+		cfg.Alternatives[key] = alt
+	}
+
+	if err := scanner.Err(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadAlternatives_FileNotFoundReturnsDefaults(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := LoadAlternatives(dir)
+	if err != nil {
+		t.Fatalf("LoadAlternatives() returned error for missing file: %v", err)
+	}
+	if got, ok := cfg.Lookup("postgresql@16", "psql"); !ok || got != "libpq" {
+		t.Errorf("Lookup(postgresql@16, psql) = %q, %v; want libpq, true", got, ok)
+	}
+}
+
+func TestLoadAlternatives_OverridesAndDisables(t *testing.T) {
+	dir := t.TempDir()
+	content := `# custom mappings
+llvm/clang-format=clang-format@18
+postgresql@16/psql=
+gdal/ogr2ogr=ogr-lite
+not-a-valid-line
+noslash=foo
+trailing/=foo
+`
+	if err := os.WriteFile(filepath.Join(dir, "alternatives"), []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cfg, err := LoadAlternatives(dir)
+	if err != nil {
+		t.Fatalf("LoadAlternatives() error: %v", err)
+	}
+
+	if got, _ := cfg.Lookup("llvm", "clang-format"); got != "clang-format@18" {
+		t.Errorf("override not applied: got %q", got)
+	}
+	if _, ok := cfg.Lookup("postgresql@16", "psql"); ok {
+		t.Error("empty value should disable the default mapping")
+	}
+	if got, _ := cfg.Lookup("gdal", "ogr2ogr"); got != "ogr-lite" {
+		t.Errorf("new mapping not added: got %q", got)
+	}
+	if _, ok := cfg.Alternatives["noslash"]; ok {
+		t.Error("key without package/binary separator should be skipped")
+	}
+	if _, ok := cfg.Alternatives["trailing/"]; ok {
+		t.Error("key with empty binary should be skipped")
+	}
+}
+
+func TestAlternativesConfig_LookupNil(t *testing.T) {
+	var cfg *AlternativesConfig
+	if _, ok := cfg.Lookup("llvm", "clang-format"); ok {
+		t.Error("Lookup on nil config should return false")
+	}
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scoring models accepted by the "scoring.model" setting.
+const (
+	ScoringModelBuckets = "buckets" // last-use recency buckets (default)
+	ScoringModelDecay   = "decay"   // exponentially decayed usage intensity
+)
+
+// DefaultHalfLifeDays is the half-life used by the decay scoring model when
+// none is configured.
+const DefaultHalfLifeDays = 14.0
+
+// Usage log rotation defaults.
+const (
+	DefaultLogMaxSize    = 10 << 20 // bytes
+	DefaultLogMaxAgeDays = 30
+)
+
+// DefaultRuntimeMaxDepth is how many directory levels below each runtime
+// root are searched for virtualenvs and symlinks when none is configured.
+const DefaultRuntimeMaxDepth = 5
+
+// Settings holds the tunables read from {dir}/config.
+type Settings struct {
+	// ScoringModel selects how the usage component of the confidence score
+	// is computed: ScoringModelBuckets or ScoringModelDecay.
+	ScoringModel string
+
+	// HalfLifeDays is the time after which a single use counts half as much
+	// under the decay scoring model.
+	HalfLifeDays float64
+
+	// ProcSampling enables the /proc sampling usage source of the watcher on
+	// Linux, which records binaries the PATH shims never see. Off by default.
+	ProcSampling bool
+
+	// ProcSampleInterval is how often /proc is walked when ProcSampling is on.
+	ProcSampleInterval time.Duration
+
+	// ProjectDirs are directories holding the user's projects. Makefiles and
+	// scripts under them are searched for hardcoded brew-prefix paths.
+	ProjectDirs []string
+
+	// TrackCwd makes the shims record the working directory of each run, so
+	// usage can be attributed to projects. Off by default.
+	TrackCwd bool
+
+	// BrewPrefixes are the Homebrew prefixes scanned when "scan --prefix" is
+	// not given. Empty means the prefix of the brew found on PATH.
+	BrewPrefixes []string
+
+	// RuntimeRoots are the directories searched for virtualenvs and symlinks
+	// that reference brew-installed language runtimes. Empty means the
+	// virtualenv and version-manager directories in the home directory
+	// (~/.virtualenvs, ~/.pyenv, ~/.nvm, ...) and ProjectDirs; the whole home
+	// directory is only walked when listed here.
+	RuntimeRoots []string
+
+	// RuntimeMaxDepth bounds how many directory levels below each runtime
+	// root are searched.
+	RuntimeMaxDepth int
+
+	// RuntimeScriptDirs are directories whose scripts' "#!" lines are
+	// checked for brew-installed interpreters. Empty means ~/bin and
+	// ~/.local/bin.
+	RuntimeScriptDirs []string
+
+	// LogMaxSize is the size in bytes at which the watcher rotates the shim
+	// usage log; 0 disables size-based rotation.
+	LogMaxSize int64
+
+	// LogMaxAgeDays rotates the usage log once its oldest entry is this many
+	// days old; 0 disables age-based rotation.
+	LogMaxAgeDays int
+
+	// LogArchive keeps processed log segments as gzip files under
+	// ~/.brewprune/archive instead of deleting them.
+	LogArchive bool
+}
+
+// DefaultSettings returns the settings used when no config file exists.
+func DefaultSettings() *Settings {
+	return &Settings{
+		ScoringModel:    ScoringModelBuckets,
+		HalfLifeDays:    DefaultHalfLifeDays,
+		RuntimeMaxDepth: DefaultRuntimeMaxDepth,
+		LogMaxSize:      DefaultLogMaxSize,
+		LogMaxAgeDays:   DefaultLogMaxAgeDays,
+	}
+}
+
+// LoadSettings reads the config file at {dir}/config. Each line has the form
+// "key = value"; blank lines and lines starting with "#" are ignored. If the
+// file does not exist, DefaultSettings is returned without an error. Unknown
+// keys and invalid values are silently skipped so an older brewprune can read
+// a newer config file.
+func LoadSettings(dir string) (*Settings, error) {
+	cfg := DefaultSettings()
+
+	path := filepath.Join(dir, "config")
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip blank lines and comments.
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		idx := strings.IndexByte(line, '=')
+		if idx <= 0 {
+			continue
+		}
+
+		key := strings.TrimSpace(line[:idx])
+		value := strings.TrimSpace(line[idx+1:])
+		if value == "" {
+			continue
+		}
+
+		cfg.apply(key, value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// apply sets a single key, ignoring unknown keys and invalid values.
+func (s *Settings) apply(key, value string) {
+	switch key {
+	case "scoring.model":
+		if value == ScoringModelBuckets || value == ScoringModelDecay {
+			s.ScoringModel = value
+		}
+	case "scoring.half_life_days":
+		if days, err := strconv.ParseFloat(value, 64); err == nil && days > 0 {
+			s.HalfLifeDays = days
+		}
+	case "watch.proc_sampling":
+		if on, err := strconv.ParseBool(value); err == nil {
+			s.ProcSampling = on
+		}
+	case "watch.proc_interval":
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			s.ProcSampleInterval = d
+		}
+	case "log.max_size":
+		if size, ok := parseSize(value); ok {
+			s.LogMaxSize = size
+		}
+	case "log.max_age_days":
+		if days, err := strconv.Atoi(value); err == nil && days >= 0 {
+			s.LogMaxAgeDays = days
+		}
+	case "log.archive":
+		if on, err := strconv.ParseBool(value); err == nil {
+			s.LogArchive = on
+		}
+	case "projects.dirs":
+		s.ProjectDirs = splitPaths(value)
+	case "projects.track_cwd":
+		if on, err := strconv.ParseBool(value); err == nil {
+			s.TrackCwd = on
+		}
+	case "brew.prefixes":
+		s.BrewPrefixes = splitPaths(value)
+	case "runtimes.roots":
+		s.RuntimeRoots = splitPaths(value)
+	case "runtimes.max_depth":
+		if depth, err := strconv.Atoi(value); err == nil && depth > 0 {
+			s.RuntimeMaxDepth = depth
+		}
+	case "runtimes.script_dirs":
+		s.RuntimeScriptDirs = splitPaths(value)
+	}
+}
+
+// splitPaths splits a comma-separated list of paths, expanding "~" and
+// dropping empty entries.
+func splitPaths(value string) []string {
+	var paths []string
+	for _, path := range strings.Split(value, ",") {
+		if path = expandHome(strings.TrimSpace(path)); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// parseSize parses a byte count with an optional KB, MB or GB suffix
+// (binary multiples, case-insensitive), e.g. "512KB" or "10MB".
+func parseSize(value string) (int64, bool) {
+	v := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		factor int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(v, unit.suffix) {
+			v = strings.TrimSpace(strings.TrimSuffix(v, unit.suffix))
+			multiplier = unit.factor
+			break
+		}
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n * multiplier, true
+}
+
+// expandHome replaces a leading "~" with the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadSettings_FileNotFound(t *testing.T) {
+	cfg, err := LoadSettings(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadSettings() returned error for missing file: %v", err)
+	}
+	if cfg.ScoringModel != ScoringModelBuckets {
+		t.Errorf("ScoringModel = %q, want %q", cfg.ScoringModel, ScoringModelBuckets)
+	}
+	if cfg.HalfLifeDays != DefaultHalfLifeDays {
+		t.Errorf("HalfLifeDays = %v, want %v", cfg.HalfLifeDays, DefaultHalfLifeDays)
+	}
+}
+
+func TestLoadSettings_ScoringKeys(t *testing.T) {
+	dir := t.TempDir()
+	content := `# scoring
+scoring.model = decay
+scoring.half_life_days = 30
+unknown.key = whatever
+`
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cfg, err := LoadSettings(dir)
+	if err != nil {
+		t.Fatalf("LoadSettings() error: %v", err)
+	}
+	if cfg.ScoringModel != ScoringModelDecay {
+		t.Errorf("ScoringModel = %q, want %q", cfg.ScoringModel, ScoringModelDecay)
+	}
+	if cfg.HalfLifeDays != 30 {
+		t.Errorf("HalfLifeDays = %v, want 30", cfg.HalfLifeDays)
+	}
+}
+
+func TestLoadSettings_InvalidValuesKeepDefaults(t *testing.T) {
+	dir := t.TempDir()
+	content := `scoring.model = magic
+scoring.half_life_days = -3
+scoring.half_life_days = soon
+no-equals-sign
+`
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cfg, err := LoadSettings(dir)
+	if err != nil {
+		t.Fatalf("LoadSettings() error: %v", err)
+	}
+	if cfg.ScoringModel != ScoringModelBuckets {
+		t.Errorf("ScoringModel = %q, want default %q", cfg.ScoringModel, ScoringModelBuckets)
+	}
+	if cfg.HalfLifeDays != DefaultHalfLifeDays {
+		t.Errorf("HalfLifeDays = %v, want default %v", cfg.HalfLifeDays, DefaultHalfLifeDays)
+	}
+}
+
+func TestLoadSettings_WatchKeys(t *testing.T) {
+	dir := t.TempDir()
+	content := `watch.proc_sampling = true
+watch.proc_interval = 500ms
+`
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cfg, err := LoadSettings(dir)
+	if err != nil {
+		t.Fatalf("LoadSettings() error: %v", err)
+	}
+	if !cfg.ProcSampling {
+		t.Error("ProcSampling = false, want true")
+	}
+	if cfg.ProcSampleInterval != 500*time.Millisecond {
+		t.Errorf("ProcSampleInterval = %v, want 500ms", cfg.ProcSampleInterval)
+	}
+}
+
+func TestLoadSettings_ProjectDirs(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", "/home/tester")
+	content := "projects.dirs = ~/src, /work ,, ~\n"
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cfg, err := LoadSettings(dir)
+	if err != nil {
+		t.Fatalf("LoadSettings() error: %v", err)
+	}
+	want := []string{"/home/tester/src", "/work", "/home/tester"}
+	if len(cfg.ProjectDirs) != len(want) {
+		t.Fatalf("ProjectDirs = %v, want %v", cfg.ProjectDirs, want)
+	}
+	for i := range want {
+		if cfg.ProjectDirs[i] != want[i] {
+			t.Errorf("ProjectDirs[%d] = %q, want %q", i, cfg.ProjectDirs[i], want[i])
+		}
+	}
+}
+
+func TestLoadSettings_BrewPrefixes(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", "/home/tester")
+	content := "brew.prefixes = /opt/homebrew, /usr/local, ~/.linuxbrew\n"
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cfg, err := LoadSettings(dir)
+	if err != nil {
+		t.Fatalf("LoadSettings() error: %v", err)
+	}
+	want := []string{"/opt/homebrew", "/usr/local", "/home/tester/.linuxbrew"}
+	if len(cfg.BrewPrefixes) != len(want) {
+		t.Fatalf("BrewPrefixes = %v, want %v", cfg.BrewPrefixes, want)
+	}
+	for i := range want {
+		if cfg.BrewPrefixes[i] != want[i] {
+			t.Errorf("BrewPrefixes[%d] = %q, want %q", i, cfg.BrewPrefixes[i], want[i])
+		}
+	}
+}
+
+func TestLoadSettings_TrackCwd(t *testing.T) {
+	if DefaultSettings().TrackCwd {
+		t.Error("DefaultSettings().TrackCwd = true, want off by default")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte("projects.track_cwd = true\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cfg, err := LoadSettings(dir)
+	if err != nil {
+		t.Fatalf("LoadSettings() error: %v", err)
+	}
+	if !cfg.TrackCwd {
+		t.Error("TrackCwd = false, want true")
+	}
+}
+
+func TestLoadSettings_RuntimeKeys(t *testing.T) {
+	if got := DefaultSettings().RuntimeMaxDepth; got != DefaultRuntimeMaxDepth {
+		t.Errorf("DefaultSettings().RuntimeMaxDepth = %d, want %d", got, DefaultRuntimeMaxDepth)
+	}
+
+	dir := t.TempDir()
+	t.Setenv("HOME", "/home/tester")
+	content := `runtimes.roots = ~/src, /srv/envs
+runtimes.max_depth = 3
+runtimes.script_dirs = ~/bin
+`
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cfg, err := LoadSettings(dir)
+	if err != nil {
+		t.Fatalf("LoadSettings() error: %v", err)
+	}
+	if len(cfg.RuntimeRoots) != 2 || cfg.RuntimeRoots[0] != "/home/tester/src" || cfg.RuntimeRoots[1] != "/srv/envs" {
+		t.Errorf("RuntimeRoots = %v, want [/home/tester/src /srv/envs]", cfg.RuntimeRoots)
+	}
+	if cfg.RuntimeMaxDepth != 3 {
+		t.Errorf("RuntimeMaxDepth = %d, want 3", cfg.RuntimeMaxDepth)
+	}
+	if len(cfg.RuntimeScriptDirs) != 1 || cfg.RuntimeScriptDirs[0] != "/home/tester/bin" {
+		t.Errorf("RuntimeScriptDirs = %v, want [/home/tester/bin]", cfg.RuntimeScriptDirs)
+	}
+}
+
+func TestLoadSettings_LogKeys(t *testing.T) {
+	dir := t.TempDir()
+	content := `log.max_size = 512KB
+log.max_age_days = 0
+log.archive = true
+`
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cfg, err := LoadSettings(dir)
+	if err != nil {
+		t.Fatalf("LoadSettings() error: %v", err)
+	}
+	if cfg.LogMaxSize != 512<<10 {
+		t.Errorf("LogMaxSize = %d, want %d", cfg.LogMaxSize, 512<<10)
+	}
+	if cfg.LogMaxAgeDays != 0 {
+		t.Errorf("LogMaxAgeDays = %d, want 0", cfg.LogMaxAgeDays)
+	}
+	if !cfg.LogArchive {
+		t.Error("LogArchive = false, want true")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"0":     0,
+		"100":   100,
+		"100b":  100,
+		"10MB":  10 << 20,
+		"2 gb":  2 << 30,
+		"64kb":  64 << 10,
+		" 1MB ": 1 << 20,
+	}
+	for in, want := range tests {
+		got, ok := parseSize(in)
+		if !ok || got != want {
+			t.Errorf("parseSize(%q) = %d, %v; want %d, true", in, got, ok, want)
+		}
+	}
+	for _, in := range []string{"", "MB", "-1", "ten", "1TB"} {
+		if _, ok := parseSize(in); ok {
+			t.Errorf("parseSize(%q) succeeded, want failure", in)
+		}
+	}
+}
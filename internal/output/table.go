@@ -54,12 +54,18 @@ func RenderPackageTable(packages []*brew.Package) string {
 		return sorted[i].Name < sorted[j].Name
 	})
 
+	prefixes := make([]string, len(sorted))
+	for i, pkg := range sorted {
+		prefixes[i] = pkg.Prefix
+	}
+	showPrefix := multiplePrefixes(prefixes)
+
 	var sb strings.Builder
 
 	// Header — Version column removed (pkg.Version is never populated from Homebrew metadata)
-	fmt.Fprintf(&sb, "%-20s %-8s %-13s %-13s\n",
-		"Package", "Size", "Installed", "Last Used")
-	sb.WriteString(strings.Repeat("─", 60))
+	fmt.Fprintf(&sb, "%-20s %s%-8s %-13s %-13s\n",
+		"Package", prefixCell("Prefix", showPrefix), "Size", "Installed", "Last Used")
+	sb.WriteString(strings.Repeat("─", 60+prefixCellWidth(showPrefix)))
 	sb.WriteString("\n")
 
 	// Rows
@@ -68,8 +74,9 @@ func RenderPackageTable(packages []*brew.Package) string {
 		installed := formatRelativeTime(pkg.InstalledAt)
 		lastUsed := "never" // Default, will be overridden by analyzer data
 
-		fmt.Fprintf(&sb, "%-20s %-8s %-13s %-13s\n",
+		fmt.Fprintf(&sb, "%-20s %s%-8s %-13s %-13s\n",
 			truncate(pkg.Name, 20),
+			prefixCell(pkg.Prefix, showPrefix),
 			size,
 			installed,
 			lastUsed)
@@ -99,6 +106,13 @@ func RenderConfidenceTable(scores []ConfidenceScore, hasUsageData bool) string {
 		}
 	}
 
+	// Show which Homebrew prefix each package lives in when there are several
+	prefixes := make([]string, len(scores))
+	for i, score := range scores {
+		prefixes[i] = score.Prefix
+	}
+	showPrefix := multiplePrefixes(prefixes)
+
 	var sb strings.Builder
 
 	// Header — use "Installed" column header when showInstalled is true
@@ -106,9 +120,9 @@ func RenderConfidenceTable(scores []ConfidenceScore, hasUsageData bool) string {
 	if showInstalled {
 		timeColHeader = "Installed"
 	}
-	fmt.Fprintf(&sb, "%-16s %-8s %-7s %-10s %-16s %-13s %s\n",
-		"Package", "Size", "Score", "Uses (7d)", timeColHeader, "Depended On", "Status")
-	sb.WriteString(strings.Repeat("─", 88))
+	fmt.Fprintf(&sb, "%-16s %s%-8s %-7s %-10s %-16s %-13s %s\n",
+		"Package", prefixCell("Prefix", showPrefix), "Size", "Score", "Uses (7d)", timeColHeader, "Depended On", "Status")
+	sb.WriteString(strings.Repeat("─", 88+prefixCellWidth(showPrefix)))
 	sb.WriteString("\n")
 
 	// Rows
@@ -120,11 +134,16 @@ func RenderConfidenceTable(scores []ConfidenceScore, hasUsageData bool) string {
 		// For risky/critical packages, show "⚠ risky" instead of tier name
 		tierLabel := formatTierLabel(score.Tier, score.IsCritical)
 		tierColor := getTierColor(score.Tier)
+		labels := ""
+		if len(score.Labels) > 0 {
+			labels = "  " + strings.Join(score.Labels, "  ")
+		}
 
-		// Cask packages show "n/a" for usage columns (shim tracking not applicable)
+		// Casks show "n/a" for usage columns unless their app launches or
+		// command-line binaries are tracked
 		var usesStr string
 		var timeCol string
-		if score.IsCask {
+		if score.IsCask && !score.UsageTracked {
 			usesStr = "n/a"
 			timeCol = "n/a"
 		} else if showInstalled {
@@ -145,8 +164,9 @@ func RenderConfidenceTable(scores []ConfidenceScore, hasUsageData bool) string {
 		}
 
 		if IsColorEnabled() {
-			fmt.Fprintf(&sb, "%-16s %-8s %-7s %-10s %-16s %-13s %s%s%s\n",
+			fmt.Fprintf(&sb, "%-16s %s%-8s %-7s %-10s %-16s %-13s %s%s%s%s\n",
 				truncate(score.Package, 16),
+				prefixCell(score.Prefix, showPrefix),
 				size,
 				scoreStr,
 				usesStr,
@@ -154,22 +174,62 @@ func RenderConfidenceTable(scores []ConfidenceScore, hasUsageData bool) string {
 				depStr,
 				tierColor,
 				tierLabel,
-				colorReset)
+				colorReset,
+				labels)
 		} else {
-			fmt.Fprintf(&sb, "%-16s %-8s %-7s %-10s %-16s %-13s %s\n",
+			fmt.Fprintf(&sb, "%-16s %s%-8s %-7s %-10s %-16s %-13s %s%s\n",
 				truncate(score.Package, 16),
+				prefixCell(score.Prefix, showPrefix),
 				size,
 				scoreStr,
 				usesStr,
 				timeCol,
 				depStr,
-				tierLabel)
+				tierLabel,
+				labels)
 		}
 	}
 
 	return sb.String()
 }
 
+// prefixColumnWidth is the width of the optional Prefix table column.
+const prefixColumnWidth = 16
+
+// multiplePrefixes reports whether prefixes holds more than one distinct
+// non-empty Homebrew prefix, in which case tables get a Prefix column.
+func multiplePrefixes(prefixes []string) bool {
+	first := ""
+	for _, p := range prefixes {
+		if p == "" {
+			continue
+		}
+		if first == "" {
+			first = p
+		} else if p != first {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixCell formats value as a Prefix column cell, including the trailing
+// separator, or returns "" when the column is hidden.
+func prefixCell(value string, show bool) string {
+	if !show {
+		return ""
+	}
+	return fmt.Sprintf("%-*s ", prefixColumnWidth, truncate(value, prefixColumnWidth))
+}
+
+// prefixCellWidth returns the width prefixCell adds to a table row.
+func prefixCellWidth(show bool) int {
+	if !show {
+		return 0
+	}
+	return prefixColumnWidth + 1
+}
+
 // formatDepCount formats reverse dependency count for display.
 func formatDepCount(count int) string {
 	if count == 0 {
@@ -214,6 +274,14 @@ type VerboseScore struct {
 		AgeDetail   string
 		TypeDetail  string
 	}
+	Adjustments []VerboseAdjustment
+}
+
+// VerboseAdjustment mirrors analyzer.ScoreAdjustment: a cap or hold applied
+// to the summed score components.
+type VerboseAdjustment struct {
+	Points int
+	Reason string
 }
 
 // RenderConfidenceTableVerbose renders a detailed table showing score breakdown.
@@ -250,6 +318,9 @@ func RenderConfidenceTableVerbose(scores []VerboseScore) string {
 		if score.IsCritical {
 			sb.WriteString("  Critical:     YES      - capped at 70 (core system dependency)\n")
 		}
+		for _, adj := range score.Adjustments {
+			fmt.Fprintf(&sb, "  Adjustment:  %+3d pts - %s\n", adj.Points, adj.Reason)
+		}
 
 		sb.WriteString("\nReason: " + score.Reason + "\n")
 		sb.WriteString(strings.Repeat("─", 72))
@@ -313,6 +384,32 @@ func RenderUsageTable(stats map[string]UsageStats) string {
 	return sb.String()
 }
 
+// RenderBinaryTable renders per-binary usage for a single package.
+// Rows are rendered in the order given; callers sort by usage.
+func RenderBinaryTable(binaries []BinaryStats) string {
+	if len(binaries) == 0 {
+		return "No binaries recorded for this package.\n"
+	}
+
+	var sb strings.Builder
+
+	// Header
+	fmt.Fprintf(&sb, "%-24s %-10s %s\n", "Binary", "Total Runs", "Last Used")
+	sb.WriteString(strings.Repeat("─", 50))
+	sb.WriteString("\n")
+
+	// Rows
+	for _, b := range binaries {
+		lastUsed := "never"
+		if !b.LastUsed.IsZero() {
+			lastUsed = formatRelativeTime(b.LastUsed)
+		}
+		fmt.Fprintf(&sb, "%-24s %-10d %s\n", truncate(b.Name, 24), b.TotalRuns, lastUsed)
+	}
+
+	return sb.String()
+}
+
 // RenderSnapshotTable renders a table of snapshots.
 func RenderSnapshotTable(snapshots []*store.Snapshot) string {
 	if len(snapshots) == 0 {
@@ -475,17 +572,20 @@ func truncate(s string, maxLen int) string {
 // ConfidenceScore represents a package's confidence score for removal.
 // This is a placeholder definition - the actual type will come from analyzer package.
 type ConfidenceScore struct {
-	Package     string
-	Score       int
-	Tier        string // "safe", "medium", "risky"
-	LastUsed    time.Time
-	Reason      string
-	SizeBytes   int64     // Package size in bytes
-	Uses7d      int       // Usage count in the last 7 days
-	DepCount    int       // Number of reverse dependencies (packages depending on this one)
-	IsCritical  bool      // True if package is a core dependency
-	IsCask      bool      // True if package is a cask (GUI app)
-	InstalledAt time.Time // Non-zero signals "show Installed column"; set when sort=age
+	Package      string
+	Score        int
+	Tier         string // "safe", "medium", "risky"
+	LastUsed     time.Time
+	Reason       string
+	SizeBytes    int64     // Package size in bytes
+	Uses7d       int       // Usage count in the last 7 days
+	DepCount     int       // Number of reverse dependencies (packages depending on this one)
+	IsCritical   bool      // True if package is a core dependency
+	IsCask       bool      // True if package is a cask (GUI app)
+	UsageTracked bool      // True for a cask whose app launches (or, without apps, binaries) are tracked
+	InstalledAt  time.Time // Non-zero signals "show Installed column"; set when sort=age
+	Labels       []string  // Extra status markers shown after the tier, e.g. "PERIODIC — next expected ~2026-12-01"
+	Prefix       string    // Homebrew prefix the package is installed in; shown when scores span several
 }
 
 // TierStats holds aggregated statistics for a confidence tier.
@@ -503,6 +603,15 @@ type UsageStats struct {
 	Trend     string // "increasing", "stable", "decreasing"
 }
 
+// BinaryStats represents usage statistics for a single binary of a package.
+// This mirrors analyzer.BinaryStats but is defined here to avoid circular
+// dependencies.
+type BinaryStats struct {
+	Name      string
+	TotalRuns int
+	LastUsed  time.Time
+}
+
 // RenderTierSummary renders a colored one-line tier breakdown header.
 // Format: "SAFE: 5 packages (43 MB) · MEDIUM: 19 (186 MB) · RISKY: 143 (hidden, use --all)"
 // When showAll is true, risky shows its size instead of "hidden".
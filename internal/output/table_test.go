@@ -651,6 +651,31 @@ func TestRenderConfidenceTable_CaskDisplay(t *testing.T) {
 	}
 }
 
+func TestRenderConfidenceTable_CaskLaunchTracked(t *testing.T) {
+	scores := []ConfidenceScore{
+		{
+			Package:      "firefox",
+			Score:        40,
+			Tier:         "risky",
+			LastUsed:     time.Now().Add(-48 * time.Hour),
+			SizeBytes:    209715200, // 200 MB
+			Uses7d:       5,
+			IsCask:       true,
+			UsageTracked: true,
+		},
+	}
+
+	result := RenderConfidenceTable(scores, true)
+
+	// Tracked casks show their recorded launches like formulae
+	if strings.Contains(result, "n/a") {
+		t.Errorf("tracked cask should not show 'n/a', got:\n%s", result)
+	}
+	if !strings.Contains(result, "2 days ago") {
+		t.Errorf("expected relative last launch time for tracked cask, got:\n%s", result)
+	}
+}
+
 func TestRenderTierSummary_ShowAll(t *testing.T) {
 	safe := TierStats{Count: 5, SizeBytes: 45088768}      // ~43 MB
 	medium := TierStats{Count: 19, SizeBytes: 195035136}  // ~186 MB
@@ -904,6 +929,44 @@ func TestRenderPackageTable_NoVersionColumn(t *testing.T) {
 	}
 }
 
+func TestRenderPackageTable_PrefixColumn(t *testing.T) {
+	single := []*brew.Package{
+		{Name: "wget", Prefix: "/opt/homebrew"},
+		{Name: "jq", Prefix: "/opt/homebrew"},
+	}
+	if result := RenderPackageTable(single); strings.Contains(result, "Prefix") {
+		t.Errorf("RenderPackageTable() with one prefix should not show a Prefix column, got:\n%s", result)
+	}
+
+	mixed := append(single, &brew.Package{Name: "gcc", Prefix: "/usr/local"})
+	result := RenderPackageTable(mixed)
+	for _, want := range []string{"Prefix", "/opt/homebrew", "/usr/local"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("RenderPackageTable() with two prefixes missing %q, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestRenderConfidenceTable_PrefixColumn(t *testing.T) {
+	scores := []ConfidenceScore{
+		{Package: "gcc", Score: 80, Tier: "safe", Prefix: "/opt/homebrew"},
+		{Package: "gcc@13", Score: 85, Tier: "safe", Prefix: "/usr/local"},
+	}
+	result := RenderConfidenceTable(scores, true)
+	lines := strings.Split(result, "\n")
+	if !strings.Contains(lines[0], "Prefix") {
+		t.Errorf("header = %q, want a Prefix column", lines[0])
+	}
+	if !strings.Contains(result, "/usr/local") {
+		t.Errorf("RenderConfidenceTable() missing /usr/local, got:\n%s", result)
+	}
+
+	scores[1].Prefix = "/opt/homebrew"
+	if result := RenderConfidenceTable(scores, true); strings.Contains(result, "Prefix") {
+		t.Errorf("RenderConfidenceTable() with one prefix should not show a Prefix column, got:\n%s", result)
+	}
+}
+
 // TestRenderUsageTable_SortedByRunsThenLastUsed verifies that packages with
 // equal TotalRuns are ordered by LastUsed descending, with zero times last.
 func TestRenderUsageTable_SortedByRunsThenLastUsed(t *testing.T) {
@@ -1111,3 +1174,42 @@ func TestRenderConfidenceTable_NoUsageData(t *testing.T) {
 		t.Errorf("expected wget line to contain 'never' when hasUsageData=true, got:\n%s", wgetLine2)
 	}
 }
+
+func TestRenderBinaryTable(t *testing.T) {
+	now := time.Now()
+
+	out := RenderBinaryTable(nil)
+	if !strings.Contains(out, "No binaries") {
+		t.Errorf("expected empty message, got %q", out)
+	}
+
+	out = RenderBinaryTable([]BinaryStats{
+		{Name: "psql", TotalRuns: 42, LastUsed: now.Add(-2 * time.Hour)},
+		{Name: "pg_dump", TotalRuns: 0},
+	})
+	for _, want := range []string{"Binary", "psql", "42", "2 hours ago", "pg_dump", "never"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "psql") > strings.Index(out, "pg_dump") {
+		t.Error("expected rows in the order given")
+	}
+}
+
+func TestRenderConfidenceTable_Labels(t *testing.T) {
+	scores := []ConfidenceScore{
+		{Package: "certbot", Score: 79, Tier: "medium", Labels: []string{"PERIODIC — next expected ~2026-11-01"}},
+		{Package: "jq", Score: 90, Tier: "safe"},
+	}
+
+	out := RenderConfidenceTable(scores, true)
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "certbot") && !strings.Contains(line, "~ medium  PERIODIC — next expected ~2026-11-01") {
+			t.Errorf("certbot row missing label: %q", line)
+		}
+		if strings.HasPrefix(line, "jq") && strings.Contains(line, "PERIODIC") {
+			t.Errorf("jq row should not carry a label: %q", line)
+		}
+	}
+}
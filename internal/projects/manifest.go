@@ -0,0 +1,471 @@
+package projects
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxManifestSize skips large files (generated code, data).
+const maxManifestSize = 1 << 20
+
+// Kinds of declared requirement recorded in Requirement.Kind.
+const (
+	// KindFormula is a formula named in a Brewfile.
+	KindFormula = "formula"
+
+	// KindCask is a cask named in a Brewfile.
+	KindCask = "cask"
+
+	// KindTool is a tool pinned in .tool-versions or mise.toml. It names a
+	// formula or a command.
+	KindTool = "tool"
+
+	// KindCommand is a command run by a Makefile recipe, package.json
+	// script, pre-commit hook or shell script.
+	KindCommand = "command"
+)
+
+// Requirement is a tool a project declares it needs, found in one of its
+// manifests or scripts.
+type Requirement struct {
+	Project  string // Project root directory
+	Kind     string // KindFormula, KindCask, KindTool or KindCommand
+	Name     string // Formula, cask, tool or command name
+	Source   string // Manifest type, e.g. "Brewfile" or "Makefile"
+	Location string // "file:line"
+}
+
+// declaration is one requirement found in a manifest, before it is tied to
+// a project and file.
+type declaration struct {
+	kind string
+	name string
+	line int
+}
+
+// manifestParser extracts the declarations from a manifest's contents.
+type manifestParser func(data []byte) []declaration
+
+// manifestSource returns the manifest type of the file at path and its
+// parser, or "" when the file is not a recognised manifest or script.
+func manifestSource(path string, d fs.DirEntry) (string, manifestParser) {
+	name := d.Name()
+	switch name {
+	case "Brewfile", ".Brewfile":
+		return "Brewfile", parseBrewfile
+	case ".tool-versions":
+		return ".tool-versions", parseToolVersions
+	case "mise.toml", ".mise.toml":
+		return "mise.toml", parseMiseToml
+	case "Makefile", "makefile", "GNUmakefile":
+		return "Makefile", parseMakefile
+	case "package.json":
+		return "package.json", parsePackageJSON
+	case ".pre-commit-config.yaml", ".pre-commit-config.yml":
+		return ".pre-commit-config.yaml", parsePreCommitConfig
+	}
+	switch filepath.Ext(name) {
+	case ".mk":
+		return "Makefile", parseMakefile
+	case ".sh", ".bash", ".zsh", ".fish":
+		return "script", parseShellScript
+	}
+	if isShebangScript(path, d) {
+		return "script", parseShellScript
+	}
+	return "", nil
+}
+
+// ScanManifests returns the requirements declared by the manifests and
+// scripts under each of dirs, up to MaxDepth levels deep: Brewfile
+// entries, .tool-versions and mise.toml tools, and the commands run by
+// Makefile recipes, package.json scripts, system pre-commit hooks and shell
+// scripts. Each requirement is attributed to the project enclosing its file
+// (see Root), or to the scanned directory itself when none does.
+// Unreadable files and directories are skipped.
+func ScanManifests(dirs []string) []Requirement {
+	var reqs []Requirement
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		reqs = append(reqs, scanManifestDir(dir)...)
+	}
+	return reqs
+}
+
+// scanManifestDir scans one directory for ScanManifests.
+func scanManifestDir(dir string) []Requirement {
+	// A directory that is itself a repository is one project; otherwise
+	// its children are, as for a configured project directory.
+	projectDirs := []string{dir}
+	if isVCSRoot(dir) {
+		projectDirs = nil
+	}
+
+	var reqs []Requirement
+	WalkProject(dir, func(path string, d fs.DirEntry) {
+		if !d.Type().IsRegular() {
+			return
+		}
+
+		source, parse := manifestSource(path, d)
+		if parse == nil {
+			return
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxManifestSize {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+
+		project := Root(filepath.Dir(path), projectDirs)
+		if project == "" {
+			project = dir
+		}
+		for _, decl := range parse(data) {
+			reqs = append(reqs, Requirement{
+				Project:  project,
+				Kind:     decl.kind,
+				Name:     decl.name,
+				Source:   source,
+				Location: fmt.Sprintf("%s:%d", path, decl.line),
+			})
+		}
+	})
+	return reqs
+}
+
+// isShebangScript reports whether a file is executable and starts with a
+// shell interpreter line.
+func isShebangScript(path string, d fs.DirEntry) bool {
+	first, ok := Shebang(path, d)
+	if !ok {
+		return false
+	}
+	for _, shell := range []string{"sh", "bash", "zsh", "fish", "dash", "ksh"} {
+		if strings.HasSuffix(first, "/"+shell) || strings.HasSuffix(first, " "+shell) {
+			return true
+		}
+	}
+	return false
+}
+
+// eachLine calls fn with every line of data and its 1-based number.
+func eachLine(data []byte, fn func(line string, n int)) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), maxManifestSize)
+	n := 0
+	for scanner.Scan() {
+		n++
+		fn(scanner.Text(), n)
+	}
+}
+
+// brewfileEntry matches `brew "name"` and `cask "name"` Brewfile lines.
+var brewfileEntry = regexp.MustCompile(`^\s*(brew|cask)\s+["']([^"']+)["']`)
+
+// parseBrewfile returns the formulae and casks a Brewfile installs. Tapped
+// names ("owner/tap/name") are reduced to the formula name.
+func parseBrewfile(data []byte) []declaration {
+	var decls []declaration
+	eachLine(data, func(line string, n int) {
+		m := brewfileEntry.FindStringSubmatch(line)
+		if m == nil {
+			return
+		}
+		kind := KindFormula
+		if m[1] == "cask" {
+			kind = KindCask
+		}
+		decls = append(decls, declaration{kind: kind, name: filepath.Base(m[2]), line: n})
+	})
+	return decls
+}
+
+// toolAliases maps asdf and mise plugin names to the command they provide,
+// where the two differ.
+var toolAliases = map[string]string{
+	"nodejs": "node",
+	"golang": "go",
+	"python": "python3",
+}
+
+// toolName normalises a .tool-versions or mise.toml tool name. Tools from
+// non-default mise backends (npm:, cargo:, pipx:, ...) are not Homebrew's,
+// and yield "".
+func toolName(name string) string {
+	if backend, rest, ok := strings.Cut(name, ":"); ok {
+		if backend != "core" && backend != "asdf" {
+			return ""
+		}
+		name = rest
+	}
+	if alias, ok := toolAliases[name]; ok {
+		return alias
+	}
+	if !validCommandName(name) {
+		return ""
+	}
+	return name
+}
+
+// parseToolVersions returns the tools an asdf .tool-versions file pins.
+func parseToolVersions(data []byte) []declaration {
+	var decls []declaration
+	eachLine(data, func(line string, n int) {
+		line, _, _ = strings.Cut(line, "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return
+		}
+		if name := toolName(fields[0]); name != "" {
+			decls = append(decls, declaration{kind: KindTool, name: name, line: n})
+		}
+	})
+	return decls
+}
+
+// parseMiseToml returns the tools in a mise.toml [tools] table.
+func parseMiseToml(data []byte) []declaration {
+	var decls []declaration
+	inTools := false
+	eachLine(data, func(line string, n int) {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			inTools = line == "[tools]"
+			return
+		}
+		if !inTools || strings.HasPrefix(line, "#") {
+			return
+		}
+		key, _, ok := strings.Cut(line, "=")
+		if !ok {
+			return
+		}
+		if name := toolName(strings.Trim(strings.TrimSpace(key), `"'`)); name != "" {
+			decls = append(decls, declaration{kind: KindTool, name: name, line: n})
+		}
+	})
+	return decls
+}
+
+// makeShellCall matches $(shell ...) function calls in a Makefile.
+var makeShellCall = regexp.MustCompile(`\$\(shell\s+([^)]*)\)`)
+
+// makeReference matches make variable references and escaped dollars.
+var makeReference = regexp.MustCompile(`\$\$|\$\([^)]*\)|\$\{[^}]*\}|\$.`)
+
+// expandMakeReferences prepares a recipe line for the shell the way make
+// would: variables become an opaque "$v" and "$$" a literal "$".
+func expandMakeReferences(recipe string) string {
+	return makeReference.ReplaceAllStringFunc(recipe, func(ref string) string {
+		if ref == "$$" {
+			return "$"
+		}
+		return "$v"
+	})
+}
+
+// parseMakefile returns the commands run by a Makefile's recipes and
+// $(shell ...) calls.
+func parseMakefile(data []byte) []declaration {
+	var decls []declaration
+	eachLine(data, func(line string, n int) {
+		for _, m := range makeShellCall.FindAllStringSubmatch(line, -1) {
+			decls = appendCommands(decls, expandMakeReferences(m[1]), n)
+		}
+		if !strings.HasPrefix(line, "\t") {
+			return
+		}
+		recipe := strings.TrimLeft(strings.TrimSpace(line), "@-+")
+		recipe = makeShellCall.ReplaceAllString(recipe, "$v")
+		decls = appendCommands(decls, expandMakeReferences(recipe), n)
+	})
+	return decls
+}
+
+// parsePackageJSON returns the commands run by a package.json's scripts.
+func parsePackageJSON(data []byte) []declaration {
+	var manifest struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(manifest.Scripts))
+	for name := range manifest.Scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	scripts := bytes.Index(data, []byte(`"scripts"`))
+	var decls []declaration
+	for _, name := range names {
+		key, _ := json.Marshal(name)
+		line := 1
+		if i := bytes.Index(data[max(scripts, 0):], key); i >= 0 {
+			line += bytes.Count(data[:max(scripts, 0)+i], []byte("\n"))
+		}
+		decls = appendCommands(decls, manifest.Scripts[name], line)
+	}
+	return decls
+}
+
+// preCommitHookField matches "entry:" and "language:" keys of a pre-commit
+// hook, and the "- id:" or "- repo:" key that starts a new list item.
+var preCommitHookField = regexp.MustCompile(`^\s*(-\s+)?(id|repo|entry|language)\s*:\s*(.*?)\s*$`)
+
+// parsePreCommitConfig returns the pre-commit command itself and the
+// commands run by hooks with a system language. Hooks in other languages
+// run tools pre-commit installs in its own environments.
+func parsePreCommitConfig(data []byte) []declaration {
+	decls := []declaration{{kind: KindCommand, name: "pre-commit", line: 1}}
+
+	var entry, language string
+	entryLine := 0
+	flush := func() {
+		if entry != "" && (language == "system" || language == "unsupported") {
+			decls = appendCommands(decls, entry, entryLine)
+		}
+		entry, language, entryLine = "", "", 0
+	}
+	eachLine(data, func(line string, n int) {
+		m := preCommitHookField.FindStringSubmatch(line)
+		if m == nil {
+			return
+		}
+		if m[1] != "" {
+			flush()
+		}
+		value := strings.Trim(m[3], `"'`)
+		switch m[2] {
+		case "entry":
+			entry, entryLine = value, n
+		case "language":
+			language = value
+		}
+	})
+	flush()
+	return decls
+}
+
+// parseShellScript returns the commands a shell script runs.
+func parseShellScript(data []byte) []declaration {
+	var decls []declaration
+	eachLine(data, func(line string, n int) {
+		decls = appendCommands(decls, line, n)
+	})
+	return decls
+}
+
+// appendCommands appends a KindCommand declaration for each command run by
+// a line of shell.
+func appendCommands(decls []declaration, line string, n int) []declaration {
+	for _, name := range commandNames(line) {
+		decls = append(decls, declaration{kind: KindCommand, name: name, line: n})
+	}
+	return decls
+}
+
+// commandSeparator splits a line of shell into simple commands.
+var commandSeparator = regexp.MustCompile("&&|\\|\\||[;|&()`]")
+
+// envAssignment matches a leading NAME=value assignment.
+var envAssignment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// commandWrappers run the command that follows them; the wrapped command is
+// the one reported.
+var commandWrappers = map[string]bool{
+	"sudo": true, "env": true, "exec": true, "command": true, "builtin": true,
+	"time": true, "nohup": true, "nice": true, "xargs": true, "!": true,
+	"if": true, "then": true, "else": true, "elif": true, "while": true,
+	"until": true, "do": true, "{": true,
+}
+
+// shellBuiltins are shell builtins and keywords. They are never reported,
+// even where a formula such as coreutils ships a binary of the same name.
+var shellBuiltins = map[string]bool{
+	"alias": true, "bg": true, "break": true, "case": true, "cd": true,
+	"continue": true, "declare": true, "done": true, "echo": true, "esac": true,
+	"eval": true, "exit": true, "export": true, "false": true, "fg": true,
+	"fi": true, "for": true, "function": true, "getopts": true, "hash": true,
+	"in": true, "jobs": true, "kill": true, "let": true, "local": true,
+	"printf": true, "pwd": true, "read": true, "readonly": true, "return": true,
+	"select": true, "set": true, "shift": true, "source": true, "test": true,
+	"trap": true, "true": true, "type": true, "typeset": true, "ulimit": true,
+	"umask": true, "unalias": true, "unset": true, "wait": true,
+}
+
+// commandNames returns the names of the commands run by a line of shell,
+// in order. Comments, variables, relative paths and builtins are skipped;
+// absolute paths are reduced to their basename.
+func commandNames(line string) []string {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+	if i := strings.Index(line, " #"); i >= 0 {
+		line = line[:i]
+	}
+
+	var names []string
+	for _, segment := range commandSeparator.Split(line, -1) {
+		if name := segmentCommand(strings.Fields(segment)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// segmentCommand returns the command a simple command runs, or "".
+func segmentCommand(words []string) string {
+	wrapped := false
+	for _, word := range words {
+		word = strings.Trim(word, `"'`)
+		switch {
+		case word == "":
+			continue
+		case envAssignment.MatchString(word):
+			continue
+		case wrapped && strings.HasPrefix(word, "-"):
+			continue // Wrapper flags, e.g. "env -i" or "xargs -n1"
+		}
+		if strings.ContainsAny(word, "$<>*?[=") {
+			return ""
+		}
+		if strings.Contains(word, "/") {
+			if !filepath.IsAbs(word) {
+				return "" // Project-relative script
+			}
+			word = filepath.Base(word)
+		}
+		if commandWrappers[word] {
+			wrapped = true
+			continue
+		}
+		if shellBuiltins[word] || !validCommandName(word) {
+			return ""
+		}
+		return word
+	}
+	return ""
+}
+
+// commandNamePattern matches plausible executable names.
+var commandNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+@-]*$`)
+
+// validCommandName reports whether name could be an installed command.
+func validCommandName(name string) bool {
+	return commandNamePattern.MatchString(name)
+}
@@ -0,0 +1,212 @@
+package projects
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommandNames(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"jq -r .name package.json", []string{"jq"}},
+		{"  terraform fmt && terraform validate", []string{"terraform", "terraform"}},
+		{"curl -s https://x | jq . > out.json", []string{"curl", "jq"}},
+		{"GOOS=linux go build ./...", []string{"go"}},
+		{"sudo env -i PATH=/bin /opt/homebrew/bin/wget -q x", []string{"wget"}},
+		{"if shellcheck *.sh; then echo ok; fi", []string{"shellcheck"}},
+		{"for f in *.json; do jq . $f; done", []string{"jq"}},
+		{"VERSION=$(git describe --tags)", []string{"git"}},
+		{"echo `date`", []string{"date"}},
+		{"./scripts/release.sh && ${GO} build", nil},
+		{"{ gofmt -l .; } | head", []string{"gofmt", "head"}},
+		{"cd web; npm ci", []string{"npm"}},
+		{"# gh release create", nil},
+		{"rg TODO # ripgrep", []string{"rg"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := commandNames(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("commandNames(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestManifestParsers(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse manifestParser
+		data  string
+		want  []declaration
+	}{
+		{
+			name:  "Brewfile",
+			parse: parseBrewfile,
+			data: `tap "hashicorp/tap"
+brew "jq"
+brew "hashicorp/tap/terraform", restart_service: false
+cask "docker"
+mas "Xcode", id: 497799835
+`,
+			want: []declaration{
+				{KindFormula, "jq", 2},
+				{KindFormula, "terraform", 3},
+				{KindCask, "docker", 4},
+			},
+		},
+		{
+			name:  ".tool-versions",
+			parse: parseToolVersions,
+			data:  "nodejs 20.11.0\n# comment\nterraform 1.7.0 1.6.0\ngolang 1.22\nbroken\n",
+			want: []declaration{
+				{KindTool, "node", 1},
+				{KindTool, "terraform", 3},
+				{KindTool, "go", 4},
+			},
+		},
+		{
+			name:  "mise.toml",
+			parse: parseMiseToml,
+			data: `[env]
+NODE_ENV = "production"
+
+[tools]
+python = "3.12"
+"npm:prettier" = "latest"
+"core:node" = "20"
+shellcheck = { version = "0.10" }
+
+[tasks.lint]
+run = "shellcheck *.sh"
+`,
+			want: []declaration{
+				{KindTool, "python3", 5},
+				{KindTool, "node", 7},
+				{KindTool, "shellcheck", 8},
+			},
+		},
+		{
+			name:  "Makefile",
+			parse: parseMakefile,
+			data: "VERSION := $(shell git describe --tags)\n" +
+				"\n" +
+				"lint:\n" +
+				"\t@golangci-lint run ./...\n" +
+				"\t-$(MAKE) -C docs lint\n" +
+				"\tfor f in $$(ls *.md); do markdownlint $$f; done\n" +
+				"release: lint\n" +
+				"\tgoreleaser release --clean\n",
+			want: []declaration{
+				{KindCommand, "git", 1},
+				{KindCommand, "golangci-lint", 4},
+				{KindCommand, "ls", 6},
+				{KindCommand, "markdownlint", 6},
+				{KindCommand, "goreleaser", 8},
+			},
+		},
+		{
+			name:  "package.json",
+			parse: parsePackageJSON,
+			data: `{
+  "name": "web",
+  "scripts": {
+    "build": "vite build",
+    "deploy": "npm run build && aws s3 sync dist s3://bucket"
+  }
+}
+`,
+			want: []declaration{
+				{KindCommand, "vite", 4},
+				{KindCommand, "npm", 5},
+				{KindCommand, "aws", 5},
+			},
+		},
+		{
+			name:  ".pre-commit-config.yaml",
+			parse: parsePreCommitConfig,
+			data: `repos:
+  - repo: https://github.com/psf/black
+    rev: 24.1.0
+    hooks:
+      - id: black
+  - repo: local
+    hooks:
+      - id: shellcheck
+        name: shellcheck
+        entry: shellcheck
+        language: system
+      - id: lint
+        entry: "./scripts/lint.sh"
+        language: script
+`,
+			want: []declaration{
+				{KindCommand, "pre-commit", 1},
+				{KindCommand, "shellcheck", 10},
+			},
+		},
+		{
+			name:  "script",
+			parse: parseShellScript,
+			data:  "#!/bin/sh\nset -e\n# build the image\ndocker build -t app .\nexport TAG=1\ngh release upload v1 app.tar.gz\n",
+			want: []declaration{
+				{KindCommand, "docker", 4},
+				{KindCommand, "gh", 6},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.parse([]byte(tt.data)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string, perm os.FileMode) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanManifests(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	src := filepath.Join(home, "src")
+	app := filepath.Join(src, "app")
+	repo := filepath.Join(home, "work", "api")
+
+	mkdirs(t, filepath.Join(repo, ".git"))
+	writeFile(t, filepath.Join(app, "Brewfile"), "brew \"jq\"\n", 0644)
+	writeFile(t, filepath.Join(app, "web", "package.json"), `{"scripts": {"e2e": "playwright test"}}`, 0644)
+	writeFile(t, filepath.Join(app, "node_modules", "x", "Makefile"), "all:\n\tcmake .\n", 0644)
+	writeFile(t, filepath.Join(src, "bootstrap.sh"), "brew bundle\n", 0644)
+	writeFile(t, filepath.Join(repo, "tools", "release"), "#!/usr/bin/env bash\ngoreleaser release\n", 0755)
+	writeFile(t, filepath.Join(repo, "tools", "data"), "not a script\n", 0755)
+	writeFile(t, filepath.Join(repo, "cmd", ".tool-versions"), "terraform 1.7.0\n", 0644)
+
+	got := ScanManifests([]string{src, repo + "/"})
+	var lines []string
+	for _, r := range got {
+		rel, _ := filepath.Rel(home, strings.TrimSuffix(r.Location, ":1"))
+		lines = append(lines, strings.Join([]string{r.Project, r.Kind, r.Name, r.Source, rel}, " "))
+	}
+	want := []string{
+		strings.Join([]string{app, KindFormula, "jq", "Brewfile", "src/app/Brewfile"}, " "),
+		strings.Join([]string{app, KindCommand, "playwright", "package.json", "src/app/web/package.json"}, " "),
+		strings.Join([]string{src, KindCommand, "brew", "script", "src/bootstrap.sh"}, " "),
+		strings.Join([]string{repo, KindTool, "terraform", ".tool-versions", "work/api/cmd/.tool-versions"}, " "),
+		strings.Join([]string{repo, KindCommand, "goreleaser", "script", "work/api/tools/release:2"}, " "),
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ScanManifests() =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
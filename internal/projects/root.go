@@ -0,0 +1,77 @@
+// Package projects attributes Homebrew tool usage to the user's projects.
+package projects
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// vcsMarkers are the entries whose presence makes a directory the root of a
+// version-controlled project.
+var vcsMarkers = []string{".git", ".hg", ".svn", ".jj", ".bzr", "_darcs", ".fossil"}
+
+// Root returns the project a command run in cwd belongs to: the nearest
+// enclosing directory that is a VCS root, or that sits directly inside one
+// of projectDirs (so ~/src/app/sub maps to ~/src/app with ~/src configured).
+// The walk stops below the home directory and the project directories
+// themselves, so neither they nor a dotfiles repository in the home
+// directory count as projects. Returns "" when cwd belongs to no project.
+func Root(cwd string, projectDirs []string) string {
+	if cwd == "" || !filepath.IsAbs(cwd) {
+		return ""
+	}
+	home, _ := os.UserHomeDir()
+	if home != "" {
+		home = filepath.Clean(home)
+	}
+	dirs := make(map[string]bool, len(projectDirs))
+	for _, d := range projectDirs {
+		dirs[filepath.Clean(d)] = true
+	}
+
+	for dir := filepath.Clean(cwd); ; {
+		if dir == home || dirs[dir] {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if isVCSRoot(dir) || dirs[parent] {
+			return dir
+		}
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// isVCSRoot reports whether dir holds a VCS metadata entry.
+func isVCSRoot(dir string) bool {
+	for _, marker := range vcsMarkers {
+		if _, err := os.Lstat(filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolver maps working directories to projects with Root, remembering
+// each answer since usage history repeats the same few directories.
+type Resolver struct {
+	projectDirs []string
+	cache       map[string]string
+}
+
+// NewResolver returns a Resolver for the configured project directories.
+func NewResolver(projectDirs []string) *Resolver {
+	return &Resolver{projectDirs: projectDirs, cache: make(map[string]string)}
+}
+
+// Root returns Root(cwd) for the resolver's project directories.
+func (r *Resolver) Root(cwd string) string {
+	root, ok := r.cache[cwd]
+	if !ok {
+		root = Root(cwd, r.projectDirs)
+		r.cache[cwd] = root
+	}
+	return root
+}
@@ -0,0 +1,78 @@
+package projects
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func mkdirs(t *testing.T, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRoot(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	src := filepath.Join(home, "src")
+	other := t.TempDir()
+
+	mkdirs(t,
+		filepath.Join(home, ".git"), // dotfiles repository
+		filepath.Join(src, "app", ".git"),
+		filepath.Join(src, "app", "web", "node_modules"),
+		filepath.Join(src, "app", "vendor", "lib", ".hg"),
+		filepath.Join(src, "scratch", "notes"),
+		filepath.Join(home, "work", "svc", ".jj"),
+		filepath.Join(home, "Downloads"),
+		filepath.Join(other, "build", "repo", ".git"),
+		filepath.Join(other, "build", "repo", "cmd"),
+	)
+
+	tests := []struct {
+		cwd  string
+		want string
+	}{
+		{filepath.Join(src, "app"), filepath.Join(src, "app")},
+		{filepath.Join(src, "app", "web", "node_modules"), filepath.Join(src, "app")},
+		{filepath.Join(src, "app", "vendor", "lib"), filepath.Join(src, "app", "vendor", "lib")}, // nearest VCS root
+		{filepath.Join(src, "scratch", "notes"), filepath.Join(src, "scratch")},                  // configured project dir
+		{filepath.Join(home, "work", "svc"), filepath.Join(home, "work", "svc")},
+		{filepath.Join(other, "build", "repo", "cmd"), filepath.Join(other, "build", "repo")}, // outside home
+		{src, ""},
+		{home, ""},
+		{filepath.Join(home, "Downloads"), ""}, // not under a repository other than dotfiles
+		{other, ""},
+		{"relative/dir", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Root(tt.cwd, []string{src + "/"}); got != tt.want {
+			t.Errorf("Root(%q) = %q, want %q", tt.cwd, got, tt.want)
+		}
+	}
+}
+
+func TestResolver_Caches(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	repo := filepath.Join(home, "repo")
+	mkdirs(t, filepath.Join(repo, ".git"))
+
+	r := NewResolver(nil)
+	if got := r.Root(repo); got != repo {
+		t.Fatalf("Root(%q) = %q, want %q", repo, got, repo)
+	}
+	// Later lookups are answered from the cache, even once the repository
+	// is gone.
+	if err := os.RemoveAll(repo); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Root(repo); got != repo {
+		t.Errorf("cached Root(%q) = %q, want %q", repo, got, repo)
+	}
+}
@@ -0,0 +1,82 @@
+package projects
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaxDepth bounds how deep project directories are walked: files are
+// visited at most MaxDepth path elements below the walked directory.
+const MaxDepth = 4
+
+// SkipDirs are directories never descended into under a project directory:
+// version-control metadata, dependency trees and build output.
+var SkipDirs = map[string]bool{
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	".jj":          true,
+	"node_modules": true,
+	"vendor":       true,
+	".venv":        true,
+	"venv":         true,
+	"target":       true,
+	"dist":         true,
+	"build":        true,
+}
+
+// WalkProject calls fn with every file under dir, up to MaxDepth levels
+// deep, without descending into SkipDirs.
+func WalkProject(dir string, fn func(path string, d fs.DirEntry)) {
+	Walk(dir, MaxDepth, func(_ string, d fs.DirEntry) bool {
+		return SkipDirs[d.Name()]
+	}, fn)
+}
+
+// Walk calls fn with every non-directory entry under dir that is at most
+// maxDepth path elements below it. Directories for which skipDir returns
+// true are not descended into. Unreadable entries are skipped.
+func Walk(dir string, maxDepth int, skipDir func(path string, d fs.DirEntry) bool, fn func(path string, d fs.DirEntry)) {
+	dir = filepath.Clean(dir)
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Unreadable entries are skipped
+		}
+		if d.IsDir() {
+			if path == dir {
+				return nil
+			}
+			rel, _ := filepath.Rel(dir, path)
+			if strings.Count(rel, string(filepath.Separator)) >= maxDepth-1 || skipDir(path, d) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		fn(path, d)
+		return nil
+	})
+}
+
+// Shebang returns the "#!" line of an executable file, without the "#!",
+// and whether it has one.
+func Shebang(path string, d fs.DirEntry) (string, bool) {
+	info, err := d.Info()
+	if err != nil || info.Mode().Perm()&0111 == 0 {
+		return "", false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	head := make([]byte, 256)
+	n, _ := f.Read(head)
+	first, _, _ := strings.Cut(string(head[:n]), "\n")
+	if line, ok := strings.CutPrefix(first, "#!"); ok {
+		return line, true
+	}
+	return "", false
+}
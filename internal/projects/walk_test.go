@@ -0,0 +1,63 @@
+package projects
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWalkProject(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Makefile"), "all:\n", 0644)
+	writeFile(t, filepath.Join(dir, "a", "b", "run.sh"), "#!/bin/sh\n", 0755)
+	writeFile(t, filepath.Join(dir, "a", "b", "c", "d", "deep.sh"), "#!/bin/sh\n", 0755)
+	writeFile(t, filepath.Join(dir, "node_modules", "x", "run.sh"), "#!/bin/sh\n", 0755)
+	writeFile(t, filepath.Join(dir, "vendor", "run.sh"), "#!/bin/sh\n", 0755)
+
+	var got []string
+	WalkProject(dir, func(path string, d fs.DirEntry) {
+		rel, _ := filepath.Rel(dir, path)
+		got = append(got, rel)
+	})
+	want := []string{"Makefile", filepath.Join("a", "b", "run.sh")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WalkProject visited %q, want %q", got, want)
+	}
+}
+
+func TestShebang(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "script"), "#!/usr/bin/env python3\nprint()\n", 0755)
+	writeFile(t, filepath.Join(dir, "plain"), "#!/bin/sh\n", 0644)
+	writeFile(t, filepath.Join(dir, "binary"), "\x7fELF", 0755)
+
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"script", "/usr/bin/env python3", true},
+		{"plain", "", false}, // Not executable
+		{"binary", "", false},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		entry := fileEntry(t, path)
+		got, ok := Shebang(path, entry)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Shebang(%s) = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// fileEntry returns the directory entry for path.
+func fileEntry(t *testing.T, path string) fs.DirEntry {
+	t.Helper()
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fs.FileInfoToDirEntry(info)
+}
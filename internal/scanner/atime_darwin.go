@@ -0,0 +1,19 @@
+//go:build darwin
+
+package scanner
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// accessTime returns the last access time recorded for a file, if the
+// platform exposes it.
+func accessTime(fi os.FileInfo) (time.Time, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(st.Atimespec.Sec, st.Atimespec.Nsec), true
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package scanner
+
+import (
+	"os"
+	"time"
+)
+
+// accessTime reports no access time on platforms without a known stat layout.
+func accessTime(fi os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
@@ -0,0 +1,347 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/projects"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// Bypass detection limits.
+const (
+	// accessSlack absorbs clock granularity between a recorded shim event
+	// and the access time the same execution left on the binary.
+	accessSlack = time.Minute
+
+	// maxScannedFileSize skips large files (generated code, data) when
+	// searching for hardcoded paths.
+	maxScannedFileSize = 1 << 20
+)
+
+// rcFiles are shell startup files, relative to the home directory, that are
+// searched for hardcoded brew-prefix paths.
+var rcFiles = []string{
+	".bashrc",
+	".bash_profile",
+	".bash_aliases",
+	".profile",
+	".zshrc",
+	".zprofile",
+	".zshenv",
+	".config/fish/config.fish",
+	".tmux.conf",
+}
+
+// editorConfigFiles are editor and IDE settings, relative to the home
+// directory, that commonly hardcode tool paths (formatters, linters, LSPs).
+var editorConfigFiles = []string{
+	".vimrc",
+	".config/nvim/init.vim",
+	".config/nvim/init.lua",
+	".emacs",
+	".emacs.d/init.el",
+	".gitconfig",
+	".config/git/config",
+	".config/Code/User/settings.json",
+	"Library/Application Support/Code/User/settings.json",
+	".config/zed/settings.json",
+	".config/helix/languages.toml",
+}
+
+// projectScriptNames are build files searched regardless of extension.
+var projectScriptNames = map[string]bool{
+	"Makefile":    true,
+	"makefile":    true,
+	"GNUmakefile": true,
+	"justfile":    true,
+	"Justfile":    true,
+	".envrc":      true,
+}
+
+// projectScriptExts are script extensions searched under project dirs.
+var projectScriptExts = map[string]bool{
+	".sh":   true,
+	".bash": true,
+	".zsh":  true,
+	".fish": true,
+	".mk":   true,
+}
+
+// BypassOptions configures DetectBypass.
+type BypassOptions struct {
+	// Prefixes are the Homebrew prefixes whose absolute paths count as a
+	// bypass, e.g. "/opt/homebrew".
+	Prefixes []string
+
+	// HomeDir is where shell rc and editor config files are looked up.
+	HomeDir string
+
+	// ProjectDirs are searched for Makefiles and scripts.
+	ProjectDirs []string
+}
+
+// binaryRef is an absolute reference to a brew binary found in a file.
+type binaryRef struct {
+	pkg    string // empty when the path is a linked bin/ path
+	binary string
+	path   string
+}
+
+// DetectBypass looks for evidence that package binaries run without going
+// through their PATH shims, so their recorded usage is undercounted:
+//
+//   - the real binary in the keg was accessed (atime) after its last
+//     recorded use, while tracking was active;
+//   - shell rc files, editor configs, or Makefiles and scripts under the
+//     project directories hardcode the binary's brew-prefix path.
+//
+// Access times are only meaningful on filesystems that maintain them
+// (relatime updates them at most daily); on noatime mounts that check simply
+// finds nothing.
+func (s *Scanner) DetectBypass(opts BypassOptions) ([]*store.BypassFinding, error) {
+	now := time.Now()
+
+	findings, err := s.detectAccessBypass(now)
+	if err != nil {
+		return nil, err
+	}
+
+	refFindings, err := s.detectReferenceBypass(opts, now)
+	if err != nil {
+		return nil, err
+	}
+	findings = append(findings, refFindings...)
+
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Package != findings[j].Package {
+			return findings[i].Package < findings[j].Package
+		}
+		if findings[i].BinaryName != findings[j].BinaryName {
+			return findings[i].BinaryName < findings[j].BinaryName
+		}
+		return findings[i].Location < findings[j].Location
+	})
+
+	return findings, nil
+}
+
+// detectAccessBypass compares each keg binary's access time with its last
+// recorded use. Accesses before tracking started are not evidence of a
+// bypass, so nothing is reported until the first usage event exists; nor
+// are accesses during a linkage scan, which reads every binary.
+func (s *Scanner) detectAccessBypass(now time.Time) ([]*store.BypassFinding, error) {
+	trackingSince, err := s.store.GetFirstEventTime()
+	if err != nil || trackingSince.IsZero() {
+		return nil, nil
+	}
+	scans, err := s.store.GetLinkageScans(trackingSince)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get linkage scans: %w", err)
+	}
+
+	packages, err := s.store.ListPackages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list packages: %w", err)
+	}
+
+	var findings []*store.BypassFinding
+	for _, pkg := range packages {
+		if pkg.IsCask {
+			continue
+		}
+		usage, err := s.store.GetBinaryUsage(pkg.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get binary usage for %s: %w", pkg.Name, err)
+		}
+
+		for _, u := range usage {
+			if u.Path == "" {
+				continue
+			}
+			realPath, err := filepath.EvalSymlinks(u.Path)
+			if err != nil {
+				continue
+			}
+			info, err := os.Stat(realPath)
+			if err != nil {
+				continue
+			}
+			atime, ok := accessTime(info)
+			if !ok {
+				continue
+			}
+
+			// Untouched since it was written (installed), or accessed before
+			// tracking began.
+			if !atime.After(info.ModTime().Add(accessSlack)) || atime.Before(trackingSince) {
+				continue
+			}
+			if u.LastUsed != nil && !atime.After(u.LastUsed.Add(accessSlack)) {
+				continue
+			}
+			if duringScan(atime, scans) {
+				continue
+			}
+
+			findings = append(findings, &store.BypassFinding{
+				Package:    pkg.Name,
+				BinaryName: u.Name,
+				Kind:       store.BypassAccessTime,
+				Location:   realPath,
+				EvidenceAt: atime,
+				DetectedAt: now,
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+// duringScan reports whether t falls inside one of the scan windows, give
+// or take accessSlack.
+func duringScan(t time.Time, scans []store.ScanWindow) bool {
+	for _, w := range scans {
+		if !t.Before(w.Start.Add(-accessSlack)) && !t.After(w.End.Add(accessSlack)) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectReferenceBypass searches rc files, editor configs and project
+// scripts for absolute paths to installed package binaries.
+func (s *Scanner) detectReferenceBypass(opts BypassOptions, now time.Time) ([]*store.BypassFinding, error) {
+	if len(opts.Prefixes) == 0 {
+		return nil, nil
+	}
+
+	packages, err := s.store.ListPackages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list packages: %w", err)
+	}
+	installed := make(map[string]bool, len(packages))
+	linked := make(map[string]string) // linked bin path → package
+	for _, pkg := range packages {
+		installed[pkg.Name] = true
+		for _, binPath := range pkg.BinaryPaths {
+			linked[binPath] = pkg.Name
+		}
+	}
+
+	pattern := brewPathPattern(opts.Prefixes)
+
+	var files []string
+	if opts.HomeDir != "" {
+		for _, rel := range append(append([]string{}, rcFiles...), editorConfigFiles...) {
+			files = append(files, filepath.Join(opts.HomeDir, rel))
+		}
+	}
+	for _, dir := range opts.ProjectDirs {
+		files = append(files, projectScripts(dir)...)
+	}
+
+	var findings []*store.BypassFinding
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil || !info.Mode().IsRegular() || info.Size() > maxScannedFileSize {
+			continue
+		}
+
+		refs, err := findBrewReferences(file, pattern)
+		if err != nil {
+			continue // Unreadable files cannot be checked
+		}
+		for line, lineRefs := range refs {
+			for _, ref := range lineRefs {
+				pkg := ref.pkg
+				if pkg == "" {
+					pkg = linked[ref.path]
+				}
+				if pkg == "" || !installed[pkg] {
+					continue
+				}
+				findings = append(findings, &store.BypassFinding{
+					Package:    pkg,
+					BinaryName: ref.binary,
+					Kind:       store.BypassReference,
+					Location:   fmt.Sprintf("%s:%d", file, line),
+					EvidenceAt: info.ModTime(),
+					DetectedAt: now,
+				})
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// brewPathPattern matches absolute paths to binaries under any of prefixes:
+// linked ("<prefix>/bin/jq"), opt ("<prefix>/opt/jq/bin/jq") and keg
+// ("<prefix>/Cellar/jq/1.7/bin/jq") forms.
+func brewPathPattern(prefixes []string) *regexp.Regexp {
+	quoted := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		quoted[i] = regexp.QuoteMeta(strings.TrimSuffix(p, "/"))
+	}
+	const name = `[A-Za-z0-9][A-Za-z0-9._+@-]*`
+	return regexp.MustCompile(`(?:` + strings.Join(quoted, "|") + `)/` +
+		`(?:s?bin/(` + name + `)` +
+		`|opt/(` + name + `)/s?bin/(` + name + `)` +
+		`|Cellar/(` + name + `)/[^/\s"']+/s?bin/(` + name + `))`)
+}
+
+// findBrewReferences returns the brew binary references in a file, keyed by
+// 1-based line number.
+func findBrewReferences(path string, pattern *regexp.Regexp) (map[int][]binaryRef, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	refs := make(map[int][]binaryRef)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxScannedFileSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		for _, m := range pattern.FindAllStringSubmatch(scanner.Text(), -1) {
+			switch {
+			case m[1] != "":
+				refs[line] = append(refs[line], binaryRef{binary: m[1], path: m[0]})
+			case m[3] != "":
+				refs[line] = append(refs[line], binaryRef{pkg: m[2], binary: m[3], path: m[0]})
+			case m[5] != "":
+				refs[line] = append(refs[line], binaryRef{pkg: m[4], binary: m[5], path: m[0]})
+			}
+		}
+	}
+	return refs, scanner.Err()
+}
+
+// projectScripts returns the Makefiles and scripts under dir, up to
+// projects.MaxDepth levels deep. Executable files are included when they
+// start with a "#!" line.
+func projectScripts(dir string) []string {
+	var files []string
+	projects.WalkProject(dir, func(path string, d fs.DirEntry) {
+		if !d.Type().IsRegular() {
+			return
+		}
+		name := d.Name()
+		if projectScriptNames[name] || projectScriptExts[filepath.Ext(name)] {
+			files = append(files, path)
+		} else if _, ok := projects.Shebang(path, d); ok {
+			files = append(files, path)
+		}
+	})
+	return files
+}
@@ -0,0 +1,193 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+func TestDetectBypass_HardcodedReferences(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	for _, pkg := range []*brew.Package{
+		{Name: "fzf", InstalledAt: time.Now(), HasBinary: true, BinaryPaths: []string{"/opt/homebrew/bin/fzf"}},
+		{Name: "black", InstalledAt: time.Now(), HasBinary: true},
+		{Name: "jq", InstalledAt: time.Now(), HasBinary: true},
+	} {
+		if err := s.InsertPackage(pkg); err != nil {
+			t.Fatalf("InsertPackage(%s): %v", pkg.Name, err)
+		}
+	}
+
+	home := t.TempDir()
+	project := filepath.Join(t.TempDir(), "api")
+	files := map[string]string{
+		filepath.Join(home, ".zshrc"): "eval \"$(/opt/homebrew/bin/brew shellenv)\"\n" +
+			"export PATH=/opt/homebrew/bin:$PATH\n" +
+			"source <(/opt/homebrew/bin/fzf --zsh)\n",
+		filepath.Join(home, ".config/Code/User/settings.json"): `{"black-formatter.path": ["/opt/homebrew/opt/black/bin/black"]}` + "\n",
+		filepath.Join(project, "Makefile"):                     "lint:\n\t/opt/homebrew/Cellar/jq/1.7.1/bin/jq . data.json\n",
+		filepath.Join(project, "node_modules/x/run.sh"):        "/opt/homebrew/bin/fzf\n",
+		filepath.Join(project, "notes.txt"):                    "/opt/homebrew/bin/fzf\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	findings, err := New(s).DetectBypass(BypassOptions{
+		Prefixes:    []string{"/opt/homebrew"},
+		HomeDir:     home,
+		ProjectDirs: []string{filepath.Dir(project)},
+	})
+	if err != nil {
+		t.Fatalf("DetectBypass: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, f := range findings {
+		if f.Kind != store.BypassReference {
+			t.Errorf("unexpected %s finding for %s", f.Kind, f.Package)
+		}
+		got[f.Package+"/"+f.BinaryName] = f.Location
+	}
+	want := map[string]string{
+		"fzf/fzf":     filepath.Join(home, ".zshrc") + ":3",
+		"black/black": filepath.Join(home, ".config/Code/User/settings.json") + ":1",
+		"jq/jq":       filepath.Join(project, "Makefile") + ":2",
+	}
+	if len(got) != len(want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+	for key, loc := range want {
+		if got[key] != loc {
+			t.Errorf("%s location = %q, want %q", key, got[key], loc)
+		}
+	}
+}
+
+func TestDetectBypass_AccessTime(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("access times not supported on " + runtime.GOOS)
+	}
+
+	s := setupTestStore(t)
+	defer s.Close()
+
+	root := t.TempDir()
+	psqlBin := filepath.Join(root, "Cellar", "libpq", "16.4", "bin", "psql")
+	pgDumpBin := filepath.Join(root, "Cellar", "libpq", "16.4", "bin", "pg_dump")
+	writeKegFile(t, psqlBin)
+	writeKegFile(t, pgDumpBin)
+	binDir := filepath.Join(root, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	for _, target := range []string{psqlBin, pgDumpBin} {
+		if err := os.Symlink(target, filepath.Join(binDir, filepath.Base(target))); err != nil {
+			t.Fatalf("Symlink: %v", err)
+		}
+	}
+
+	if err := s.InsertPackage(&brew.Package{
+		Name:        "libpq",
+		InstalledAt: time.Now().AddDate(0, -1, 0),
+		HasBinary:   true,
+		BinaryPaths: []string{filepath.Join(binDir, "psql"), filepath.Join(binDir, "pg_dump")},
+	}); err != nil {
+		t.Fatalf("InsertPackage: %v", err)
+	}
+
+	// Tracking started ten days ago; pg_dump was last recorded five days ago.
+	now := time.Now()
+	installed := now.AddDate(0, -1, 0)
+	if err := s.InsertUsageEvent(&store.UsageEvent{Package: "libpq", EventType: "exec", BinaryName: "pg_dump", Timestamp: now.AddDate(0, 0, -10)}); err != nil {
+		t.Fatalf("InsertUsageEvent: %v", err)
+	}
+	if err := s.InsertUsageEvent(&store.UsageEvent{Package: "libpq", EventType: "exec", BinaryName: "pg_dump", Timestamp: now.AddDate(0, 0, -5)}); err != nil {
+		t.Fatalf("InsertUsageEvent: %v", err)
+	}
+
+	// psql was accessed two days ago without a recorded use; pg_dump's
+	// access matches its recorded run.
+	if err := os.Chtimes(psqlBin, now.AddDate(0, 0, -2), installed); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+	if err := os.Chtimes(pgDumpBin, now.AddDate(0, 0, -5), installed); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+
+	findings, err := New(s).DetectBypass(BypassOptions{})
+	if err != nil {
+		t.Fatalf("DetectBypass: %v", err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Kind != store.BypassAccessTime || f.BinaryName != "psql" || !strings.HasSuffix(f.Location, "/bin/psql") {
+		t.Errorf("finding = %+v, want atime finding for psql", f)
+	}
+}
+
+func TestDetectBypass_IgnoresLinkageScanReads(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("access times not supported on " + runtime.GOOS)
+	}
+
+	s := setupTestStore(t)
+	defer s.Close()
+
+	root := t.TempDir()
+	psqlBin := filepath.Join(root, "Cellar", "libpq", "16.4", "bin", "psql")
+	writeKegFile(t, psqlBin)
+	binDir := filepath.Join(root, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.Symlink(psqlBin, filepath.Join(binDir, "psql")); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+	if err := s.InsertPackage(&brew.Package{
+		Name:        "libpq",
+		InstalledAt: time.Now().AddDate(0, -1, 0),
+		HasBinary:   true,
+		BinaryPaths: []string{filepath.Join(binDir, "psql")},
+	}); err != nil {
+		t.Fatalf("InsertPackage: %v", err)
+	}
+
+	now := time.Now()
+	if err := s.InsertUsageEvent(&store.UsageEvent{Package: "libpq", EventType: "exec", BinaryName: "pg_dump", Timestamp: now.AddDate(0, 0, -10)}); err != nil {
+		t.Fatalf("InsertUsageEvent: %v", err)
+	}
+
+	// The only access since tracking began was a linkage scan reading the
+	// binary's headers two days ago.
+	accessed := now.AddDate(0, 0, -2).Truncate(time.Second)
+	if err := os.Chtimes(psqlBin, accessed, now.AddDate(0, -1, 0)); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+	if err := s.RecordLinkageScan(accessed.Add(-time.Second), accessed.Add(time.Second)); err != nil {
+		t.Fatalf("RecordLinkageScan: %v", err)
+	}
+
+	findings, err := New(s).DetectBypass(BypassOptions{})
+	if err != nil {
+		t.Fatalf("DetectBypass: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("access during a linkage scan reported as bypass: %+v", findings)
+	}
+}
@@ -0,0 +1,237 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// caskRubyApp matches an app artifact in a cask's Ruby definition, with an
+// optional target: app "Foo.app", target: "Bar.app".
+var caskRubyApp = regexp.MustCompile(`(?m)^\s*app\s+"([^"]+)"(?:\s*,\s*target:\s*"([^"]+)")?`)
+
+// ScanCaskApps maps the application bundles of every installed cask to the
+// cask and stores the result. Bundles are found through the cask definition
+// Homebrew keeps in the Caskroom of the cask's prefix (its app artifacts,
+// looked up in appDirs), bundles left inside the Caskroom itself, and bundles
+// in appDirs that are symlinks into the Caskroom. It returns the number of
+// bundles stored.
+func (s *Scanner) ScanCaskApps(appDirs []string) (int, error) {
+	prefixes, err := s.Prefixes()
+	if err != nil {
+		return 0, err
+	}
+	packages, err := s.store.ListPackages()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list packages: %w", err)
+	}
+	casks := make(map[string][]string) // prefix -> casks installed there
+	for _, pkg := range packages {
+		if !pkg.IsCask {
+			continue
+		}
+		prefix := pkg.Prefix
+		if prefix == "" {
+			prefix = prefixes[0]
+		}
+		casks[prefix] = append(casks[prefix], pkg.Name)
+	}
+
+	roots := make([]string, 0, len(casks))
+	for prefix := range casks {
+		roots = append(roots, prefix)
+	}
+	sort.Strings(roots)
+
+	var apps []*store.CaskApp
+	seen := make(map[string]bool)
+	for _, prefix := range roots {
+		for _, app := range findCaskApps(filepath.Join(prefix, "Caskroom"), appDirs, casks[prefix]) {
+			if !seen[app.Path] {
+				seen[app.Path] = true
+				apps = append(apps, app)
+			}
+		}
+	}
+	if err := s.store.ReplaceCaskApps(apps); err != nil {
+		return 0, err
+	}
+	return len(apps), nil
+}
+
+// findCaskApps returns the application bundles of casks. Unreadable or
+// missing directories yield no bundles rather than an error.
+func findCaskApps(caskroom string, appDirs []string, casks []string) []*store.CaskApp {
+	var apps []*store.CaskApp
+	seen := make(map[string]bool)
+	add := func(cask, path string) {
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		apps = append(apps, &store.CaskApp{Package: cask, Name: filepath.Base(path), Path: path})
+	}
+
+	installed := make(map[string]bool, len(casks))
+	for _, cask := range casks {
+		installed[cask] = true
+		caskDir := filepath.Join(caskroom, cask)
+
+		for _, name := range caskArtifactApps(caskDir, cask) {
+			for _, dir := range appDirs {
+				path := filepath.Join(dir, name)
+				if isAppBundle(path) {
+					add(cask, path)
+				}
+			}
+		}
+
+		// Bundles kept in the Caskroom: <caskroom>/<cask>/<version>/*.app.
+		versions, _ := os.ReadDir(caskDir)
+		for _, v := range versions {
+			if !v.IsDir() || strings.HasPrefix(v.Name(), ".") {
+				continue
+			}
+			bundles, _ := filepath.Glob(filepath.Join(caskDir, v.Name(), "*.app"))
+			for _, path := range bundles {
+				add(cask, path)
+			}
+		}
+	}
+
+	// Bundles in the application directories that link into the Caskroom.
+	for _, dir := range appDirs {
+		bundles, _ := filepath.Glob(filepath.Join(dir, "*.app"))
+		for _, path := range bundles {
+			target, err := os.Readlink(path)
+			if err != nil {
+				continue
+			}
+			if cask := caskFromCaskroomPath(target, caskroom); installed[cask] {
+				add(cask, path)
+			}
+		}
+	}
+
+	sort.Slice(apps, func(i, j int) bool {
+		if apps[i].Package != apps[j].Package {
+			return apps[i].Package < apps[j].Package
+		}
+		return apps[i].Path < apps[j].Path
+	})
+	return apps
+}
+
+// caskArtifactApps returns the bundle names a cask installs, read from the
+// most recent definition Homebrew stored under
+// <caskDir>/.metadata/<version>/<timestamp>/Casks/<cask>.{json,rb}.
+func caskArtifactApps(caskDir, cask string) []string {
+	var latest string
+	for _, ext := range []string{".json", ".rb"} {
+		matches, _ := filepath.Glob(filepath.Join(caskDir, ".metadata", "*", "*", "Casks", cask+ext))
+		for _, m := range matches {
+			if latest == "" || newerFile(m, latest) {
+				latest = m
+			}
+		}
+	}
+	if latest == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(latest)
+	if err != nil {
+		return nil
+	}
+	if strings.HasSuffix(latest, ".json") {
+		return parseCaskJSONApps(data)
+	}
+	return parseCaskRubyApps(data)
+}
+
+// newerFile reports whether a was modified after b.
+func newerFile(a, b string) bool {
+	ai, errA := os.Stat(a)
+	bi, errB := os.Stat(b)
+	if errA != nil || errB != nil {
+		return errB != nil && errA == nil
+	}
+	return ai.ModTime().After(bi.ModTime())
+}
+
+// parseCaskJSONApps returns the app artifacts of a cask JSON definition:
+// "artifacts": [{"app": ["Foo.app", {"target": "Bar.app"}]}, ...], where a
+// target object renames the bundle before it.
+func parseCaskJSONApps(data []byte) []string {
+	var def struct {
+		Artifacts []map[string]json.RawMessage `json:"artifacts"`
+	}
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, artifact := range def.Artifacts {
+		raw, ok := artifact["app"]
+		if !ok {
+			continue
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			continue
+		}
+		for _, item := range items {
+			var name string
+			if err := json.Unmarshal(item, &name); err == nil {
+				names = append(names, filepath.Base(name))
+				continue
+			}
+			var opts struct {
+				Target string `json:"target"`
+			}
+			if err := json.Unmarshal(item, &opts); err == nil && opts.Target != "" && len(names) > 0 {
+				names[len(names)-1] = filepath.Base(opts.Target)
+			}
+		}
+	}
+	return names
+}
+
+// parseCaskRubyApps returns the app artifacts of a cask Ruby definition.
+func parseCaskRubyApps(data []byte) []string {
+	var names []string
+	for _, m := range caskRubyApp.FindAllStringSubmatch(string(data), -1) {
+		name := m[1]
+		if m[2] != "" {
+			name = m[2]
+		}
+		names = append(names, filepath.Base(name))
+	}
+	return names
+}
+
+// caskFromCaskroomPath returns the cask token of a path inside caskroom
+// ("<caskroom>/<cask>/<version>/Foo.app"), or "" for other paths.
+func caskFromCaskroomPath(path, caskroom string) string {
+	rest, ok := strings.CutPrefix(filepath.Clean(path), filepath.Clean(caskroom)+string(filepath.Separator))
+	if !ok {
+		return ""
+	}
+	if i := strings.IndexRune(rest, filepath.Separator); i > 0 {
+		return rest[:i]
+	}
+	return ""
+}
+
+// isAppBundle reports whether path is an application bundle directory,
+// following symlinks.
+func isAppBundle(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
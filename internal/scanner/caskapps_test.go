@@ -0,0 +1,152 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/brew"
+)
+
+func TestParseCaskJSONApps(t *testing.T) {
+	data := []byte(`{"token":"visual-studio-code","artifacts":[
+		{"app":["Visual Studio Code.app"]},
+		{"binary":["{{appdir}}/Visual Studio Code.app/Contents/Resources/app/bin/code"]},
+		{"app":["Docker.app",{"target":"Docker Desktop.app"}]},
+		{"zap":[{"trash":["~/Library/Caches/com.microsoft.VSCode"]}]}
+	]}`)
+
+	got := parseCaskJSONApps(data)
+	want := []string{"Visual Studio Code.app", "Docker Desktop.app"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCaskJSONApps() = %q, want %q", got, want)
+	}
+
+	if got := parseCaskJSONApps([]byte("not json")); got != nil {
+		t.Errorf("parseCaskJSONApps(invalid) = %q, want nil", got)
+	}
+}
+
+func TestParseCaskRubyApps(t *testing.T) {
+	data := []byte(`cask "iterm2" do
+  version "3.5.0"
+  app "iTerm.app"
+  app "Helper/Extra.app", target: "iTerm Extra.app"
+  # app "Commented.app"
+end
+`)
+
+	got := parseCaskRubyApps(data)
+	want := []string{"iTerm.app", "iTerm Extra.app"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCaskRubyApps() = %q, want %q", got, want)
+	}
+}
+
+func TestFindCaskApps(t *testing.T) {
+	caskroom := t.TempDir()
+	apps := t.TempDir()
+
+	mkdir := func(path string) {
+		t.Helper()
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+	}
+	write := func(path, content string) {
+		t.Helper()
+		mkdir(filepath.Dir(path))
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	// Moved to the application directory, found through its definition.
+	write(filepath.Join(caskroom, "firefox", ".metadata", "130.0", "20240901120000.000", "Casks", "firefox.json"),
+		`{"artifacts":[{"app":["Firefox.app"]}]}`)
+	mkdir(filepath.Join(apps, "Firefox.app", "Contents"))
+
+	// An older definition naming a different bundle is ignored.
+	oldDef := filepath.Join(caskroom, "iterm2", ".metadata", "3.4.0", "20230101000000.000", "Casks", "iterm2.rb")
+	write(oldDef, `app "iTerm Old.app"`)
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(oldDef, old, old); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+	write(filepath.Join(caskroom, "iterm2", ".metadata", "3.5.0", "20240601000000.000", "Casks", "iterm2.rb"),
+		`app "iTerm.app"`)
+	mkdir(filepath.Join(apps, "iTerm.app"))
+	mkdir(filepath.Join(apps, "iTerm Old.app"))
+
+	// Symlinked from the application directory into the Caskroom.
+	mkdir(filepath.Join(caskroom, "rectangle", "0.80", "Rectangle.app"))
+	if err := os.Symlink(filepath.Join(caskroom, "rectangle", "0.80", "Rectangle.app"), filepath.Join(apps, "Rectangle.app")); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+
+	// Not an installed cask.
+	mkdir(filepath.Join(caskroom, "stale", "1.0", "Stale.app"))
+	if err := os.Symlink(filepath.Join(caskroom, "stale", "1.0", "Stale.app"), filepath.Join(apps, "Stale.app")); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+
+	found := findCaskApps(caskroom, []string{apps, filepath.Join(t.TempDir(), "missing")},
+		[]string{"firefox", "iterm2", "rectangle", "no-such-cask"})
+
+	got := make(map[string]string)
+	for _, app := range found {
+		if app.Name != filepath.Base(app.Path) {
+			t.Errorf("Name = %q, want base of %q", app.Name, app.Path)
+		}
+		got[app.Path] = app.Package
+	}
+	want := map[string]string{
+		filepath.Join(apps, "Firefox.app"):                            "firefox",
+		filepath.Join(apps, "iTerm.app"):                              "iterm2",
+		filepath.Join(caskroom, "rectangle", "0.80", "Rectangle.app"): "rectangle",
+		filepath.Join(apps, "Rectangle.app"):                          "rectangle",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findCaskApps() = %v, want %v", got, want)
+	}
+}
+
+func TestScanCaskApps_StoresInstalledCasks(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	for _, pkg := range []*brew.Package{
+		{Name: "firefox", InstalledAt: time.Now(), IsCask: true},
+		{Name: "jq", InstalledAt: time.Now(), HasBinary: true},
+	} {
+		if err := s.InsertPackage(pkg); err != nil {
+			t.Fatalf("InsertPackage(%s): %v", pkg.Name, err)
+		}
+	}
+
+	prefix := t.TempDir()
+	bundle := filepath.Join(prefix, "Caskroom", "firefox", "130.0", "Firefox.app")
+	if err := os.MkdirAll(bundle, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	sc := New(s)
+	sc.SetPrefixes([]string{prefix})
+	n, err := sc.ScanCaskApps(nil)
+	if err != nil {
+		t.Fatalf("ScanCaskApps: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("ScanCaskApps() = %d, want 1", n)
+	}
+
+	apps, err := s.ListCaskApps()
+	if err != nil {
+		t.Fatalf("ListCaskApps: %v", err)
+	}
+	if len(apps) != 1 || apps[0].Package != "firefox" || apps[0].Path != bundle {
+		t.Errorf("ListCaskApps() = %+v, want firefox at %s", apps, bundle)
+	}
+}
@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/projects"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// DetectDeclaredRequirements scans the project manifests and scripts under
+// dirs (see projects.ScanManifests) and maps each requirement to the
+// installed packages providing it:
+//
+//   - Brewfile formulae and casks by package name;
+//   - .tool-versions and mise.toml tools by package name, else as a command;
+//   - commands through the binary index, to every package shipping a binary
+//     of that name.
+//
+// Requirements that no installed package provides are dropped.
+func (s *Scanner) DetectDeclaredRequirements(dirs []string) ([]*store.DeclaredRequirement, error) {
+	packages, err := s.store.ListPackages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list packages: %w", err)
+	}
+	installed := make(map[string]bool, len(packages))
+	for _, pkg := range packages {
+		installed[pkg.Name] = true
+	}
+
+	owners := make(map[string][]string) // command → providing packages
+	binaryOwners := func(name string) ([]string, error) {
+		if pkgs, ok := owners[name]; ok {
+			return pkgs, nil
+		}
+		pkgs, err := s.store.GetBinaryOwners(name)
+		if err != nil {
+			return nil, err
+		}
+		owners[name] = pkgs
+		return pkgs, nil
+	}
+
+	now := time.Now()
+	var reqs []*store.DeclaredRequirement
+	for _, r := range projects.ScanManifests(dirs) {
+		var pkgs []string
+		switch {
+		case r.Kind == projects.KindFormula || r.Kind == projects.KindCask:
+			if installed[r.Name] {
+				pkgs = []string{r.Name}
+			}
+		case r.Kind == projects.KindTool && installed[r.Name]:
+			pkgs = []string{r.Name}
+		default:
+			if pkgs, err = binaryOwners(r.Name); err != nil {
+				return nil, fmt.Errorf("failed to look up %s: %w", r.Name, err)
+			}
+		}
+
+		for _, pkg := range pkgs {
+			reqs = append(reqs, &store.DeclaredRequirement{
+				Package:    pkg,
+				Project:    r.Project,
+				Name:       r.Name,
+				Source:     r.Source,
+				Location:   r.Location,
+				DetectedAt: now,
+			})
+		}
+	}
+
+	return reqs, nil
+}
@@ -0,0 +1,69 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/brew"
+)
+
+func TestDetectDeclaredRequirements(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	for _, pkg := range []*brew.Package{
+		{Name: "jq", InstalledAt: time.Now(), HasBinary: true, BinaryPaths: []string{"/opt/homebrew/bin/jq"}},
+		{Name: "terraform", InstalledAt: time.Now(), HasBinary: true, BinaryPaths: []string{"/opt/homebrew/bin/terraform"}},
+		{Name: "node", InstalledAt: time.Now(), HasBinary: true, BinaryPaths: []string{"/opt/homebrew/bin/node", "/opt/homebrew/bin/npm"}},
+		{Name: "docker", InstalledAt: time.Now(), IsCask: true},
+	} {
+		if err := s.InsertPackage(pkg); err != nil {
+			t.Fatalf("InsertPackage(%s): %v", pkg.Name, err)
+		}
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	app := filepath.Join(dir, "app")
+	files := map[string]string{
+		filepath.Join(app, "Brewfile"):       "brew \"terraform\"\nbrew \"wget\"\ncask \"docker\"\n",
+		filepath.Join(app, ".tool-versions"): "nodejs 20.11.0\n",
+		filepath.Join(app, "Makefile"):       "data:\n\tcurl -s $(URL) | jq . > data.json\n\tnpm run build\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	reqs, err := New(s).DetectDeclaredRequirements([]string{dir})
+	if err != nil {
+		t.Fatalf("DetectDeclaredRequirements: %v", err)
+	}
+
+	var got []string
+	for _, r := range reqs {
+		if r.Project != app {
+			t.Errorf("%s declared by project %q, want %q", r.Package, r.Project, app)
+		}
+		got = append(got, r.Package+" "+r.Name+" "+filepath.Base(r.Location))
+	}
+	// curl and wget are not installed; the tool "nodejs" and the command
+	// "npm" both map to node.
+	want := []string{
+		"node node .tool-versions:1",
+		"terraform terraform Brewfile:1",
+		"docker docker Brewfile:3",
+		"jq jq Makefile:2",
+		"node npm Makefile:3",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("DetectDeclaredRequirements() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
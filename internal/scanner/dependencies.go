@@ -27,9 +27,7 @@ var coreDependencies = map[string]bool{
 	"sqlite":   true,
 	"ncurses":  true,
 
-	// Python versions
-	"python@3.12": true,
-	"python@3.11": true,
+	// Python versions are matched by IsCoreRuntimeVersion instead.
 
 	// Core utilities and tools
 	"coreutils": true,
@@ -126,9 +124,8 @@ func IsCoreDependency(pkg string) bool {
 		return true
 	}
 
-	// Check for versioned variants (e.g., python@3.x)
-	// Allow any python@3.x version
-	if strings.HasPrefix(pkg, "python@3.") {
+	// Check for versioned runtimes (e.g., python@3.x)
+	if IsCoreRuntimeVersion(pkg) {
 		return true
 	}
 
@@ -140,6 +137,14 @@ func IsCoreDependency(pkg string) bool {
 	return false
 }
 
+// IsCoreRuntimeVersion reports whether pkg is a version of a core language
+// runtime, such as python@3.12. Unlike the other core dependencies, only
+// the version in use needs protecting: an unused one superseded by a newer
+// version that is used can go (see analyzer.Analyzer.IsCoreDependency).
+func IsCoreRuntimeVersion(pkg string) bool {
+	return strings.HasPrefix(pkg, "python@3.")
+}
+
 // GetPruneCandidates returns packages that are good candidates for pruning.
 // A package is a prune candidate if:
 // - It has no dependents (is a leaf)
@@ -1,39 +1,66 @@
 package scanner
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/store"
 )
 
 // ScanPackages scans all installed packages via brew and stores them in the database.
-// This includes package metadata, dependencies, and binary paths.
+// This includes package metadata, pins, service status, dependencies, and
+// binary paths. Every prefix returned by Prefixes is scanned with its own
+// brew; a package installed in several prefixes is recorded under the first.
 func (s *Scanner) ScanPackages() error {
-	// Get all installed packages from brew
-	packages, err := brew.ListInstalled()
+	prefixes, err := s.Prefixes()
 	if err != nil {
-		return fmt.Errorf("failed to list installed packages: %w", err)
+		return err
 	}
 
-	// Store each package first
-	for _, pkg := range packages {
-		if err := s.store.InsertPackage(pkg); err != nil {
-			return fmt.Errorf("failed to insert package %s: %w", pkg.Name, err)
+	owner := make(map[string]string) // package name -> prefix it is recorded under
+	for _, prefix := range prefixes {
+		// Get all installed packages from this prefix's brew
+		packages, err := brew.ListInstalledIn(prefix)
+		if err != nil {
+			return fmt.Errorf("failed to list installed packages in %s: %w", prefix, err)
+		}
+
+		// Record which formulae run as brew services
+		services := brew.ListServicesIn(prefix)
+		for _, pkg := range packages {
+			pkg.Service = services[pkg.Name]
+		}
+
+		// Store each package first
+		for _, pkg := range packages {
+			if _, dup := owner[pkg.Name]; dup {
+				continue
+			}
+			owner[pkg.Name] = prefix
+			if err := s.store.InsertPackage(pkg); err != nil {
+				return fmt.Errorf("failed to insert package %s: %w", pkg.Name, err)
+			}
+		}
+
+		// Get all dependencies in one call (much faster than per-package)
+		depsTree, err := brew.GetAllDependenciesIn(prefix)
+		if err != nil {
+			// Dependencies are optional: some systems may not have any
+			// packages with dependencies
+			continue
 		}
-	}
 
-	// Get all dependencies in one call (much faster than per-package)
-	depsTree, err := brew.GetAllDependencies()
-	if err != nil {
-		// Log warning but continue - dependencies are optional
-		// Some systems may not have any packages with dependencies
-	} else {
 		// Clear stale dependency rows before re-inserting fresh data.
 		// INSERT OR IGNORE would silently skip updated rows without this.
+		// Packages recorded under another prefix keep that prefix's edges.
 		for pkgName := range depsTree {
+			if owner[pkgName] != prefix {
+				continue
+			}
 			if err := s.store.ClearDependencies(pkgName); err != nil {
 				return fmt.Errorf("failed to clear dependencies for %s: %w", pkgName, err)
 			}
@@ -41,6 +68,9 @@ func (s *Scanner) ScanPackages() error {
 
 		// Store all dependency relationships
 		for pkgName, deps := range depsTree {
+			if owner[pkgName] != prefix {
+				continue
+			}
 			for _, dep := range deps {
 				// Check if both package and dependency exist before inserting relationship
 				// This skips runtime dependencies that aren't installed as top-level packages
@@ -76,21 +106,14 @@ func (s *Scanner) GetInventory() ([]*brew.Package, error) {
 	return packages, nil
 }
 
-// RefreshBinaryPaths rescans the brew prefix bin directory and updates
-// binary paths for all packages in the database.
+// RefreshBinaryPaths rescans the bin directory of every brew prefix and
+// updates binary paths for all packages in the database. A link is only
+// attributed to a package recorded under the same prefix, so the same
+// formula in two prefixes does not collect both prefixes' binaries.
 func (s *Scanner) RefreshBinaryPaths() error {
-	// Get brew prefix
-	prefix, err := brew.GetBrewPrefix()
-	if err != nil {
-		return fmt.Errorf("failed to get brew prefix: %w", err)
-	}
-
-	binDir := filepath.Join(prefix, "bin")
-
-	// Read all files in bin directory
-	entries, err := os.ReadDir(binDir)
+	prefixes, err := s.Prefixes()
 	if err != nil {
-		return fmt.Errorf("failed to read bin directory: %w", err)
+		return err
 	}
 
 	// Get all packages from database
@@ -99,6 +122,13 @@ func (s *Scanner) RefreshBinaryPaths() error {
 		return fmt.Errorf("failed to list packages: %w", err)
 	}
 
+	// Cask binaries may link into an application bundle rather than the
+	// Caskroom (e.g. bin/code -> /Applications/Visual Studio Code.app/...).
+	caskApps, err := s.store.ListCaskApps()
+	if err != nil && !errors.Is(err, store.ErrNotInitialized) {
+		return fmt.Errorf("failed to list cask apps: %w", err)
+	}
+
 	// Build a map of package names for quick lookup
 	pkgMap := make(map[string]*brew.Package)
 	for _, pkg := range packages {
@@ -108,52 +138,66 @@ func (s *Scanner) RefreshBinaryPaths() error {
 		pkg.HasBinary = false
 	}
 
-	// Scan binaries and match to packages
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
-		name := entry.Name()
-		fullPath := filepath.Join(binDir, name)
+	for _, prefix := range prefixes {
+		binDir := filepath.Join(prefix, "bin")
 
-		// Check if this is a symlink
-		info, err := os.Lstat(fullPath)
+		// Read all files in bin directory
+		entries, err := os.ReadDir(binDir)
 		if err != nil {
-			continue
+			return fmt.Errorf("failed to read bin directory: %w", err)
 		}
 
-		if info.Mode()&os.ModeSymlink != 0 {
-			// Verify the symlink target is reachable before adding it.
-			// This prevents storing broken symlinks (e.g., bat's cat alias
-			// that was removed but symlink metadata still exists).
-			if _, err := os.Stat(fullPath); err != nil {
-				continue // Broken symlink - skip
+		// Scan binaries and match to packages
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
 			}
 
-			// Resolve symlink to find which package it belongs to
-			target, err := os.Readlink(fullPath)
+			name := entry.Name()
+			fullPath := filepath.Join(binDir, name)
+
+			// Check if this is a symlink
+			info, err := os.Lstat(fullPath)
 			if err != nil {
 				continue
 			}
 
-			// If not absolute, make it absolute relative to binDir
-			if !filepath.IsAbs(target) {
-				target = filepath.Join(binDir, target)
-			}
+			if info.Mode()&os.ModeSymlink != 0 {
+				// Verify the symlink target is reachable before adding it.
+				// This prevents storing broken symlinks (e.g., bat's cat alias
+				// that was removed but symlink metadata still exists).
+				if _, err := os.Stat(fullPath); err != nil {
+					continue // Broken symlink - skip
+				}
 
-			// Extract package name from the symlink target path
-			// Typical path: /opt/homebrew/Cellar/PACKAGE/VERSION/bin/BINARY
-			// or: ../Cellar/PACKAGE/VERSION/bin/BINARY
-			pkgName := extractPackageFromPath(target)
-			if pkgName == "" {
-				continue
-			}
+				// Resolve symlink to find which package it belongs to
+				target, err := os.Readlink(fullPath)
+				if err != nil {
+					continue
+				}
+
+				// If not absolute, make it absolute relative to binDir
+				if !filepath.IsAbs(target) {
+					target = filepath.Join(binDir, target)
+				}
+
+				// Extract package name from the symlink target path
+				// Typical path: /opt/homebrew/Cellar/PACKAGE/VERSION/bin/BINARY
+				// or: ../Cellar/PACKAGE/VERSION/bin/BINARY
+				// or, for casks: ../Caskroom/CASK/VERSION/BINARY
+				pkgName := extractPackageFromPath(target)
+				if pkgName == "" {
+					pkgName = linkOwner(fullPath, caskApps)
+				}
+				if pkgName == "" {
+					continue
+				}
 
-			// Update package binary paths
-			if pkg, exists := pkgMap[pkgName]; exists {
-				pkg.BinaryPaths = append(pkg.BinaryPaths, fullPath)
-				pkg.HasBinary = true
+				// Update package binary paths
+				if pkg, exists := pkgMap[pkgName]; exists && (pkg.Prefix == "" || pkg.Prefix == prefix) {
+					pkg.BinaryPaths = append(pkg.BinaryPaths, fullPath)
+					pkg.HasBinary = true
+				}
 			}
 		}
 	}
@@ -168,9 +212,11 @@ func (s *Scanner) RefreshBinaryPaths() error {
 	return nil
 }
 
-// extractPackageFromPath extracts the package name from a Cellar path.
+// extractPackageFromPath extracts the package name from a Cellar or
+// Caskroom path.
 // Example: /opt/homebrew/Cellar/git/2.43.0/bin/git -> "git"
 // Example: ../Cellar/node/20.10.0/bin/node -> "node"
+// Example: /opt/homebrew/Caskroom/1password-cli/2.30.0/op -> "1password-cli"
 func extractPackageFromPath(path string) string {
 	// Normalize path
 	path = filepath.Clean(path)
@@ -178,9 +224,9 @@ func extractPackageFromPath(path string) string {
 	// Split path into components
 	parts := strings.Split(path, string(filepath.Separator))
 
-	// Find "Cellar" in the path
+	// Find "Cellar" or "Caskroom" in the path
 	for i, part := range parts {
-		if part == "Cellar" && i+1 < len(parts) {
+		if (part == "Cellar" || part == "Caskroom") && i+1 < len(parts) {
 			// Next component is the package name
 			return parts[i+1]
 		}
@@ -188,3 +234,35 @@ func extractPackageFromPath(path string) string {
 
 	return ""
 }
+
+// linkOwner returns the package owning the binary linked at binPath when its
+// immediate target does not name one: the keg or Caskroom directory the link
+// finally resolves into, or the cask whose application bundle it points into
+// (bin/code -> /Applications/Visual Studio Code.app/...). It returns ""
+// when no package matches.
+func linkOwner(binPath string, apps []*store.CaskApp) string {
+	resolved, err := filepath.EvalSymlinks(binPath)
+	if err != nil {
+		return ""
+	}
+	if pkg := extractPackageFromPath(resolved); pkg != "" {
+		return pkg
+	}
+
+	target, err := os.Readlink(binPath)
+	if err != nil {
+		return ""
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(binPath), target)
+	}
+	target = filepath.Clean(target)
+	for _, app := range apps {
+		for _, p := range []string{target, resolved} {
+			if strings.HasPrefix(p, app.Path+string(filepath.Separator)) {
+				return app.Package
+			}
+		}
+	}
+	return ""
+}
@@ -147,6 +147,11 @@ func TestExtractPackageFromPath(t *testing.T) {
 			path:     "/usr/local/Cellar/python@3.11/3.11.7/bin/python3",
 			expected: "python@3.11",
 		},
+		{
+			name:     "caskroom path",
+			path:     "../Caskroom/1password-cli/2.30.0/op",
+			expected: "1password-cli",
+		},
 		{
 			name:     "no cellar in path",
 			path:     "/usr/bin/git",
@@ -169,6 +174,60 @@ func TestExtractPackageFromPath(t *testing.T) {
 	}
 }
 
+func TestLinkOwner(t *testing.T) {
+	root := t.TempDir()
+	bin := filepath.Join(root, "prefix", "bin")
+	caskroom := filepath.Join(root, "prefix", "Caskroom")
+	apps := filepath.Join(root, "Applications")
+
+	files := []string{
+		filepath.Join(apps, "Visual Studio Code.app", "Contents", "Resources", "app", "bin", "code"),
+		filepath.Join(caskroom, "rectangle", "0.80", "Rectangle.app", "Contents", "MacOS", "rectangle-cli"),
+		filepath.Join(caskroom, "google-cloud-sdk", "latest", "google-cloud-sdk", "bin", "gcloud"),
+		filepath.Join(root, "elsewhere", "tool"),
+	}
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f), 0755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(f, []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	symlink := func(target, link string) {
+		t.Helper()
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatalf("Symlink: %v", err)
+		}
+	}
+	// Rectangle.app is moved to Applications as a symlink into the Caskroom.
+	symlink(filepath.Join(caskroom, "rectangle", "0.80", "Rectangle.app"), filepath.Join(apps, "Rectangle.app"))
+
+	symlink(filepath.Join(apps, "Visual Studio Code.app", "Contents", "Resources", "app", "bin", "code"), filepath.Join(bin, "code"))
+	symlink(filepath.Join(apps, "Rectangle.app", "Contents", "MacOS", "rectangle-cli"), filepath.Join(bin, "rectangle-cli"))
+	symlink("../Caskroom/google-cloud-sdk/latest/google-cloud-sdk/bin/gcloud", filepath.Join(bin, "gcloud"))
+	symlink(filepath.Join(root, "elsewhere", "tool"), filepath.Join(bin, "tool"))
+
+	caskApps := []*store.CaskApp{
+		{Package: "visual-studio-code", Name: "Visual Studio Code.app", Path: filepath.Join(apps, "Visual Studio Code.app")},
+	}
+
+	tests := map[string]string{
+		"code":          "visual-studio-code",
+		"rectangle-cli": "rectangle",
+		"gcloud":        "google-cloud-sdk",
+		"tool":          "",
+	}
+	for name, want := range tests {
+		if got := linkOwner(filepath.Join(bin, name), caskApps); got != want {
+			t.Errorf("linkOwner(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
 func TestRefreshBinaryPaths_Integration(t *testing.T) {
 	// This is more of an integration test that would need actual brew binaries
 	// For now, we'll create a mock directory structure
@@ -235,6 +294,62 @@ func TestRefreshBinaryPaths_EmptyBinDir(t *testing.T) {
 	t.Skip("RefreshBinaryPaths requires brew integration mocking")
 }
 
+func TestRefreshBinaryPaths_MultiplePrefixes(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	arm, intel := t.TempDir(), t.TempDir()
+	link := func(prefix, pkg, bin string) {
+		t.Helper()
+		keg := filepath.Join(prefix, "Cellar", pkg, "1.0", "bin")
+		if err := os.MkdirAll(keg, 0755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(keg, bin), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		if err := os.MkdirAll(filepath.Join(prefix, "bin"), 0755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		target := filepath.Join("..", "Cellar", pkg, "1.0", "bin", bin)
+		if err := os.Symlink(target, filepath.Join(prefix, "bin", bin)); err != nil {
+			t.Fatalf("Symlink: %v", err)
+		}
+	}
+	link(arm, "gcc", "gcc-14")
+	link(intel, "gcc", "gcc-14")
+	link(intel, "wget", "wget")
+
+	for _, pkg := range []*brew.Package{
+		{Name: "gcc", InstalledAt: time.Now(), Prefix: arm},
+		{Name: "wget", InstalledAt: time.Now(), Prefix: intel},
+	} {
+		if err := s.InsertPackage(pkg); err != nil {
+			t.Fatalf("InsertPackage(%s): %v", pkg.Name, err)
+		}
+	}
+
+	sc := New(s)
+	sc.SetPrefixes([]string{arm, intel})
+	if err := sc.RefreshBinaryPaths(); err != nil {
+		t.Fatalf("RefreshBinaryPaths: %v", err)
+	}
+
+	want := map[string]string{
+		"gcc":  filepath.Join(arm, "bin", "gcc-14"),
+		"wget": filepath.Join(intel, "bin", "wget"),
+	}
+	for name, path := range want {
+		pkg, err := s.GetPackage(name)
+		if err != nil {
+			t.Fatalf("GetPackage(%s): %v", name, err)
+		}
+		if len(pkg.BinaryPaths) != 1 || pkg.BinaryPaths[0] != path {
+			t.Errorf("%s BinaryPaths = %v, want [%s]", name, pkg.BinaryPaths, path)
+		}
+	}
+}
+
 func TestScanPackages_Mock(t *testing.T) {
 	// This test demonstrates the expected behavior with mocked brew functions
 	// In a real implementation, we would use a mocking framework or
@@ -0,0 +1,248 @@
+package scanner
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// maxLinkageDepth bounds how far library-to-library linkage is followed from
+// an executable. Real dependency chains in Homebrew are shallow; the limit
+// guards against pathological cycles in rpath-heavy kegs.
+const maxLinkageDepth = 8
+
+// libraryRef identifies a shared library inside a Cellar keg.
+type libraryRef struct {
+	pkg  string
+	path string
+}
+
+// readImportedLibraries returns the shared libraries an object file loads.
+// It is a variable so tests can substitute fixtures for real binaries.
+var readImportedLibraries = importedLibraries
+
+// ScanLinkage inspects every binary linked into the brew prefix, follows its
+// dynamic library dependencies (ELF DT_NEEDED on Linux, Mach-O load commands
+// on macOS) and records which other packages' shared libraries it loads.
+// Library-to-library dependencies are followed transitively, so a binary that
+// loads libA, which in turn loads libB, is linked to both owning packages.
+//
+// cellars are the Homebrew Cellar directories, e.g. /opt/homebrew/Cellar,
+// one per scanned prefix. It returns the number of linkage rows recorded.
+//
+// Reading the binaries updates their access times; the scan's time window
+// is recorded so DetectBypass does not mistake it for executions.
+func (s *Scanner) ScanLinkage(cellars ...string) (total int, err error) {
+	start := time.Now()
+	defer func() {
+		if recErr := s.store.RecordLinkageScan(start, time.Now()); recErr != nil && err == nil {
+			err = recErr
+		}
+	}()
+
+	index, err := buildLibraryIndex(cellars...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to index Cellar libraries: %w", err)
+	}
+
+	packages, err := s.store.ListPackages()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list packages: %w", err)
+	}
+
+	// Library imports are shared heavily (libc++, openssl, ...); parse each
+	// object at most once per scan.
+	cache := make(map[string][]string)
+
+	for _, pkg := range packages {
+		var links []*store.Linkage
+		for _, binPath := range pkg.BinaryPaths {
+			realPath, err := filepath.EvalSymlinks(binPath)
+			if err != nil {
+				continue // Broken symlink — RefreshBinaryPaths will drop it
+			}
+
+			binaryName := filepath.Base(binPath)
+			for _, lib := range resolveLinkage(realPath, index, cache) {
+				if lib.pkg == pkg.Name {
+					continue // A package loading its own libraries is not usage of another
+				}
+				links = append(links, &store.Linkage{
+					Package:        pkg.Name,
+					BinaryName:     binaryName,
+					LibraryPackage: lib.pkg,
+					Library:        filepath.Base(lib.path),
+				})
+			}
+		}
+
+		if err := s.store.ReplaceLinkages(pkg.Name, links); err != nil {
+			return total, err
+		}
+		total += len(links)
+	}
+
+	return total, nil
+}
+
+// resolveLinkage returns every Cellar library reachable from the object at
+// path, following library dependencies breadth-first.
+func resolveLinkage(path string, index map[string]libraryRef, cache map[string][]string) []libraryRef {
+	var result []libraryRef
+	visited := map[string]bool{path: true}
+	frontier := []string{path}
+
+	for depth := 0; depth < maxLinkageDepth && len(frontier) > 0; depth++ {
+		var next []string
+		for _, obj := range frontier {
+			imports, ok := cache[obj]
+			if !ok {
+				imports, _ = readImportedLibraries(obj) // unreadable objects link nothing
+				cache[obj] = imports
+			}
+
+			for _, name := range imports {
+				lib, ok := lookupLibrary(name, index)
+				if !ok || visited[lib.path] {
+					continue
+				}
+				visited[lib.path] = true
+				result = append(result, lib)
+				next = append(next, lib.path)
+			}
+		}
+		frontier = next
+	}
+
+	return result
+}
+
+// lookupLibrary maps an imported library name to the keg that owns it.
+// Mach-O install names are usually absolute opt or Cellar paths; ELF
+// DT_NEEDED entries and @rpath names are matched by basename.
+func lookupLibrary(name string, index map[string]libraryRef) (libraryRef, bool) {
+	if lib, ok := index[filepath.Base(name)]; ok {
+		if pkg := extractPackageFromLibraryPath(name); pkg != "" && pkg != lib.pkg {
+			// Basename collision across kegs — trust the explicit path.
+			return libraryRef{pkg: pkg, path: lib.path}, true
+		}
+		return lib, true
+	}
+
+	if pkg := extractPackageFromLibraryPath(name); pkg != "" {
+		return libraryRef{pkg: pkg, path: name}, true
+	}
+
+	return libraryRef{}, false
+}
+
+// extractPackageFromLibraryPath extracts the owning package from an absolute
+// library path under the brew prefix.
+// Example: /opt/homebrew/Cellar/pcre2/10.42/lib/libpcre2-8.0.dylib -> "pcre2"
+// Example: /opt/homebrew/opt/openssl@3/lib/libssl.3.dylib -> "openssl@3"
+func extractPackageFromLibraryPath(path string) string {
+	if !filepath.IsAbs(path) {
+		return ""
+	}
+	if pkg := extractPackageFromPath(path); pkg != "" {
+		return pkg
+	}
+
+	parts := strings.Split(filepath.Clean(path), string(filepath.Separator))
+	for i := 0; i+2 < len(parts); i++ {
+		if parts[i] == "opt" && parts[i+2] == "lib" {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
+// buildLibraryIndex walks the lib directory of every keg in the cellars and
+// maps shared library basenames to their owning package; on a basename
+// collision the earlier cellar wins. Missing Cellars contribute nothing.
+func buildLibraryIndex(cellars ...string) (map[string]libraryRef, error) {
+	index := make(map[string]libraryRef)
+
+	for _, cellar := range cellars {
+		pkgDirs, err := os.ReadDir(cellar)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, pkgDir := range pkgDirs {
+			if !pkgDir.IsDir() {
+				continue
+			}
+			pkgName := pkgDir.Name()
+
+			versions, err := os.ReadDir(filepath.Join(cellar, pkgName))
+			if err != nil {
+				continue
+			}
+			for _, version := range versions {
+				libDir := filepath.Join(cellar, pkgName, version.Name(), "lib")
+				_ = filepath.WalkDir(libDir, func(path string, d fs.DirEntry, err error) error {
+					if err != nil {
+						return nil // Keg without lib/ or unreadable subtree
+					}
+					if d.IsDir() || !isSharedLibrary(d.Name()) {
+						return nil
+					}
+					if _, exists := index[d.Name()]; !exists {
+						index[d.Name()] = libraryRef{pkg: pkgName, path: path}
+					}
+					return nil
+				})
+			}
+		}
+	}
+
+	return index, nil
+}
+
+// isSharedLibrary reports whether a filename looks like a shared library.
+func isSharedLibrary(name string) bool {
+	return strings.HasSuffix(name, ".dylib") ||
+		strings.HasSuffix(name, ".so") ||
+		strings.Contains(name, ".so.")
+}
+
+// importedLibraries returns the shared libraries imported by an ELF or
+// Mach-O object. Files in neither format (scripts, data) import nothing and
+// are not an error.
+func importedLibraries(path string) ([]string, error) {
+	if f, err := elf.Open(path); err == nil {
+		defer f.Close()
+		return f.ImportedLibraries()
+	}
+
+	if f, err := macho.Open(path); err == nil {
+		defer f.Close()
+		return f.ImportedLibraries()
+	}
+
+	if f, err := macho.OpenFat(path); err == nil {
+		defer f.Close()
+		// Every architecture in a universal binary is linked the same way in
+		// practice; the first one is representative.
+		if len(f.Arches) > 0 {
+			return f.Arches[0].ImportedLibraries()
+		}
+		return nil, nil
+	} else if errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	return nil, nil
+}
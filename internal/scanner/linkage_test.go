@@ -0,0 +1,156 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// writeKegFile creates an empty file inside a fake Cellar keg.
+func writeKegFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(path, nil, 0755); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
+
+func TestScanLinkage_TransitiveLibraries(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	root := t.TempDir()
+	cellar := filepath.Join(root, "Cellar")
+	binDir := filepath.Join(root, "bin")
+
+	gitBin := filepath.Join(cellar, "git", "2.43.0", "bin", "git")
+	pcreLib := filepath.Join(cellar, "pcre2", "10.42", "lib", "libpcre2-8.so.0")
+	zlibLib := filepath.Join(cellar, "zlib", "1.3", "lib", "libz.so.1")
+	ownLib := filepath.Join(cellar, "git", "2.43.0", "lib", "libgit-internal.so")
+	for _, p := range []string{gitBin, pcreLib, zlibLib, ownLib} {
+		writeKegFile(t, p)
+	}
+
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	gitLink := filepath.Join(binDir, "git")
+	if err := os.Symlink(gitBin, gitLink); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+
+	// git loads pcre2 and its own library; pcre2 in turn loads zlib.
+	imports := map[string][]string{
+		gitBin:  {"libpcre2-8.so.0", "libgit-internal.so", "libc.so.6"},
+		pcreLib: {"libz.so.1"},
+	}
+	orig := readImportedLibraries
+	readImportedLibraries = func(path string) ([]string, error) { return imports[path], nil }
+	defer func() { readImportedLibraries = orig }()
+
+	for _, pkg := range []*brew.Package{
+		{Name: "git", InstalledAt: time.Now(), HasBinary: true, BinaryPaths: []string{gitLink}},
+		{Name: "pcre2", InstalledAt: time.Now()},
+		{Name: "zlib", InstalledAt: time.Now()},
+	} {
+		if err := s.InsertPackage(pkg); err != nil {
+			t.Fatalf("InsertPackage: %v", err)
+		}
+	}
+
+	count, err := New(s).ScanLinkage(cellar)
+	if err != nil {
+		t.Fatalf("ScanLinkage() error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("ScanLinkage() recorded %d linkages, want 2 (pcre2, zlib)", count)
+	}
+
+	if err := s.InsertUsageEvent(&store.UsageEvent{
+		Package:    "git",
+		EventType:  "exec",
+		BinaryPath: gitLink,
+		BinaryName: "git",
+		Timestamp:  time.Now(),
+	}); err != nil {
+		t.Fatalf("InsertUsageEvent: %v", err)
+	}
+
+	for _, lib := range []string{"pcre2", "zlib"} {
+		usage, err := s.GetIndirectUsage(lib)
+		if err != nil {
+			t.Fatalf("GetIndirectUsage(%s) error: %v", lib, err)
+		}
+		if len(usage) != 1 || usage[0].Via != "git" || usage[0].Binary != "git" {
+			t.Errorf("GetIndirectUsage(%s) = %+v, want one entry via git", lib, usage)
+		}
+	}
+
+	usage, err := s.GetIndirectUsage("git")
+	if err != nil {
+		t.Fatalf("GetIndirectUsage(git) error: %v", err)
+	}
+	if len(usage) != 0 {
+		t.Errorf("self-linkage should not count as indirect usage, got %+v", usage)
+	}
+}
+
+func TestLookupLibrary_MachOInstallNames(t *testing.T) {
+	index := map[string]libraryRef{
+		"libssl.3.dylib": {pkg: "openssl@3", path: "/opt/homebrew/Cellar/openssl@3/3.2.0/lib/libssl.3.dylib"},
+	}
+
+	tests := []struct {
+		name    string
+		want    string
+		wantHit bool
+	}{
+		{"@rpath/libssl.3.dylib", "openssl@3", true},
+		{"/opt/homebrew/opt/openssl@3/lib/libssl.3.dylib", "openssl@3", true},
+		{"/opt/homebrew/opt/libyaml/lib/libyaml-0.2.dylib", "libyaml", true},
+		{"/usr/local/Cellar/gettext/0.22/lib/libintl.8.dylib", "gettext", true},
+		{"/usr/lib/libSystem.B.dylib", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lib, ok := lookupLibrary(tt.name, index)
+			if ok != tt.wantHit || lib.pkg != tt.want {
+				t.Errorf("lookupLibrary(%q) = %q, %v; want %q, %v", tt.name, lib.pkg, ok, tt.want, tt.wantHit)
+			}
+		})
+	}
+}
+
+func TestImportedLibraries_NonObjectFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho hi\n"), 0755); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	libs, err := importedLibraries(path)
+	if err != nil {
+		t.Errorf("importedLibraries() error for script: %v", err)
+	}
+	if len(libs) != 0 {
+		t.Errorf("importedLibraries() = %v, want none for script", libs)
+	}
+}
+
+func TestImportedLibraries_RealExecutable(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skipf("cannot locate test executable: %v", err)
+	}
+	// The test binary is a native ELF or Mach-O object; parsing it must not fail
+	// even if it is statically linked and imports nothing.
+	if _, err := importedLibraries(exe); err != nil {
+		t.Errorf("importedLibraries(%s) error: %v", exe, err)
+	}
+}
@@ -0,0 +1,344 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/projects"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// runtimeSkipDirs are directories never descended into when searching the
+// runtime roots: caches, package trees and the macOS Library.
+var runtimeSkipDirs = map[string]bool{
+	".git":          true,
+	".cache":        true,
+	".Trash":        true,
+	".npm":          true,
+	"Library":       true,
+	"node_modules":  true,
+	"site-packages": true,
+	"__pycache__":   true,
+}
+
+// pyvenvKeys are the pyvenv.cfg keys naming the base interpreter, in the
+// order they are tried.
+var pyvenvKeys = []string{"home", "executable", "base-executable"}
+
+// RuntimeOptions configures DetectRuntimeReferences.
+type RuntimeOptions struct {
+	// Prefixes are the Homebrew prefixes whose opt and Cellar directories
+	// hold the runtimes, e.g. "/opt/homebrew".
+	Prefixes []string
+
+	// Roots are searched for pyvenv.cfg files and symlinks, up to MaxDepth
+	// directory levels deep.
+	Roots    []string
+	MaxDepth int
+
+	// ScriptDirs are directories whose scripts' "#!" lines are checked.
+	ScriptDirs []string
+}
+
+// runtimeDetector maps paths inside the brew prefixes to installed packages.
+type runtimeDetector struct {
+	opts      RuntimeOptions
+	installed map[string]bool
+	pattern   *regexp.Regexp
+	now       time.Time
+}
+
+// DetectRuntimeReferences looks for language runtimes (python@3.9, node@16,
+// ruby, ...) that are used without any of their binaries passing through a
+// shim:
+//
+//   - Python virtualenvs whose pyvenv.cfg names the runtime as their home;
+//   - scripts in the script directories whose "#!" line runs its
+//     interpreter;
+//   - symlinks, such as version-manager entries, into its opt or Cellar
+//     directory.
+//
+// Linked paths such as "<prefix>/bin/python3" are resolved to the keg they
+// point at. Symlinks inside a virtualenv that is itself reported for the
+// same package are not reported again.
+func (s *Scanner) DetectRuntimeReferences(opts RuntimeOptions) ([]*store.RuntimeReference, error) {
+	if len(opts.Prefixes) == 0 {
+		return nil, nil
+	}
+
+	packages, err := s.store.ListPackages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list packages: %w", err)
+	}
+	d := &runtimeDetector{
+		opts:      opts,
+		installed: make(map[string]bool, len(packages)),
+		pattern:   kegPathPattern(withResolvedPaths(opts.Prefixes)),
+		now:       time.Now(),
+	}
+	for _, pkg := range packages {
+		d.installed[pkg.Name] = true
+	}
+
+	var refs []*store.RuntimeReference
+	for _, root := range opts.Roots {
+		refs = append(refs, d.walkRoot(root)...)
+	}
+	for _, dir := range opts.ScriptDirs {
+		refs = append(refs, d.scanScriptDir(filepath.Clean(dir))...)
+	}
+	refs = dropVenvSymlinks(refs)
+
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Package != refs[j].Package {
+			return refs[i].Package < refs[j].Package
+		}
+		if refs[i].Kind != refs[j].Kind {
+			return refs[i].Kind < refs[j].Kind
+		}
+		return refs[i].Path < refs[j].Path
+	})
+	return refs, nil
+}
+
+// kegPathPattern matches paths into an opt or Cellar directory under any of
+// prefixes, capturing the package name.
+func kegPathPattern(prefixes []string) *regexp.Regexp {
+	quoted := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		quoted[i] = regexp.QuoteMeta(strings.TrimSuffix(filepath.Clean(p), "/"))
+	}
+	return regexp.MustCompile(`^(?:` + strings.Join(quoted, "|") + `)/(?:opt|Cellar)/([^/]+)(?:/|$)`)
+}
+
+// withResolvedPaths returns paths followed by the symlink-resolved form of
+// each that differs, so paths resolved by packageFor still match.
+func withResolvedPaths(paths []string) []string {
+	all := append([]string{}, paths...)
+	for _, p := range paths {
+		if real, err := filepath.EvalSymlinks(p); err == nil && real != filepath.Clean(p) {
+			all = append(all, real)
+		}
+	}
+	return all
+}
+
+// packageFor returns the installed package whose keg holds path, or "" when
+// path is outside every installed keg. Paths elsewhere in a prefix, such as linked
+// bin/ entries, are resolved through their symlinks first.
+func (d *runtimeDetector) packageFor(path string) string {
+	path = filepath.Clean(path)
+	if m := d.pattern.FindStringSubmatch(path); m != nil {
+		if d.installed[m[1]] {
+			return m[1]
+		}
+		return ""
+	}
+	if !d.inPrefix(path) {
+		return ""
+	}
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return ""
+	}
+	if m := d.pattern.FindStringSubmatch(real); m != nil && d.installed[m[1]] {
+		return m[1]
+	}
+	return ""
+}
+
+// inPrefix reports whether path lies inside one of the brew prefixes.
+func (d *runtimeDetector) inPrefix(path string) bool {
+	for _, prefix := range d.opts.Prefixes {
+		prefix = filepath.Clean(prefix)
+		if path == prefix || strings.HasPrefix(path, prefix+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// walkRoot finds virtualenvs and symlinks into kegs under root.
+func (d *runtimeDetector) walkRoot(root string) []*store.RuntimeReference {
+	var refs []*store.RuntimeReference
+	skipDir := func(path string, entry fs.DirEntry) bool {
+		return runtimeSkipDirs[entry.Name()] || d.inPrefix(path)
+	}
+	projects.Walk(root, d.opts.MaxDepth+1, skipDir, func(path string, entry fs.DirEntry) {
+		switch {
+		case entry.Type()&fs.ModeSymlink != 0:
+			if ref := d.symlinkReference(path); ref != nil {
+				refs = append(refs, ref)
+			}
+		case entry.Name() == "pyvenv.cfg" && entry.Type().IsRegular():
+			if ref := d.venvReference(path); ref != nil {
+				refs = append(refs, ref)
+			}
+		}
+	})
+	return refs
+}
+
+// scanScriptDir checks the scripts directly inside dir for "#!" lines
+// running a brew runtime, and its symlinks for links into kegs.
+func (d *runtimeDetector) scanScriptDir(dir string) []*store.RuntimeReference {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var refs []*store.RuntimeReference
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		var ref *store.RuntimeReference
+		switch {
+		case entry.Type()&fs.ModeSymlink != 0:
+			ref = d.symlinkReference(path)
+		case entry.Type().IsRegular():
+			ref = d.shebangReference(path)
+		}
+		if ref != nil {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// reference returns a RuntimeReference to target's package, or nil when
+// target is not inside an installed keg.
+func (d *runtimeDetector) reference(kind, path, target string) *store.RuntimeReference {
+	pkg := d.packageFor(target)
+	if pkg == "" {
+		return nil
+	}
+	return &store.RuntimeReference{
+		Package:    pkg,
+		Kind:       kind,
+		Path:       path,
+		Target:     target,
+		DetectedAt: d.now,
+	}
+}
+
+// symlinkReference reports a symlink pointing into a keg.
+func (d *runtimeDetector) symlinkReference(path string) *store.RuntimeReference {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return nil
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	return d.reference(store.RuntimeSymlink, path, filepath.Clean(target))
+}
+
+// venvReference reports the virtualenv holding a pyvenv.cfg whose base
+// interpreter is in a keg.
+func (d *runtimeDetector) venvReference(cfgPath string) *store.RuntimeReference {
+	values, err := readPyvenvCfg(cfgPath)
+	if err != nil {
+		return nil
+	}
+	for _, key := range pyvenvKeys {
+		if value := values[key]; filepath.IsAbs(value) {
+			if ref := d.reference(store.RuntimeVenv, filepath.Dir(cfgPath), value); ref != nil {
+				return ref
+			}
+		}
+	}
+	return nil
+}
+
+// shebangReference reports a script whose "#!" interpreter is in a keg.
+func (d *runtimeDetector) shebangReference(path string) *store.RuntimeReference {
+	interpreter, err := readShebang(path)
+	if err != nil || !filepath.IsAbs(interpreter) {
+		return nil
+	}
+	return d.reference(store.RuntimeShebang, path, interpreter)
+}
+
+// readPyvenvCfg parses the "key = value" lines of a pyvenv.cfg file.
+func readPyvenvCfg(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return values, scanner.Err()
+}
+
+// readShebang returns the interpreter named by a file's "#!" line, or ""
+// when it has none.
+func readShebang(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, 256)
+	n, _ := f.Read(head)
+	line, _, _ := strings.Cut(string(head[:n]), "\n")
+	rest, ok := strings.CutPrefix(line, "#!")
+	if !ok {
+		return "", nil
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return fields[0], nil
+}
+
+// dropVenvSymlinks removes symlink references inside a virtualenv that is
+// reported for the same package, such as .venv/bin/python.
+func dropVenvSymlinks(refs []*store.RuntimeReference) []*store.RuntimeReference {
+	venvs := make(map[string]string) // virtualenv directory → package
+	for _, r := range refs {
+		if r.Kind == store.RuntimeVenv {
+			venvs[r.Path] = r.Package
+		}
+	}
+	if len(venvs) == 0 {
+		return refs
+	}
+
+	kept := refs[:0]
+	for _, r := range refs {
+		if r.Kind == store.RuntimeSymlink && insideVenv(r, venvs) {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
+// insideVenv reports whether r lies inside a virtualenv reported for its
+// package.
+func insideVenv(r *store.RuntimeReference, venvs map[string]string) bool {
+	for dir := filepath.Dir(r.Path); ; dir = filepath.Dir(dir) {
+		if venvs[dir] == r.Package {
+			return true
+		}
+		if dir == filepath.Dir(dir) {
+			return false
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/brew"
+)
+
+func TestDetectRuntimeReferences(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	for _, name := range []string{"python@3.9", "node@16", "ruby", "jq"} {
+		if err := s.InsertPackage(&brew.Package{Name: name, InstalledAt: time.Now()}); err != nil {
+			t.Fatalf("InsertPackage(%s): %v", name, err)
+		}
+	}
+
+	prefix := t.TempDir()
+	home := t.TempDir()
+	mkdir := func(path string) {
+		t.Helper()
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(path, content string) {
+		t.Helper()
+		mkdir(filepath.Dir(path))
+		if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	symlink := func(target, path string) {
+		t.Helper()
+		mkdir(filepath.Dir(path))
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Kegs, their opt links and a linked bin/ entry.
+	for _, keg := range []string{"python@3.9/3.9.18", "node@16/16.20.2", "ruby/3.3.0", "perl/5.38"} {
+		mkdir(filepath.Join(prefix, "Cellar", keg, "bin"))
+		pkg := filepath.Dir(keg)
+		symlink(filepath.Join("..", "Cellar", keg), filepath.Join(prefix, "opt", pkg))
+	}
+	write(filepath.Join(prefix, "Cellar", "ruby", "3.3.0", "bin", "ruby"), "")
+	symlink("../Cellar/ruby/3.3.0/bin/ruby", filepath.Join(prefix, "bin", "ruby"))
+
+	venv := filepath.Join(home, "src", "app", ".venv")
+	write(filepath.Join(venv, "pyvenv.cfg"), "home = "+filepath.Join(prefix, "opt", "python@3.9", "bin")+"\ninclude-system-site-packages = false\n")
+	symlink(filepath.Join(prefix, "opt", "python@3.9", "bin", "python3.9"), filepath.Join(venv, "bin", "python"))
+	write(filepath.Join(home, "src", "tool", "venv", "pyvenv.cfg"), "home = /usr/bin\n")
+	symlink(filepath.Join(prefix, "opt", "node@16"), filepath.Join(home, ".nodenv", "versions", "16"))
+	symlink(filepath.Join(prefix, "opt", "perl"), filepath.Join(home, ".plenv", "versions", "5.38")) // not installed
+	write(filepath.Join(home, "a", "b", "c", "d", "e", "pyvenv.cfg"), "home = "+filepath.Join(prefix, "opt", "python@3.9", "bin")+"\n")
+	write(filepath.Join(home, "Library", "x", "pyvenv.cfg"), "home = "+filepath.Join(prefix, "opt", "python@3.9", "bin")+"\n")
+
+	scripts := filepath.Join(home, "bin")
+	write(filepath.Join(scripts, "deploy"), "#!"+filepath.Join(prefix, "bin", "ruby")+" -w\nputs 1\n")
+	write(filepath.Join(scripts, "plain"), "#!/bin/sh\necho hi\n")
+	write(filepath.Join(scripts, "notes.txt"), "hello\n")
+
+	refs, err := New(s).DetectRuntimeReferences(RuntimeOptions{
+		Prefixes:   []string{prefix},
+		Roots:      []string{home},
+		MaxDepth:   4,
+		ScriptDirs: []string{scripts},
+	})
+	if err != nil {
+		t.Fatalf("DetectRuntimeReferences: %v", err)
+	}
+
+	var got []string
+	for _, r := range refs {
+		rel, _ := filepath.Rel(home, r.Path)
+		got = append(got, r.Package+" "+r.Kind+" "+rel)
+	}
+	want := []string{
+		"node@16 symlink .nodenv/versions/16",
+		"python@3.9 venv src/app/.venv",
+		"ruby shebang bin/deploy",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("DetectRuntimeReferences() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, r := range refs {
+		if r.Package == "ruby" && r.Target != filepath.Join(prefix, "bin", "ruby") {
+			t.Errorf("ruby Target = %q, want the interpreter path", r.Target)
+		}
+	}
+}
+
+func TestDetectRuntimeReferences_NoPrefixes(t *testing.T) {
+	s := setupTestStore(t)
+	defer s.Close()
+
+	refs, err := New(s).DetectRuntimeReferences(RuntimeOptions{Roots: []string{t.TempDir()}, MaxDepth: 3})
+	if err != nil || refs != nil {
+		t.Errorf("DetectRuntimeReferences() = %v, %v; want nil, nil", refs, err)
+	}
+}
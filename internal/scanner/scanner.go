@@ -1,13 +1,38 @@
 package scanner
 
-import "github.com/blackwell-systems/brewprune/internal/store"
+import (
+	"fmt"
+
+	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
 
 // Scanner manages package inventory and dependency graph operations.
 type Scanner struct {
-	store *store.Store
+	store    *store.Store
+	prefixes []string
 }
 
 // New creates a new Scanner instance with the given store.
 func New(store *store.Store) *Scanner {
 	return &Scanner{store: store}
 }
+
+// SetPrefixes sets the Homebrew prefixes the scanner inventories. When a
+// package is installed in several of them, the earliest prefix wins.
+func (s *Scanner) SetPrefixes(prefixes []string) {
+	s.prefixes = prefixes
+}
+
+// Prefixes returns the Homebrew prefixes the scanner inventories: those given
+// to SetPrefixes, or else the default prefix.
+func (s *Scanner) Prefixes() ([]string, error) {
+	if len(s.prefixes) == 0 {
+		prefix, err := brew.DefaultPrefix()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get brew prefix: %w", err)
+		}
+		s.prefixes = []string{prefix}
+	}
+	return s.prefixes, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+)
+
+// LaunchdPlist renders the launchd agent that runs spec's daemon at login.
+// KeepAlive restarts it only when it fails, so 'brewprune watch --stop'
+// leaves it stopped until the next login or 'service install'.
+func LaunchdPlist(spec Spec) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + LaunchdLabel + `</string>
+	<key>ProgramArguments</key>
+	<array>
+`)
+	for _, arg := range spec.args() {
+		b.WriteString("\t\t<string>" + xmlEscape(arg) + "</string>\n")
+	}
+	b.WriteString(`	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>ThrottleInterval</key>
+	<integer>10</integer>
+	<key>ProcessType</key>
+	<string>Background</string>
+	<key>StandardOutPath</key>
+	<string>` + xmlEscape(spec.LogFile) + `</string>
+	<key>StandardErrorPath</key>
+	<string>` + xmlEscape(spec.LogFile) + `</string>
+</dict>
+</plist>
+`)
+	return b.Bytes()
+}
+
+// SystemdUnitFile renders the systemd --user unit that runs spec's daemon.
+// Restart=on-failure mirrors the launchd agent's KeepAlive.
+func SystemdUnitFile(spec Spec) []byte {
+	args := spec.args()
+	for i, arg := range args {
+		args[i] = systemdQuote(arg)
+	}
+	logFile := systemdEscape(spec.LogFile)
+
+	return []byte(`[Unit]
+Description=brewprune usage tracking daemon
+Documentation=https://github.com/blackwell-systems/brewprune
+
+[Service]
+Type=simple
+ExecStart=` + strings.Join(args, " ") + `
+Restart=on-failure
+RestartSec=10
+StandardOutput=append:` + logFile + `
+StandardError=append:` + logFile + `
+
+[Install]
+WantedBy=default.target
+`)
+}
+
+// xmlEscape escapes s for use as XML character data.
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s)) //nolint:errcheck
+	return b.String()
+}
+
+// systemdEscape escapes the specifier character % in a unit file value.
+func systemdEscape(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+// systemdQuote quotes an ExecStart argument when it contains characters
+// systemd would split on or interpret.
+func systemdQuote(s string) string {
+	s = systemdEscape(s)
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\;$") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,222 @@
+// Package service installs the brewprune watch daemon as a per-user service:
+// a launchd agent on macOS or a systemd --user unit on Linux. The service
+// manager runs "brewprune watch --daemon-child" in the foreground and
+// restarts it when it fails, instead of relying on the PID-file fork done by
+// "watch --daemon".
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Service names.
+const (
+	// LaunchdLabel is the launchd job label; the agent plist is named after it.
+	LaunchdLabel = "com.blackwell-systems.brewprune"
+
+	// SystemdUnit is the name of the systemd --user unit.
+	SystemdUnit = "brewprune-watch.service"
+)
+
+// Spec describes the watch daemon a service runs.
+type Spec struct {
+	Executable string // Absolute path of the brewprune binary
+	PIDFile    string
+	LogFile    string
+}
+
+// args returns the daemon's command line, executable first.
+func (s Spec) args() []string {
+	return []string{s.Executable, "watch", "--daemon-child", "--pid-file", s.PIDFile, "--log-file", s.LogFile}
+}
+
+// runCommand runs a service manager command and returns its combined output.
+// Tests replace it.
+var runCommand = func(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	return strings.TrimSpace(string(out)), err
+}
+
+// Manager installs and controls the watch service with the platform's
+// service manager.
+type Manager struct {
+	// Name is the service manager, "launchd" or "systemd".
+	Name string
+
+	// Path is where the service definition is written.
+	Path string
+
+	render     func(Spec) []byte
+	activate   func(m *Manager) error
+	deactivate func(m *Manager) error
+	running    func(m *Manager) (bool, error)
+}
+
+// NewManager returns the service manager for goos, with definitions placed
+// under home. Only darwin (launchd) and linux (systemd) are supported.
+func NewManager(goos, home string) (*Manager, error) {
+	switch goos {
+	case "darwin":
+		return &Manager{
+			Name:       "launchd",
+			Path:       filepath.Join(home, "Library", "LaunchAgents", LaunchdLabel+".plist"),
+			render:     LaunchdPlist,
+			activate:   launchdActivate,
+			deactivate: launchdDeactivate,
+			running:    launchdRunning,
+		}, nil
+	case "linux":
+		return &Manager{
+			Name:       "systemd",
+			Path:       filepath.Join(systemdUserDir(home), SystemdUnit),
+			render:     SystemdUnitFile,
+			activate:   systemdActivate,
+			deactivate: systemdDeactivate,
+			running:    systemdRunning,
+		}, nil
+	default:
+		return nil, fmt.Errorf("services are not supported on %s; use 'brewprune watch --daemon'", goos)
+	}
+}
+
+// Render returns the service definition for spec.
+func (m *Manager) Render(spec Spec) []byte {
+	return m.render(spec)
+}
+
+// Installed reports whether the service definition exists.
+func (m *Manager) Installed() bool {
+	_, err := os.Stat(m.Path)
+	return err == nil
+}
+
+// Install writes the service definition for spec and starts the service. An
+// existing installation is replaced and restarted.
+func (m *Manager) Install(spec Spec) error {
+	if m.Installed() {
+		// Unload the old definition so the new one takes effect; it may
+		// already be stopped.
+		m.deactivate(m) //nolint:errcheck
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(m.Path), err)
+	}
+	if err := os.WriteFile(m.Path, m.render(spec), 0644); err != nil {
+		return fmt.Errorf("failed to write service file: %w", err)
+	}
+	if err := m.activate(m); err != nil {
+		return fmt.Errorf("failed to start service: %w", err)
+	}
+	return nil
+}
+
+// Uninstall stops the service and removes its definition. It is a no-op
+// when the service is not installed.
+func (m *Manager) Uninstall() error {
+	if !m.Installed() {
+		return nil
+	}
+	if err := m.deactivate(m); err != nil {
+		return fmt.Errorf("failed to stop service: %w", err)
+	}
+	if err := os.Remove(m.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove service file: %w", err)
+	}
+	if m.Name == "systemd" {
+		runCommand("systemctl", "--user", "daemon-reload") //nolint:errcheck
+	}
+	return nil
+}
+
+// Running reports whether the service manager has the service running.
+func (m *Manager) Running() (bool, error) {
+	return m.running(m)
+}
+
+// BrewServicesRegistration returns the service file "brew services start
+// brewprune" creates for goos under home, and whether it exists. A service
+// installed by brewprune would run a second daemon alongside it.
+func BrewServicesRegistration(goos, home string) (string, bool) {
+	var path string
+	switch goos {
+	case "darwin":
+		path = filepath.Join(home, "Library", "LaunchAgents", "homebrew.mxcl.brewprune.plist")
+	case "linux":
+		path = filepath.Join(systemdUserDir(home), "homebrew.brewprune.service")
+	default:
+		return "", false
+	}
+	_, err := os.Stat(path)
+	return path, err == nil
+}
+
+// systemdUserDir returns the directory holding systemd --user units.
+func systemdUserDir(home string) string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "systemd", "user")
+	}
+	return filepath.Join(home, ".config", "systemd", "user")
+}
+
+// launchdDomain returns the launchd domain of the current user's GUI session.
+func launchdDomain() string {
+	return "gui/" + strconv.Itoa(os.Getuid())
+}
+
+func launchdActivate(m *Manager) error {
+	if out, err := runCommand("launchctl", "bootstrap", launchdDomain(), m.Path); err != nil {
+		return fmt.Errorf("launchctl bootstrap: %v: %s", err, out)
+	}
+	return nil
+}
+
+func launchdDeactivate(m *Manager) error {
+	out, err := runCommand("launchctl", "bootout", launchdDomain()+"/"+LaunchdLabel)
+	if err != nil && !strings.Contains(out, "No such process") && !strings.Contains(out, "Could not find") {
+		return fmt.Errorf("launchctl bootout: %v: %s", err, out)
+	}
+	return nil
+}
+
+func launchdRunning(m *Manager) (bool, error) {
+	out, err := runCommand("launchctl", "print", launchdDomain()+"/"+LaunchdLabel)
+	if err != nil {
+		// Not loaded.
+		return false, nil
+	}
+	return strings.Contains(out, "state = running"), nil
+}
+
+func systemdActivate(m *Manager) error {
+	if out, err := runCommand("systemctl", "--user", "daemon-reload"); err != nil {
+		return fmt.Errorf("systemctl --user daemon-reload: %v: %s", err, out)
+	}
+	if out, err := runCommand("systemctl", "--user", "enable", "--now", SystemdUnit); err != nil {
+		return fmt.Errorf("systemctl --user enable: %v: %s", err, out)
+	}
+	return nil
+}
+
+func systemdDeactivate(m *Manager) error {
+	if out, err := runCommand("systemctl", "--user", "disable", "--now", SystemdUnit); err != nil {
+		return fmt.Errorf("systemctl --user disable: %v: %s", err, out)
+	}
+	return nil
+}
+
+func systemdRunning(m *Manager) (bool, error) {
+	out, err := runCommand("systemctl", "--user", "is-active", SystemdUnit)
+	if out == "active" {
+		return true, nil
+	}
+	if err != nil && out == "" {
+		return false, fmt.Errorf("systemctl --user is-active: %w", err)
+	}
+	return false, nil
+}
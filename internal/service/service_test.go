@@ -0,0 +1,206 @@
+package service
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// testSpec is the daemon spec used by the golden files.
+var testSpec = Spec{
+	Executable: "/opt/homebrew/bin/brewprune",
+	PIDFile:    "/Users/alice/.brewprune/watch.pid",
+	LogFile:    "/Users/alice/.brewprune/watch.log",
+}
+
+// checkGolden compares got with testdata/name, rewriting it with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("write golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s mismatch (run go test -update to accept)\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
+
+func TestLaunchdPlist_Golden(t *testing.T) {
+	checkGolden(t, "launchd.plist.golden", LaunchdPlist(testSpec))
+}
+
+func TestSystemdUnitFile_Golden(t *testing.T) {
+	spec := Spec{
+		Executable: "/home/linuxbrew/.linuxbrew/bin/brewprune",
+		PIDFile:    "/home/alice/.brewprune/watch.pid",
+		LogFile:    "/home/alice/.brewprune/watch.log",
+	}
+	checkGolden(t, "systemd.service.golden", SystemdUnitFile(spec))
+}
+
+func TestLaunchdPlist_EscapesPaths(t *testing.T) {
+	spec := testSpec
+	spec.LogFile = "/Users/a&b/<logs>/watch.log"
+	got := string(LaunchdPlist(spec))
+	if !strings.Contains(got, "<string>/Users/a&amp;b/&lt;logs&gt;/watch.log</string>") {
+		t.Errorf("log path not XML-escaped:\n%s", got)
+	}
+}
+
+func TestSystemdUnitFile_QuotesArguments(t *testing.T) {
+	spec := Spec{
+		Executable: "/opt/brew prefix/bin/brewprune",
+		PIDFile:    "/home/alice/.brewprune/watch.pid",
+		LogFile:    "/home/alice/100%/watch.log",
+	}
+	got := string(SystemdUnitFile(spec))
+	if !strings.Contains(got, `ExecStart="/opt/brew prefix/bin/brewprune" watch`) {
+		t.Errorf("executable with a space not quoted:\n%s", got)
+	}
+	if !strings.Contains(got, "StandardOutput=append:/home/alice/100%%/watch.log") {
+		t.Errorf("%% not escaped in log path:\n%s", got)
+	}
+}
+
+func TestNewManager_Paths(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "")
+	home := "/home/alice"
+
+	m, err := NewManager("darwin", home)
+	if err != nil {
+		t.Fatalf("NewManager(darwin): %v", err)
+	}
+	if want := filepath.Join(home, "Library", "LaunchAgents", "com.blackwell-systems.brewprune.plist"); m.Path != want {
+		t.Errorf("launchd path = %q, want %q", m.Path, want)
+	}
+
+	m, err = NewManager("linux", home)
+	if err != nil {
+		t.Fatalf("NewManager(linux): %v", err)
+	}
+	if want := filepath.Join(home, ".config", "systemd", "user", "brewprune-watch.service"); m.Path != want {
+		t.Errorf("systemd path = %q, want %q", m.Path, want)
+	}
+
+	if _, err := NewManager("windows", home); err == nil {
+		t.Error("NewManager(windows) succeeded, want error")
+	}
+}
+
+// recordCommands replaces runCommand for the test and returns the list of
+// commands run, each joined with spaces.
+func recordCommands(t *testing.T, output string) *[]string {
+	t.Helper()
+	var calls []string
+	orig := runCommand
+	runCommand = func(name string, args ...string) (string, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		return output, nil
+	}
+	t.Cleanup(func() { runCommand = orig })
+	return &calls
+}
+
+func TestManager_InstallAndUninstallSystemd(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", "")
+	calls := recordCommands(t, "active")
+
+	m, err := NewManager("linux", home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Install(testSpec); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	data, err := os.ReadFile(m.Path)
+	if err != nil {
+		t.Fatalf("service file not written: %v", err)
+	}
+	if string(data) != string(SystemdUnitFile(testSpec)) {
+		t.Error("service file does not match the rendered unit")
+	}
+	want := []string{
+		"systemctl --user daemon-reload",
+		"systemctl --user enable --now brewprune-watch.service",
+	}
+	if strings.Join(*calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("install ran %q, want %q", *calls, want)
+	}
+
+	if running, err := m.Running(); err != nil || !running {
+		t.Errorf("Running() = %v, %v; want true", running, err)
+	}
+
+	*calls = nil
+	if err := m.Uninstall(); err != nil {
+		t.Fatalf("Uninstall: %v", err)
+	}
+	if m.Installed() {
+		t.Error("service file still present after Uninstall")
+	}
+	if len(*calls) == 0 || (*calls)[0] != "systemctl --user disable --now brewprune-watch.service" {
+		t.Errorf("uninstall ran %q, want disable first", *calls)
+	}
+}
+
+func TestManager_ReinstallRestartsLaunchd(t *testing.T) {
+	home := t.TempDir()
+	calls := recordCommands(t, "")
+
+	m, err := NewManager("darwin", home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Install(testSpec); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if err := m.Install(testSpec); err != nil {
+		t.Fatalf("second Install: %v", err)
+	}
+	var bootouts, bootstraps int
+	for _, c := range *calls {
+		switch {
+		case strings.HasPrefix(c, "launchctl bootout "):
+			bootouts++
+		case strings.HasPrefix(c, "launchctl bootstrap "):
+			bootstraps++
+		}
+	}
+	if bootstraps != 2 || bootouts != 1 {
+		t.Errorf("calls = %q, want 2 bootstraps and 1 bootout", *calls)
+	}
+}
+
+func TestBrewServicesRegistration(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	if _, ok := BrewServicesRegistration("darwin", home); ok {
+		t.Error("registration reported without a plist")
+	}
+	path, _ := BrewServicesRegistration("darwin", home)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("<plist/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := BrewServicesRegistration("darwin", home); !ok || filepath.Base(got) != "homebrew.mxcl.brewprune.plist" {
+		t.Errorf("BrewServicesRegistration = %q, %v; want the homebrew.mxcl plist", got, ok)
+	}
+
+	if path, _ := BrewServicesRegistration("linux", home); filepath.Base(path) != "homebrew.brewprune.service" {
+		t.Errorf("linux registration path = %q", path)
+	}
+}
@@ -8,6 +8,10 @@ import (
 	"strings"
 )
 
+// pathMarker is the comment EnsurePathEntry writes above the PATH line it
+// appends; RemovePathEntry uses it to find the block again.
+const pathMarker = "# brewprune shims"
+
 // EnsurePathEntry checks whether dir is on PATH and, if not, appends the
 // export line to the appropriate shell config file.
 // Returns (added bool, configFile string, err error).
@@ -54,7 +58,7 @@ func EnsurePathEntry(dir string) (added bool, configFile string, err error) {
 	existingContent, readErr := os.ReadFile(configPath)
 	if readErr == nil {
 		// File exists, check if brewprune shims marker is present
-		if strings.Contains(string(existingContent), "# brewprune shims") {
+		if strings.Contains(string(existingContent), pathMarker) {
 			// Already configured
 			return false, configPath, nil
 		}
@@ -63,9 +67,9 @@ func EnsurePathEntry(dir string) (added bool, configFile string, err error) {
 	// Build the export line to append.
 	var line string
 	if isFish {
-		line = fmt.Sprintf("\n# brewprune shims\nfish_add_path %s\n", dir)
+		line = fmt.Sprintf("\n%s\nfish_add_path %s\n", pathMarker, dir)
 	} else {
-		line = fmt.Sprintf("\n# brewprune shims\nexport PATH=%q:$PATH\n", dir)
+		line = fmt.Sprintf("\n%s\nexport PATH=%q:$PATH\n", pathMarker, dir)
 	}
 
 	// Open the file for appending, creating it if it doesn't exist.
@@ -81,3 +85,78 @@ func EnsurePathEntry(dir string) (added bool, configFile string, err error) {
 
 	return true, configPath, nil
 }
+
+// ConfigFiles returns the shell config files under home that EnsurePathEntry
+// may have written to, for any shell.
+func ConfigFiles(home string) []string {
+	return []string{
+		filepath.Join(home, ".zprofile"),
+		filepath.Join(home, ".bash_profile"),
+		filepath.Join(home, ".profile"),
+		filepath.Join(home, ".config", "fish", "conf.d", "brewprune.fish"),
+	}
+}
+
+// RemovePathEntry removes the block EnsurePathEntry appended to configPath:
+// the marker comment and the PATH line below it. The original file is first
+// copied to configPath + ".brewprune-backup". A file left with nothing but
+// whitespace (such as the fish conf.d snippet) is deleted.
+// Returns (removed bool, backupPath string, err error); removed=false means
+// the file is missing or has no marked block (no change made).
+func RemovePathEntry(configPath string) (removed bool, backupPath string, err error) {
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return false, "", nil
+	}
+	if err != nil {
+		return false, "", fmt.Errorf("cannot read config file %s: %w", configPath, err)
+	}
+
+	lines := strings.Split(string(data), "\n")
+	kept := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != pathMarker {
+			kept = append(kept, lines[i])
+			continue
+		}
+		removed = true
+		// EnsurePathEntry separates the block with a blank line.
+		if n := len(kept); n > 0 && strings.TrimSpace(kept[n-1]) == "" {
+			kept = kept[:n-1]
+		}
+		if i+1 < len(lines) && isPathLine(lines[i+1]) {
+			i++
+		}
+	}
+	if !removed {
+		return false, "", nil
+	}
+
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return false, "", fmt.Errorf("cannot stat config file %s: %w", configPath, err)
+	}
+	backupPath = configPath + ".brewprune-backup"
+	if err := os.WriteFile(backupPath, data, info.Mode().Perm()); err != nil {
+		return false, "", fmt.Errorf("cannot write backup %s: %w", backupPath, err)
+	}
+
+	content := strings.Join(kept, "\n")
+	if strings.TrimSpace(content) == "" {
+		if err := os.Remove(configPath); err != nil {
+			return false, backupPath, fmt.Errorf("cannot remove config file %s: %w", configPath, err)
+		}
+		return true, backupPath, nil
+	}
+	if err := os.WriteFile(configPath, []byte(content), info.Mode().Perm()); err != nil {
+		return false, backupPath, fmt.Errorf("cannot write config file %s: %w", configPath, err)
+	}
+	return true, backupPath, nil
+}
+
+// isPathLine reports whether line is a PATH entry as written by
+// EnsurePathEntry.
+func isPathLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "export PATH=") || strings.HasPrefix(line, "fish_add_path ")
+}
@@ -358,3 +358,97 @@ func TestEnsurePathEntry_Idempotency(t *testing.T) {
 		})
 	}
 }
+
+// TestRemovePathEntry_RestoresOriginal verifies that removing the block
+// EnsurePathEntry appended leaves the rest of the file as it was and writes
+// a backup of the modified file.
+func TestRemovePathEntry_RestoresOriginal(t *testing.T) {
+	tmpDir := t.TempDir()
+	shimDir := filepath.Join(tmpDir, "shims")
+
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("SHELL", "/bin/zsh")
+
+	origPath := os.Getenv("PATH")
+	t.Cleanup(func() { os.Setenv("PATH", origPath) })
+	os.Setenv("PATH", "/usr/bin:/bin")
+
+	configPath := filepath.Join(tmpDir, ".zprofile")
+	original := "eval \"$(/opt/homebrew/bin/brew shellenv)\"\n"
+	if err := os.WriteFile(configPath, []byte(original), 0600); err != nil {
+		t.Fatalf("failed to write .zprofile: %v", err)
+	}
+	if _, _, err := EnsurePathEntry(shimDir); err != nil {
+		t.Fatalf("EnsurePathEntry: %v", err)
+	}
+	withEntry, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	removed, backupPath, err := RemovePathEntry(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !removed {
+		t.Fatalf("expected removed=true")
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read .zprofile: %v", err)
+	}
+	if string(data) != original {
+		t.Errorf("content after removal = %q, want %q", data, original)
+	}
+	info, err := os.Stat(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	backup, err := os.ReadFile(backupPath)
+	if err != nil {
+		t.Fatalf("failed to read backup: %v", err)
+	}
+	if string(backup) != string(withEntry) {
+		t.Errorf("backup = %q, want %q", backup, withEntry)
+	}
+
+	// A second call finds nothing to remove.
+	removed, backupPath, err = RemovePathEntry(configPath)
+	if err != nil || removed || backupPath != "" {
+		t.Errorf("second call = (%v, %q, %v), want (false, \"\", nil)", removed, backupPath, err)
+	}
+}
+
+// TestRemovePathEntry_DeletesEmptyFishSnippet verifies that the fish conf.d
+// file, which holds only the brewprune block, is deleted.
+func TestRemovePathEntry_DeletesEmptyFishSnippet(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "brewprune.fish")
+	if err := os.WriteFile(configPath, []byte("\n# brewprune shims\nfish_add_path /x/.brewprune/bin\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, _, err := RemovePathEntry(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !removed {
+		t.Errorf("expected removed=true")
+	}
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be deleted, stat err = %v", configPath, err)
+	}
+}
+
+// TestRemovePathEntry_MissingFile verifies a missing file is not an error.
+func TestRemovePathEntry_MissingFile(t *testing.T) {
+	removed, _, err := RemovePathEntry(filepath.Join(t.TempDir(), ".profile"))
+	if err != nil || removed {
+		t.Errorf("RemovePathEntry on missing file = (%v, %v), want (false, nil)", removed, err)
+	}
+}
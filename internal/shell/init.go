@@ -0,0 +1,199 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Shells lists the shells InitScript supports.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// brewRefreshCommands are the brew subcommands after which the shims are
+// refreshed, since they add, remove or move Homebrew binaries or change the
+// installed taps.
+const brewRefreshCommands = "install upgrade uninstall remove rm reinstall tap untap"
+
+// InitScript returns the shell integration snippet for shellName, meant to
+// be evaluated from the shell's startup file. It puts shimDir on PATH,
+// wraps brew so the shims are refreshed after commands that change installed
+// binaries, and hooks command-not-found to explain commands whose package
+// brewprune removed. Existing command-not-found handlers are kept and called
+// when brewprune has nothing to say.
+func InitScript(shellName, shimDir string) ([]byte, error) {
+	switch shellName {
+	case "bash":
+		return []byte(posixInitScript(bashHooks, shimDir)), nil
+	case "zsh":
+		return []byte(posixInitScript(zshHooks, shimDir)), nil
+	case "fish":
+		return []byte(fishInitScript(shimDir)), nil
+	default:
+		return nil, fmt.Errorf("unsupported shell %q (supported: %s)", shellName, strings.Join(Shells, ", "))
+	}
+}
+
+// posixHooks holds the parts of the bash and zsh snippets that differ.
+type posixHooks struct {
+	shell      string
+	rcFile     string
+	handler    string // command-not-found hook function name
+	isFunction string // tests whether the function named by $1 exists
+	showFunc   string // prints the definition of the function named by $1
+	notFound   string // printf format of the shell's own message
+}
+
+var bashHooks = posixHooks{
+	shell:      "bash",
+	rcFile:     "~/.bash_profile",
+	handler:    "command_not_found_handle",
+	isFunction: `declare -F "$1" >/dev/null`,
+	showFunc:   `declare -f "$1"`,
+	notFound:   `bash: %s: command not found\n`,
+}
+
+var zshHooks = posixHooks{
+	shell:      "zsh",
+	rcFile:     "~/.zshrc",
+	handler:    "command_not_found_handler",
+	isFunction: `(( ${+functions[$1]} ))`,
+	showFunc:   `functions "$1"`,
+	notFound:   `zsh: command not found: %s\n`,
+}
+
+func posixInitScript(h posixHooks, shimDir string) string {
+	dir := posixQuote(shimDir)
+	prev := "_brewprune_prev_" + h.handler
+	cases := strings.ReplaceAll(brewRefreshCommands, " ", "|")
+
+	return fmt.Sprintf(`# brewprune shell integration for %[1]s.
+# Add to %[2]s:  eval "$(brewprune shell-init %[1]s)"
+
+_brewprune_is_function() { %[3]s; }
+
+case ":$PATH:" in
+  *:%[4]s:*) ;;
+  *) export PATH=%[4]s:"$PATH" ;;
+esac
+
+# Refresh the shims after brew changes installed binaries.
+brew() {
+  command brew "$@"
+  local _brewprune_rc=$?
+  case "$1" in
+    %[5]s)
+      (command brewprune scan --refresh-shims --quiet >/dev/null 2>&1 &)
+      ;;
+  esac
+  return $_brewprune_rc
+}
+
+# Explain commands whose package brewprune removed.
+if [ -z "${_brewprune_cnf_hooked:-}" ]; then
+  _brewprune_cnf_hooked=1
+  if _brewprune_is_function %[6]s; then
+    eval "_brewprune_prev_$(%[7]s)"
+  fi
+  %[6]s() {
+    if command -v brewprune >/dev/null && command brewprune command-not-found "$1"; then
+      return 127
+    fi
+    if _brewprune_is_function %[8]s; then
+      %[8]s "$@"
+      return $?
+    fi
+    printf '%[9]s' "$1" >&2
+    return 127
+  }
+fi
+`, h.shell, h.rcFile, h.isFunction, dir, cases, h.handler,
+		strings.ReplaceAll(h.showFunc, "$1", h.handler), prev, h.notFound)
+}
+
+func fishInitScript(shimDir string) string {
+	dir := fishQuote(shimDir)
+
+	return fmt.Sprintf(`# brewprune shell integration for fish.
+# Add to ~/.config/fish/config.fish:  brewprune shell-init fish | source
+
+if not contains -- %[1]s $PATH
+    set -gx PATH %[1]s $PATH
+end
+
+# Refresh the shims after brew changes installed binaries.
+function brew --wraps brew
+    command brew $argv
+    set -l brewprune_rc $status
+    switch "$argv[1]"
+        case %[2]s
+            command brewprune scan --refresh-shims --quiet >/dev/null 2>&1 &
+            disown 2>/dev/null
+    end
+    return $brewprune_rc
+end
+
+# Explain commands whose package brewprune removed.
+if not functions -q _brewprune_prev_fish_command_not_found
+    if functions -q fish_command_not_found
+        functions -c fish_command_not_found _brewprune_prev_fish_command_not_found
+    else
+        function _brewprune_prev_fish_command_not_found
+            printf 'fish: Unknown command: %%s\n' $argv[1] >&2
+        end
+    end
+    function fish_command_not_found
+        if type -q brewprune; and command brewprune command-not-found $argv[1]
+            return
+        end
+        _brewprune_prev_fish_command_not_found $argv
+    end
+end
+`, dir, brewRefreshCommands)
+}
+
+// posixQuote single-quotes s for bash and zsh.
+func posixQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes s for fish, where backslash and single quote are
+// escaped inside single quotes.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// InitReferences returns the shell startup files under home that evaluate
+// "brewprune shell-init". Unlike the block EnsurePathEntry writes, these lines
+// are added by the user and are not removed automatically.
+func InitReferences(home string) []string {
+	return startupFilesContaining(home, "brewprune shell-init")
+}
+
+// ShimDirReferences returns the shell startup files under home that still
+// mention the shim directory, ~/.brewprune/bin, however it is spelled.
+func ShimDirReferences(home string) []string {
+	return startupFilesContaining(home, filepath.Join(".brewprune", "bin"))
+}
+
+// startupFilesContaining returns the shell startup files under home whose
+// contents include substr.
+func startupFilesContaining(home, substr string) []string {
+	candidates := []string{
+		filepath.Join(home, ".zprofile"),
+		filepath.Join(home, ".zshrc"),
+		filepath.Join(home, ".bash_profile"),
+		filepath.Join(home, ".bashrc"),
+		filepath.Join(home, ".profile"),
+		filepath.Join(home, ".config", "fish", "config.fish"),
+		filepath.Join(home, ".config", "fish", "conf.d", "brewprune.fish"),
+	}
+	var found []string
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if err == nil && strings.Contains(string(data), substr) {
+			found = append(found, path)
+		}
+	}
+	return found
+}
@@ -0,0 +1,107 @@
+package shell
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// checkGolden compares got with testdata/name, rewriting it with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("write golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s mismatch (run go test -update to accept)\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
+
+// TestInitScript_Golden checks the snippet printed for each supported shell.
+func TestInitScript_Golden(t *testing.T) {
+	for _, sh := range Shells {
+		t.Run(sh, func(t *testing.T) {
+			script, err := InitScript(sh, "/Users/alice/.brewprune/bin")
+			if err != nil {
+				t.Fatalf("InitScript(%q): %v", sh, err)
+			}
+			checkGolden(t, "init."+sh+".golden", script)
+		})
+	}
+}
+
+// TestInitScript_UnsupportedShell verifies an unknown shell is rejected with
+// the list of supported ones.
+func TestInitScript_UnsupportedShell(t *testing.T) {
+	_, err := InitScript("tcsh", "/x")
+	if err == nil {
+		t.Fatal("expected an error for tcsh")
+	}
+	if !strings.Contains(err.Error(), "bash, zsh, fish") {
+		t.Errorf("error should list supported shells, got: %v", err)
+	}
+}
+
+// TestInitScript_QuotesShimDir verifies that a shim directory containing
+// quotes cannot break out of the quoted PATH entry.
+func TestInitScript_QuotesShimDir(t *testing.T) {
+	dir := "/Users/o'brien/.brewprune/bin"
+	cases := map[string]string{
+		"bash": `'/Users/o'\''brien/.brewprune/bin'`,
+		"zsh":  `'/Users/o'\''brien/.brewprune/bin'`,
+		"fish": `'/Users/o\'brien/.brewprune/bin'`,
+	}
+	for sh, want := range cases {
+		script, err := InitScript(sh, dir)
+		if err != nil {
+			t.Fatalf("InitScript(%q): %v", sh, err)
+		}
+		if !strings.Contains(string(script), want) {
+			t.Errorf("%s snippet does not contain %s:\n%s", sh, want, script)
+		}
+	}
+}
+
+// TestInitReferences finds startup files that evaluate shell-init.
+func TestInitReferences(t *testing.T) {
+	home := t.TempDir()
+	zshrc := filepath.Join(home, ".zshrc")
+	if err := os.WriteFile(zshrc, []byte("eval \"$(brewprune shell-init zsh)\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".bashrc"), []byte("alias ll='ls -l'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := InitReferences(home)
+	if len(got) != 1 || got[0] != zshrc {
+		t.Errorf("InitReferences() = %v, want [%s]", got, zshrc)
+	}
+}
+
+func TestShimDirReferences(t *testing.T) {
+	home := t.TempDir()
+	bashrc := filepath.Join(home, ".bashrc")
+	if err := os.WriteFile(bashrc, []byte("export PATH=\"$HOME/.brewprune/bin:$PATH\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".zprofile"), []byte("umask 022\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := ShimDirReferences(home)
+	if len(got) != 1 || got[0] != bashrc {
+		t.Errorf("ShimDirReferences() = %v, want [%s]", got, bashrc)
+	}
+}
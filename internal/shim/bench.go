@@ -0,0 +1,194 @@
+package shim
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BenchResult holds the median wall time of one exec of a command, run bare
+// and through the shim, as measured by BenchShim.
+type BenchResult struct {
+	Command  string
+	RealPath string
+	Runs     int
+	Bare     time.Duration // the real binary, run directly
+	Indexed  time.Duration // through the shim, resolved from the shim index
+	Fallback time.Duration // through the shim, without a shim index
+}
+
+// Overhead returns the time the shim adds to each exec on its fast path.
+func (r *BenchResult) Overhead() time.Duration {
+	return r.Indexed - r.Bare
+}
+
+// FallbackOverhead returns the time the shim adds to each exec when it has
+// no shim index and searches the prefixes and PATH instead.
+func (r *BenchResult) FallbackOverhead() time.Duration {
+	return r.Fallback - r.Bare
+}
+
+// ResolveRealBinary returns the binary a shim for name would exec: the one in
+// name's prefix per the prefix manifest, or else the first on PATH outside
+// the shim directory.
+func ResolveRealBinary(name string) (string, error) {
+	if m, err := ReadPrefixManifest(); err == nil {
+		if prefix, ok := m.Binaries[name]; ok {
+			path := filepath.Join(prefix, "bin", name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+	}
+	shimDir, err := GetShimDir()
+	if err != nil {
+		return "", err
+	}
+	return lookPathExcludingShimDir(name, shimDir)
+}
+
+// BenchShim measures the per-exec overhead of the installed shim binary for
+// command name run with args. Each of runs rounds executes the real binary
+// directly, through the shim with a shim index, and through the shim without
+// one, interleaved so machine load affects all three alike; the median of
+// each is reported.
+//
+// The shim runs in a throwaway HOME holding a copy of the shim binary and the
+// prefix manifest, so the benchmark neither logs usage nor touches the
+// user's shim index.
+func BenchShim(name string, args []string, runs int) (*BenchResult, error) {
+	if runs < 1 {
+		return nil, fmt.Errorf("runs must be at least 1, got %d", runs)
+	}
+	shimDir, err := GetShimDir()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get shim dir: %w", err)
+	}
+	shimBinary := filepath.Join(shimDir, shimBinaryName)
+	if _, err := os.Stat(shimBinary); err != nil {
+		return nil, fmt.Errorf(
+			"shim binary not found at %s; run 'brewprune scan' first to build it",
+			shimBinary,
+		)
+	}
+	if name == shimBinaryName || strings.ContainsRune(name, filepath.Separator) {
+		return nil, fmt.Errorf("cannot benchmark %q: give a command name", name)
+	}
+	realPath, err := ResolveRealBinary(name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find %s outside the shim directory: %w", name, err)
+	}
+
+	indexedHome, err := newBenchHome(shimBinary, name)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(indexedHome)
+	index := EncodeShimIndex("", ShimIndexOptions{}, map[string]string{name: realPath})
+	if err := os.WriteFile(filepath.Join(indexedHome, ".brewprune", "shim.index"), index, 0644); err != nil {
+		return nil, fmt.Errorf("write benchmark shim index: %w", err)
+	}
+
+	fallbackHome, err := newBenchHome(shimBinary, name)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(fallbackHome)
+
+	variants := []struct {
+		path string
+		home string
+		runs []time.Duration
+	}{
+		{path: realPath, home: indexedHome},
+		{path: filepath.Join(indexedHome, ".brewprune", "bin", name), home: indexedHome},
+		{path: filepath.Join(fallbackHome, ".brewprune", "bin", name), home: fallbackHome},
+	}
+	for i := 0; i < runs; i++ {
+		for v := range variants {
+			d, err := timeExec(variants[v].path, args, variants[v].home)
+			if err != nil {
+				return nil, err
+			}
+			variants[v].runs = append(variants[v].runs, d)
+		}
+	}
+
+	return &BenchResult{
+		Command:  name,
+		RealPath: realPath,
+		Runs:     runs,
+		Bare:     median(variants[0].runs),
+		Indexed:  median(variants[1].runs),
+		Fallback: median(variants[2].runs),
+	}, nil
+}
+
+// newBenchHome creates a throwaway HOME for BenchShim with the shim binary
+// linked (or copied) as ~/.brewprune/bin/<name> and a copy of the user's
+// prefix manifest. The caller removes it.
+func newBenchHome(shimBinary, name string) (string, error) {
+	home, err := os.MkdirTemp("", "brewprune-bench-")
+	if err != nil {
+		return "", fmt.Errorf("create benchmark home: %w", err)
+	}
+	binDir := filepath.Join(home, ".brewprune", "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		os.RemoveAll(home)
+		return "", fmt.Errorf("create benchmark home: %w", err)
+	}
+
+	// Keep the binary's own name so the shim recognises itself, then link
+	// the command to it the way GenerateShims does.
+	benchShim := filepath.Join(binDir, shimBinaryName)
+	if err := os.Link(shimBinary, benchShim); err != nil {
+		if err := copyFile(shimBinary, benchShim); err != nil {
+			os.RemoveAll(home)
+			return "", fmt.Errorf("copy shim binary: %w", err)
+		}
+	}
+	if err := createShimEntry(benchShim, filepath.Join(binDir, name)); err != nil {
+		os.RemoveAll(home)
+		return "", fmt.Errorf("create benchmark shim: %w", err)
+	}
+
+	if manifestPath, err := GetPrefixManifestPath(); err == nil {
+		if data, err := os.ReadFile(manifestPath); err == nil {
+			_ = os.WriteFile(filepath.Join(home, ".brewprune", "prefixes"), data, 0644)
+		}
+	}
+	return home, nil
+}
+
+// timeExec runs path with args and HOME set to home, discarding its output,
+// and returns the wall time. A non-zero exit status is not an error.
+func timeExec(path string, args []string, home string) (time.Duration, error) {
+	cmd := exec.Command(path, args...) //nolint:gosec
+	cmd.Env = append(os.Environ(), "HOME="+home)
+	start := time.Now()
+	err := cmd.Run()
+	elapsed := time.Since(start)
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return 0, fmt.Errorf("run %s: %w", path, err)
+	}
+	return elapsed, nil
+}
+
+// median returns the middle value of durations, which it sorts.
+func median(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	mid := len(durations) / 2
+	if len(durations)%2 == 0 {
+		return (durations[mid-1] + durations[mid]) / 2
+	}
+	return durations[mid]
+}
@@ -523,3 +523,36 @@ func RemoveShims() error {
 
 	return nil
 }
+
+// RemoveShimDir removes the brewprune-shim binary, the shim version file, the
+// prefix manifest, the shim index and the shim directory itself. Call RemoveShims first: the directory is only
+// removed once empty, so files brewprune did not create are left in place.
+func RemoveShimDir() error {
+	shimDir, err := GetShimDir()
+	if err != nil {
+		return fmt.Errorf("cannot get shim dir: %w", err)
+	}
+
+	if err := os.Remove(filepath.Join(shimDir, shimBinaryName)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot remove shim binary: %w", err)
+	}
+	if versionPath, err := shimVersionPath(); err == nil {
+		if err := os.Remove(versionPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove shim version file: %w", err)
+		}
+	}
+	if manifestPath, err := GetPrefixManifestPath(); err == nil {
+		if err := os.Remove(manifestPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove prefix manifest: %w", err)
+		}
+	}
+	if indexPath, err := GetShimIndexPath(); err == nil {
+		if err := os.Remove(indexPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove shim index: %w", err)
+		}
+	}
+	if err := os.Remove(shimDir); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot remove shim dir %s: %w", shimDir, err)
+	}
+	return nil
+}
@@ -123,6 +123,44 @@ func TestRemoveShims_LeavesShimBinaryIntact(t *testing.T) {
 	}
 }
 
+func TestRemoveShimDir(t *testing.T) {
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+
+	shimDir := filepath.Join(tmpHome, ".brewprune", "bin")
+	if err := os.MkdirAll(shimDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	shimBin := filepath.Join(shimDir, shimBinaryName)
+	if err := os.WriteFile(shimBin, []byte("fake"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(shimBin, filepath.Join(shimDir, "git")); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteShimVersion("1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RemoveShims(); err != nil {
+		t.Fatalf("RemoveShims() error: %v", err)
+	}
+	if err := RemoveShimDir(); err != nil {
+		t.Fatalf("RemoveShimDir() error: %v", err)
+	}
+	if _, err := os.Stat(shimDir); !os.IsNotExist(err) {
+		t.Errorf("shim dir still exists: %v", err)
+	}
+	if v, err := ReadShimVersion(); err != nil || v != "" {
+		t.Errorf("ReadShimVersion() = (%q, %v), want empty after removal", v, err)
+	}
+
+	// Removing again is a no-op.
+	if err := RemoveShimDir(); err != nil {
+		t.Errorf("RemoveShimDir() on missing dir = %v, want nil", err)
+	}
+}
+
 // setupRefreshShimsEnv creates a temp home with a fake shim binary and a
 // "brew bin" directory containing a fake binary named basename.
 // It sets HOME and PATH so that LookPath(basename) resolves to brewBin/basename
@@ -0,0 +1,115 @@
+package shim
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// shimIndexMagic starts the header line of the shim index. The number is the
+// format version; the shim ignores an index whose header it does not know.
+const shimIndexMagic = "brewprune-shim-index 1"
+
+// ShimIndexOptions are shim behaviours switched on in the shim index header,
+// which the shim reads on every run anyway, so they cost no extra syscall.
+type ShimIndexOptions struct {
+	// RecordCwd makes the shim log the working directory of each run.
+	RecordCwd bool
+}
+
+// GetShimIndexPath returns the path to the shim index: the shim binary's fast
+// path, mapping each shimmed command to the real binary it execs, so a shimmed
+// run costs one open and read instead of a stat per candidate prefix, a read
+// of shim.version and a PATH walk.
+func GetShimIndexPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	return filepath.Join(home, ".brewprune", "shim.index"), nil
+}
+
+// EncodeShimIndex returns the shim index for entries (command name -> real
+// binary path) with the given expected shim version and options: a header
+// line followed by one "name\tpath" line per command, sorted by name so the
+// shim can binary search the raw bytes.
+//
+//	brewprune-shim-index 1	<expected shim version>	<options>
+//	gcc-14	/usr/local/bin/gcc-14
+//	git	/opt/homebrew/bin/git
+//
+// The version is what shim.version holds, which the shim would otherwise
+// read on every run. Options is a comma-separated list of enabled
+// ShimIndexOptions ("cwd"), empty when none are. cmd/brewprune-shim parses
+// this format itself. Names or paths that would break the line format, and
+// the shim binary itself, are left out.
+func EncodeShimIndex(version string, opts ShimIndexOptions, entries map[string]string) []byte {
+	names := make([]string, 0, len(entries))
+	for name, path := range entries {
+		if name == "" || name == shimBinaryName || path == "" ||
+			strings.ContainsAny(name, "\t\n/") || strings.ContainsAny(path, "\t\n") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	var options []string
+	if opts.RecordCwd {
+		options = append(options, "cwd")
+	}
+	fmt.Fprintf(&b, "%s\t%s\t%s\n", shimIndexMagic, strings.TrimSpace(version), strings.Join(options, ","))
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s\t%s\n", name, entries[name])
+	}
+	return b.Bytes()
+}
+
+// WriteShimIndex writes the shim index for the commands in m, with opts,
+// atomically to GetShimIndexPath. Each command maps to <prefix>/bin/<name> in its manifest
+// prefix: the link rather than its Cellar target, so the index stays valid
+// across brew upgrades. Commands whose binary no longer exists, or that would
+// resolve into the shim directory, are left out and take the shim's slow path.
+func WriteShimIndex(m *PrefixManifest, opts ShimIndexOptions) error {
+	indexPath, err := GetShimIndexPath()
+	if err != nil {
+		return err
+	}
+	shimDir, err := GetShimDir()
+	if err != nil {
+		return err
+	}
+	version, err := ReadShimVersion()
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[string]string, len(m.Binaries))
+	for name, prefix := range m.Binaries {
+		path := filepath.Join(prefix, "bin", name)
+		if filepath.Dir(path) == shimDir {
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		entries[name] = path
+	}
+
+	dir := filepath.Dir(indexPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create directory %s: %w", dir, err)
+	}
+	tmpPath := filepath.Join(dir, ".shim.index.tmp")
+	if err := os.WriteFile(tmpPath, EncodeShimIndex(version, opts, entries), 0644); err != nil {
+		return fmt.Errorf("write temp shim index: %w", err)
+	}
+	if err := os.Rename(tmpPath, indexPath); err != nil {
+		return fmt.Errorf("rename shim index: %w", err)
+	}
+	return nil
+}
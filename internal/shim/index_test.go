@@ -0,0 +1,93 @@
+package shim
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeShimIndex(t *testing.T) {
+	got := string(EncodeShimIndex("1.2.3\n", ShimIndexOptions{}, map[string]string{
+		"wget":         "/usr/local/bin/wget",
+		"gcc-14":       "/usr/local/bin/gcc-14",
+		"git":          "/opt/homebrew/bin/git",
+		shimBinaryName: "/opt/homebrew/bin/brewprune-shim",
+		"bad\tname":    "/opt/homebrew/bin/bad",
+		"sub/dir":      "/opt/homebrew/bin/sub/dir",
+		"newline":      "/opt/homebrew/bin/new\nline",
+		"empty":        "",
+	}))
+
+	want := "brewprune-shim-index 1\t1.2.3\t\n" +
+		"gcc-14\t/usr/local/bin/gcc-14\n" +
+		"git\t/opt/homebrew/bin/git\n" +
+		"wget\t/usr/local/bin/wget\n"
+	if got != want {
+		t.Errorf("EncodeShimIndex() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestWriteShimIndex(t *testing.T) {
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+	if err := WriteShimVersion("0.9.0"); err != nil {
+		t.Fatal(err)
+	}
+	prefix := t.TempDir()
+	jq := makePrefixBin(t, prefix, "jq")
+
+	m := &PrefixManifest{
+		Prefixes: []string{prefix},
+		Binaries: map[string]string{
+			"jq":   prefix,
+			"gone": prefix, // uninstalled since the manifest was built
+		},
+	}
+	if err := WriteShimIndex(m, ShimIndexOptions{RecordCwd: true}); err != nil {
+		t.Fatalf("WriteShimIndex() error: %v", err)
+	}
+
+	indexPath, err := GetShimIndexPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatalf("shim index not written: %v", err)
+	}
+	want := "brewprune-shim-index 1\t0.9.0\tcwd\njq\t" + jq + "\n"
+	if string(data) != want {
+		t.Errorf("shim index =\n%q\nwant\n%q", data, want)
+	}
+	if _, err := os.Stat(filepath.Join(tmpHome, ".brewprune", ".shim.index.tmp")); !os.IsNotExist(err) {
+		t.Errorf("temp file left behind: %v", err)
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		in   []time.Duration
+		want time.Duration
+	}{
+		{nil, 0},
+		{[]time.Duration{5}, 5},
+		{[]time.Duration{9, 1, 5}, 5},
+		{[]time.Duration{8, 2, 4, 6}, 5},
+	}
+	for _, tt := range tests {
+		if got := median(tt.in); got != tt.want {
+			t.Errorf("median(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBenchShim_NoShimBinary(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	_, err := BenchShim("true", nil, 1)
+	if err == nil || !strings.Contains(err.Error(), "brewprune scan") {
+		t.Errorf("BenchShim() error = %v, want hint to run brewprune scan", err)
+	}
+}
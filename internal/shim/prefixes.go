@@ -0,0 +1,159 @@
+package shim
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PrefixManifest tells the shim binary which Homebrew prefix each shimmed
+// command belongs to, so a machine with several prefixes (e.g. an ARM brew
+// in /opt/homebrew next to an Intel brew in /usr/local) runs the binary of
+// the package that was scanned rather than the first prefix that has one.
+//
+// On disk (~/.brewprune/prefixes) it is a tab-separated text file:
+//
+//	prefix	/opt/homebrew
+//	prefix	/usr/local
+//	bin	gcc-14	/usr/local
+//
+// "prefix" lines list the scanned prefixes in priority order; "bin" lines map
+// a command name to the prefix whose bin directory holds it. The shim binary
+// parses this format itself, so it must stay line-oriented and simple.
+type PrefixManifest struct {
+	Prefixes []string
+	Binaries map[string]string // command name -> prefix
+}
+
+// GetPrefixManifestPath returns the path to the prefix manifest read by the
+// shim binary.
+func GetPrefixManifestPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	return filepath.Join(home, ".brewprune", "prefixes"), nil
+}
+
+// BuildPrefixManifest maps each binary in binaries (full paths such as
+// /usr/local/bin/gcc-14) to the prefix in prefixes whose bin directory holds
+// it. When several prefixes provide the same command, the one found first on
+// PATH wins, since that is the binary the user ran before shimming; failing
+// that, the earliest prefix wins. Binaries outside every prefix are ignored.
+func BuildPrefixManifest(prefixes, binaries []string) *PrefixManifest {
+	m := &PrefixManifest{Binaries: make(map[string]string)}
+	rank := make(map[string]int, len(prefixes))
+	for _, prefix := range prefixes {
+		prefix = filepath.Clean(prefix)
+		if _, dup := rank[prefix]; dup {
+			continue
+		}
+		rank[prefix] = len(m.Prefixes)
+		m.Prefixes = append(m.Prefixes, prefix)
+	}
+
+	shimDir, _ := GetShimDir()
+	candidates := make(map[string][]string) // command name -> prefixes
+	for _, binPath := range binaries {
+		prefix := filepath.Dir(filepath.Dir(filepath.Clean(binPath)))
+		if _, ok := rank[prefix]; !ok {
+			continue
+		}
+		name := filepath.Base(binPath)
+		candidates[name] = append(candidates[name], prefix)
+	}
+
+	for name, owners := range candidates {
+		best := owners[0]
+		for _, prefix := range owners[1:] {
+			if rank[prefix] < rank[best] {
+				best = prefix
+			}
+		}
+		if len(owners) > 1 {
+			if found, err := lookPathExcludingShimDir(name, shimDir); err == nil {
+				for _, prefix := range owners {
+					if filepath.Dir(found) == filepath.Join(prefix, "bin") {
+						best = prefix
+						break
+					}
+				}
+			}
+		}
+		m.Binaries[name] = best
+	}
+	return m
+}
+
+// WritePrefixManifest writes m atomically to GetPrefixManifestPath.
+func WritePrefixManifest(m *PrefixManifest) error {
+	manifestPath, err := GetPrefixManifestPath()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("# Written by brewprune scan; read by brewprune-shim.\n")
+	for _, prefix := range m.Prefixes {
+		fmt.Fprintf(&b, "prefix\t%s\n", prefix)
+	}
+	names := make([]string, 0, len(m.Binaries))
+	for name := range m.Binaries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "bin\t%s\t%s\n", name, m.Binaries[name])
+	}
+
+	dir := filepath.Dir(manifestPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create directory %s: %w", dir, err)
+	}
+	tmpPath := filepath.Join(dir, ".prefixes.tmp")
+	if err := os.WriteFile(tmpPath, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("write temp prefix manifest: %w", err)
+	}
+	if err := os.Rename(tmpPath, manifestPath); err != nil {
+		return fmt.Errorf("rename prefix manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadPrefixManifest reads the manifest written by WritePrefixManifest.
+// A missing file yields an empty manifest and no error. Malformed lines are
+// skipped.
+func ReadPrefixManifest() (*PrefixManifest, error) {
+	m := &PrefixManifest{Binaries: make(map[string]string)}
+	manifestPath, err := GetPrefixManifestPath()
+	if err != nil {
+		return m, err
+	}
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return m, nil
+		}
+		return m, fmt.Errorf("read prefix manifest: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		switch {
+		case len(fields) == 2 && fields[0] == "prefix":
+			m.Prefixes = append(m.Prefixes, fields[1])
+		case len(fields) == 3 && fields[0] == "bin":
+			m.Binaries[fields[1]] = fields[2]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return m, fmt.Errorf("read prefix manifest: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,90 @@
+package shim
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// makePrefixBin creates an executable named name in <prefix>/bin.
+func makePrefixBin(t *testing.T, prefix, name string) string {
+	t.Helper()
+	dir := filepath.Join(prefix, "bin")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBuildPrefixManifest(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	arm, intel := t.TempDir(), t.TempDir()
+
+	armGcc := makePrefixBin(t, arm, "gcc-14")
+	intelGcc := makePrefixBin(t, intel, "gcc-14")
+	intelWget := makePrefixBin(t, intel, "wget")
+	armGit := makePrefixBin(t, arm, "git")
+	intelGit := makePrefixBin(t, intel, "git")
+
+	// The Intel prefix comes first on PATH, so its git is the one users run.
+	t.Setenv("PATH", filepath.Join(intel, "bin"))
+
+	m := BuildPrefixManifest([]string{arm, intel, arm},
+		[]string{armGcc, intelGcc, intelWget, armGit, intelGit, "/elsewhere/bin/tool"})
+
+	if want := []string{arm, intel}; !reflect.DeepEqual(m.Prefixes, want) {
+		t.Errorf("Prefixes = %v, want %v", m.Prefixes, want)
+	}
+	want := map[string]string{
+		"gcc-14": intel, // on PATH
+		"wget":   intel, // only candidate
+		"git":    intel, // on PATH
+	}
+	if !reflect.DeepEqual(m.Binaries, want) {
+		t.Errorf("Binaries = %v, want %v", m.Binaries, want)
+	}
+
+	// Off PATH, the earliest prefix wins.
+	t.Setenv("PATH", "")
+	m = BuildPrefixManifest([]string{arm, intel}, []string{intelGit, armGit})
+	if got := m.Binaries["git"]; got != arm {
+		t.Errorf("Binaries[git] off PATH = %q, want %q", got, arm)
+	}
+}
+
+func TestWriteReadPrefixManifest_RoundTrip(t *testing.T) {
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+	t.Setenv("PATH", "")
+	prefix := t.TempDir()
+	jq := makePrefixBin(t, prefix, "jq")
+
+	if err := WritePrefixManifest(BuildPrefixManifest([]string{prefix}, []string{jq})); err != nil {
+		t.Fatalf("WritePrefixManifest() error: %v", err)
+	}
+
+	m, err := ReadPrefixManifest()
+	if err != nil {
+		t.Fatalf("ReadPrefixManifest() error: %v", err)
+	}
+	if !reflect.DeepEqual(m.Prefixes, []string{prefix}) || m.Binaries["jq"] != prefix {
+		t.Errorf("ReadPrefixManifest() = %+v, want jq under %s", m, prefix)
+	}
+}
+
+func TestReadPrefixManifest_MissingFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	m, err := ReadPrefixManifest()
+	if err != nil {
+		t.Fatalf("ReadPrefixManifest() error: %v, want nil", err)
+	}
+	if len(m.Prefixes) != 0 || len(m.Binaries) != 0 {
+		t.Errorf("ReadPrefixManifest() = %+v, want empty", m)
+	}
+}
@@ -15,6 +15,13 @@ import (
 // CreateSnapshot creates a snapshot of the specified packages and returns the snapshot ID.
 // If packages is empty, snapshots all installed packages.
 func (m *Manager) CreateSnapshot(packages []string, reason string) (int64, error) {
+	return m.CreateTapSnapshot(packages, nil, reason)
+}
+
+// CreateTapSnapshot creates a snapshot of the specified packages and of taps
+// about to be untapped, which restoring adds back, and returns the snapshot
+// ID. If both packages and taps are empty, snapshots all installed packages.
+func (m *Manager) CreateTapSnapshot(packages []string, taps []*TapSnapshot, reason string) (int64, error) {
 	// Ensure snapshot directory exists
 	if err := os.MkdirAll(m.snapshotDir, 0755); err != nil {
 		return 0, fmt.Errorf("failed to create snapshot directory: %w", err)
@@ -27,7 +34,7 @@ func (m *Manager) CreateSnapshot(packages []string, reason string) (int64, error
 	}
 
 	// If no packages specified, snapshot all installed packages
-	if len(packages) == 0 {
+	if len(packages) == 0 && len(taps) == 0 {
 		allPkgs, err := m.store.ListPackages()
 		if err != nil {
 			return 0, fmt.Errorf("failed to list packages: %w", err)
@@ -44,6 +51,7 @@ func (m *Manager) CreateSnapshot(packages []string, reason string) (int64, error
 		CreatedAt:   time.Now(),
 		Reason:      reason,
 		Packages:    make([]*PackageSnapshot, 0, len(packages)),
+		Taps:        taps,
 		BrewVersion: brewVersion,
 	}
 
@@ -174,6 +174,30 @@ func TestCreateSnapshot(t *testing.T) {
 		}
 	})
 
+	// Test snapshot of a tap with no packages left to remove
+	t.Run("CreateTapSnapshotOnlyTaps", func(t *testing.T) {
+		snapshotID, err := manager.CreateTapSnapshot(nil, []*TapSnapshot{{Name: "user/tools", Prefix: "/opt/homebrew"}}, "untap")
+		if err != nil {
+			t.Fatalf("Failed to create snapshot: %v", err)
+		}
+
+		snapshot, err := db.GetSnapshot(snapshotID)
+		if err != nil {
+			t.Fatalf("Failed to get snapshot: %v", err)
+		}
+		if snapshot.PackageCount != 0 {
+			t.Errorf("Expected package count 0, got %d", snapshot.PackageCount)
+		}
+
+		snapshotData, err := loadSnapshotFile(snapshot.SnapshotPath)
+		if err != nil {
+			t.Fatalf("Failed to load snapshot file: %v", err)
+		}
+		if len(snapshotData.Taps) != 1 || *snapshotData.Taps[0] != (TapSnapshot{Name: "user/tools", Prefix: "/opt/homebrew"}) {
+			t.Errorf("Expected tap user/tools in /opt/homebrew, got %v", snapshotData.Taps)
+		}
+	})
+
 	// Test snapshot with nonexistent package
 	t.Run("CreateSnapshotNonexistentPackage", func(t *testing.T) {
 		_, err := manager.CreateSnapshot([]string{"nonexistent"}, "test")
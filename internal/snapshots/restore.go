@@ -34,7 +34,17 @@ func (m *Manager) RestoreSnapshot(id int64) error {
 	var successCount, failureCount int
 	var failures []string
 
-	// Restore packages
+	// Restore untapped taps, then packages
+	for _, tap := range snapshotData.Taps {
+		if err := brew.AddTap(tap.Prefix, tap.Name); err != nil {
+			failureCount++
+			failures = append(failures, fmt.Sprintf("%s: %v", tap.Name, err))
+			fmt.Fprintf(os.Stderr, "Failed to restore tap %s: %v\n", tap.Name, err)
+		} else {
+			fmt.Printf("Restored tap %s\n", tap.Name)
+		}
+	}
+
 	for _, pkg := range snapshotData.Packages {
 		if err := restorePackage(pkg); err != nil {
 			failureCount++
@@ -68,7 +78,7 @@ func formatRestoredPkg(name, version string) string {
 func restorePackage(pkg *PackageSnapshot) error {
 	// Add tap if needed and not empty
 	if pkg.Tap != "" && pkg.Tap != "homebrew/core" {
-		if err := brew.AddTap(pkg.Tap); err != nil {
+		if err := brew.AddTap("", pkg.Tap); err != nil {
 			return fmt.Errorf("failed to add tap %s: %w", pkg.Tap, err)
 		}
 	}
@@ -11,6 +11,7 @@ type SnapshotData struct {
 	CreatedAt   time.Time
 	Reason      string
 	Packages    []*PackageSnapshot
+	Taps        []*TapSnapshot `json:",omitempty"` // taps removed along with the packages
 	BrewVersion string
 }
 
@@ -23,6 +24,12 @@ type PackageSnapshot struct {
 	Dependencies []string
 }
 
+// TapSnapshot represents an untapped tap in a snapshot file.
+type TapSnapshot struct {
+	Name   string
+	Prefix string // Homebrew prefix it was tapped in; "" for the brew on PATH
+}
+
 // Manager manages snapshot creation, restoration, and cleanup.
 type Manager struct {
 	store       *store.Store
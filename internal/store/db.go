@@ -3,6 +3,7 @@ package store
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 
 	_ "modernc.org/sqlite"
 )
@@ -52,11 +53,30 @@ func (s *Store) DB() *sql.DB {
 	return s.db
 }
 
-// CreateSchema creates all tables and indexes.
+// BackupTo writes a consistent copy of the database, including changes still
+// in the WAL, to path. path must not exist.
+func (s *Store) BackupTo(path string) error {
+	if _, err := s.db.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to back up database to %s: %w", path, err)
+	}
+	return nil
+}
+
+// CreateSchema creates all tables and indexes, then applies column migrations
+// so databases created by older releases pick up new columns.
 func (s *Store) CreateSchema() error {
 	_, err := s.db.Exec(schema)
 	if err != nil {
 		return fmt.Errorf("failed to create schema: %w", err)
 	}
+
+	for _, stmt := range migrations {
+		if _, err := s.db.Exec(stmt); err != nil {
+			if strings.Contains(err.Error(), "duplicate column name") {
+				continue // Column already present
+			}
+			return fmt.Errorf("failed to migrate schema: %w", err)
+		}
+	}
 	return nil
 }
@@ -2,6 +2,8 @@ package store
 
 import (
 	"errors"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -126,6 +128,40 @@ func TestCreateSchema(t *testing.T) {
 	}
 }
 
+func TestBackupTo(t *testing.T) {
+	dir := t.TempDir()
+	src, err := New(filepath.Join(dir, "brewprune.db"))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer src.Close()
+	if err := src.CreateSchema(); err != nil {
+		t.Fatalf("CreateSchema() failed: %v", err)
+	}
+	if err := src.InsertPackage(&brew.Package{Name: "jq", Version: "1.7", InstalledAt: time.Now()}); err != nil {
+		t.Fatalf("InsertPackage() failed: %v", err)
+	}
+
+	backupPath := filepath.Join(dir, "backup.db")
+	if err := src.BackupTo(backupPath); err != nil {
+		t.Fatalf("BackupTo() failed: %v", err)
+	}
+
+	backup, err := New(backupPath)
+	if err != nil {
+		t.Fatalf("New(backup) failed: %v", err)
+	}
+	defer backup.Close()
+	if _, err := backup.GetPackage("jq"); err != nil {
+		t.Errorf("backup is missing package jq: %v", err)
+	}
+
+	// The destination must not exist.
+	if err := src.BackupTo(backupPath); err == nil {
+		t.Error("BackupTo() over an existing file should fail")
+	}
+}
+
 func TestInsertAndGetPackage(t *testing.T) {
 	store := newTestStore(t)
 	defer store.Close()
@@ -243,6 +279,65 @@ func TestInsertPackageReplace(t *testing.T) {
 	}
 }
 
+func TestInsertPackage_Prefix(t *testing.T) {
+	store := newTestStore(t)
+	defer store.Close()
+
+	pkg := &brew.Package{Name: "gcc", InstalledAt: time.Now(), Prefix: "/usr/local"}
+	if err := store.InsertPackage(pkg); err != nil {
+		t.Fatalf("InsertPackage() failed: %v", err)
+	}
+
+	// An update without a prefix keeps the recorded one.
+	if err := store.InsertPackage(&brew.Package{Name: "gcc", Version: "14.1", InstalledAt: time.Now()}); err != nil {
+		t.Fatalf("InsertPackage() (update) failed: %v", err)
+	}
+
+	retrieved, err := store.GetPackage("gcc")
+	if err != nil {
+		t.Fatalf("GetPackage() failed: %v", err)
+	}
+	if retrieved.Prefix != "/usr/local" {
+		t.Errorf("Prefix = %q, want /usr/local", retrieved.Prefix)
+	}
+
+	pkgs, err := store.ListPackages()
+	if err != nil {
+		t.Fatalf("ListPackages() failed: %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].Prefix != "/usr/local" {
+		t.Errorf("ListPackages() = %+v, want gcc with prefix /usr/local", pkgs)
+	}
+}
+
+func TestInsertPackage_PinnedAndService(t *testing.T) {
+	store := newTestStore(t)
+	defer store.Close()
+
+	if err := store.InsertPackage(&brew.Package{Name: "postgresql@16", InstalledAt: time.Now(), Pinned: true, Service: "started"}); err != nil {
+		t.Fatalf("InsertPackage() failed: %v", err)
+	}
+	retrieved, err := store.GetPackage("postgresql@16")
+	if err != nil {
+		t.Fatalf("GetPackage() failed: %v", err)
+	}
+	if !retrieved.Pinned || retrieved.Service != "started" {
+		t.Errorf("Pinned, Service = %v, %q; want true, started", retrieved.Pinned, retrieved.Service)
+	}
+
+	// A rescan after 'brew unpin' and 'brew services stop' clears both.
+	if err := store.InsertPackage(&brew.Package{Name: "postgresql@16", InstalledAt: time.Now()}); err != nil {
+		t.Fatalf("InsertPackage() (update) failed: %v", err)
+	}
+	pkgs, err := store.ListPackages()
+	if err != nil {
+		t.Fatalf("ListPackages() failed: %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].Pinned || pkgs[0].Service != "" {
+		t.Errorf("ListPackages() = %+v, want postgresql@16 unpinned without a service", pkgs)
+	}
+}
+
 func TestGetPackageNotFound(t *testing.T) {
 	store := newTestStore(t)
 	defer store.Close()
@@ -717,6 +812,67 @@ func TestGetLastUsage(t *testing.T) {
 	}
 }
 
+func TestInsertUsageEvent_ProcessFields(t *testing.T) {
+	store := newTestStore(t)
+	defer store.Close()
+
+	if err := store.InsertPackage(&brew.Package{Name: "jq", InstalledAt: time.Now(), InstallType: "explicit"}); err != nil {
+		t.Fatalf("InsertPackage() failed: %v", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	tty, exitCode := true, 0
+	events := []*UsageEvent{
+		{
+			Package:    "jq",
+			EventType:  "exec",
+			BinaryPath: "/opt/homebrew/bin/jq",
+			PID:        4242,
+			PPID:       4100,
+			TTY:        &tty,
+			ExitCode:   &exitCode,
+			Cwd:        "/Users/alice/src/app",
+			Timestamp:  now,
+		},
+		{
+			// A v1 shim log entry: none of the process fields are known.
+			Package:    "jq",
+			EventType:  "exec",
+			BinaryPath: "/opt/homebrew/bin/jq",
+			Timestamp:  now.Add(-time.Hour),
+		},
+	}
+	for _, event := range events {
+		if err := store.InsertUsageEvent(event); err != nil {
+			t.Fatalf("InsertUsageEvent() failed: %v", err)
+		}
+	}
+
+	got, err := store.GetUsageEvents("jq", now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("GetUsageEvents() failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("GetUsageEvents() returned %d events, want 2", len(got))
+	}
+
+	full := got[0]
+	if full.PID != 4242 || full.PPID != 4100 || full.Cwd != "/Users/alice/src/app" {
+		t.Errorf("event = pid %d ppid %d cwd %q, want 4242 4100 /Users/alice/src/app", full.PID, full.PPID, full.Cwd)
+	}
+	if full.TTY == nil || !*full.TTY {
+		t.Errorf("TTY = %v, want true", full.TTY)
+	}
+	if full.ExitCode == nil || *full.ExitCode != 0 {
+		t.Errorf("ExitCode = %v, want 0", full.ExitCode)
+	}
+
+	bare := got[1]
+	if bare.PID != 0 || bare.PPID != 0 || bare.TTY != nil || bare.ExitCode != nil || bare.Cwd != "" {
+		t.Errorf("event without process fields = %+v, want them unset", bare)
+	}
+}
+
 func TestInsertAndGetSnapshot(t *testing.T) {
 	store := newTestStore(t)
 	defer store.Close()
@@ -1152,3 +1308,469 @@ func TestNilBinaryPaths(t *testing.T) {
 		t.Errorf("BinaryPaths length = %d, want 0", len(retrieved.BinaryPaths))
 	}
 }
+
+func TestInsertPackage_SyncsPackageBinaries(t *testing.T) {
+	store := newTestStore(t)
+	defer store.Close()
+
+	pkg := &brew.Package{
+		Name:        "postgresql@16",
+		Version:     "16.2",
+		InstalledAt: time.Now(),
+		InstallType: "explicit",
+		HasBinary:   true,
+		BinaryPaths: []string{"/opt/homebrew/bin/psql", "/opt/homebrew/bin/pg_dump"},
+	}
+	if err := store.InsertPackage(pkg); err != nil {
+		t.Fatalf("InsertPackage() failed: %v", err)
+	}
+
+	// Re-inserting with fewer binaries must drop the stale row.
+	pkg.BinaryPaths = []string{"/opt/homebrew/bin/psql"}
+	if err := store.InsertPackage(pkg); err != nil {
+		t.Fatalf("InsertPackage() failed: %v", err)
+	}
+
+	binaries, err := store.ListPackageBinaries("postgresql@16")
+	if err != nil {
+		t.Fatalf("ListPackageBinaries() failed: %v", err)
+	}
+	if len(binaries) != 1 || binaries[0].Name != "psql" {
+		t.Fatalf("ListPackageBinaries() = %+v, want only psql", binaries)
+	}
+
+	owners, err := store.GetBinaryOwners("psql")
+	if err != nil {
+		t.Fatalf("GetBinaryOwners() failed: %v", err)
+	}
+	if len(owners) != 1 || owners[0] != "postgresql@16" {
+		t.Errorf("GetBinaryOwners(psql) = %v, want [postgresql@16]", owners)
+	}
+}
+
+func TestGetBinaryUsage(t *testing.T) {
+	store := newTestStore(t)
+	defer store.Close()
+
+	pkg := &brew.Package{
+		Name:        "postgresql@16",
+		Version:     "16.2",
+		InstalledAt: time.Now(),
+		InstallType: "explicit",
+		HasBinary:   true,
+		BinaryPaths: []string{"/opt/homebrew/bin/psql", "/opt/homebrew/bin/pg_dump"},
+	}
+	if err := store.InsertPackage(pkg); err != nil {
+		t.Fatalf("InsertPackage() failed: %v", err)
+	}
+
+	now := time.Now()
+	events := []*UsageEvent{
+		{Package: "postgresql@16", EventType: "exec", BinaryPath: "/home/u/.brewprune/bin/psql", BinaryName: "psql", Timestamp: now.Add(-time.Hour)},
+		// Legacy event without binary_name falls back to basename(binary_path).
+		{Package: "postgresql@16", EventType: "exec", BinaryPath: "/home/u/.brewprune/bin/psql", Timestamp: now},
+		{Package: "postgresql@16", EventType: "probe", BinaryPath: "/home/u/.brewprune/bin/pg_dump", BinaryName: "pg_dump", Timestamp: now},
+	}
+	for _, e := range events {
+		if err := store.InsertUsageEvent(e); err != nil {
+			t.Fatalf("InsertUsageEvent() failed: %v", err)
+		}
+	}
+
+	usage, err := store.GetBinaryUsage("postgresql@16")
+	if err != nil {
+		t.Fatalf("GetBinaryUsage() failed: %v", err)
+	}
+
+	byName := make(map[string]*BinaryUsage)
+	for _, u := range usage {
+		byName[u.Name] = u
+	}
+	if got := byName["psql"]; got == nil || got.TotalUses != 2 || got.LastUsed == nil {
+		t.Errorf("psql usage = %+v, want 2 uses with LastUsed", got)
+	}
+	if got := byName["pg_dump"]; got == nil || got.TotalUses != 0 {
+		t.Errorf("pg_dump usage = %+v, want 0 uses (probe excluded)", got)
+	}
+}
+
+func TestCreateSchema_MigratesOldUsageEvents(t *testing.T) {
+	store, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer store.Close()
+
+	// Simulate a database created before binary_name existed.
+	if _, err := store.db.Exec(`CREATE TABLE usage_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		package TEXT NOT NULL,
+		event_type TEXT NOT NULL,
+		binary_path TEXT,
+		timestamp TEXT NOT NULL
+	)`); err != nil {
+		t.Fatalf("failed to create legacy table: %v", err)
+	}
+
+	if err := store.CreateSchema(); err != nil {
+		t.Fatalf("CreateSchema() failed: %v", err)
+	}
+	// Running it twice must be a no-op.
+	if err := store.CreateSchema(); err != nil {
+		t.Fatalf("second CreateSchema() failed: %v", err)
+	}
+
+	if _, err := store.db.Exec(`INSERT INTO usage_events (package, event_type, binary_name, timestamp) VALUES ('git', 'exec', 'git', ?)`,
+		time.Now().Format(time.RFC3339)); err != nil {
+		t.Errorf("binary_name column missing after migration: %v", err)
+	}
+}
+
+func TestGetBypassFindings_Coverage(t *testing.T) {
+	store := newTestStore(t)
+	defer store.Close()
+
+	for _, name := range []string{"fzf", "libpq"} {
+		if err := store.InsertPackage(&brew.Package{Name: name, Version: "1.0", InstalledAt: time.Now()}); err != nil {
+			t.Fatalf("InsertPackage(%s) failed: %v", name, err)
+		}
+	}
+
+	now := time.Now().Truncate(time.Second)
+	findings := []*BypassFinding{
+		{Package: "libpq", BinaryName: "psql", Kind: BypassAccessTime, Location: "/opt/homebrew/Cellar/libpq/16/bin/psql", EvidenceAt: now.Add(-time.Hour), DetectedAt: now},
+		{Package: "fzf", BinaryName: "fzf", Kind: BypassReference, Location: "/home/u/.zshrc:3", EvidenceAt: now.Add(-48 * time.Hour), DetectedAt: now.Add(-time.Minute)},
+	}
+	if err := store.ReplaceBypassFindings(findings); err != nil {
+		t.Fatalf("ReplaceBypassFindings() failed: %v", err)
+	}
+
+	got, err := store.GetBypassFindings("")
+	if err != nil {
+		t.Fatalf("GetBypassFindings() failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d findings, want 2", len(got))
+	}
+
+	// A recorded psql run after the access time covers the atime finding;
+	// a shim exec of fzf from before the reference was detected does not
+	// cover it.
+	events := []*UsageEvent{
+		{Package: "libpq", EventType: "exec", BinaryName: "psql", Timestamp: now},
+		{Package: "fzf", EventType: "exec", BinaryName: "fzf", Timestamp: now.Add(-2 * time.Minute)},
+	}
+	for _, e := range events {
+		if err := store.InsertUsageEvent(e); err != nil {
+			t.Fatalf("InsertUsageEvent() failed: %v", err)
+		}
+	}
+	got, err = store.GetBypassFindings("")
+	if err != nil {
+		t.Fatalf("GetBypassFindings() failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Package != "fzf" {
+		t.Fatalf("got %+v, want only the fzf reference", got)
+	}
+
+	// A later shim exec does not cover the reference either: the hardcoded
+	// path in .zshrc still bypasses the shim.
+	if err := store.InsertUsageEvent(&UsageEvent{Package: "fzf", EventType: "exec", BinaryName: "fzf", Timestamp: now}); err != nil {
+		t.Fatalf("InsertUsageEvent() failed: %v", err)
+	}
+	if got, _ := store.GetBypassFindings("fzf"); len(got) != 1 {
+		t.Errorf("got %d fzf findings after a later shim exec, want 1", len(got))
+	}
+
+	// Detecting the reference again keeps its first detection time, so a
+	// proc event since then covers it.
+	findings[1].DetectedAt = now.Add(time.Hour)
+	if err := store.ReplaceBypassFindings(findings[1:]); err != nil {
+		t.Fatalf("ReplaceBypassFindings() failed: %v", err)
+	}
+	if err := store.InsertUsageEvent(&UsageEvent{Package: "fzf", EventType: "proc", BinaryName: "fzf", Timestamp: now}); err != nil {
+		t.Fatalf("InsertUsageEvent() failed: %v", err)
+	}
+	if got, _ := store.GetBypassFindings("fzf"); len(got) != 0 {
+		t.Errorf("got %d fzf findings after a later proc event, want 0", len(got))
+	}
+}
+
+func TestGetBypassFindings_ProcEventCoversReference(t *testing.T) {
+	store := newTestStore(t)
+	defer store.Close()
+
+	if err := store.InsertPackage(&brew.Package{Name: "black", Version: "1.0", InstalledAt: time.Now()}); err != nil {
+		t.Fatalf("InsertPackage() failed: %v", err)
+	}
+	now := time.Now().Truncate(time.Second)
+	if err := store.ReplaceBypassFindings([]*BypassFinding{
+		{Package: "black", BinaryName: "black", Kind: BypassReference, Location: "/home/u/.vimrc:9", EvidenceAt: now.Add(-time.Hour), DetectedAt: now.Add(-time.Minute)},
+	}); err != nil {
+		t.Fatalf("ReplaceBypassFindings() failed: %v", err)
+	}
+
+	// An observed absolute-path run of any of the package's binaries covers
+	// the reference.
+	if err := store.InsertUsageEvent(&UsageEvent{Package: "black", EventType: "proc", BinaryName: "python3.12", Timestamp: now}); err != nil {
+		t.Fatalf("InsertUsageEvent() failed: %v", err)
+	}
+	if got, _ := store.GetBypassFindings("black"); len(got) != 0 {
+		t.Errorf("got %d black findings after proc event, want 0", len(got))
+	}
+}
+
+func TestUnresolvedEvents_SummaryAndReplay(t *testing.T) {
+	store := newTestStore(t)
+	defer store.Close()
+
+	if err := store.InsertPackage(&brew.Package{Name: "eza", Version: "1.0", InstalledAt: time.Now()}); err != nil {
+		t.Fatalf("InsertPackage() failed: %v", err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	entries := []struct {
+		name, path string
+		at         time.Time
+	}{
+		{"ll", "/home/u/.brewprune/bin/ll", now.Add(-2 * time.Hour)},
+		{"gs", "/home/u/.brewprune/bin/gs", now.Add(-time.Hour)},
+		{"ll", "/usr/local/brewprune/bin/ll", now},
+	}
+	for _, e := range entries {
+		if err := store.InsertUnresolvedEvent(e.name, e.path, e.at); err != nil {
+			t.Fatalf("InsertUnresolvedEvent() failed: %v", err)
+		}
+	}
+
+	commands, err := store.GetUnresolvedCommands(0)
+	if err != nil {
+		t.Fatalf("GetUnresolvedCommands() failed: %v", err)
+	}
+	if len(commands) != 2 {
+		t.Fatalf("got %d commands, want 2", len(commands))
+	}
+	ll := commands[0]
+	if ll.BinaryName != "ll" || ll.Count != 2 {
+		t.Errorf("first command = %s x%d, want ll x2", ll.BinaryName, ll.Count)
+	}
+	if ll.BinaryPath != "/usr/local/brewprune/bin/ll" {
+		t.Errorf("BinaryPath = %q, want the most recent path", ll.BinaryPath)
+	}
+	if !ll.FirstSeen.Equal(now.Add(-2*time.Hour)) || !ll.LastSeen.Equal(now) {
+		t.Errorf("seen range = %v..%v, want %v..%v", ll.FirstSeen, ll.LastSeen, now.Add(-2*time.Hour), now)
+	}
+
+	if top, err := store.GetUnresolvedCommands(1); err != nil || len(top) != 1 {
+		t.Errorf("GetUnresolvedCommands(1) = %d commands, %v; want 1", len(top), err)
+	}
+
+	moved, err := store.ReplayUnresolvedEvents("ll", "eza", "exec")
+	if err != nil {
+		t.Fatalf("ReplayUnresolvedEvents() failed: %v", err)
+	}
+	if moved != 2 {
+		t.Errorf("moved %d events, want 2", moved)
+	}
+	events, err := store.GetUsageEvents("eza", time.Time{})
+	if err != nil {
+		t.Fatalf("GetUsageEvents() failed: %v", err)
+	}
+	if len(events) != 2 || events[0].BinaryName != "ll" {
+		t.Errorf("replayed events = %+v, want 2 ll runs", events)
+	}
+	if count, _ := store.GetUnresolvedEventCount(); count != 1 {
+		t.Errorf("GetUnresolvedEventCount() = %d, want 1 (gs left)", count)
+	}
+
+	pruned, err := store.PruneUnresolvedEvents(now)
+	if err != nil {
+		t.Fatalf("PruneUnresolvedEvents() failed: %v", err)
+	}
+	if pruned != 1 {
+		t.Errorf("pruned %d events, want 1", pruned)
+	}
+}
+
+func TestRemovedCommands_RecordAndLookup(t *testing.T) {
+	store := newTestStore(t)
+	defer store.Close()
+
+	pkg := &brew.Package{
+		Name:        "jq",
+		Version:     "1.7",
+		InstalledAt: time.Now(),
+		BinaryPaths: []string{"/opt/homebrew/bin/jq"},
+	}
+	if err := store.InsertPackage(pkg); err != nil {
+		t.Fatalf("InsertPackage() failed: %v", err)
+	}
+
+	removedAt := time.Now().Truncate(time.Second)
+	if err := store.RecordRemovedPackage("jq", 7, removedAt); err != nil {
+		t.Fatalf("RecordRemovedPackage() failed: %v", err)
+	}
+	if err := store.DeletePackage("jq"); err != nil {
+		t.Fatalf("DeletePackage() failed: %v", err)
+	}
+
+	c, err := store.GetRemovedCommand("jq")
+	if err != nil {
+		t.Fatalf("GetRemovedCommand() failed: %v", err)
+	}
+	if c == nil {
+		t.Fatal("GetRemovedCommand() = nil, want jq")
+	}
+	if c.Package != "jq" || c.SnapshotID != 7 || !c.RemovedAt.Equal(removedAt) {
+		t.Errorf("GetRemovedCommand() = %+v", c)
+	}
+
+	if c, err := store.GetRemovedCommand("yq"); err != nil || c != nil {
+		t.Errorf("GetRemovedCommand(yq) = (%v, %v), want (nil, nil)", c, err)
+	}
+
+	// Reinstalling the package clears the explanation.
+	if err := store.InsertPackage(pkg); err != nil {
+		t.Fatalf("InsertPackage() failed: %v", err)
+	}
+	if c, err := store.GetRemovedCommand("jq"); err != nil || c != nil {
+		t.Errorf("GetRemovedCommand after reinstall = (%v, %v), want (nil, nil)", c, err)
+	}
+}
+
+func TestReplaceDeclaredRequirements_ScopedToDirs(t *testing.T) {
+	store := newTestStore(t)
+	defer store.Close()
+
+	for _, name := range []string{"jq", "terraform", "gh"} {
+		if err := store.InsertPackage(&brew.Package{Name: name, Version: "1.0", InstalledAt: time.Now()}); err != nil {
+			t.Fatalf("InsertPackage(%s) failed: %v", name, err)
+		}
+	}
+
+	now := time.Now().Truncate(time.Second)
+	initial := []*DeclaredRequirement{
+		{Package: "jq", Project: "/src/app", Name: "jq", Source: "Brewfile", Location: "/src/app/Brewfile:1", DetectedAt: now},
+		{Package: "terraform", Project: "/src/app-infra", Name: "terraform", Source: ".tool-versions", Location: "/src/app-infra/.tool-versions:2", DetectedAt: now},
+		{Package: "gh", Project: "/work/api", Name: "gh", Source: "script", Location: "/work/api/release.sh:4", DetectedAt: now},
+	}
+	if err := store.ReplaceDeclaredRequirements([]string{"/src", "/work"}, initial); err != nil {
+		t.Fatalf("ReplaceDeclaredRequirements() failed: %v", err)
+	}
+
+	// Rescanning /src/app replaces its requirements only; /src/app-infra
+	// merely shares the prefix.
+	rescan := []*DeclaredRequirement{
+		{Package: "gh", Project: "/src/app", Name: "gh", Source: "Makefile", Location: "/src/app/Makefile:7", DetectedAt: now},
+	}
+	if err := store.ReplaceDeclaredRequirements([]string{"/src/app/"}, rescan); err != nil {
+		t.Fatalf("ReplaceDeclaredRequirements() failed: %v", err)
+	}
+
+	got, err := store.GetDeclaredRequirements("")
+	if err != nil {
+		t.Fatalf("GetDeclaredRequirements() failed: %v", err)
+	}
+	var keys []string
+	for _, r := range got {
+		keys = append(keys, r.Package+" "+r.Location)
+	}
+	want := []string{
+		"gh /src/app/Makefile:7",
+		"gh /work/api/release.sh:4",
+		"terraform /src/app-infra/.tool-versions:2",
+	}
+	if strings.Join(keys, "\n") != strings.Join(want, "\n") {
+		t.Errorf("GetDeclaredRequirements() =\n%s\nwant\n%s", strings.Join(keys, "\n"), strings.Join(want, "\n"))
+	}
+	if !got[0].DetectedAt.Equal(now) || got[0].Source != "Makefile" || got[0].Project != "/src/app" {
+		t.Errorf("first requirement = %+v", got[0])
+	}
+
+	got, err = store.GetDeclaredRequirements("terraform")
+	if err != nil {
+		t.Fatalf("GetDeclaredRequirements(terraform) failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "terraform" {
+		t.Errorf("GetDeclaredRequirements(terraform) = %+v, want one requirement", got)
+	}
+}
+
+func TestReplaceRuntimeReferences(t *testing.T) {
+	store := newTestStore(t)
+	defer store.Close()
+
+	for _, name := range []string{"python@3.9", "node@16"} {
+		if err := store.InsertPackage(&brew.Package{Name: name, Version: "1.0", InstalledAt: time.Now()}); err != nil {
+			t.Fatalf("InsertPackage(%s) failed: %v", name, err)
+		}
+	}
+
+	now := time.Now().Truncate(time.Second)
+	refs := []*RuntimeReference{
+		{Package: "python@3.9", Kind: RuntimeVenv, Path: "/home/u/src/app/.venv", Target: "/opt/homebrew/opt/python@3.9/bin", DetectedAt: now},
+		{Package: "node@16", Kind: RuntimeSymlink, Path: "/home/u/.nodenv/versions/16", Target: "/opt/homebrew/opt/node@16", DetectedAt: now},
+	}
+	if err := store.ReplaceRuntimeReferences(refs); err != nil {
+		t.Fatalf("ReplaceRuntimeReferences() failed: %v", err)
+	}
+
+	got, err := store.GetRuntimeReferences("python@3.9")
+	if err != nil {
+		t.Fatalf("GetRuntimeReferences() failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Kind != RuntimeVenv || got[0].Target != refs[0].Target || !got[0].DetectedAt.Equal(now) {
+		t.Errorf("GetRuntimeReferences(python@3.9) = %+v, want the venv reference", got)
+	}
+
+	// A later scan that no longer finds the venv drops it.
+	if err := store.ReplaceRuntimeReferences(refs[1:]); err != nil {
+		t.Fatalf("ReplaceRuntimeReferences() failed: %v", err)
+	}
+	got, err = store.GetRuntimeReferences("")
+	if err != nil {
+		t.Fatalf("GetRuntimeReferences() failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Package != "node@16" {
+		t.Errorf("GetRuntimeReferences() = %+v, want only node@16", got)
+	}
+}
+
+func TestGetIndirectUsage_EventsWithoutBinaryName(t *testing.T) {
+	store := newTestStore(t)
+	defer store.Close()
+
+	for _, name := range []string{"git", "pcre2"} {
+		if err := store.InsertPackage(&brew.Package{Name: name, InstalledAt: time.Now()}); err != nil {
+			t.Fatalf("InsertPackage() failed: %v", err)
+		}
+	}
+	if err := store.ReplaceLinkages("git", []*Linkage{
+		{Package: "git", BinaryName: "git", LibraryPackage: "pcre2", Library: "libpcre2-8.0.dylib"},
+	}); err != nil {
+		t.Fatalf("ReplaceLinkages() failed: %v", err)
+	}
+
+	// The shim logger writes an empty binary_name rather than NULL; the
+	// binary is then taken from binary_path. scalar is not linked.
+	now := time.Now()
+	for _, e := range []*UsageEvent{
+		{Package: "git", EventType: "exec", BinaryPath: "/home/u/.brewprune/bin/scalar", Timestamp: now},
+		{Package: "git", EventType: "exec", BinaryPath: "/home/u/.brewprune/bin/git", Timestamp: now.Add(-time.Hour)},
+	} {
+		if err := store.InsertUsageEvent(e); err != nil {
+			t.Fatalf("InsertUsageEvent() failed: %v", err)
+		}
+	}
+
+	usage, err := store.GetIndirectUsage("pcre2")
+	if err != nil {
+		t.Fatalf("GetIndirectUsage() failed: %v", err)
+	}
+	if len(usage) != 1 || usage[0].Via != "git" || usage[0].Binary != "git" {
+		t.Fatalf("GetIndirectUsage(pcre2) = %+v, want one entry via git", usage)
+	}
+	if want := now.Add(-time.Hour).Truncate(time.Second); !usage[0].LastUsed.Equal(want) {
+		t.Errorf("LastUsed = %v, want %v (the git run, not scalar)", usage[0].LastUsed, want)
+	}
+}
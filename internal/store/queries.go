@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -26,8 +27,8 @@ func (s *Store) InsertPackage(pkg *brew.Package) error {
 
 	query := `
 		INSERT INTO packages
-		(name, installed_at, install_type, version, tap, is_cask, size_bytes, has_binary, binary_paths)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		(name, installed_at, install_type, version, tap, is_cask, size_bytes, has_binary, binary_paths, prefix, pinned, service)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(name) DO UPDATE SET
 			installed_at = excluded.installed_at,
 			install_type = excluded.install_type,
@@ -36,10 +37,18 @@ func (s *Store) InsertPackage(pkg *brew.Package) error {
 			is_cask      = excluded.is_cask,
 			size_bytes   = excluded.size_bytes,
 			has_binary   = excluded.has_binary,
-			binary_paths = excluded.binary_paths
+			binary_paths = excluded.binary_paths,
+			prefix       = COALESCE(excluded.prefix, packages.prefix),
+			pinned       = excluded.pinned,
+			service      = excluded.service
 	`
 
-	_, err = s.db.Exec(query,
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for %s: %w", pkg.Name, err)
+	}
+
+	_, err = tx.Exec(query,
 		pkg.Name,
 		pkg.InstalledAt.Format(time.RFC3339),
 		pkg.InstallType,
@@ -49,19 +58,44 @@ func (s *Store) InsertPackage(pkg *brew.Package) error {
 		pkg.SizeBytes,
 		pkg.HasBinary,
 		string(binaryPathsJSON),
+		sql.NullString{String: pkg.Prefix, Valid: pkg.Prefix != ""},
+		pkg.Pinned,
+		pkg.Service,
 	)
 
 	if err != nil {
+		tx.Rollback() //nolint:errcheck
 		return fmt.Errorf("failed to insert package %s: %w", pkg.Name, err)
 	}
 
+	// Keep the normalized package_binaries rows in sync with BinaryPaths.
+	if _, err := tx.Exec(`DELETE FROM package_binaries WHERE package = ?`, pkg.Name); err != nil {
+		tx.Rollback() //nolint:errcheck
+		return fmt.Errorf("failed to clear binaries for %s: %w", pkg.Name, err)
+	}
+	for _, binPath := range pkg.BinaryPaths {
+		_, err := tx.Exec(
+			`INSERT OR IGNORE INTO package_binaries (package, name, path) VALUES (?, ?, ?)`,
+			pkg.Name, filepath.Base(binPath), binPath,
+		)
+		if err != nil {
+			tx.Rollback() //nolint:errcheck
+			return fmt.Errorf("failed to insert binary %s for %s: %w", binPath, pkg.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit package %s: %w", pkg.Name, err)
+	}
+
 	return nil
 }
 
 // GetPackage retrieves a package by name.
 func (s *Store) GetPackage(name string) (*brew.Package, error) {
 	query := `
-		SELECT name, installed_at, install_type, version, tap, is_cask, size_bytes, has_binary, binary_paths
+		SELECT name, installed_at, install_type, version, tap, is_cask, size_bytes, has_binary, binary_paths, COALESCE(prefix, ''),
+		       COALESCE(pinned, 0), COALESCE(service, '')
 		FROM packages
 		WHERE name = ?
 	`
@@ -80,6 +114,9 @@ func (s *Store) GetPackage(name string) (*brew.Package, error) {
 		&pkg.SizeBytes,
 		&pkg.HasBinary,
 		&binaryPathsJSON,
+		&pkg.Prefix,
+		&pkg.Pinned,
+		&pkg.Service,
 	)
 
 	if err == sql.ErrNoRows {
@@ -109,7 +146,8 @@ func (s *Store) GetPackage(name string) (*brew.Package, error) {
 // ListPackages returns all packages.
 func (s *Store) ListPackages() ([]*brew.Package, error) {
 	query := `
-		SELECT name, installed_at, install_type, version, tap, is_cask, size_bytes, has_binary, binary_paths
+		SELECT name, installed_at, install_type, version, tap, is_cask, size_bytes, has_binary, binary_paths, COALESCE(prefix, ''),
+		       COALESCE(pinned, 0), COALESCE(service, '')
 		FROM packages
 		ORDER BY name
 	`
@@ -139,6 +177,9 @@ func (s *Store) ListPackages() ([]*brew.Package, error) {
 			&pkg.SizeBytes,
 			&pkg.HasBinary,
 			&binaryPathsJSON,
+			&pkg.Prefix,
+			&pkg.Pinned,
+			&pkg.Service,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan package row: %w", err)
@@ -279,14 +320,34 @@ func (s *Store) GetDependents(pkg string) ([]string, error) {
 // InsertUsageEvent records a package usage event.
 func (s *Store) InsertUsageEvent(event *UsageEvent) error {
 	query := `
-		INSERT INTO usage_events (package, event_type, binary_path, timestamp)
-		VALUES (?, ?, ?, ?)
+		INSERT INTO usage_events (package, event_type, binary_path, binary_name, pid, ppid, tty, exit_code, cwd, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
+	pid := sql.NullInt64{Int64: int64(event.PID), Valid: event.PID > 0}
+	ppid := sql.NullInt64{Int64: int64(event.PPID), Valid: event.PPID > 0}
+	var tty, exitCode sql.NullInt64
+	if event.TTY != nil {
+		tty.Valid = true
+		if *event.TTY {
+			tty.Int64 = 1
+		}
+	}
+	if event.ExitCode != nil {
+		exitCode = sql.NullInt64{Int64: int64(*event.ExitCode), Valid: true}
+	}
+	cwd := sql.NullString{String: event.Cwd, Valid: event.Cwd != ""}
+
 	_, err := s.db.Exec(query,
 		event.Package,
 		event.EventType,
 		event.BinaryPath,
+		event.BinaryName,
+		pid,
+		ppid,
+		tty,
+		exitCode,
+		cwd,
 		event.Timestamp.Format(time.RFC3339),
 	)
 
@@ -300,7 +361,8 @@ func (s *Store) InsertUsageEvent(event *UsageEvent) error {
 // GetUsageEvents returns usage events for a package since the given time.
 func (s *Store) GetUsageEvents(pkg string, since time.Time) ([]*UsageEvent, error) {
 	query := `
-		SELECT package, event_type, binary_path, timestamp
+		SELECT package, event_type, binary_path, COALESCE(binary_name, ''), COALESCE(pid, 0),
+		       COALESCE(ppid, 0), tty, exit_code, COALESCE(cwd, ''), timestamp
 		FROM usage_events
 		WHERE package = ? AND timestamp >= ?
 		ORDER BY timestamp DESC
@@ -319,16 +381,31 @@ func (s *Store) GetUsageEvents(pkg string, since time.Time) ([]*UsageEvent, erro
 	for rows.Next() {
 		var event UsageEvent
 		var timestamp string
+		var tty, exitCode sql.NullInt64
 
 		err := rows.Scan(
 			&event.Package,
 			&event.EventType,
 			&event.BinaryPath,
+			&event.BinaryName,
+			&event.PID,
+			&event.PPID,
+			&tty,
+			&exitCode,
+			&event.Cwd,
 			&timestamp,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan usage event row: %w", err)
 		}
+		if tty.Valid {
+			isTTY := tty.Int64 != 0
+			event.TTY = &isTTY
+		}
+		if exitCode.Valid {
+			code := int(exitCode.Int64)
+			event.ExitCode = &code
+		}
 
 		// Parse timestamp
 		event.Timestamp, err = time.Parse(time.RFC3339, timestamp)
@@ -346,6 +423,45 @@ func (s *Store) GetUsageEvents(pkg string, since time.Time) ([]*UsageEvent, erro
 	return events, nil
 }
 
+// GetDirUsage returns, for each working directory and package, how often
+// the package was run there and when it was last run. Only events whose
+// working directory was recorded are counted, and config probes are skipped.
+// pkg limits the result to one package; "" returns all of them.
+func (s *Store) GetDirUsage(pkg string) ([]*DirUsage, error) {
+	rows, err := s.db.Query(`
+		SELECT cwd, package, COUNT(*), MAX(timestamp)
+		FROM usage_events
+		WHERE cwd IS NOT NULL AND cwd != '' AND event_type != 'probe'
+		  AND (? = '' OR package = ?)
+		GROUP BY cwd, package
+		ORDER BY cwd, package
+	`, pkg, pkg)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil, ErrNotInitialized
+		}
+		return nil, fmt.Errorf("failed to get usage by directory: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []*DirUsage
+	for rows.Next() {
+		var u DirUsage
+		var lastUsed string
+		if err := rows.Scan(&u.Cwd, &u.Package, &u.Runs, &lastUsed); err != nil {
+			return nil, fmt.Errorf("failed to scan directory usage row: %w", err)
+		}
+		if u.LastUsed, err = time.Parse(time.RFC3339, lastUsed); err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		usage = append(usage, &u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating directory usage: %w", err)
+	}
+	return usage, nil
+}
+
 // GetLastUsage returns the timestamp of the most recent usage event for a package.
 // Returns nil if no usage events exist.
 func (s *Store) GetLastUsage(pkg string) (*time.Time, error) {
@@ -374,6 +490,836 @@ func (s *Store) GetLastUsage(pkg string) (*time.Time, error) {
 	return &t, nil
 }
 
+// Binary operations
+
+// ListPackageBinaries returns the binaries owned by a package, ordered by name.
+func (s *Store) ListPackageBinaries(pkg string) ([]*PackageBinary, error) {
+	rows, err := s.db.Query(`
+		SELECT package, name, path
+		FROM package_binaries
+		WHERE package = ?
+		ORDER BY name
+	`, pkg)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil, ErrNotInitialized
+		}
+		return nil, fmt.Errorf("failed to list binaries for %s: %w", pkg, err)
+	}
+	defer rows.Close()
+
+	var binaries []*PackageBinary
+	for rows.Next() {
+		var b PackageBinary
+		if err := rows.Scan(&b.Package, &b.Name, &b.Path); err != nil {
+			return nil, fmt.Errorf("failed to scan binary row: %w", err)
+		}
+		binaries = append(binaries, &b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating binaries: %w", err)
+	}
+
+	return binaries, nil
+}
+
+// GetBinaryOwners returns the packages that ship a binary with the given
+// basename. More than one owner means the basename collides across formulae.
+func (s *Store) GetBinaryOwners(name string) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT DISTINCT package
+		FROM package_binaries
+		WHERE name = ?
+		ORDER BY package
+	`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get owners of binary %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	var owners []string
+	for rows.Next() {
+		var owner string
+		if err := rows.Scan(&owner); err != nil {
+			return nil, fmt.Errorf("failed to scan binary owner row: %w", err)
+		}
+		owners = append(owners, owner)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating binary owners: %w", err)
+	}
+
+	return owners, nil
+}
+
+// GetBinaryUsage returns per-binary usage for a package: one entry for every
+// binary in package_binaries (zero uses if never executed) plus any binary
+// that has events but is no longer linked. Probe events are excluded. Events
+// recorded before binary_name existed fall back to the basename of binary_path.
+func (s *Store) GetBinaryUsage(pkg string) ([]*BinaryUsage, error) {
+	binaries, err := s.ListPackageBinaries(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*BinaryUsage, len(binaries))
+	var usage []*BinaryUsage
+	for _, b := range binaries {
+		if _, exists := byName[b.Name]; exists {
+			continue
+		}
+		u := &BinaryUsage{Name: b.Name, Path: b.Path}
+		byName[b.Name] = u
+		usage = append(usage, u)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT COALESCE(binary_name, ''), COALESCE(binary_path, ''), timestamp
+		FROM usage_events
+		WHERE package = ? AND event_type != 'probe'
+	`, pkg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get binary usage for %s: %w", pkg, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, binPath, timestamp string
+		if err := rows.Scan(&name, &binPath, &timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan binary usage row: %w", err)
+		}
+		if name == "" {
+			if binPath == "" {
+				continue
+			}
+			name = filepath.Base(binPath)
+		}
+
+		t, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+
+		u, exists := byName[name]
+		if !exists {
+			u = &BinaryUsage{Name: name}
+			byName[name] = u
+			usage = append(usage, u)
+		}
+		u.TotalUses++
+		if u.LastUsed == nil || t.After(*u.LastUsed) {
+			u.LastUsed = &t
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating binary usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// Linkage operations
+
+// ReplaceLinkages replaces all linkage rows for a package in one transaction.
+func (s *Store) ReplaceLinkages(pkg string, links []*Linkage) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM binary_linkages WHERE package = ?`, pkg); err != nil {
+		return fmt.Errorf("failed to clear linkages for %s: %w", pkg, err)
+	}
+
+	for _, l := range links {
+		_, err := tx.Exec(`
+			INSERT OR IGNORE INTO binary_linkages (package, binary_name, library_package, library)
+			VALUES (?, ?, ?, ?)
+		`, pkg, l.BinaryName, l.LibraryPackage, l.Library)
+		if err != nil {
+			return fmt.Errorf("failed to insert linkage %s -> %s: %w", l.BinaryName, l.Library, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit linkages for %s: %w", pkg, err)
+	}
+	return nil
+}
+
+// RecordLinkageScan records the time window in which a linkage scan read
+// package binaries. Reading a binary updates its access time, so the bypass
+// detector ignores access times inside these windows.
+func (s *Store) RecordLinkageScan(start, end time.Time) error {
+	_, err := s.db.Exec(`INSERT INTO linkage_scans (started_at, finished_at) VALUES (?, ?)`,
+		start.Format(time.RFC3339), end.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to record linkage scan: %w", err)
+	}
+	return nil
+}
+
+// GetLinkageScans returns the linkage scan windows that finished at or after
+// since, oldest first.
+func (s *Store) GetLinkageScans(since time.Time) ([]ScanWindow, error) {
+	rows, err := s.db.Query(`
+		SELECT started_at, finished_at FROM linkage_scans
+		WHERE finished_at >= ?
+		ORDER BY started_at
+	`, since.Format(time.RFC3339))
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil, ErrNotInitialized
+		}
+		return nil, fmt.Errorf("failed to get linkage scans: %w", err)
+	}
+	defer rows.Close()
+
+	var windows []ScanWindow
+	for rows.Next() {
+		var start, end string
+		if err := rows.Scan(&start, &end); err != nil {
+			return nil, fmt.Errorf("failed to scan linkage scan row: %w", err)
+		}
+		var w ScanWindow
+		if w.Start, err = time.Parse(time.RFC3339, start); err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		if w.End, err = time.Parse(time.RFC3339, end); err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		windows = append(windows, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating linkage scans: %w", err)
+	}
+	return windows, nil
+}
+
+// GetIndirectUsage returns, for each package whose executed binaries load a
+// library owned by libPkg, the most recent such execution. Results are ordered
+// most recent first. Events without a binary name (NULL or empty) fall back
+// to the basename of binary_path, and are attributed to every linked binary
+// of their package when that is missing too.
+func (s *Store) GetIndirectUsage(libPkg string) ([]*IndirectUsage, error) {
+	rows, err := s.db.Query(`
+		SELECT l.package, l.binary_name, MAX(e.timestamp) AS last_used
+		FROM binary_linkages l
+		JOIN usage_events e
+		  ON e.package = l.package
+		 AND (e.binary_name = l.binary_name
+		      OR (COALESCE(e.binary_name, '') = ''
+		          AND (COALESCE(e.binary_path, '') = ''
+		               OR replace(e.binary_path, rtrim(e.binary_path, replace(e.binary_path, '/', '')), '') = l.binary_name)))
+		WHERE l.library_package = ?
+		  AND l.package != l.library_package
+		  AND e.event_type != 'probe'
+		GROUP BY l.package, l.binary_name
+		ORDER BY last_used DESC
+	`, libPkg)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil, ErrNotInitialized
+		}
+		return nil, fmt.Errorf("failed to get indirect usage for %s: %w", libPkg, err)
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	var usage []*IndirectUsage
+	for rows.Next() {
+		var via, binary, timestamp string
+		if err := rows.Scan(&via, &binary, &timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan indirect usage row: %w", err)
+		}
+		// Keep only the most recent binary per package.
+		if seen[via] {
+			continue
+		}
+		seen[via] = true
+
+		t, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		usage = append(usage, &IndirectUsage{Via: via, Binary: binary, LastUsed: t})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating indirect usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// Bypass operations
+
+// ReplaceBypassFindings replaces every stored bypass finding with findings in
+// one transaction. Detection always covers all packages, so findings that
+// were not found again are dropped. A finding found again keeps the
+// detected_at of its first detection, so usage recorded since then still
+// covers it.
+func (s *Store) ReplaceBypassFindings(findings []*BypassFinding) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	type findingKey struct{ pkg, binary, kind, location string }
+	firstDetected := make(map[findingKey]string)
+	rows, err := tx.Query(`SELECT package, binary_name, kind, location, detected_at FROM bypass_findings`)
+	if err != nil {
+		return fmt.Errorf("failed to get bypass findings: %w", err)
+	}
+	for rows.Next() {
+		var k findingKey
+		var detectedAt string
+		if err := rows.Scan(&k.pkg, &k.binary, &k.kind, &k.location, &detectedAt); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan bypass finding row: %w", err)
+		}
+		firstDetected[k] = detectedAt
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating bypass findings: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM bypass_findings`); err != nil {
+		return fmt.Errorf("failed to clear bypass findings: %w", err)
+	}
+
+	for _, f := range findings {
+		detectedAt, ok := firstDetected[findingKey{f.Package, f.BinaryName, f.Kind, f.Location}]
+		if !ok {
+			detectedAt = f.DetectedAt.Format(time.RFC3339)
+		}
+		_, err := tx.Exec(`
+			INSERT OR IGNORE INTO bypass_findings (package, binary_name, kind, location, evidence_at, detected_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, f.Package, f.BinaryName, f.Kind, f.Location, f.EvidenceAt.Format(time.RFC3339), detectedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert bypass finding for %s: %w", f.Package, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit bypass findings: %w", err)
+	}
+	return nil
+}
+
+// GetBypassFindings returns the bypass findings for pkg (all packages when
+// pkg is empty) that recorded usage has not yet covered:
+//
+//   - an atime finding is covered once a use of that binary is recorded at
+//     or after the access time;
+//   - a reference finding is covered once a "proc" event for any binary of
+//     the package is recorded after it was first detected: the bypassing
+//     runs themselves are being observed. Shim events never cover it, since
+//     the hardcoded path still skips the shim; a rescan that no longer
+//     finds the reference drops it instead.
+//
+// For atime findings, events without a binary name (NULL or empty) count as
+// uses of every binary of their package.
+func (s *Store) GetBypassFindings(pkg string) ([]*BypassFinding, error) {
+	rows, err := s.db.Query(`
+		SELECT f.package, f.binary_name, f.kind, f.location, f.evidence_at, f.detected_at
+		FROM bypass_findings f
+		WHERE (? = '' OR f.package = ?)
+		  AND NOT EXISTS (
+			SELECT 1 FROM usage_events e
+			WHERE e.package = f.package
+			  AND e.event_type != 'probe'
+			  AND CASE WHEN f.kind = 'atime'
+			    THEN (e.binary_name = f.binary_name OR COALESCE(e.binary_name, '') = '')
+			      AND e.timestamp >= f.evidence_at
+			    ELSE e.event_type = 'proc' AND e.timestamp >= f.detected_at
+			  END
+		  )
+		ORDER BY f.package, f.binary_name, f.kind, f.location
+	`, pkg, pkg)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil, ErrNotInitialized
+		}
+		return nil, fmt.Errorf("failed to get bypass findings: %w", err)
+	}
+	defer rows.Close()
+
+	var findings []*BypassFinding
+	for rows.Next() {
+		var f BypassFinding
+		var evidenceAt, detectedAt string
+		if err := rows.Scan(&f.Package, &f.BinaryName, &f.Kind, &f.Location, &evidenceAt, &detectedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan bypass finding row: %w", err)
+		}
+		if f.EvidenceAt, err = time.Parse(time.RFC3339, evidenceAt); err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		if f.DetectedAt, err = time.Parse(time.RFC3339, detectedAt); err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		findings = append(findings, &f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating bypass findings: %w", err)
+	}
+
+	return findings, nil
+}
+
+// Runtime reference operations
+
+// ReplaceRuntimeReferences replaces every stored runtime reference with refs
+// in one transaction. Detection always covers all roots, so references that
+// were not found again are dropped.
+func (s *Store) ReplaceRuntimeReferences(refs []*RuntimeReference) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM runtime_references`); err != nil {
+		return fmt.Errorf("failed to clear runtime references: %w", err)
+	}
+
+	for _, r := range refs {
+		_, err := tx.Exec(`
+			INSERT OR IGNORE INTO runtime_references (package, kind, path, target, detected_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, r.Package, r.Kind, r.Path, r.Target, r.DetectedAt.Format(time.RFC3339))
+		if err != nil {
+			return fmt.Errorf("failed to insert runtime reference for %s: %w", r.Package, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit runtime references: %w", err)
+	}
+	return nil
+}
+
+// GetRuntimeReferences returns the runtime references for pkg (all packages
+// when pkg is empty), ordered by package, kind and path.
+func (s *Store) GetRuntimeReferences(pkg string) ([]*RuntimeReference, error) {
+	rows, err := s.db.Query(`
+		SELECT package, kind, path, target, detected_at
+		FROM runtime_references
+		WHERE (? = '' OR package = ?)
+		ORDER BY package, kind, path
+	`, pkg, pkg)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil, ErrNotInitialized
+		}
+		return nil, fmt.Errorf("failed to get runtime references: %w", err)
+	}
+	defer rows.Close()
+
+	var refs []*RuntimeReference
+	for rows.Next() {
+		var r RuntimeReference
+		var detectedAt string
+		if err := rows.Scan(&r.Package, &r.Kind, &r.Path, &r.Target, &detectedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan runtime reference row: %w", err)
+		}
+		if r.DetectedAt, err = time.Parse(time.RFC3339, detectedAt); err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		refs = append(refs, &r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating runtime references: %w", err)
+	}
+
+	return refs, nil
+}
+
+// Declared requirement operations
+
+// ReplaceDeclaredRequirements replaces the stored requirements of every
+// project in or under dirs with reqs, in one transaction. Projects outside
+// dirs keep theirs, so directories can be scanned one at a time.
+func (s *Store) ReplaceDeclaredRequirements(dirs []string, reqs []*DeclaredRequirement) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		_, err := tx.Exec(`
+			DELETE FROM declared_requirements
+			WHERE project = ? OR substr(project, 1, length(?)) = ?
+		`, dir, dir+"/", dir+"/")
+		if err != nil {
+			return fmt.Errorf("failed to clear declared requirements for %s: %w", dir, err)
+		}
+	}
+
+	for _, r := range reqs {
+		_, err := tx.Exec(`
+			INSERT OR IGNORE INTO declared_requirements (package, project, name, source, location, detected_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, r.Package, r.Project, r.Name, r.Source, r.Location, r.DetectedAt.Format(time.RFC3339))
+		if err != nil {
+			return fmt.Errorf("failed to insert declared requirement for %s: %w", r.Package, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit declared requirements: %w", err)
+	}
+	return nil
+}
+
+// GetDeclaredRequirements returns the declared requirements for pkg (all
+// packages when pkg is empty), ordered by package, project and location.
+func (s *Store) GetDeclaredRequirements(pkg string) ([]*DeclaredRequirement, error) {
+	rows, err := s.db.Query(`
+		SELECT package, project, name, source, location, detected_at
+		FROM declared_requirements
+		WHERE (? = '' OR package = ?)
+		ORDER BY package, project, location, name
+	`, pkg, pkg)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil, ErrNotInitialized
+		}
+		return nil, fmt.Errorf("failed to get declared requirements: %w", err)
+	}
+	defer rows.Close()
+
+	var reqs []*DeclaredRequirement
+	for rows.Next() {
+		var r DeclaredRequirement
+		var detectedAt string
+		if err := rows.Scan(&r.Package, &r.Project, &r.Name, &r.Source, &r.Location, &detectedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan declared requirement row: %w", err)
+		}
+		if r.DetectedAt, err = time.Parse(time.RFC3339, detectedAt); err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		reqs = append(reqs, &r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating declared requirements: %w", err)
+	}
+
+	return reqs, nil
+}
+
+// Cask app operations
+
+// ReplaceCaskApps replaces the stored application bundles of all casks with
+// apps. CheckedAt is kept for bundles that were already known.
+func (s *Store) ReplaceCaskApps(apps []*CaskApp) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	checked := make(map[[2]string]string)
+	rows, err := tx.Query(`SELECT package, path, checked_at FROM cask_apps WHERE checked_at IS NOT NULL`)
+	if err != nil {
+		return fmt.Errorf("failed to read cask apps: %w", err)
+	}
+	for rows.Next() {
+		var pkg, path, at string
+		if err := rows.Scan(&pkg, &path, &at); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan cask app row: %w", err)
+		}
+		checked[[2]string{pkg, path}] = at
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating cask apps: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM cask_apps`); err != nil {
+		return fmt.Errorf("failed to clear cask apps: %w", err)
+	}
+	for _, app := range apps {
+		var at interface{}
+		if v, ok := checked[[2]string{app.Package, app.Path}]; ok {
+			at = v
+		}
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO cask_apps (package, name, path, checked_at)
+			VALUES (?, ?, ?, ?)
+		`, app.Package, app.Name, app.Path, at); err != nil {
+			return fmt.Errorf("failed to insert cask app %s: %w", app.Path, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit cask apps: %w", err)
+	}
+	return nil
+}
+
+// ListCaskApps returns all known cask application bundles, ordered by
+// package and path.
+func (s *Store) ListCaskApps() ([]*CaskApp, error) {
+	rows, err := s.db.Query(`
+		SELECT package, name, path, checked_at
+		FROM cask_apps
+		ORDER BY package, path
+	`)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil, ErrNotInitialized
+		}
+		return nil, fmt.Errorf("failed to list cask apps: %w", err)
+	}
+	defer rows.Close()
+
+	var apps []*CaskApp
+	for rows.Next() {
+		var app CaskApp
+		var checkedAt sql.NullString
+		if err := rows.Scan(&app.Package, &app.Name, &app.Path, &checkedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan cask app row: %w", err)
+		}
+		if checkedAt.Valid {
+			t, err := time.Parse(time.RFC3339, checkedAt.String)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse timestamp: %w", err)
+			}
+			app.CheckedAt = &t
+		}
+		apps = append(apps, &app)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cask apps: %w", err)
+	}
+
+	return apps, nil
+}
+
+// HasCaskApps reports whether any application bundle of pkg is known.
+func (s *Store) HasCaskApps(pkg string) (bool, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM cask_apps WHERE package = ?`, pkg).Scan(&n)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check cask apps for %s: %w", pkg, err)
+	}
+	return n > 0, nil
+}
+
+// IsCaskLaunchTracked reports whether a launch source has answered for any
+// of pkg's application bundles, with or without a launch date, i.e. whether
+// the cask's launches are being recorded.
+func (s *Store) IsCaskLaunchTracked(pkg string) (bool, error) {
+	var n int
+	err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM cask_apps WHERE package = ? AND checked_at IS NOT NULL`, pkg,
+	).Scan(&n)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check launch tracking for %s: %w", pkg, err)
+	}
+	return n > 0, nil
+}
+
+// Unresolved event operations
+
+// InsertUnresolvedEvent records a shim log entry whose command could not be
+// mapped to a package, so it can be replayed once a scan adds the mapping.
+func (s *Store) InsertUnresolvedEvent(binaryName, binaryPath string, timestamp time.Time) error {
+	_, err := s.db.Exec(`
+		INSERT INTO unresolved_events (binary_name, binary_path, timestamp)
+		VALUES (?, ?, ?)
+	`, binaryName, binaryPath, timestamp.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to insert unresolved event for %s: %w", binaryName, err)
+	}
+	return nil
+}
+
+// GetUnresolvedCommands returns unresolved commands ordered by how often
+// they were run, most frequent first. limit <= 0 returns all of them.
+func (s *Store) GetUnresolvedCommands(limit int) ([]*UnresolvedCommand, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.Query(`
+		SELECT u.binary_name, COUNT(*), MIN(u.timestamp), MAX(u.timestamp),
+		       (SELECT l.binary_path FROM unresolved_events l
+		        WHERE l.binary_name = u.binary_name
+		        ORDER BY l.timestamp DESC, l.id DESC LIMIT 1)
+		FROM unresolved_events u
+		GROUP BY u.binary_name
+		ORDER BY COUNT(*) DESC, MAX(u.timestamp) DESC, u.binary_name
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil, ErrNotInitialized
+		}
+		return nil, fmt.Errorf("failed to get unresolved commands: %w", err)
+	}
+	defer rows.Close()
+
+	var commands []*UnresolvedCommand
+	for rows.Next() {
+		var c UnresolvedCommand
+		var firstSeen, lastSeen string
+		if err := rows.Scan(&c.BinaryName, &c.Count, &firstSeen, &lastSeen, &c.BinaryPath); err != nil {
+			return nil, fmt.Errorf("failed to scan unresolved command row: %w", err)
+		}
+		if c.FirstSeen, err = time.Parse(time.RFC3339, firstSeen); err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		if c.LastSeen, err = time.Parse(time.RFC3339, lastSeen); err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		commands = append(commands, &c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating unresolved commands: %w", err)
+	}
+
+	return commands, nil
+}
+
+// GetUnresolvedEventCount returns the number of stored unresolved events.
+func (s *Store) GetUnresolvedEventCount() (int, error) {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM unresolved_events`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count unresolved events: %w", err)
+	}
+	return count, nil
+}
+
+// ReplayUnresolvedEvents moves the unresolved events for binaryName into
+// usage_events, attributed to pkg with the given event type, and returns how
+// many were moved. Both steps run in one transaction.
+func (s *Store) ReplayUnresolvedEvents(binaryName, pkg, eventType string) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO usage_events (package, event_type, binary_path, binary_name, pid, ppid, tty, exit_code, cwd, timestamp)
+		SELECT ?, ?, binary_path, binary_name, pid, ppid, tty, exit_code, cwd, timestamp
+		FROM unresolved_events
+		WHERE binary_name = ?
+		ORDER BY id
+	`, pkg, eventType, binaryName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to replay unresolved events for %s: %w", binaryName, err)
+	}
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to replay unresolved events for %s: %w", binaryName, err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM unresolved_events WHERE binary_name = ?`, binaryName); err != nil {
+		return 0, fmt.Errorf("failed to clear unresolved events for %s: %w", binaryName, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit replayed events: %w", err)
+	}
+	return int(moved), nil
+}
+
+// PruneUnresolvedEvents deletes unresolved events logged before cutoff and
+// returns how many were deleted.
+func (s *Store) PruneUnresolvedEvents(cutoff time.Time) (int, error) {
+	result, err := s.db.Exec(`DELETE FROM unresolved_events WHERE timestamp < ?`, cutoff.Format(time.RFC3339))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune unresolved events: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune unresolved events: %w", err)
+	}
+	return int(n), nil
+}
+
+// Removed command operations
+
+// RecordRemovedPackage remembers the binaries of pkg as removed by brewprune,
+// so a later "command not found" can be explained. Call it before
+// DeletePackage, which drops the package's binaries. snapshotID is 0 when no
+// snapshot was taken.
+func (s *Store) RecordRemovedPackage(pkg string, snapshotID int64, removedAt time.Time) error {
+	var snapshot interface{}
+	if snapshotID != 0 {
+		snapshot = snapshotID
+	}
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO removed_commands (name, package, snapshot_id, removed_at)
+		SELECT name, package, ?, ?
+		FROM package_binaries
+		WHERE package = ?
+	`, snapshot, removedAt.Format(time.RFC3339), pkg)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return ErrNotInitialized
+		}
+		return fmt.Errorf("failed to record removed package %s: %w", pkg, err)
+	}
+	return nil
+}
+
+// GetRemovedCommand returns the most recent brewprune removal of a package
+// providing the command name, or nil if there is none. Packages that have
+// been installed again since are ignored.
+func (s *Store) GetRemovedCommand(name string) (*RemovedCommand, error) {
+	var (
+		c         RemovedCommand
+		snapshot  sql.NullInt64
+		removedAt string
+	)
+	err := s.db.QueryRow(`
+		SELECT name, package, snapshot_id, removed_at
+		FROM removed_commands
+		WHERE name = ? AND package NOT IN (SELECT name FROM packages)
+		ORDER BY removed_at DESC
+		LIMIT 1
+	`, name).Scan(&c.Name, &c.Package, &snapshot, &removedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil, ErrNotInitialized
+		}
+		return nil, fmt.Errorf("failed to get removed command %s: %w", name, err)
+	}
+	c.SnapshotID = snapshot.Int64
+	c.RemovedAt, err = time.Parse(time.RFC3339, removedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timestamp: %w", err)
+	}
+	return &c, nil
+}
+
 // Snapshot operations
 
 // InsertSnapshot creates a new snapshot record and returns its ID.
@@ -10,7 +10,10 @@ CREATE TABLE IF NOT EXISTS packages (
     is_cask BOOLEAN,
     size_bytes INTEGER,
     has_binary BOOLEAN,
-    binary_paths TEXT
+    binary_paths TEXT,
+    prefix TEXT,
+    pinned BOOLEAN DEFAULT 0,
+    service TEXT DEFAULT ''
 );
 
 CREATE TABLE IF NOT EXISTS dependencies (
@@ -26,10 +29,99 @@ CREATE TABLE IF NOT EXISTS usage_events (
     package TEXT NOT NULL,
     event_type TEXT NOT NULL,
     binary_path TEXT,
+    binary_name TEXT,
+    pid INTEGER,
+    ppid INTEGER,
+    tty INTEGER,
+    exit_code INTEGER,
+    cwd TEXT,
     timestamp TIMESTAMP NOT NULL,
     FOREIGN KEY (package) REFERENCES packages(name) ON DELETE CASCADE
 );
 
+CREATE TABLE IF NOT EXISTS package_binaries (
+    package TEXT NOT NULL,
+    name TEXT NOT NULL,
+    path TEXT NOT NULL,
+    PRIMARY KEY (package, path),
+    FOREIGN KEY (package) REFERENCES packages(name) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS cask_apps (
+    package TEXT NOT NULL,
+    name TEXT NOT NULL,
+    path TEXT NOT NULL,
+    checked_at TIMESTAMP,
+    PRIMARY KEY (package, path),
+    FOREIGN KEY (package) REFERENCES packages(name) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS binary_linkages (
+    package TEXT NOT NULL,
+    binary_name TEXT NOT NULL,
+    library_package TEXT NOT NULL,
+    library TEXT NOT NULL,
+    PRIMARY KEY (package, binary_name, library_package, library),
+    FOREIGN KEY (package) REFERENCES packages(name) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS linkage_scans (
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS bypass_findings (
+    package TEXT NOT NULL,
+    binary_name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    location TEXT NOT NULL,
+    evidence_at TIMESTAMP NOT NULL,
+    detected_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (package, binary_name, kind, location),
+    FOREIGN KEY (package) REFERENCES packages(name) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS runtime_references (
+    package TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    path TEXT NOT NULL,
+    target TEXT NOT NULL,
+    detected_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (package, kind, path),
+    FOREIGN KEY (package) REFERENCES packages(name) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS declared_requirements (
+    package TEXT NOT NULL,
+    project TEXT NOT NULL,
+    name TEXT NOT NULL,
+    source TEXT NOT NULL,
+    location TEXT NOT NULL,
+    detected_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (package, project, name, location),
+    FOREIGN KEY (package) REFERENCES packages(name) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS unresolved_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    binary_name TEXT NOT NULL,
+    binary_path TEXT NOT NULL,
+    pid INTEGER,
+    ppid INTEGER,
+    tty INTEGER,
+    exit_code INTEGER,
+    cwd TEXT,
+    timestamp TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS removed_commands (
+    name TEXT NOT NULL,
+    package TEXT NOT NULL,
+    snapshot_id INTEGER,
+    removed_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (name, package)
+);
+
 CREATE TABLE IF NOT EXISTS snapshots (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     created_at TIMESTAMP NOT NULL,
@@ -52,4 +144,27 @@ CREATE INDEX IF NOT EXISTS idx_usage_timestamp ON usage_events(timestamp);
 CREATE INDEX IF NOT EXISTS idx_deps_package ON dependencies(package);
 CREATE INDEX IF NOT EXISTS idx_deps_depends ON dependencies(depends_on);
 CREATE INDEX IF NOT EXISTS idx_snapshot_packages ON snapshot_packages(snapshot_id);
+CREATE INDEX IF NOT EXISTS idx_package_binaries_name ON package_binaries(name);
+CREATE INDEX IF NOT EXISTS idx_linkages_library ON binary_linkages(library_package);
+CREATE INDEX IF NOT EXISTS idx_unresolved_name ON unresolved_events(binary_name);
 `
+
+// migrations upgrade databases created by older releases. Each statement adds
+// a column that is already part of the base schema above, so on a fresh
+// database it fails with "duplicate column name" and is skipped.
+var migrations = []string{
+	`ALTER TABLE usage_events ADD COLUMN binary_name TEXT`,
+	`ALTER TABLE usage_events ADD COLUMN pid INTEGER`,
+	`ALTER TABLE packages ADD COLUMN prefix TEXT`,
+	`ALTER TABLE usage_events ADD COLUMN ppid INTEGER`,
+	`ALTER TABLE usage_events ADD COLUMN tty INTEGER`,
+	`ALTER TABLE usage_events ADD COLUMN exit_code INTEGER`,
+	`ALTER TABLE usage_events ADD COLUMN cwd TEXT`,
+	`ALTER TABLE unresolved_events ADD COLUMN pid INTEGER`,
+	`ALTER TABLE unresolved_events ADD COLUMN ppid INTEGER`,
+	`ALTER TABLE unresolved_events ADD COLUMN tty INTEGER`,
+	`ALTER TABLE unresolved_events ADD COLUMN exit_code INTEGER`,
+	`ALTER TABLE unresolved_events ADD COLUMN cwd TEXT`,
+	`ALTER TABLE packages ADD COLUMN pinned BOOLEAN DEFAULT 0`,
+	`ALTER TABLE packages ADD COLUMN service TEXT DEFAULT ''`,
+}
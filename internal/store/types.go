@@ -23,7 +23,152 @@ type SnapshotPackage struct {
 // UsageEvent records when a package binary was executed.
 type UsageEvent struct {
 	Package    string
-	EventType  string // "exec", "app_launch", or "probe"
+	EventType  string // "exec", "proc", "app_launch", or "probe"
 	BinaryPath string
+	BinaryName string // Resolved binary basename, e.g. "gls" for coreutils
+	PID        int    // Process ID when known; 0 otherwise
+	PPID       int    // Parent process ID when known; 0 otherwise
+	TTY        *bool  // Whether stdin was a terminal; nil when unknown
+	ExitCode   *int   // Exit status; nil when unknown
+	Cwd        string // Working directory when recorded; empty otherwise
 	Timestamp  time.Time
 }
+
+// PackageBinary is one binary owned by a package, as linked into the brew
+// prefix bin directory.
+type PackageBinary struct {
+	Package string
+	Name    string // Basename, e.g. "psql"
+	Path    string // Full path, e.g. "/opt/homebrew/bin/psql"
+}
+
+// BinaryUsage summarises recorded executions of a single package binary.
+type BinaryUsage struct {
+	Name      string
+	Path      string // Empty when the binary is no longer linked
+	TotalUses int
+	LastUsed  *time.Time
+}
+
+// Linkage records that a package binary loads a shared library owned by
+// another package, either directly or through another library.
+type Linkage struct {
+	Package        string // Package owning the executable
+	BinaryName     string // Executable basename, e.g. "git"
+	LibraryPackage string // Package owning the shared library, e.g. "pcre2"
+	Library        string // Library basename, e.g. "libpcre2-8.0.dylib"
+}
+
+// Kinds of shim bypass evidence recorded in BypassFinding.Kind.
+const (
+	// BypassAccessTime means the real binary in the keg was accessed after
+	// its last recorded use.
+	BypassAccessTime = "atime"
+
+	// BypassReference means a config file or script hardcodes the binary's
+	// absolute brew-prefix path, so the PATH shim never sees those runs.
+	BypassReference = "reference"
+)
+
+// Kinds of runtime reference recorded in RuntimeReference.Kind.
+const (
+	// RuntimeVenv means a Python virtualenv's pyvenv.cfg names the runtime
+	// as its base interpreter.
+	RuntimeVenv = "venv"
+
+	// RuntimeShebang means a script's "#!" line runs the runtime's
+	// interpreter.
+	RuntimeShebang = "shebang"
+
+	// RuntimeSymlink means a symlink, such as a version manager's entry,
+	// points into the runtime's opt or Cellar directory.
+	RuntimeSymlink = "symlink"
+)
+
+// CaskApp is an application bundle installed by a cask.
+type CaskApp struct {
+	Package string
+	Name    string // Bundle name, e.g. "Firefox.app"
+	Path    string // Absolute bundle path, e.g. "/Applications/Firefox.app"
+
+	// CheckedAt is when a launch source last reported on the bundle; nil
+	// until launches have been looked up once.
+	CheckedAt *time.Time
+}
+
+// BypassFinding is evidence that a package binary runs without passing
+// through its PATH shim, so its recorded usage is likely undercounted.
+type BypassFinding struct {
+	Package    string
+	BinaryName string
+	Kind       string    // BypassAccessTime or BypassReference
+	Location   string    // Keg path for atime; "file:line" for a reference
+	EvidenceAt time.Time // Access time, or the referencing file's mtime
+	DetectedAt time.Time
+}
+
+// ScanWindow is the time span of one scan that read package binaries.
+type ScanWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// RuntimeReference is a path outside Homebrew that reaches a language
+// runtime directly, so the runtime is in use even when none of its
+// binaries is run through a shim.
+type RuntimeReference struct {
+	Package    string
+	Kind       string // RuntimeVenv, RuntimeShebang or RuntimeSymlink
+	Path       string // Virtualenv directory, script or symlink
+	Target     string // Referenced path inside the brew prefix
+	DetectedAt time.Time
+}
+
+// DeclaredRequirement records that a project declares it needs a package:
+// a Brewfile or .tool-versions entry names it, or a Makefile recipe,
+// package.json script, pre-commit hook or shell script runs one of its
+// binaries.
+type DeclaredRequirement struct {
+	Package    string
+	Project    string // Project root directory
+	Name       string // Declared formula, tool or command name
+	Source     string // Manifest type, e.g. "Brewfile" or "Makefile"
+	Location   string // "file:line"
+	DetectedAt time.Time
+}
+
+// UnresolvedCommand summarises shim log entries for one command that could
+// not be mapped to any package.
+type UnresolvedCommand struct {
+	BinaryName string
+	BinaryPath string // Most recently logged argv0
+	Count      int
+	FirstSeen  time.Time
+	LastSeen   time.Time
+}
+
+// RemovedCommand is a command that went away when brewprune removed the
+// package providing it.
+type RemovedCommand struct {
+	Name       string
+	Package    string
+	SnapshotID int64 // 0 when the removal was not snapshotted
+	RemovedAt  time.Time
+}
+
+// IndirectUsage is a library package's usage derived from an executed binary
+// of another package that links against it.
+type IndirectUsage struct {
+	Via      string // Package whose binary was executed
+	Binary   string // Executed binary basename
+	LastUsed time.Time
+}
+
+// DirUsage summarises one package's usage events recorded in one working
+// directory.
+type DirUsage struct {
+	Cwd      string
+	Package  string
+	Runs     int
+	LastUsed time.Time
+}
@@ -0,0 +1,155 @@
+package watcher
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// DefaultLaunchCheckInterval is how often the watcher asks its launch source
+// about cask application bundles.
+const DefaultLaunchCheckInterval = 15 * time.Minute
+
+// mdlsTimeLayout is the format of dates printed by mdls -raw.
+const mdlsTimeLayout = "2006-01-02 15:04:05 -0700"
+
+// AppLaunch is the most recent launch of an application bundle.
+type AppLaunch struct {
+	Path string
+	Time time.Time
+}
+
+// LaunchSource reports when application bundles were last launched. It is
+// the usage source for casks, whose apps never pass through the PATH shims.
+type LaunchSource interface {
+	// LastLaunches returns the last launch of each bundle in paths that has
+	// one. Bundles never launched are omitted. A nil error means the source
+	// answered for every path.
+	LastLaunches(paths []string) ([]AppLaunch, error)
+}
+
+// LaunchStats holds the summary of one IngestAppLaunches.
+type LaunchStats struct {
+	Checked    int // bundles asked about
+	Inserted   int // app_launch events written
+	Duplicates int // launches already recorded
+}
+
+// IngestAppLaunches asks src about every known cask application bundle and
+// records each reported launch as an "app_launch" usage event. Launch
+// sources only know the latest launch, so one event is recorded per distinct
+// last-launch time; several launches between two checks count as one.
+//
+// Every bundle is marked as checked once src answers, with or without a
+// launch date, which tells the analyzer that the cask's launches are being
+// recorded: a cask whose app was never opened can then score as unused.
+// Bundles stay unchecked only while no source is available or its queries
+// fail.
+func IngestAppLaunches(st *store.Store, src LaunchSource) (LaunchStats, error) {
+	var stats LaunchStats
+
+	apps, err := st.ListCaskApps()
+	if err != nil {
+		if errors.Is(err, store.ErrNotInitialized) {
+			return stats, nil
+		}
+		return stats, fmt.Errorf("launch: list cask apps: %w", err)
+	}
+	if len(apps) == 0 {
+		return stats, nil
+	}
+
+	owner := make(map[string]string, len(apps))
+	paths := make([]string, 0, len(apps))
+	for _, app := range apps {
+		if _, ok := owner[app.Path]; !ok {
+			owner[app.Path] = app.Package
+			paths = append(paths, app.Path)
+		}
+	}
+	stats.Checked = len(paths)
+
+	launches, err := src.LastLaunches(paths)
+	if err != nil {
+		return stats, fmt.Errorf("launch: query launch source: %w", err)
+	}
+	checkedAt := time.Now().Format(time.RFC3339)
+
+	tx, err := st.DB().Begin()
+	if err != nil {
+		return stats, fmt.Errorf("launch: begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	for _, launch := range launches {
+		pkg, ok := owner[launch.Path]
+		if !ok || launch.Time.IsZero() {
+			continue
+		}
+		ts := launch.Time.Local().Format(time.RFC3339)
+
+		var n int
+		if err := tx.QueryRow(
+			`SELECT COUNT(*) FROM usage_events WHERE package = ? AND event_type = 'app_launch' AND binary_path = ? AND timestamp = ?`,
+			pkg, launch.Path, ts,
+		).Scan(&n); err != nil {
+			return stats, fmt.Errorf("launch: check events for %s: %w", pkg, err)
+		}
+		if n > 0 {
+			stats.Duplicates++
+			continue
+		}
+
+		if _, err := tx.Exec(
+			`INSERT INTO usage_events (package, event_type, binary_path, binary_name, timestamp) VALUES (?, 'app_launch', ?, ?, ?)`,
+			pkg, launch.Path, filepath.Base(launch.Path), ts,
+		); err != nil {
+			return stats, fmt.Errorf("launch: insert event for %s: %w", pkg, err)
+		}
+		stats.Inserted++
+	}
+
+	for _, path := range paths {
+		if _, err := tx.Exec(`UPDATE cask_apps SET checked_at = ? WHERE path = ?`, checkedAt, path); err != nil {
+			return stats, fmt.Errorf("launch: mark %s checked: %w", path, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return stats, fmt.Errorf("launch: commit: %w", err)
+	}
+	return stats, nil
+}
+
+// parseMDLSOutput parses the output of
+// "mdls -raw -nullMarker (null) -name kMDItemLastUsedDate <paths...>": one
+// NUL-separated value per path, in order, where "(null)" means the bundle
+// has never been launched.
+func parseMDLSOutput(out []byte, paths []string) ([]AppLaunch, error) {
+	values := bytes.Split(bytes.TrimSuffix(out, []byte{0}), []byte{0})
+	if len(out) == 0 {
+		values = nil
+	}
+	if len(values) != len(paths) {
+		return nil, fmt.Errorf("mdls returned %d values for %d paths", len(values), len(paths))
+	}
+
+	var launches []AppLaunch
+	for i, raw := range values {
+		value := strings.TrimSpace(string(raw))
+		if value == "" || value == "(null)" {
+			continue
+		}
+		t, err := time.Parse(mdlsTimeLayout, value)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected mdls date %q for %s: %w", value, paths[i], err)
+		}
+		launches = append(launches, AppLaunch{Path: paths[i], Time: t})
+	}
+	return launches, nil
+}
@@ -0,0 +1,253 @@
+package watcher
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/analyzer"
+	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// fakeLaunchSource reports fixed launch times and records the paths asked
+// about.
+type fakeLaunchSource struct {
+	launches map[string]time.Time
+	asked    []string
+}
+
+func (f *fakeLaunchSource) LastLaunches(paths []string) ([]AppLaunch, error) {
+	f.asked = append(f.asked, paths...)
+	var out []AppLaunch
+	for _, p := range paths {
+		if t, ok := f.launches[p]; ok {
+			out = append(out, AppLaunch{Path: p, Time: t})
+		}
+	}
+	return out, nil
+}
+
+func TestIngestAppLaunches(t *testing.T) {
+	st := setupTestStore(t)
+
+	for _, name := range []string{"firefox", "slack"} {
+		if err := st.InsertPackage(&brew.Package{Name: name, InstalledAt: time.Now(), IsCask: true}); err != nil {
+			t.Fatalf("InsertPackage(%s): %v", name, err)
+		}
+	}
+	if err := st.ReplaceCaskApps([]*store.CaskApp{
+		{Package: "firefox", Name: "Firefox.app", Path: "/Applications/Firefox.app"},
+		{Package: "slack", Name: "Slack.app", Path: "/Applications/Slack.app"},
+	}); err != nil {
+		t.Fatalf("ReplaceCaskApps: %v", err)
+	}
+
+	for _, pkg := range []string{"firefox", "slack"} {
+		if tracked, err := st.IsCaskLaunchTracked(pkg); err != nil || tracked {
+			t.Fatalf("IsCaskLaunchTracked(%s) before ingest = %v, %v; want false", pkg, tracked, err)
+		}
+	}
+
+	launched := time.Date(2026, 9, 1, 9, 30, 0, 0, time.UTC)
+	src := &fakeLaunchSource{launches: map[string]time.Time{"/Applications/Firefox.app": launched}}
+
+	stats, err := IngestAppLaunches(st, src)
+	if err != nil {
+		t.Fatalf("IngestAppLaunches: %v", err)
+	}
+	if stats.Checked != 2 || stats.Inserted != 1 || stats.Duplicates != 0 {
+		t.Errorf("first ingest stats = %+v, want 2 checked, 1 inserted", stats)
+	}
+
+	// The same last-launch time is not recorded twice.
+	stats, err = IngestAppLaunches(st, src)
+	if err != nil {
+		t.Fatalf("IngestAppLaunches (repeat): %v", err)
+	}
+	if stats.Inserted != 0 || stats.Duplicates != 1 {
+		t.Errorf("repeat ingest stats = %+v, want 0 inserted, 1 duplicate", stats)
+	}
+
+	events, err := st.GetUsageEvents("firefox", time.Time{})
+	if err != nil {
+		t.Fatalf("GetUsageEvents: %v", err)
+	}
+	if len(events) != 1 || events[0].EventType != "app_launch" || events[0].BinaryName != "Firefox.app" || !events[0].Timestamp.Equal(launched) {
+		t.Errorf("firefox events = %+v, want one app_launch of Firefox.app at %v", events, launched)
+	}
+
+	// The source answered for both bundles, so both are tracked, slack with
+	// no launch date at all.
+	for _, pkg := range []string{"firefox", "slack"} {
+		if tracked, err := st.IsCaskLaunchTracked(pkg); err != nil || !tracked {
+			t.Errorf("IsCaskLaunchTracked(%s) after ingest = %v, %v; want true", pkg, tracked, err)
+		}
+	}
+
+	// A rescan keeps the checked state of known bundles.
+	if err := st.ReplaceCaskApps([]*store.CaskApp{
+		{Package: "firefox", Name: "Firefox.app", Path: "/Applications/Firefox.app"},
+		{Package: "slack", Name: "Slack.app", Path: "/Applications/Slack.app"},
+	}); err != nil {
+		t.Fatalf("ReplaceCaskApps (rescan): %v", err)
+	}
+	if tracked, _ := st.IsCaskLaunchTracked("firefox"); !tracked {
+		t.Error("IsCaskLaunchTracked(firefox) after rescan = false, want true")
+	}
+}
+
+// failingLaunchSource is a launch source whose queries always fail.
+type failingLaunchSource struct{}
+
+func (failingLaunchSource) LastLaunches(paths []string) ([]AppLaunch, error) {
+	return nil, errors.New("mdls: not found")
+}
+
+func TestRunShimLog_LaunchErrorDoesNotBlockProcFlush(t *testing.T) {
+	setTestHome(t)
+	st := setupTestStore(t)
+	insertPkg(t, st, "ripgrep", []string{"/home/linuxbrew/.linuxbrew/bin/rg"})
+	if err := st.InsertPackage(&brew.Package{Name: "firefox", InstalledAt: time.Now(), IsCask: true}); err != nil {
+		t.Fatalf("InsertPackage: %v", err)
+	}
+	if err := st.ReplaceCaskApps([]*store.CaskApp{{Package: "firefox", Name: "Firefox.app", Path: "/Applications/Firefox.app"}}); err != nil {
+		t.Fatalf("ReplaceCaskApps: %v", err)
+	}
+
+	root := fakeProc(t, time.Now().Add(-time.Hour))
+	addProc(t, root, 100, 100, "/home/linuxbrew/.linuxbrew/Cellar/ripgrep/14.1.0/bin/rg", "rg")
+
+	w, err := New(st)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := w.EnableProcSampling(root, time.Hour); err != nil {
+		t.Fatalf("EnableProcSampling: %v", err)
+	}
+	w.SetLaunchSource(failingLaunchSource{}, time.Nanosecond)
+	if _, err := w.proc.Sample(); err != nil {
+		t.Fatalf("Sample: %v", err)
+	}
+
+	if err := w.runShimLog(); err != nil {
+		t.Fatalf("runShimLog: %v", err)
+	}
+	if events := procEvents(t, st, "ripgrep"); len(events) != 1 {
+		t.Errorf("got %d proc events after a failed launch check, want 1", len(events))
+	}
+}
+
+func TestIngestAppLaunches_NeverLaunchedCaskScoresSafe(t *testing.T) {
+	st := setupTestStore(t)
+	if err := st.InsertPackage(&brew.Package{
+		Name:        "zoom",
+		InstalledAt: time.Now().AddDate(-2, 0, 0),
+		InstallType: "explicit",
+		IsCask:      true,
+	}); err != nil {
+		t.Fatalf("InsertPackage: %v", err)
+	}
+	if err := st.ReplaceCaskApps([]*store.CaskApp{{Package: "zoom", Name: "zoom.us.app", Path: "/Applications/zoom.us.app"}}); err != nil {
+		t.Fatalf("ReplaceCaskApps: %v", err)
+	}
+
+	// A failed query leaves the cask untracked and held.
+	if _, err := IngestAppLaunches(st, failingLaunchSource{}); err == nil {
+		t.Fatal("IngestAppLaunches with a failing source succeeded")
+	}
+	score, err := analyzer.New(st).ComputeScore("zoom")
+	if err != nil {
+		t.Fatalf("ComputeScore: %v", err)
+	}
+	if score.UsageTracked || score.Tier == "safe" {
+		t.Errorf("cask after a failed launch query = %d (%s), tracked=%v; want held", score.Score, score.Tier, score.UsageTracked)
+	}
+
+	// The source answers with no launch date: the app was never opened.
+	if _, err := IngestAppLaunches(st, &fakeLaunchSource{}); err != nil {
+		t.Fatalf("IngestAppLaunches: %v", err)
+	}
+	score, err = analyzer.New(st).ComputeScore("zoom")
+	if err != nil {
+		t.Fatalf("ComputeScore: %v", err)
+	}
+	if !score.UsageTracked || score.Tier != "safe" {
+		t.Errorf("never-launched cask = %d (%s), tracked=%v; want safe", score.Score, score.Tier, score.UsageTracked)
+	}
+}
+
+func TestIngestAppLaunches_NoApps(t *testing.T) {
+	st := setupTestStore(t)
+	src := &fakeLaunchSource{}
+
+	stats, err := IngestAppLaunches(st, src)
+	if err != nil {
+		t.Fatalf("IngestAppLaunches: %v", err)
+	}
+	if stats.Checked != 0 || len(src.asked) != 0 {
+		t.Errorf("IngestAppLaunches with no apps asked about %v", src.asked)
+	}
+}
+
+func TestParseMDLSOutput(t *testing.T) {
+	paths := []string{"/Applications/A.app", "/Applications/B.app", "/Applications/C.app"}
+
+	tests := []struct {
+		name    string
+		out     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "mixed",
+			out:  "2026-09-01 09:30:00 +0000\x00(null)\x002026-08-15 18:02:11 +0200",
+			want: map[string]string{
+				"/Applications/A.app": "2026-09-01T09:30:00Z",
+				"/Applications/C.app": "2026-08-15T16:02:11Z",
+			},
+		},
+		{
+			name: "trailing NUL",
+			out:  "(null)\x00(null)\x00(null)\x00",
+			want: map[string]string{},
+		},
+		{
+			name:    "value count mismatch",
+			out:     "(null)\x00(null)",
+			wantErr: true,
+		},
+		{
+			name:    "bad date",
+			out:     "yesterday\x00(null)\x00(null)",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			launches, err := parseMDLSOutput([]byte(tt.out), paths)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseMDLSOutput() = %v, want error", launches)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseMDLSOutput: %v", err)
+			}
+			got := make(map[string]string)
+			for _, l := range launches {
+				got[l.Path] = l.Time.UTC().Format(time.RFC3339)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseMDLSOutput() = %v, want %v", got, tt.want)
+			}
+			for p, w := range tt.want {
+				if got[p] != w {
+					t.Errorf("launch of %s = %q, want %q", p, got[p], w)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package watcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Control socket commands.
+const (
+	ControlStatus       = "status"
+	ControlFlush        = "flush"
+	ControlReloadConfig = "reload-config"
+	ControlRescanIndex  = "rescan-index"
+	ControlStop         = "stop"
+)
+
+// Control socket timeouts.
+const (
+	// controlWaitTimeout bounds how long a flush or rescan-index request
+	// waits for the shim log to be processed.
+	controlWaitTimeout = 10 * time.Second
+
+	// controlIOTimeout bounds reading a request and a client's whole call.
+	controlIOTimeout = controlWaitTimeout + 5*time.Second
+)
+
+// ErrControlUnavailable is returned by CallControl when no watcher is
+// listening on the socket, e.g. the daemon is stopped or predates the
+// control socket. Callers fall back to the PID file.
+var ErrControlUnavailable = errors.New("control socket unavailable")
+
+// ControlRequest is one request on the control socket. The protocol is a
+// single JSON object per connection in each direction.
+type ControlRequest struct {
+	Command string `json:"command"`
+}
+
+// ControlResponse is the reply to a ControlRequest.
+type ControlResponse struct {
+	OK     bool          `json:"ok"`
+	Error  string        `json:"error,omitempty"`
+	Status *DaemonStatus `json:"status,omitempty"`
+}
+
+// DaemonStatus is the watcher health reported by the status command.
+type DaemonStatus struct {
+	PID             int        `json:"pid"`
+	StartedAt       time.Time  `json:"started_at"`
+	UptimeSeconds   int64      `json:"uptime_seconds"`
+	LastTick        *time.Time `json:"last_tick,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+	LastErrorAt     *time.Time `json:"last_error_at,omitempty"`
+	EventsProcessed int64      `json:"events_processed"`
+	BacklogBytes    int64      `json:"backlog_bytes"`
+	Notifications   bool       `json:"notifications"`
+	ProcSampling    bool       `json:"proc_sampling"`
+}
+
+// ControlSocketPath returns the control socket that belongs to a daemon PID
+// file: "watch.pid" → "watch.sock" in the same directory.
+func ControlSocketPath(pidFile string) string {
+	dir, base := filepath.Split(pidFile)
+	return filepath.Join(dir, strings.TrimSuffix(base, filepath.Ext(base))+".sock")
+}
+
+// Status reports the watcher's current health.
+func (w *Watcher) Status() DaemonStatus {
+	w.mu.Lock()
+	status := DaemonStatus{
+		PID:             os.Getpid(),
+		StartedAt:       w.startedAt,
+		UptimeSeconds:   int64(time.Since(w.startedAt).Seconds()),
+		EventsProcessed: w.processed,
+		Notifications:   w.notifier != nil,
+		ProcSampling:    w.proc != nil,
+	}
+	if !w.lastRun.IsZero() {
+		lastRun := w.lastRun
+		status.LastTick = &lastRun
+	}
+	if w.lastErr != nil {
+		lastErrAt := w.lastErrAt
+		status.LastError = w.lastErr.Error()
+		status.LastErrorAt = &lastErrAt
+	}
+	w.mu.Unlock()
+
+	if pending, err := PendingLogBytes(); err == nil {
+		status.BacklogBytes = pending
+	}
+	return status
+}
+
+// ListenControl starts serving the control socket at path until Stop. A
+// socket left behind by a crashed daemon is replaced; one with a live
+// listener is an error.
+func (w *Watcher) ListenControl(path string) error {
+	if _, err := os.Lstat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return fmt.Errorf("control socket %s is in use by another watcher", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove stale control socket: %w", err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return fmt.Errorf("failed to restrict control socket permissions: %w", err)
+	}
+
+	w.control = ln
+	w.controlPath = path
+	w.controlWG.Add(1)
+	go w.serveControl(ln)
+	return nil
+}
+
+// serveControl accepts control connections until the listener is closed.
+func (w *Watcher) serveControl(ln net.Listener) {
+	defer w.controlWG.Done()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		w.controlWG.Add(1)
+		go func() {
+			defer w.controlWG.Done()
+			defer conn.Close()
+			w.serveControlConn(conn)
+		}()
+	}
+}
+
+// serveControlConn reads one request from conn and writes its response.
+func (w *Watcher) serveControlConn(conn net.Conn) {
+	conn.SetReadDeadline(time.Now().Add(controlIOTimeout)) //nolint:errcheck
+
+	var req ControlRequest
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(ControlResponse{Error: "invalid request: " + err.Error()}) //nolint:errcheck
+		return
+	}
+
+	resp := w.handleControl(req)
+	conn.SetWriteDeadline(time.Now().Add(controlIOTimeout)) //nolint:errcheck
+	json.NewEncoder(conn).Encode(resp)                      //nolint:errcheck
+}
+
+// handleControl executes one control command.
+func (w *Watcher) handleControl(req ControlRequest) ControlResponse {
+	var err error
+	switch req.Command {
+	case ControlStatus:
+		status := w.Status()
+		return ControlResponse{OK: true, Status: &status}
+	case ControlFlush:
+		err = w.Flush(controlWaitTimeout)
+	case ControlReloadConfig:
+		err = w.Reload()
+	case ControlRescanIndex:
+		err = w.RescanIndex(controlWaitTimeout)
+	case ControlStop:
+		w.RequestStop()
+	default:
+		return ControlResponse{Error: fmt.Sprintf("unknown command %q", req.Command)}
+	}
+	if err != nil {
+		return ControlResponse{Error: err.Error()}
+	}
+	return ControlResponse{OK: true}
+}
+
+// CallControl sends command to the watcher listening on socketPath and
+// returns its response. When nothing is listening the error wraps
+// ErrControlUnavailable; when the watcher rejects the command the response
+// is returned along with an error carrying its message.
+func CallControl(socketPath, command string) (*ControlResponse, error) {
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err != nil {
+		if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ECONNREFUSED) {
+			return nil, fmt.Errorf("%w: %s", ErrControlUnavailable, socketPath)
+		}
+		return nil, fmt.Errorf("failed to connect to control socket: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(controlIOTimeout)) //nolint:errcheck
+
+	if err := json.NewEncoder(conn).Encode(ControlRequest{Command: command}); err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", command, err)
+	}
+	var resp ControlResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", command, err)
+	}
+	if !resp.OK {
+		return &resp, fmt.Errorf("daemon rejected %s: %s", command, resp.Error)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,209 @@
+package watcher
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// shortSocketPath returns a socket path short enough for sun_path limits,
+// which t.TempDir paths can exceed on macOS.
+func shortSocketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "bp")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "watch.sock")
+}
+
+// startControlledWatcher starts a watcher on a store with jq indexed and its
+// control socket listening, and registers Stop as cleanup. It returns the
+// watcher, the temp HOME and the socket path.
+func startControlledWatcher(t *testing.T) (*Watcher, string, string) {
+	t.Helper()
+	home := setTestHome(t)
+	if err := os.MkdirAll(filepath.Join(home, ".brewprune"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	st := newTestStore(t)
+	insertPkg(t, st, "jq", []string{"/opt/homebrew/bin/jq"})
+
+	w, err := New(st)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	w.SetInterval(time.Hour)
+	socketPath := shortSocketPath(t)
+	if err := w.ListenControl(socketPath); err != nil {
+		t.Fatalf("ListenControl: %v", err)
+	}
+	if err := w.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { w.Stop() })
+	return w, home, socketPath
+}
+
+func TestControlSocketPath(t *testing.T) {
+	tests := map[string]string{
+		"/home/u/.brewprune/watch.pid": "/home/u/.brewprune/watch.sock",
+		"/tmp/custom":                  "/tmp/custom.sock",
+		"/tmp/a.b/watch.pid":           "/tmp/a.b/watch.sock",
+	}
+	for pidFile, want := range tests {
+		if got := ControlSocketPath(pidFile); got != want {
+			t.Errorf("ControlSocketPath(%q) = %q, want %q", pidFile, got, want)
+		}
+	}
+}
+
+func TestControl_FlushAndStatus(t *testing.T) {
+	w, home, socketPath := startControlledWatcher(t)
+
+	appendShimLine(t, home, "jq")
+	appendShimLine(t, home, "jq")
+	if _, err := CallControl(socketPath, ControlFlush); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	// flush returns only after the run, so the events are already recorded.
+	waitForEvents(t, w.store, "jq", 2, 0)
+
+	resp, err := CallControl(socketPath, ControlStatus)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	status := resp.Status
+	if status == nil {
+		t.Fatal("status response has no status")
+	}
+	if status.PID != os.Getpid() {
+		t.Errorf("PID = %d, want %d", status.PID, os.Getpid())
+	}
+	if status.EventsProcessed != 2 {
+		t.Errorf("EventsProcessed = %d, want 2", status.EventsProcessed)
+	}
+	if status.BacklogBytes != 0 {
+		t.Errorf("BacklogBytes = %d, want 0", status.BacklogBytes)
+	}
+	if status.LastTick == nil || time.Since(*status.LastTick) > time.Minute {
+		t.Errorf("LastTick = %v, want a recent time", status.LastTick)
+	}
+	if status.LastError != "" {
+		t.Errorf("LastError = %q, want none", status.LastError)
+	}
+}
+
+func TestControl_RescanIndexPicksUpNewPackages(t *testing.T) {
+	w, home, socketPath := startControlledWatcher(t)
+
+	// The index was cached at Start; fd is only known after a rescan.
+	insertPkg(t, w.store, "fd", []string{"/opt/homebrew/bin/fd"})
+	appendShimLine(t, home, "fd")
+	if _, err := CallControl(socketPath, ControlRescanIndex); err != nil {
+		t.Fatalf("rescan-index: %v", err)
+	}
+	waitForEvents(t, w.store, "fd", 1, 0)
+}
+
+func TestControl_ReloadConfig(t *testing.T) {
+	w, _, socketPath := startControlledWatcher(t)
+
+	if _, err := CallControl(socketPath, ControlReloadConfig); err == nil {
+		t.Error("reload-config without a reload function succeeded, want error")
+	}
+
+	reloads := 0
+	w.SetReloadFunc(func() error {
+		reloads++
+		return nil
+	})
+	if _, err := CallControl(socketPath, ControlReloadConfig); err != nil {
+		t.Fatalf("reload-config: %v", err)
+	}
+	if reloads != 1 {
+		t.Errorf("reload function ran %d times, want 1", reloads)
+	}
+
+	resp, err := CallControl(socketPath, "bogus")
+	if err == nil || resp == nil || resp.OK {
+		t.Errorf("unknown command: resp = %+v, err = %v, want rejection", resp, err)
+	}
+}
+
+func TestControl_StopRemovesSocket(t *testing.T) {
+	w, _, socketPath := startControlledWatcher(t)
+
+	if _, err := CallControl(socketPath, ControlStop); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	select {
+	case <-w.StopRequested():
+	case <-time.After(time.Second):
+		t.Fatal("stop request not signalled")
+	}
+
+	if err := w.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Errorf("control socket still exists after Stop: %v", err)
+	}
+	if _, err := CallControl(socketPath, ControlStatus); !errors.Is(err, ErrControlUnavailable) {
+		t.Errorf("CallControl after Stop: err = %v, want ErrControlUnavailable", err)
+	}
+}
+
+func TestListenControl_ReplacesStaleSocket(t *testing.T) {
+	socketPath := shortSocketPath(t)
+
+	// Leave a socket file with no listener behind, as a crashed daemon would.
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+	if _, err := CallControl(socketPath, ControlStatus); !errors.Is(err, ErrControlUnavailable) {
+		t.Fatalf("CallControl on stale socket: err = %v, want ErrControlUnavailable", err)
+	}
+
+	w, err := New(newTestStore(t))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := w.ListenControl(socketPath); err != nil {
+		t.Fatalf("ListenControl over stale socket: %v", err)
+	}
+	defer w.Stop()
+
+	// A second watcher must not take over a live socket.
+	other, err := New(newTestStore(t))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := other.ListenControl(socketPath); err == nil {
+		t.Error("ListenControl on a live socket succeeded, want error")
+	}
+}
+
+func TestEnableProcSampling_WhileRunning(t *testing.T) {
+	w, _, _ := startControlledWatcher(t)
+
+	root := fakeProc(t, time.Now().Add(-time.Hour))
+	if err := w.EnableProcSampling(root, time.Hour); err != nil {
+		t.Fatalf("EnableProcSampling: %v", err)
+	}
+	if !w.Status().ProcSampling {
+		t.Error("Status().ProcSampling = false after enabling")
+	}
+
+	w.DisableProcSampling()
+	if w.Status().ProcSampling {
+		t.Error("Status().ProcSampling = true after disabling")
+	}
+}
@@ -15,7 +15,10 @@ import (
 // It forks the current process, writes the PID to pidFile, and redirects
 // daemon output to logFile. The store is not opened by the launcher —
 // the daemon child opens its own database connection after exec.
-func LaunchDaemon(pidFile, logFile string) error {
+//
+// extraArgs are appended to the child's "watch --daemon-child" command line,
+// e.g. "--interval", "1m".
+func LaunchDaemon(pidFile, logFile string, extraArgs ...string) error {
 	// Check if daemon is already running
 	running, err := IsDaemonRunning(pidFile)
 	if err != nil {
@@ -41,6 +44,7 @@ func LaunchDaemon(pidFile, logFile string) error {
 	if logFile != "" {
 		args = append(args, "--log-file", logFile)
 	}
+	args = append(args, extraArgs...)
 	cmd := exec.Command(executable, args...)
 	cmd.Stdout = logF
 	cmd.Stderr = logF
@@ -84,6 +88,12 @@ func (w *Watcher) RunDaemon(pidFile string) error {
 	// Ignore SIGHUP (parent terminal exit should not kill daemon)
 	signal.Ignore(syscall.SIGHUP)
 
+	// A service manager (launchd, systemd) starts the daemon child directly,
+	// so record the PID here too; LaunchDaemon has written the same one.
+	if err := claimPIDFile(pidFile); err != nil {
+		return err
+	}
+
 	// Log daemon startup with SIGHUP handling
 	fmt.Fprintf(os.Stderr, "%s brewprune-watch: daemon started (PID %d), ignoring SIGHUP\n",
 		time.Now().UTC().Format(time.RFC3339), os.Getpid())
@@ -93,9 +103,15 @@ func (w *Watcher) RunDaemon(pidFile string) error {
 		return fmt.Errorf("failed to start watcher: %w", err)
 	}
 
-	// Wait for shutdown signal
-	sig := <-sigCh
-	fmt.Fprintf(os.Stderr, "received signal %v, shutting down...\n", sig)
+	// Wait for a shutdown signal or a stop request on the control socket
+	var reason string
+	select {
+	case sig := <-sigCh:
+		reason = fmt.Sprintf("received signal %v", sig)
+	case <-w.StopRequested():
+		reason = "stop requested via control socket"
+	}
+	fmt.Fprintf(os.Stderr, "%s, shutting down...\n", reason)
 
 	// Stop the watcher
 	if err := w.Stop(); err != nil {
@@ -110,6 +126,23 @@ func (w *Watcher) RunDaemon(pidFile string) error {
 	return nil
 }
 
+// claimPIDFile writes the current PID to pidFile unless it names another
+// live process, i.e. a second daemon is already running.
+func claimPIDFile(pidFile string) error {
+	if pidData, err := os.ReadFile(pidFile); err == nil {
+		pid, err := strconv.Atoi(strings.TrimSpace(string(pidData)))
+		if err == nil && pid != os.Getpid() && pid > 0 {
+			if process, err := os.FindProcess(pid); err == nil && process.Signal(syscall.Signal(0)) == nil {
+				return fmt.Errorf("daemon already running (PID %d, PID file: %s)", pid, pidFile)
+			}
+		}
+	}
+	if err := os.WriteFile(pidFile, []byte(fmt.Sprintf("%d\n", os.Getpid())), 0644); err != nil {
+		return fmt.Errorf("failed to write PID file: %w", err)
+	}
+	return nil
+}
+
 // StopDaemon stops a running daemon by sending SIGTERM to the process.
 func StopDaemon(pidFile string) error {
 	// Read PID from file
@@ -377,3 +377,36 @@ func TestRunDaemon_SignalHandling(t *testing.T) {
 		t.Error("PID file was not removed after shutdown")
 	}
 }
+
+func TestClaimPIDFile(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "watch.pid")
+	own := strconv.Itoa(os.Getpid()) + "\n"
+
+	// A service manager starts the daemon without a PID file.
+	if err := claimPIDFile(pidFile); err != nil {
+		t.Fatalf("claimPIDFile() without PID file error = %v", err)
+	}
+	if data, _ := os.ReadFile(pidFile); string(data) != own {
+		t.Errorf("PID file = %q, want %q", data, own)
+	}
+
+	// A stale PID is replaced.
+	if err := os.WriteFile(pidFile, []byte("999999\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := claimPIDFile(pidFile); err != nil {
+		t.Fatalf("claimPIDFile() over stale PID error = %v", err)
+	}
+
+	// Another live daemon is not displaced.
+	other := strconv.Itoa(os.Getppid()) + "\n"
+	if err := os.WriteFile(pidFile, []byte(other), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := claimPIDFile(pidFile); err == nil {
+		t.Error("claimPIDFile() with a live daemon succeeded, want error")
+	}
+	if data, _ := os.ReadFile(pidFile); string(data) != other {
+		t.Errorf("PID file = %q, want it left as %q", data, other)
+	}
+}
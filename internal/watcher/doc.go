@@ -1,15 +1,21 @@
 // Package watcher tracks Homebrew package usage via PATH shims.
 //
 // When a user runs a shimmed command (e.g. git), a tiny interceptor binary
-// appends an entry to ~/.brewprune/usage.log. The Watcher polls that log
-// every 30 seconds, resolves binary names to package names, and batch-inserts
-// usage events into the database.
+// appends an entry to ~/.brewprune/usage.log. The Watcher is woken when that
+// log changes (inotify on Linux, kqueue on macOS), resolves binary names to
+// package names, and batch-inserts usage events into the database.
 //
 // Key features:
-//   - Shim log polling (no special permissions required)
-//   - Crash-safe offset tracking (temp file + rename pattern)
+//   - Change-driven shim log processing with debouncing, falling back to
+//     polling when notifications are unavailable (no special permissions
+//     required)
+//   - Optional /proc sampling on Linux for binaries the shims never see
+//   - Cask app launches read from Spotlight on macOS (see LaunchSource)
+//   - Crash-safe offset tracking (temp file + rename pattern), keyed by inode
+//   - Size- and age-based usage log rotation with optional gzip archiving
 //   - Batched SQLite inserts (single transaction per tick)
 //   - Daemon mode support with PID file management
+//   - Unix control socket (status, flush, reload-config, rescan-index, stop)
 //   - Graceful shutdown with SIGTERM/SIGINT handling
 //
 // Example usage:
@@ -2,8 +2,10 @@ package watcher
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/blackwell-systems/brewprune/internal/store"
@@ -17,15 +19,91 @@ func logProcessingStats(stats ProcessingStats) {
 	}
 }
 
-// Watcher polls the PATH shim usage log to track Homebrew package executions.
-// When a user runs a shimmed command (e.g. git), the shim binary appends an
-// entry to ~/.brewprune/usage.log. The Watcher processes that log every 30
-// seconds and batch-inserts resolved usage events into the database.
+// DefaultPollInterval is how often the shim log is polled when file
+// notifications are unavailable.
+const DefaultPollInterval = 30 * time.Second
+
+// Notification-driven processing parameters.
+const (
+	// debounceDelay is how long the log must stay quiet after a change
+	// before it is processed, so a burst of shim writes becomes one batch.
+	debounceDelay = 250 * time.Millisecond
+
+	// maxBatchDelay bounds how long continuous writes can postpone
+	// processing.
+	maxBatchDelay = 2 * time.Second
+
+	// safetyPollInterval is the slow poll kept while notifications work,
+	// in case an event is ever missed.
+	safetyPollInterval = 10 * time.Minute
+
+	// indexMaxAge is how long the cached package index is trusted before it
+	// is rebuilt, in case a scan ran without notifying the daemon.
+	indexMaxAge = 5 * time.Minute
+)
+
+// Watcher processes the PATH shim usage log to track Homebrew package
+// executions. When a user runs a shimmed command (e.g. git), the shim binary
+// appends an entry to ~/.brewprune/usage.log. The Watcher is woken by file
+// notifications (inotify on Linux, kqueue on macOS), waits for the burst of
+// writes to settle, and batch-inserts resolved usage events into the
+// database. Where notifications are unavailable it polls instead.
 type Watcher struct {
 	store       *store.Store
 	stopCh      chan struct{}
+	stopOnce    sync.Once
 	wg          sync.WaitGroup
 	batchTicker *time.Ticker
+
+	// interval is the poll interval used without file notifications.
+	interval time.Duration
+
+	// notifier reports usage.log changes; nil when polling.
+	notifier fileNotifier
+
+	// flushCh requests immediate processing (see RequestFlush).
+	flushCh chan struct{}
+
+	// index caches package resolution between runs; it is only touched by
+	// the goroutine processing the log. indexStale forces a rebuild.
+	index      *packageIndex
+	indexStale atomic.Bool
+
+	// reload re-applies the config file (see SetReloadFunc).
+	reload func() error
+
+	// stopReq is closed when a stop is requested over the control socket.
+	stopReq     chan struct{}
+	stopReqOnce sync.Once
+
+	// control is the control socket listener; nil when not listening.
+	control     net.Listener
+	controlPath string
+	controlWG   sync.WaitGroup
+
+	// mu guards the fields below, which control socket handlers share with
+	// the processing goroutines.
+	mu           sync.Mutex
+	started      bool
+	startedAt    time.Time
+	lastRun      time.Time
+	lastErr      error
+	lastErrAt    time.Time
+	processed    int64
+	flushWaiters []chan error
+	rotation     LogRotation
+
+	// proc is the optional /proc sampling source; nil when disabled.
+	// procStop stops its sampling goroutine.
+	proc         *ProcSampler
+	procInterval time.Duration
+	procStop     chan struct{}
+
+	// launch is the optional application launch source for casks; nil when
+	// disabled. It is queried at most every launchInterval.
+	launch          LaunchSource
+	launchInterval  time.Duration
+	lastLaunchCheck time.Time
 }
 
 // New creates a new Watcher instance.
@@ -34,54 +112,409 @@ func New(st *store.Store) (*Watcher, error) {
 		return nil, fmt.Errorf("store cannot be nil")
 	}
 	return &Watcher{
-		store:  st,
-		stopCh: make(chan struct{}),
+		store:    st,
+		stopCh:   make(chan struct{}),
+		interval: DefaultPollInterval,
+		flushCh:  make(chan struct{}, 1),
+		stopReq:  make(chan struct{}),
 	}, nil
 }
 
-// Start begins usage tracking by polling the shim log on a 30-second ticker.
-// It also processes any events already in the log immediately on startup.
+// SetInterval sets the shim log poll interval used when file notifications
+// are unavailable. Must be called before Start.
+func (w *Watcher) SetInterval(d time.Duration) {
+	if d > 0 {
+		w.interval = d
+	}
+}
+
+// RequestFlush asks the watcher to process the shim log now instead of
+// waiting for the next change or poll. It does not block.
+func (w *Watcher) RequestFlush() {
+	notify(w.flushCh)
+}
+
+// Flush processes the shim log now and waits for that run to finish, up to
+// timeout. It returns the run's error, if any.
+func (w *Watcher) Flush(timeout time.Duration) error {
+	done := make(chan error, 1)
+	w.mu.Lock()
+	w.flushWaiters = append(w.flushWaiters, done)
+	w.mu.Unlock()
+	w.RequestFlush()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out after %v waiting for shim log processing", timeout)
+	}
+}
+
+// RescanIndex discards the cached package index, so packages added or
+// removed by a scan are picked up, then flushes the log like Flush.
+func (w *Watcher) RescanIndex(timeout time.Duration) error {
+	w.indexStale.Store(true)
+	return w.Flush(timeout)
+}
+
+// SetReloadFunc sets the function run by Reload to re-read the config file
+// and apply it to the watcher.
+func (w *Watcher) SetReloadFunc(fn func() error) {
+	w.reload = fn
+}
+
+// Reload re-applies the config file using the function set by SetReloadFunc.
+func (w *Watcher) Reload() error {
+	if w.reload == nil {
+		return fmt.Errorf("config reload not supported")
+	}
+	return w.reload()
+}
+
+// RequestStop asks the process running the watcher to shut down; see
+// StopRequested. It is safe to call more than once.
+func (w *Watcher) RequestStop() {
+	w.stopReqOnce.Do(func() { close(w.stopReq) })
+}
+
+// StopRequested returns a channel that is closed once a stop is requested.
+func (w *Watcher) StopRequested() <-chan struct{} {
+	return w.stopReq
+}
+
+// EnableProcSampling adds the /proc sampling source (Linux only), walking the
+// proc filesystem at root every interval. It may be called before Start or
+// while running; re-enabling with the same root and interval is a no-op. It
+// returns an error when no proc filesystem is mounted at root.
+func (w *Watcher) EnableProcSampling(root string, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultProcSampleInterval
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.proc != nil && w.proc.root == root && w.procInterval == interval {
+		return nil
+	}
+
+	sampler, err := NewProcSampler(root)
+	if err != nil {
+		return err
+	}
+	// Track the scanned prefixes. Without an index yet, the next
+	// runShimLog builds one and sets them, as on every index rebuild.
+	if w.index != nil {
+		sampler.SetPrefixes(w.index.prefixes)
+	}
+	w.stopProcSamplerLocked()
+	w.proc = sampler
+	w.procInterval = interval
+	if w.started {
+		w.startProcSamplerLocked()
+	}
+	return nil
+}
+
+// SetLaunchSource sets the application launch source used to record cask
+// usage, queried at most every interval as part of shim log processing. A
+// nil source disables launch tracking.
+func (w *Watcher) SetLaunchSource(src LaunchSource, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultLaunchCheckInterval
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.launch = src
+	w.launchInterval = interval
+}
+
+// DisableProcSampling removes the /proc sampling source. Observations not
+// yet flushed are discarded.
+func (w *Watcher) DisableProcSampling() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopProcSamplerLocked()
+	w.proc = nil
+}
+
+// startProcSamplerLocked starts the sampling goroutine. w.mu must be held.
+func (w *Watcher) startProcSamplerLocked() {
+	stop := make(chan struct{})
+	w.procStop = stop
+	w.wg.Add(1)
+	go w.runProcSampler(w.proc, w.procInterval, stop)
+}
+
+// stopProcSamplerLocked stops the sampling goroutine, if running. w.mu must
+// be held.
+func (w *Watcher) stopProcSamplerLocked() {
+	if w.procStop != nil {
+		close(w.procStop)
+		w.procStop = nil
+	}
+}
+
+// Start begins usage tracking. Pending log entries are processed immediately;
+// afterwards the log is processed whenever it changes, falling back to
+// polling every interval when file notifications are unavailable.
 func (w *Watcher) Start() error {
-	if stats, err := ProcessUsageLog(w.store); err != nil {
-		fmt.Fprintf(os.Stderr, "watcher: initial shim log processing: %v\n", err)
+	w.mu.Lock()
+	w.startedAt = time.Now()
+	w.mu.Unlock()
+
+	w.processShimLog("initial shim log processing")
+
+	poll := w.interval
+	if logPath, _, err := usageLogPaths(); err != nil {
+		fmt.Fprintf(os.Stderr, "watcher: %v; polling every %v\n", err, w.interval)
+	} else if n, err := newFileNotifier(logPath); err != nil {
+		fmt.Fprintf(os.Stderr, "watcher: file notifications unavailable (%v); polling every %v\n", err, w.interval)
 	} else {
-		logProcessingStats(stats)
+		w.mu.Lock()
+		w.notifier = n
+		w.mu.Unlock()
+		if poll < safetyPollInterval {
+			poll = safetyPollInterval
+		}
 	}
-
-	w.batchTicker = time.NewTicker(30 * time.Second)
+	w.batchTicker = time.NewTicker(poll)
 
 	w.wg.Add(1)
 	go w.runShimLogProcessor()
 
+	w.mu.Lock()
+	w.started = true
+	if w.proc != nil {
+		w.startProcSamplerLocked()
+	}
+	w.mu.Unlock()
+
 	return nil
 }
 
-// runShimLogProcessor polls the shim log on each tick and does a final flush
-// when the stop signal is received.
+// runShimLogProcessor processes the shim log on change notifications (after
+// debouncing), poll ticks and flush requests, and does a final flush when
+// the stop signal is received.
 func (w *Watcher) runShimLogProcessor() {
 	defer w.wg.Done()
 
+	var changes <-chan struct{}
+	if n := w.notifier; n != nil {
+		changes = n.Events()
+		defer n.Close()
+	}
+
+	// debounce fires once the log has been quiet for debounceDelay, or
+	// maxBatchDelay after the first change of a burst.
+	var debounce *time.Timer
+	var debounceC <-chan time.Time
+	var burstStart time.Time
+
+	process := func(errContext string) {
+		if debounce != nil {
+			debounce.Stop()
+			debounce, debounceC = nil, nil
+		}
+		w.processShimLog(errContext)
+	}
+
 	for {
 		select {
-		case <-w.batchTicker.C:
-			if stats, err := ProcessUsageLog(w.store); err != nil {
-				fmt.Fprintf(os.Stderr, "watcher: shim log processing error: %v\n", err)
-			} else {
-				logProcessingStats(stats)
+		case _, ok := <-changes:
+			if !ok {
+				// Notifier failed; the poll ticker keeps running.
+				fmt.Fprintf(os.Stderr, "watcher: file notifications stopped; polling every %v\n", w.interval)
+				changes = nil
+				w.mu.Lock()
+				w.notifier = nil
+				w.mu.Unlock()
+				w.batchTicker.Reset(w.interval)
+				continue
+			}
+			now := time.Now()
+			if debounce == nil {
+				burstStart = now
+				debounce = time.NewTimer(debounceDelay)
+				debounceC = debounce.C
+			} else if now.Sub(burstStart)+debounceDelay < maxBatchDelay {
+				debounce.Reset(debounceDelay)
 			}
+		case <-debounceC:
+			debounce, debounceC = nil, nil
+			w.processShimLog("shim log processing error")
+		case <-w.batchTicker.C:
+			process("shim log processing error")
+		case <-w.flushCh:
+			process("shim log processing error")
 		case <-w.stopCh:
-			if stats, err := ProcessUsageLog(w.store); err != nil {
-				fmt.Fprintf(os.Stderr, "watcher: final shim log flush error: %v\n", err)
-			} else {
-				logProcessingStats(stats)
+			process("final shim log flush error")
+			return
+		}
+	}
+}
+
+// processShimLog runs ProcessUsageLog and then flushes proc observations of
+// processes started before it ran, so they are de-duplicated against the
+// shim events just inserted. errContext prefixes any error written to stderr.
+// Flush callers waiting when the run starts are sent its result.
+func (w *Watcher) processShimLog(errContext string) {
+	w.mu.Lock()
+	waiters := w.flushWaiters
+	w.flushWaiters = nil
+	w.mu.Unlock()
+
+	err := w.runShimLog()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "watcher: %s: %v\n", errContext, err)
+	}
+
+	w.mu.Lock()
+	w.lastRun = time.Now()
+	if err != nil {
+		w.lastErr, w.lastErrAt = err, w.lastRun
+	}
+	w.mu.Unlock()
+
+	for _, done := range waiters {
+		done <- err
+	}
+}
+
+// runShimLog does one processShimLog run.
+func (w *Watcher) runShimLog() error {
+	cutoff := time.Now()
+
+	if w.index == nil || w.index.empty() || w.indexStale.Swap(false) || time.Since(w.index.builtAt) > indexMaxAge {
+		idx, err := buildPackageIndex(w.store)
+		if err != nil {
+			return err
+		}
+		w.mu.Lock()
+		w.index = idx
+		if w.proc != nil {
+			w.proc.SetPrefixes(idx.prefixes)
+		}
+		w.mu.Unlock()
+	}
+
+	stats, err := processUsageLog(w.store, w.index)
+	if err != nil {
+		return err
+	}
+	logProcessingStats(stats)
+	w.addProcessed(stats.Inserted)
+
+	if stats.LinesRead >= maxShimLogLinesPerTick {
+		// Shim backlog not drained yet: process the rest right away rather
+		// than waiting for another change, and keep proc observations queued.
+		if stats.SkippedNoIndex == 0 {
+			w.RequestFlush()
+		}
+		return nil
+	}
+	if stats.SkippedNoIndex == 0 {
+		if err := w.maintainUsageLog(); err != nil {
+			return fmt.Errorf("usage log rotation: %w", err)
+		}
+	}
+
+	// A failing launch source must not hold back the proc observations.
+	if err := w.checkAppLaunches(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s brewprune-watch: app launch ingest: %v\n",
+			time.Now().UTC().Format(time.RFC3339), err)
+	}
+
+	w.mu.Lock()
+	proc := w.proc
+	w.mu.Unlock()
+	if proc == nil {
+		return nil
+	}
+	procStats, err := proc.Flush(w.store, cutoff)
+	if err != nil {
+		return fmt.Errorf("proc event flush: %w", err)
+	}
+	w.addProcessed(procStats.Inserted)
+	if procStats.Inserted > 0 {
+		fmt.Fprintf(os.Stderr, "%s brewprune-watch: recorded %d proc events, %d already seen by shims\n",
+			time.Now().UTC().Format(time.RFC3339), procStats.Inserted, procStats.Duplicates)
+	}
+	return nil
+}
+
+// checkAppLaunches records cask application launches when the launch
+// interval has passed since the last check.
+func (w *Watcher) checkAppLaunches() error {
+	w.mu.Lock()
+	src := w.launch
+	due := src != nil && time.Since(w.lastLaunchCheck) >= w.launchInterval
+	if due {
+		w.lastLaunchCheck = time.Now()
+	}
+	w.mu.Unlock()
+	if !due {
+		return nil
+	}
+
+	stats, err := IngestAppLaunches(w.store, src)
+	if err != nil {
+		return err
+	}
+	w.addProcessed(stats.Inserted)
+	if stats.Inserted > 0 {
+		fmt.Fprintf(os.Stderr, "%s brewprune-watch: recorded %d app launches\n",
+			time.Now().UTC().Format(time.RFC3339), stats.Inserted)
+	}
+	return nil
+}
+
+// addProcessed adds n to the count of recorded usage events.
+func (w *Watcher) addProcessed(n int) {
+	w.mu.Lock()
+	w.processed += int64(n)
+	w.mu.Unlock()
+}
+
+// runProcSampler walks /proc with p on every interval tick until stop is
+// closed or the watcher stops.
+func (w *Watcher) runProcSampler(p *ProcSampler, interval time.Duration, stop <-chan struct{}) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := p.Sample(); err != nil {
+				fmt.Fprintf(os.Stderr, "watcher: proc sampling error: %v\n", err)
 			}
+		case <-stop:
+			return
+		case <-w.stopCh:
 			return
 		}
 	}
 }
 
-// Stop halts the watcher and flushes any remaining log entries.
+// Stop halts the watcher, flushes any remaining log entries and closes the
+// control socket. Calls after the first are no-ops.
 func (w *Watcher) Stop() error {
+	w.stopOnce.Do(w.stop)
+	return nil
+}
+
+// stop implements Stop.
+func (w *Watcher) stop() {
+	w.mu.Lock()
+	w.started = false
+	w.mu.Unlock()
+
+	if w.control != nil {
+		w.control.Close()
+	}
+
 	close(w.stopCh)
 
 	if w.batchTicker != nil {
@@ -89,5 +522,9 @@ func (w *Watcher) Stop() error {
 	}
 
 	w.wg.Wait()
-	return nil
+
+	if w.control != nil {
+		w.controlWG.Wait()
+		os.Remove(w.controlPath)
+	}
 }
@@ -0,0 +1,161 @@
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// setTestHome points HOME at a fresh temp dir for the duration of the test.
+func setTestHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	orig := os.Getenv("HOME")
+	t.Cleanup(func() { os.Setenv("HOME", orig) })
+	os.Setenv("HOME", home)
+	return home
+}
+
+// appendShimLine appends one shim log entry for binary to the usage log.
+func appendShimLine(t *testing.T, home, binary string) {
+	t.Helper()
+	f, err := os.OpenFile(filepath.Join(home, ".brewprune", "usage.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("open usage.log: %v", err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(f, "%d,%s\n", time.Now().UnixNano(), filepath.Join(home, ".brewprune", "bin", binary)); err != nil {
+		t.Fatalf("write usage.log: %v", err)
+	}
+}
+
+// waitForEvents polls until pkg has want usage events or the timeout elapses.
+func waitForEvents(t *testing.T, st *store.Store, pkg string, want int, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		events, err := st.GetUsageEvents(pkg, time.Time{})
+		if err != nil {
+			t.Fatalf("GetUsageEvents: %v", err)
+		}
+		if len(events) >= want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d usage events for %s after %v, want %d", len(events), pkg, timeout, want)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestWatcher_ProcessesLogOnChange(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("file notifications not supported on " + runtime.GOOS)
+	}
+
+	home := setTestHome(t)
+	if err := os.MkdirAll(filepath.Join(home, ".brewprune"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	st := newTestStore(t)
+	insertPkg(t, st, "jq", []string{"/opt/homebrew/bin/jq"})
+
+	w, err := New(st)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	// A poll interval this long means only a notification can trigger
+	// processing within the test's timeout.
+	w.SetInterval(time.Hour)
+	if err := w.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer w.Stop()
+	if w.notifier == nil {
+		t.Fatal("expected file notifications to be active")
+	}
+
+	// Create the log after Start, then append a burst of entries.
+	appendShimLine(t, home, "jq")
+	waitForEvents(t, st, "jq", 1, 3*time.Second)
+
+	for i := 0; i < 3; i++ {
+		appendShimLine(t, home, "jq")
+	}
+	waitForEvents(t, st, "jq", 4, 3*time.Second)
+
+	pending, err := PendingLogBytes()
+	if err != nil {
+		t.Fatalf("PendingLogBytes: %v", err)
+	}
+	if pending != 0 {
+		t.Errorf("PendingLogBytes = %d after processing, want 0", pending)
+	}
+}
+
+func TestWatcher_RequestFlushWhilePolling(t *testing.T) {
+	// Without ~/.brewprune the notifier cannot watch the log directory, so
+	// the watcher falls back to polling.
+	home := setTestHome(t)
+	st := newTestStore(t)
+	insertPkg(t, st, "jq", []string{"/opt/homebrew/bin/jq"})
+
+	w, err := New(st)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	w.SetInterval(time.Hour)
+	if err := w.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer w.Stop()
+	if w.notifier != nil {
+		t.Fatal("expected polling fallback without a log directory")
+	}
+
+	if err := os.MkdirAll(filepath.Join(home, ".brewprune"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	appendShimLine(t, home, "jq")
+
+	pending, err := PendingLogBytes()
+	if err != nil {
+		t.Fatalf("PendingLogBytes: %v", err)
+	}
+	if pending == 0 {
+		t.Fatal("PendingLogBytes = 0 before flush, want > 0")
+	}
+
+	w.RequestFlush()
+	waitForEvents(t, st, "jq", 1, 2*time.Second)
+}
+
+func TestWatcher_StopFlushesPendingEntries(t *testing.T) {
+	home := setTestHome(t)
+	st := newTestStore(t)
+	insertPkg(t, st, "jq", []string{"/opt/homebrew/bin/jq"})
+
+	w, err := New(st)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	w.SetInterval(time.Hour)
+	if err := w.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(home, ".brewprune"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	appendShimLine(t, home, "jq")
+	if err := w.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	waitForEvents(t, st, "jq", 1, 0)
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package watcher
+
+import "os"
+
+// fileInode returns 0: inode numbers are not available on this platform, so
+// usage log rotation is disabled.
+func fileInode(info os.FileInfo) uint64 {
+	return 0
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package watcher
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileInode returns the inode number of the file described by info, or 0 if
+// it is unknown.
+func fileInode(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino)
+	}
+	return 0
+}
@@ -0,0 +1,44 @@
+package watcher
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// spotlightBatchSize bounds the number of bundles passed to one mdls call.
+const spotlightBatchSize = 200
+
+// SpotlightSource reads application last-used dates from Spotlight metadata
+// (kMDItemLastUsedDate), which LaunchServices updates whenever an app is
+// opened from Finder, the Dock, Spotlight or "open".
+type SpotlightSource struct{}
+
+// LastLaunches implements LaunchSource using mdls.
+func (SpotlightSource) LastLaunches(paths []string) ([]AppLaunch, error) {
+	var launches []AppLaunch
+	for start := 0; start < len(paths); start += spotlightBatchSize {
+		end := start + spotlightBatchSize
+		if end > len(paths) {
+			end = len(paths)
+		}
+		batch := paths[start:end]
+
+		args := append([]string{"-raw", "-nullMarker", "(null)", "-name", "kMDItemLastUsedDate"}, batch...)
+		out, err := exec.Command("mdls", args...).Output()
+		if err != nil {
+			return nil, fmt.Errorf("mdls: %w", err)
+		}
+		found, err := parseMDLSOutput(out, batch)
+		if err != nil {
+			return nil, err
+		}
+		launches = append(launches, found...)
+	}
+	return launches, nil
+}
+
+// DefaultLaunchSource returns the platform's application launch source, or
+// nil when there is none.
+func DefaultLaunchSource() LaunchSource {
+	return SpotlightSource{}
+}
@@ -0,0 +1,9 @@
+//go:build !darwin
+
+package watcher
+
+// DefaultLaunchSource returns the platform's application launch source, or
+// nil when there is none. Only macOS records application launches.
+func DefaultLaunchSource() LaunchSource {
+	return nil
+}
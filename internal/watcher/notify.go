@@ -0,0 +1,18 @@
+package watcher
+
+// fileNotifier delivers a value on Events whenever the watched file may have
+// changed. Bursts of changes are coalesced: at most one event is pending at
+// a time. Events is closed once the notifier stops.
+type fileNotifier interface {
+	Events() <-chan struct{}
+	Close() error
+}
+
+// notify queues a change event without blocking; a pending event already
+// covers this change.
+func notify(events chan struct{}) {
+	select {
+	case events <- struct{}{}:
+	default:
+	}
+}
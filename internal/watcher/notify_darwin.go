@@ -0,0 +1,143 @@
+//go:build darwin
+
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// vnodeMask selects the vnode events that can mean the log changed.
+const vnodeMask = syscall.NOTE_WRITE | syscall.NOTE_EXTEND | syscall.NOTE_DELETE | syscall.NOTE_RENAME
+
+// kqueueNotifier watches the log with kqueue. kqueue watches open file
+// descriptors rather than names, so while the log does not exist the parent
+// directory is watched instead, and the log is reopened after a rotation.
+type kqueueNotifier struct {
+	kq     int
+	wake   [2]int // pipe; writing to wake[1] stops the event loop
+	path   string
+	events chan struct{}
+	done   chan struct{} // closed when loop has exited and released its fds
+}
+
+// newFileNotifier starts watching path for changes.
+func newFileNotifier(path string) (fileNotifier, error) {
+	kq, err := syscall.Kqueue()
+	if err != nil {
+		return nil, fmt.Errorf("kqueue: %w", err)
+	}
+	n := &kqueueNotifier{kq: kq, path: path, events: make(chan struct{}, 1), done: make(chan struct{})}
+	if err := syscall.Pipe(n.wake[:]); err != nil {
+		syscall.Close(kq)
+		return nil, fmt.Errorf("pipe: %w", err)
+	}
+
+	var change syscall.Kevent_t
+	syscall.SetKevent(&change, n.wake[0], syscall.EVFILT_READ, syscall.EV_ADD)
+	if _, err := syscall.Kevent(kq, []syscall.Kevent_t{change}, nil, nil); err != nil {
+		n.closeFDs()
+		return nil, fmt.Errorf("kevent: %w", err)
+	}
+
+	go n.loop()
+	return n, nil
+}
+
+// loop waits for vnode events on the log (or its directory) until woken
+// through the pipe.
+func (n *kqueueNotifier) loop() {
+	defer close(n.events)
+	defer close(n.done)
+	defer n.closeFDs()
+
+	fd, onLog := -1, false
+	defer func() {
+		if fd >= 0 {
+			syscall.Close(fd)
+		}
+	}()
+
+	for {
+		if fd < 0 {
+			fd, onLog = n.open()
+			if fd < 0 {
+				return
+			}
+			var change syscall.Kevent_t
+			syscall.SetKevent(&change, fd, syscall.EVFILT_VNODE, syscall.EV_ADD|syscall.EV_CLEAR)
+			change.Fflags = vnodeMask
+			if _, err := syscall.Kevent(n.kq, []syscall.Kevent_t{change}, nil, nil); err != nil {
+				return
+			}
+			if onLog {
+				// The log may have been written while it was not watched.
+				notify(n.events)
+			}
+		}
+
+		out := make([]syscall.Kevent_t, 4)
+		count, err := syscall.Kevent(n.kq, nil, out, nil)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return
+		}
+
+		reopen := false
+		for _, ev := range out[:count] {
+			if int(ev.Ident) == n.wake[0] {
+				return
+			}
+			if onLog {
+				notify(n.events)
+				if ev.Fflags&(syscall.NOTE_DELETE|syscall.NOTE_RENAME) != 0 {
+					reopen = true
+				}
+			} else if _, err := os.Stat(n.path); err == nil {
+				// The log appeared in the watched directory.
+				reopen = true
+			}
+		}
+		if reopen {
+			syscall.Close(fd) // Closing the fd removes its kevent
+			fd = -1
+		}
+	}
+}
+
+// open returns an event-only descriptor for the log, or for its directory
+// when the log does not exist yet.
+func (n *kqueueNotifier) open() (fd int, onLog bool) {
+	if fd, err := syscall.Open(n.path, syscall.O_EVTONLY|syscall.O_CLOEXEC, 0); err == nil {
+		return fd, true
+	}
+	fd, err := syscall.Open(filepath.Dir(n.path), syscall.O_EVTONLY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return -1, false
+	}
+	return fd, false
+}
+
+func (n *kqueueNotifier) closeFDs() {
+	syscall.Close(n.wake[0])
+	syscall.Close(n.wake[1])
+	syscall.Close(n.kq)
+}
+
+// Events implements fileNotifier.
+func (n *kqueueNotifier) Events() <-chan struct{} { return n.events }
+
+// Close implements fileNotifier.
+func (n *kqueueNotifier) Close() error {
+	select {
+	case <-n.done:
+		return nil // Loop already exited on an error
+	default:
+	}
+	_, err := syscall.Write(n.wake[1], []byte{0})
+	return err
+}
@@ -0,0 +1,79 @@
+//go:build linux
+
+package watcher
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// inotifyMask selects the directory events that can mean the log changed:
+// appends, creation, and rotation by rename or delete.
+const inotifyMask = syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE | syscall.IN_CREATE |
+	syscall.IN_MOVED_TO | syscall.IN_MOVED_FROM | syscall.IN_DELETE
+
+// inotifyNotifier watches the log's parent directory with inotify, so the
+// watch survives the log being created, deleted or rotated.
+type inotifyNotifier struct {
+	f      *os.File
+	name   string
+	events chan struct{}
+}
+
+// newFileNotifier starts watching path for changes.
+func newFileNotifier(path string) (fileNotifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify_init1: %w", err)
+	}
+	if _, err := syscall.InotifyAddWatch(fd, filepath.Dir(path), inotifyMask); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("inotify_add_watch %s: %w", filepath.Dir(path), err)
+	}
+
+	// A non-blocking fd is registered with the runtime poller, so Close
+	// unblocks the pending Read in the reader goroutine.
+	n := &inotifyNotifier{
+		f:      os.NewFile(uintptr(fd), "inotify"),
+		name:   filepath.Base(path),
+		events: make(chan struct{}, 1),
+	}
+	go n.read()
+	return n, nil
+}
+
+// read decodes inotify events until the notifier is closed. Each event is
+// a fixed header (wd, mask, cookie, len) followed by a NUL-padded name.
+func (n *inotifyNotifier) read() {
+	defer close(n.events)
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		size, err := n.f.Read(buf)
+		if err != nil {
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= size; {
+			nameLen := int(binary.NativeEndian.Uint32(buf[off+12 : off+16]))
+			start := off + syscall.SizeofInotifyEvent
+			if start+nameLen > size {
+				break
+			}
+			name := strings.TrimRight(string(buf[start:start+nameLen]), "\x00")
+			if name == n.name {
+				notify(n.events)
+			}
+			off = start + nameLen
+		}
+	}
+}
+
+// Events implements fileNotifier.
+func (n *inotifyNotifier) Events() <-chan struct{} { return n.events }
+
+// Close implements fileNotifier.
+func (n *inotifyNotifier) Close() error { return n.f.Close() }
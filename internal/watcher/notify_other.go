@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package watcher
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// newFileNotifier reports that file notifications are unavailable, so the
+// watcher falls back to polling.
+func newFileNotifier(path string) (fileNotifier, error) {
+	return nil, fmt.Errorf("file notifications not supported on %s", runtime.GOOS)
+}
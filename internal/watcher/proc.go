@@ -0,0 +1,395 @@
+package watcher
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// DefaultProcSampleInterval is how often the proc sampler walks /proc when no
+// interval is configured.
+const DefaultProcSampleInterval = 5 * time.Second
+
+// procDedupeWindow is how close a shim event's timestamp must be to a
+// process's start time to count as the same execution. The shim logs
+// immediately before exec, and exec keeps its PID and start time, so the two
+// normally agree to within milliseconds.
+const procDedupeWindow = 2 * time.Second
+
+// clockTicksPerSecond is USER_HZ, the unit of the starttime field in
+// /proc/<pid>/stat. It is 100 on every architecture Linux supports today.
+const clockTicksPerSecond = 100
+
+// defaultBrewPrefixes are the standard Homebrew prefixes, tracked until a
+// scan has recorded the prefixes in use (see SetPrefixes).
+var defaultBrewPrefixes = []string{
+	"/opt/homebrew",
+	"/usr/local",
+	"/home/linuxbrew/.linuxbrew",
+}
+
+// ProcObservation is a running process whose executable or command line
+// points into a Homebrew prefix.
+type ProcObservation struct {
+	PID   int
+	Start time.Time
+	Paths []string // exe target first, then absolute argv[0] and argv[1]
+}
+
+// procKey identifies one process across samples; the start time guards
+// against PID reuse.
+type procKey struct {
+	pid   int
+	start int64
+}
+
+// ProcSampler is a shim-free usage source for Linux. It periodically lists
+// the processes in /proc and records those running Homebrew executables,
+// which catches programs the PATH shims never see: binaries run by absolute
+// path, from scripts with a pinned PATH, or exec'd by other tools.
+//
+// Sampling only sees processes alive at a sample, so very short-lived
+// commands can be missed; it complements the shims rather than replacing
+// them. Each process is inspected once: later samples only read its start
+// time, so a process that execs a Homebrew executable after it was first
+// seen running something else is not recorded.
+type ProcSampler struct {
+	root     string
+	prefixes []string
+	bootTime time.Time
+
+	mu      sync.Mutex
+	seen    map[procKey]bool // inspected processes, brew or not
+	pending []ProcObservation
+}
+
+// ProcStats holds the summary of one ProcSampler.Flush.
+type ProcStats struct {
+	Observed   int // processes flushed
+	Inserted   int // proc events written
+	Duplicates int // already recorded by a shim event
+	Unresolved int // no installed package matched
+}
+
+// NewProcSampler creates a sampler reading the proc filesystem mounted at
+// root (normally "/proc"). It returns an error when root is not a Linux proc
+// filesystem, e.g. on macOS.
+func NewProcSampler(root string) (*ProcSampler, error) {
+	bootTime, err := readBootTime(root)
+	if err != nil {
+		return nil, fmt.Errorf("proc filesystem unavailable at %s: %w", root, err)
+	}
+
+	p := &ProcSampler{
+		root:     root,
+		bootTime: bootTime,
+		seen:     make(map[procKey]bool),
+	}
+	p.SetPrefixes(nil)
+	return p, nil
+}
+
+// SetPrefixes sets the Homebrew prefixes whose executables are tracked,
+// normally the prefixes brewprune scanned. An empty list selects the
+// standard prefixes. HOMEBREW_PREFIX, when set, is tracked in addition.
+func (p *ProcSampler) SetPrefixes(prefixes []string) {
+	if len(prefixes) == 0 {
+		prefixes = defaultBrewPrefixes
+	}
+	prefixes = append([]string{}, prefixes...)
+	if env := os.Getenv("HOMEBREW_PREFIX"); env != "" {
+		prefixes = append(prefixes, filepath.Clean(env))
+	}
+
+	p.mu.Lock()
+	p.prefixes = prefixes
+	p.mu.Unlock()
+}
+
+// Sample walks the process list once and queues every new process that runs
+// a Homebrew executable. It returns the number of processes queued.
+// Processes that exit or deny access mid-walk are skipped.
+func (p *ProcSampler) Sample() (int, error) {
+	entries, err := os.ReadDir(p.root)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", p.root, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	alive := make(map[procKey]bool, len(p.seen))
+	queued := 0
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid <= 0 {
+			continue
+		}
+
+		dir := filepath.Join(p.root, entry.Name())
+		ticks, err := readStartTicks(dir)
+		if err != nil {
+			continue
+		}
+
+		key := procKey{pid: pid, start: ticks}
+		alive[key] = true
+		if p.seen[key] {
+			continue
+		}
+
+		paths := p.brewPaths(dir)
+		if len(paths) == 0 {
+			continue
+		}
+
+		p.pending = append(p.pending, ProcObservation{
+			PID:   pid,
+			Start: p.bootTime.Add(time.Duration(ticks) * time.Second / clockTicksPerSecond),
+			Paths: paths,
+		})
+		queued++
+	}
+
+	// Forget processes that have exited so the set stays bounded.
+	p.seen = alive
+
+	return queued, nil
+}
+
+// brewPaths returns the process's executable and leading command-line paths
+// that fall under a Homebrew prefix. argv[1] is included so interpreted
+// tools (e.g. "python3.12 /home/linuxbrew/.linuxbrew/bin/poetry") are
+// attributed to the script's package as well as the interpreter's.
+func (p *ProcSampler) brewPaths(dir string) []string {
+	var candidates []string
+	if exe, err := os.Readlink(filepath.Join(dir, "exe")); err == nil {
+		candidates = append(candidates, strings.TrimSuffix(exe, " (deleted)"))
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		args := strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+		for i := 0; i < len(args) && i < 2; i++ {
+			if filepath.IsAbs(args[i]) {
+				candidates = append(candidates, args[i])
+			}
+		}
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		c = filepath.Clean(c)
+		if !seen[c] && p.underBrewPrefix(c) {
+			seen[c] = true
+			paths = append(paths, c)
+		}
+	}
+	return paths
+}
+
+// underBrewPrefix reports whether path is inside a Cellar, a Caskroom or a
+// tracked brew prefix. p.mu must be held.
+func (p *ProcSampler) underBrewPrefix(path string) bool {
+	if strings.Contains(path, "/Cellar/") || strings.Contains(path, "/Caskroom/") {
+		return true
+	}
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Flush resolves queued observations of processes that started at or before
+// cutoff and records them as "proc" usage events. Later observations stay
+// queued.
+//
+// cutoff should be the time the shim log was last fully processed: the shim
+// writes its log line before exec, so any shim event for a process started
+// by then is already in the store and the observation can be de-duplicated
+// against it.
+func (p *ProcSampler) Flush(st *store.Store, cutoff time.Time) (ProcStats, error) {
+	var stats ProcStats
+
+	p.mu.Lock()
+	prefixes := p.prefixes
+	var ready, later []ProcObservation
+	for _, obs := range p.pending {
+		if obs.Start.After(cutoff) {
+			later = append(later, obs)
+		} else {
+			ready = append(ready, obs)
+		}
+	}
+	p.pending = later
+	p.mu.Unlock()
+
+	if len(ready) == 0 {
+		return stats, nil
+	}
+
+	optPathMap, err := buildOptPathMap(st)
+	if err != nil {
+		return stats, fmt.Errorf("proc: build opt path map: %w", err)
+	}
+	packages, err := st.ListPackages()
+	if err != nil {
+		return stats, fmt.Errorf("proc: list packages: %w", err)
+	}
+	installed := make(map[string]bool, len(packages))
+	for _, pkg := range packages {
+		installed[pkg.Name] = true
+	}
+
+	tx, err := st.DB().Begin()
+	if err != nil {
+		return stats, fmt.Errorf("proc: begin transaction: %w", err)
+	}
+
+	for _, obs := range ready {
+		stats.Observed++
+
+		recorded := make(map[string]bool)
+		for _, path := range obs.Paths {
+			pkg := resolveProcPath(path, prefixes, optPathMap)
+			if pkg == "" || !installed[pkg] || recorded[pkg] {
+				continue
+			}
+			recorded[pkg] = true
+
+			dup, err := hasShimEvent(tx, pkg, obs)
+			if err != nil {
+				tx.Rollback() //nolint:errcheck
+				return stats, err
+			}
+			if dup {
+				stats.Duplicates++
+				continue
+			}
+
+			if _, err := tx.Exec(
+				`INSERT INTO usage_events (package, event_type, binary_path, binary_name, pid, timestamp) VALUES (?, 'proc', ?, ?, ?, ?)`,
+				pkg, path, filepath.Base(path), obs.PID, obs.Start.Format(time.RFC3339),
+			); err != nil {
+				tx.Rollback() //nolint:errcheck
+				return stats, fmt.Errorf("proc: insert event for %s: %w", pkg, err)
+			}
+			stats.Inserted++
+		}
+		if len(recorded) == 0 {
+			stats.Unresolved++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return stats, fmt.Errorf("proc: commit: %w", err)
+	}
+
+	return stats, nil
+}
+
+// hasShimEvent reports whether a shim already recorded pkg for the observed
+// process: an exec or probe event within procDedupeWindow of its start time
+// whose PID, when logged, matches.
+func hasShimEvent(tx *sql.Tx, pkg string, obs ProcObservation) (bool, error) {
+	var n int
+	err := tx.QueryRow(`
+		SELECT COUNT(*) FROM usage_events
+		WHERE package = ? AND event_type IN ('exec', 'probe')
+		  AND timestamp BETWEEN ? AND ?
+		  AND (pid IS NULL OR pid = ?)`,
+		pkg,
+		obs.Start.Add(-procDedupeWindow).Format(time.RFC3339),
+		obs.Start.Add(procDedupeWindow).Format(time.RFC3339),
+		obs.PID,
+	).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("proc: check shim events for %s: %w", pkg, err)
+	}
+	return n > 0, nil
+}
+
+// resolveProcPath maps an executable path to its package: a Cellar keg or
+// Caskroom path (".../Cellar/<pkg>/<version>/...",
+// ".../Caskroom/<cask>/<version>/..."), a linked binary known to the store,
+// or an opt path ("<prefix>/opt/<pkg>/...") under one of prefixes. It
+// returns "" when nothing matches.
+func resolveProcPath(path string, prefixes []string, optPathMap map[string]string) string {
+	for _, dir := range []string{"/Cellar/", "/Caskroom/"} {
+		if i := strings.Index(path, dir); i >= 0 {
+			rest := path[i+len(dir):]
+			if j := strings.IndexByte(rest, '/'); j > 0 {
+				return rest[:j]
+			}
+			return ""
+		}
+	}
+	if pkg, ok := optPathMap[path]; ok {
+		return pkg
+	}
+	for _, prefix := range prefixes {
+		rest, ok := strings.CutPrefix(path, prefix+"/opt/")
+		if !ok {
+			continue
+		}
+		if j := strings.IndexByte(rest, '/'); j > 0 {
+			return rest[:j]
+		}
+	}
+	return ""
+}
+
+// readBootTime reads the system boot time from the btime line of <root>/stat.
+func readBootTime(root string) (time.Time, error) {
+	f, err := os.Open(filepath.Join(root, "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "btime" {
+			secs, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("parse btime %q: %w", fields[1], err)
+			}
+			return time.Unix(secs, 0), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, fmt.Errorf("no btime line in %s", filepath.Join(root, "stat"))
+}
+
+// readStartTicks returns the process start time, in clock ticks since boot,
+// from field 22 of <dir>/stat. The command name in field 2 may contain spaces
+// and parentheses, so fields are counted from the last ")".
+func readStartTicks(dir string) (int64, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return 0, err
+	}
+	s := string(data)
+	i := strings.LastIndexByte(s, ')')
+	if i < 0 {
+		return 0, fmt.Errorf("malformed stat")
+	}
+	fields := strings.Fields(s[i+1:])
+	// fields[0] is field 3 (state), so field 22 is fields[19].
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("malformed stat")
+	}
+	return strconv.ParseInt(fields[19], 10, 64)
+}
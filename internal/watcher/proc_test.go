@@ -0,0 +1,330 @@
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// fakeProc builds a minimal proc filesystem under a temp dir with the given
+// boot time.
+func fakeProc(t *testing.T, btime time.Time) string {
+	t.Helper()
+	root := t.TempDir()
+	stat := fmt.Sprintf("cpu  1 2 3 4\nbtime %d\nprocesses 42\n", btime.Unix())
+	if err := os.WriteFile(filepath.Join(root, "stat"), []byte(stat), 0644); err != nil {
+		t.Fatalf("write stat: %v", err)
+	}
+	return root
+}
+
+// addProc adds a process to a fake proc root. startTicks is the start time in
+// clock ticks since boot; argv is written NUL-separated to cmdline.
+func addProc(t *testing.T, root string, pid int, startTicks int64, exe string, argv ...string) {
+	t.Helper()
+	dir := filepath.Join(root, fmt.Sprint(pid))
+	os.RemoveAll(dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	// Fields 4..21 are zero filler; field 22 is starttime.
+	filler := strings.Repeat("0 ", 18)
+	stat := fmt.Sprintf("%d (my (odd) comm) S %s%d 0 0\n", pid, filler, startTicks)
+	if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644); err != nil {
+		t.Fatalf("write stat: %v", err)
+	}
+	if exe != "" {
+		if err := os.Symlink(exe, filepath.Join(dir, "exe")); err != nil {
+			t.Fatalf("symlink exe: %v", err)
+		}
+	}
+	cmdline := strings.Join(argv, "\x00") + "\x00"
+	if err := os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0644); err != nil {
+		t.Fatalf("write cmdline: %v", err)
+	}
+}
+
+// procEvents returns the proc events recorded for pkg.
+func procEvents(t *testing.T, st *store.Store, pkg string) []*store.UsageEvent {
+	t.Helper()
+	events, err := st.GetUsageEvents(pkg, time.Time{})
+	if err != nil {
+		t.Fatalf("GetUsageEvents(%s): %v", pkg, err)
+	}
+	var out []*store.UsageEvent
+	for _, e := range events {
+		if e.EventType == "proc" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func TestReadStartTicks_CommWithParens(t *testing.T) {
+	root := fakeProc(t, time.Now())
+	addProc(t, root, 7, 12345, "")
+
+	ticks, err := readStartTicks(filepath.Join(root, "7"))
+	if err != nil {
+		t.Fatalf("readStartTicks: %v", err)
+	}
+	if ticks != 12345 {
+		t.Errorf("ticks = %d, want 12345", ticks)
+	}
+}
+
+func TestNewProcSampler_NoProcFS(t *testing.T) {
+	if _, err := NewProcSampler(t.TempDir()); err == nil {
+		t.Fatal("expected error for directory without stat")
+	}
+}
+
+func TestProcSampler_RecordsCellarExecutable(t *testing.T) {
+	st := newTestStore(t)
+	insertPkg(t, st, "ripgrep", []string{"/home/linuxbrew/.linuxbrew/bin/rg"})
+
+	btime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	root := fakeProc(t, btime)
+	addProc(t, root, 100, 60*100, "/home/linuxbrew/.linuxbrew/Cellar/ripgrep/14.1.0/bin/rg",
+		"/home/linuxbrew/.linuxbrew/bin/rg", "needle")
+	addProc(t, root, 101, 60*100, "/usr/bin/bash", "bash")
+
+	p, err := NewProcSampler(root)
+	if err != nil {
+		t.Fatalf("NewProcSampler: %v", err)
+	}
+	if n, err := p.Sample(); err != nil || n != 1 {
+		t.Fatalf("Sample() = %d, %v; want 1, nil", n, err)
+	}
+	// A still-running process is not queued again.
+	if n, _ := p.Sample(); n != 0 {
+		t.Errorf("second Sample() queued %d, want 0", n)
+	}
+
+	stats, err := p.Flush(st, time.Now())
+	if err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if stats.Inserted != 1 {
+		t.Errorf("Inserted = %d, want 1", stats.Inserted)
+	}
+
+	events := procEvents(t, st, "ripgrep")
+	if len(events) != 1 {
+		t.Fatalf("got %d proc events, want 1", len(events))
+	}
+	e := events[0]
+	if e.PID != 100 || e.BinaryName != "rg" {
+		t.Errorf("event = pid %d binary %q, want pid 100 binary rg", e.PID, e.BinaryName)
+	}
+	if want := btime.Add(time.Minute); !e.Timestamp.Equal(want) {
+		t.Errorf("Timestamp = %v, want %v", e.Timestamp, want)
+	}
+}
+
+func TestProcSampler_NonBrewProcessInspectedOnce(t *testing.T) {
+	root := fakeProc(t, time.Now().Add(-time.Hour))
+	addProc(t, root, 300, 100, "/usr/bin/bash", "bash")
+
+	p, err := NewProcSampler(root)
+	if err != nil {
+		t.Fatalf("NewProcSampler: %v", err)
+	}
+	if n, _ := p.Sample(); n != 0 {
+		t.Fatalf("Sample() queued %d, want 0", n)
+	}
+
+	// Same PID and start time: the cached process is not read again.
+	addProc(t, root, 300, 100, "/opt/homebrew/Cellar/jq/1.7/bin/jq", "jq")
+	if n, _ := p.Sample(); n != 0 {
+		t.Errorf("Sample() re-inspected a known process and queued %d, want 0", n)
+	}
+	if !p.seen[procKey{pid: 300, start: 100}] {
+		t.Error("non-brew process not cached by PID and start time")
+	}
+}
+
+func TestProcSampler_PIDReuseIsNewProcess(t *testing.T) {
+	root := fakeProc(t, time.Now().Add(-time.Hour))
+	addProc(t, root, 200, 100, "/opt/homebrew/Cellar/jq/1.7/bin/jq", "jq")
+
+	p, err := NewProcSampler(root)
+	if err != nil {
+		t.Fatalf("NewProcSampler: %v", err)
+	}
+	p.Sample()
+
+	addProc(t, root, 200, 500, "/opt/homebrew/Cellar/jq/1.7/bin/jq", "jq")
+	if n, _ := p.Sample(); n != 1 {
+		t.Errorf("Sample() after PID reuse queued %d, want 1", n)
+	}
+}
+
+func TestProcSampler_DedupesShimEvents(t *testing.T) {
+	st := newTestStore(t)
+	insertPkg(t, st, "git", []string{"/home/linuxbrew/.linuxbrew/bin/git"})
+
+	btime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	start := btime.Add(10 * time.Minute)
+	if err := st.InsertUsageEvent(&store.UsageEvent{
+		Package:    "git",
+		EventType:  "exec",
+		BinaryPath: "/home/user/.brewprune/bin/git",
+		BinaryName: "git",
+		Timestamp:  start.Add(time.Second),
+	}); err != nil {
+		t.Fatalf("InsertUsageEvent: %v", err)
+	}
+
+	root := fakeProc(t, btime)
+	addProc(t, root, 300, 600*100, "/home/linuxbrew/.linuxbrew/Cellar/git/2.44.0/bin/git", "git", "status")
+
+	p, err := NewProcSampler(root)
+	if err != nil {
+		t.Fatalf("NewProcSampler: %v", err)
+	}
+	p.Sample()
+
+	stats, err := p.Flush(st, time.Now())
+	if err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if stats.Duplicates != 1 || stats.Inserted != 0 {
+		t.Errorf("stats = %+v, want 1 duplicate and 0 inserted", stats)
+	}
+	if n := len(procEvents(t, st, "git")); n != 0 {
+		t.Errorf("got %d proc events, want 0", n)
+	}
+}
+
+func TestProcSampler_InterpretedScript(t *testing.T) {
+	st := newTestStore(t)
+	insertPkg(t, st, "python@3.12", nil)
+	insertPkg(t, st, "poetry", []string{"/home/linuxbrew/.linuxbrew/bin/poetry"})
+
+	root := fakeProc(t, time.Now().Add(-time.Hour))
+	addProc(t, root, 400, 100, "/home/linuxbrew/.linuxbrew/Cellar/python@3.12/3.12.4/bin/python3.12",
+		"python3.12", "/home/linuxbrew/.linuxbrew/bin/poetry", "install")
+
+	p, err := NewProcSampler(root)
+	if err != nil {
+		t.Fatalf("NewProcSampler: %v", err)
+	}
+	p.Sample()
+	if _, err := p.Flush(st, time.Now()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	for _, pkg := range []string{"python@3.12", "poetry"} {
+		if n := len(procEvents(t, st, pkg)); n != 1 {
+			t.Errorf("%s: got %d proc events, want 1", pkg, n)
+		}
+	}
+}
+
+func TestProcSampler_FlushHoldsLaterObservations(t *testing.T) {
+	st := newTestStore(t)
+	insertPkg(t, st, "jq", nil)
+
+	btime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	root := fakeProc(t, btime)
+	addProc(t, root, 500, 1800*100, "/opt/homebrew/Cellar/jq/1.7/bin/jq", "jq")
+
+	p, err := NewProcSampler(root)
+	if err != nil {
+		t.Fatalf("NewProcSampler: %v", err)
+	}
+	p.Sample()
+
+	// Process started 30 minutes after boot; a cutoff before that keeps it queued.
+	stats, err := p.Flush(st, btime.Add(10*time.Minute))
+	if err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if stats.Observed != 0 {
+		t.Errorf("Observed = %d before cutoff, want 0", stats.Observed)
+	}
+
+	stats, err = p.Flush(st, time.Now())
+	if err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if stats.Inserted != 1 {
+		t.Errorf("Inserted = %d after cutoff, want 1", stats.Inserted)
+	}
+}
+
+func TestProcSampler_UnknownPackageSkipped(t *testing.T) {
+	st := newTestStore(t)
+
+	root := fakeProc(t, time.Now().Add(-time.Hour))
+	addProc(t, root, 600, 100, "/opt/homebrew/Cellar/uninstalled/1.0/bin/tool", "tool")
+
+	p, err := NewProcSampler(root)
+	if err != nil {
+		t.Fatalf("NewProcSampler: %v", err)
+	}
+	p.Sample()
+
+	stats, err := p.Flush(st, time.Now())
+	if err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if stats.Unresolved != 1 || stats.Inserted != 0 {
+		t.Errorf("stats = %+v, want 1 unresolved", stats)
+	}
+}
+
+func TestProcSampler_ScannedPrefixes(t *testing.T) {
+	t.Setenv("HOMEBREW_PREFIX", "")
+	st := newTestStore(t)
+	insertPkg(t, st, "jq", []string{"/srv/brew/bin/jq"})
+
+	root := fakeProc(t, time.Now().Add(-time.Hour))
+	addProc(t, root, 700, 100, "/srv/brew/bin/jq", "jq")
+	addProc(t, root, 701, 100, "/opt/homebrew/bin/rg", "rg")
+
+	p, err := NewProcSampler(root)
+	if err != nil {
+		t.Fatalf("NewProcSampler: %v", err)
+	}
+	p.SetPrefixes([]string{"/srv/brew"})
+	if n, err := p.Sample(); err != nil || n != 1 {
+		t.Fatalf("Sample() = %d, %v; want only the process under the scanned prefix", n, err)
+	}
+
+	stats, err := p.Flush(st, time.Now())
+	if err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if stats.Inserted != 1 || len(procEvents(t, st, "jq")) != 1 {
+		t.Errorf("stats = %+v, want the jq process recorded", stats)
+	}
+}
+
+func TestProcSamplerResolve(t *testing.T) {
+	optPathMap := map[string]string{"/opt/homebrew/bin/gls": "coreutils"}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/opt/homebrew/Cellar/git/2.44.0/bin/git", "git"},
+		{"/home/linuxbrew/.linuxbrew/Caskroom/gcloud-cli/latest/google-cloud-sdk/bin/gcloud", "gcloud-cli"},
+		{"/opt/homebrew/bin/gls", "coreutils"},
+		{"/opt/homebrew/opt/node/bin/node", "node"},
+		{"/usr/local/opt/openssl@3/bin/openssl", "openssl@3"},
+		{"/opt/homebrew/bin/unknown", ""},
+		{"/opt/homebrew/Cellar/", ""},
+	}
+	for _, tt := range tests {
+		if got := resolveProcPath(tt.path, defaultBrewPrefixes, optPathMap); got != tt.want {
+			t.Errorf("resolve(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package watcher
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LogRotation configures rotation of the shim usage log by the watcher.
+//
+// Rotation renames usage.log to usage.log.1; shims then create a fresh log
+// on their next append. The rotated segment is drained to completion before
+// the new log is read, and is only deleted (or archived) once it has been
+// quiet for rotationGrace, so entries appended by shims that opened the log
+// just before the rename are not lost.
+type LogRotation struct {
+	// MaxSize rotates the log once it reaches this many bytes; 0 disables
+	// size-based rotation.
+	MaxSize int64
+
+	// MaxAge rotates the log once its oldest entry is older than this; 0
+	// disables age-based rotation.
+	MaxAge time.Duration
+
+	// ArchiveDir, when set, receives each drained segment gzip-compressed
+	// instead of it being deleted.
+	ArchiveDir string
+}
+
+// enabled reports whether r rotates the log at all.
+func (r LogRotation) enabled() bool {
+	return r.MaxSize > 0 || r.MaxAge > 0
+}
+
+// rotatedLogSuffix names the segment a rotated usage log is renamed to.
+const rotatedLogSuffix = ".1"
+
+// rotationGrace is how long a drained segment must go unmodified before it
+// is removed. A shim holds the log open only for a single append, so this
+// comfortably covers writers that opened it before the rename.
+const rotationGrace = time.Second
+
+// rotatedLogPath returns the path of the rotated segment of logPath.
+func rotatedLogPath(logPath string) string {
+	return logPath + rotatedLogSuffix
+}
+
+// selectUsageLog returns the log file to process next: the rotated segment
+// while it is not drained, otherwise the live log.
+func selectUsageLog(logPath string, pos shimPosition) string {
+	segPath := rotatedLogPath(logPath)
+	segInfo, err := os.Stat(segPath)
+	if err != nil || segmentDrained(logPath, segInfo, pos) {
+		return logPath
+	}
+	return segPath
+}
+
+// segmentDrained reports whether the rotated segment described by segInfo
+// needs no further reading: either processing has already moved on to the
+// live log, or the offset is at the segment's end and it has been quiet for
+// rotationGrace.
+func segmentDrained(logPath string, segInfo os.FileInfo, pos shimPosition) bool {
+	if pos.inode == 0 {
+		return false
+	}
+	if live, err := os.Stat(logPath); err == nil && fileInode(live) == pos.inode {
+		return true
+	}
+	return pos.inode == fileInode(segInfo) &&
+		pos.offset >= segInfo.Size() &&
+		time.Since(segInfo.ModTime()) >= rotationGrace
+}
+
+// SetLogRotation sets when the watcher rotates the shim usage log. The zero
+// value disables rotation. It may be called while running.
+func (w *Watcher) SetLogRotation(r LogRotation) {
+	w.mu.Lock()
+	w.rotation = r
+	w.mu.Unlock()
+}
+
+// maintainUsageLog finishes a pending rotation once its segment is drained,
+// or rotates the live log when it is due. It runs after each complete
+// processing run.
+func (w *Watcher) maintainUsageLog() error {
+	w.mu.Lock()
+	r := w.rotation
+	w.mu.Unlock()
+
+	logPath, offsetPath, err := usageLogPaths()
+	if err != nil {
+		return err
+	}
+	segPath := rotatedLogPath(logPath)
+
+	segInfo, err := os.Stat(segPath)
+	if err == nil {
+		pos, err := readShimPosition(offsetPath)
+		if err != nil {
+			return fmt.Errorf("read offset: %w", err)
+		}
+		if !segmentDrained(logPath, segInfo, pos) {
+			if pos.inode == fileInode(segInfo) && pos.offset >= segInfo.Size() {
+				// Read to the end; check again once late writes have settled.
+				time.AfterFunc(rotationGrace, w.RequestFlush)
+			}
+			return nil
+		}
+		if err := finishRotation(segPath, offsetPath, pos, r.ArchiveDir); err != nil {
+			return err
+		}
+		// Entries may be waiting in the live log behind the segment.
+		w.RequestFlush()
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("stat rotated usage log: %w", err)
+	}
+
+	if !r.enabled() {
+		return nil
+	}
+	info, err := os.Stat(logPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("stat usage log: %w", err)
+	}
+	if fileInode(info) == 0 {
+		// Without inodes the offset cannot follow the rename safely.
+		return nil
+	}
+	due, err := rotationDue(logPath, info, r)
+	if err != nil || !due {
+		return err
+	}
+	if err := os.Rename(logPath, segPath); err != nil {
+		return fmt.Errorf("rotate usage log: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "%s brewprune-watch: rotated usage log (%d bytes)\n",
+		time.Now().UTC().Format(time.RFC3339), info.Size())
+	w.RequestFlush()
+	return nil
+}
+
+// rotationDue reports whether the log at logPath has reached r's size or age
+// limit. Age is judged by the timestamp of the first entry.
+func rotationDue(logPath string, info os.FileInfo, r LogRotation) (bool, error) {
+	if r.MaxSize > 0 && info.Size() >= r.MaxSize {
+		return true, nil
+	}
+	if r.MaxAge <= 0 || info.Size() == 0 {
+		return false, nil
+	}
+
+	f, err := os.Open(logPath)
+	if err != nil {
+		return false, fmt.Errorf("open usage log: %w", err)
+	}
+	defer f.Close()
+	first, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("read usage log: %w", err)
+	}
+	ts, _, ok := parseShimLogLine(strings.TrimRight(first, "\r\n"))
+	if !ok {
+		return false, nil
+	}
+	return time.Since(shimTimestamp(ts)) > r.MaxAge, nil
+}
+
+// finishRotation archives the drained segment into archiveDir (or deletes
+// it when archiveDir is empty). If the offset still points into the segment
+// it is reset, so a new log that reuses the segment's inode is read from
+// the start.
+func finishRotation(segPath, offsetPath string, pos shimPosition, archiveDir string) error {
+	segInfo, err := os.Stat(segPath)
+	if err != nil {
+		return fmt.Errorf("stat rotated usage log: %w", err)
+	}
+	if archiveDir != "" {
+		if err := archiveSegment(segPath, archiveDir); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(segPath); err != nil {
+		return fmt.Errorf("remove rotated usage log: %w", err)
+	}
+	if pos.inode == fileInode(segInfo) {
+		if err := writeShimPositionAtomic(offsetPath, shimPosition{}); err != nil {
+			return fmt.Errorf("reset offset: %w", err)
+		}
+	}
+	return nil
+}
+
+// archiveSegment writes a gzip copy of segPath to archiveDir as
+// usage-<UTC time>.log.gz. The copy is written to a temp file and renamed,
+// so a partial archive is never left under the final name.
+func archiveSegment(segPath, archiveDir string) error {
+	if err := os.MkdirAll(archiveDir, 0700); err != nil {
+		return fmt.Errorf("create archive directory: %w", err)
+	}
+
+	src, err := os.Open(segPath)
+	if err != nil {
+		return fmt.Errorf("open rotated usage log: %w", err)
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(archiveDir, ".usage-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create archive: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after the rename
+
+	zw := gzip.NewWriter(tmp)
+	zw.Name = filepath.Base(segPath)
+	if _, err := io.Copy(zw, src); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compress usage log: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compress usage log: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write archive: %w", err)
+	}
+
+	name := "usage-" + time.Now().UTC().Format("20060102T150405.000Z") + ".log.gz"
+	if err := os.Rename(tmp.Name(), filepath.Join(archiveDir, name)); err != nil {
+		return fmt.Errorf("rename archive: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package watcher
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// newRotationWatcher returns an unstarted watcher on a store with jq indexed,
+// rotating with r, and the temp HOME's ~/.brewprune directory.
+func newRotationWatcher(t *testing.T, r LogRotation) (*Watcher, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("usage log rotation needs inode numbers")
+	}
+	home := setTestHome(t)
+	dir := filepath.Join(home, ".brewprune")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	st := newTestStore(t)
+	insertPkg(t, st, "jq", []string{"/opt/homebrew/bin/jq"})
+
+	w, err := New(st)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	w.SetLogRotation(r)
+	return w, dir
+}
+
+// appendLine appends one raw line to the file at path.
+func appendLine(t *testing.T, path, line string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+// ageFile sets the modification time of path past rotationGrace.
+func ageFile(t *testing.T, path string) {
+	t.Helper()
+	old := time.Now().Add(-2 * rotationGrace)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+}
+
+func countEvents(t *testing.T, w *Watcher, pkg string) int {
+	t.Helper()
+	events, err := w.store.GetUsageEvents(pkg, time.Time{})
+	if err != nil {
+		t.Fatalf("GetUsageEvents: %v", err)
+	}
+	return len(events)
+}
+
+func TestRotation_DrainsSegmentBeforeLiveLog(t *testing.T) {
+	w, dir := newRotationWatcher(t, LogRotation{MaxSize: 1})
+	logPath := filepath.Join(dir, "usage.log")
+	segPath := rotatedLogPath(logPath)
+	line := fmt.Sprintf("%d,%s", time.Now().UnixNano(), filepath.Join(dir, "bin", "jq"))
+
+	for i := 0; i < 3; i++ {
+		appendLine(t, logPath, line)
+	}
+	if err := w.runShimLog(); err != nil {
+		t.Fatalf("runShimLog: %v", err)
+	}
+	if n := countEvents(t, w, "jq"); n != 3 {
+		t.Fatalf("got %d events after first run, want 3", n)
+	}
+	if _, err := os.Stat(segPath); err != nil {
+		t.Fatalf("usage log was not rotated: %v", err)
+	}
+	if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+		t.Fatalf("usage.log still present after rotation: %v", err)
+	}
+
+	// A shim that opened the log before the rename appends to the segment;
+	// later shims create a fresh log.
+	appendLine(t, segPath, line)
+	appendLine(t, logPath, line)
+	pending, err := PendingLogBytes()
+	if err != nil {
+		t.Fatalf("PendingLogBytes: %v", err)
+	}
+	if want := int64(2 * (len(line) + 1)); pending != want {
+		t.Errorf("PendingLogBytes = %d, want %d", pending, want)
+	}
+
+	// The segment is drained first and kept during the grace period.
+	if err := w.runShimLog(); err != nil {
+		t.Fatalf("runShimLog: %v", err)
+	}
+	if n := countEvents(t, w, "jq"); n != 4 {
+		t.Fatalf("got %d events after draining segment, want 4", n)
+	}
+	if _, err := os.Stat(segPath); err != nil {
+		t.Fatalf("segment removed within the grace period: %v", err)
+	}
+
+	// Once quiet, the live log is read and the segment removed.
+	ageFile(t, segPath)
+	if err := w.runShimLog(); err != nil {
+		t.Fatalf("runShimLog: %v", err)
+	}
+	if n := countEvents(t, w, "jq"); n != 5 {
+		t.Fatalf("got %d events after reading live log, want 5", n)
+	}
+	if _, err := os.Stat(segPath); !os.IsNotExist(err) {
+		t.Errorf("segment not removed once drained: %v", err)
+	}
+
+	// Nothing is read twice.
+	if err := w.runShimLog(); err != nil {
+		t.Fatalf("runShimLog: %v", err)
+	}
+	if n := countEvents(t, w, "jq"); n != 5 {
+		t.Errorf("got %d events after an idle run, want 5", n)
+	}
+}
+
+func TestRotation_ArchivesSegment(t *testing.T) {
+	archiveDir := filepath.Join(t.TempDir(), "archive")
+	w, dir := newRotationWatcher(t, LogRotation{MaxSize: 1, ArchiveDir: archiveDir})
+	logPath := filepath.Join(dir, "usage.log")
+	line := fmt.Sprintf("%d,%s", time.Now().UnixNano(), filepath.Join(dir, "bin", "jq"))
+
+	appendLine(t, logPath, line)
+	if err := w.runShimLog(); err != nil {
+		t.Fatalf("runShimLog: %v", err)
+	}
+	ageFile(t, rotatedLogPath(logPath))
+	if err := w.runShimLog(); err != nil {
+		t.Fatalf("runShimLog: %v", err)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(archiveDir, "usage-*.log.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("archive files = %v, want exactly one", matches)
+	}
+	f, err := os.Open(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read archive: %v", err)
+	}
+	if string(data) != line+"\n" {
+		t.Errorf("archive content = %q, want %q", data, line+"\n")
+	}
+}
+
+func TestRotationDue(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "usage.log")
+	old := time.Now().Add(-48 * time.Hour)
+	appendLine(t, logPath, fmt.Sprintf("%d,/home/u/.brewprune/bin/jq", old.UnixNano()))
+	appendLine(t, logPath, fmt.Sprintf("%d,/home/u/.brewprune/bin/jq", time.Now().UnixNano()))
+	info, err := os.Stat(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		r    LogRotation
+		want bool
+	}{
+		{"disabled", LogRotation{}, false},
+		{"under size", LogRotation{MaxSize: info.Size() + 1}, false},
+		{"at size", LogRotation{MaxSize: info.Size()}, true},
+		{"younger than max age", LogRotation{MaxAge: 72 * time.Hour}, false},
+		{"older than max age", LogRotation{MaxAge: 24 * time.Hour}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rotationDue(logPath, info, tt.r)
+			if err != nil {
+				t.Fatalf("rotationDue: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("rotationDue = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessUsageLog_ResetsOffsetForDifferentInode(t *testing.T) {
+	w, dir := newRotationWatcher(t, LogRotation{})
+	logPath := filepath.Join(dir, "usage.log")
+	line := fmt.Sprintf("%d,%s", time.Now().UnixNano(), filepath.Join(dir, "bin", "jq"))
+	appendLine(t, logPath, line)
+	appendLine(t, logPath, line)
+
+	info, err := os.Stat(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An offset inside this file but recorded for another one.
+	stale := shimPosition{offset: int64(len(line) + 1), inode: fileInode(info) + 1}
+	if err := writeShimPositionAtomic(filepath.Join(dir, "usage.offset"), stale); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := ProcessUsageLog(w.store)
+	if err != nil {
+		t.Fatalf("ProcessUsageLog: %v", err)
+	}
+	if stats.Inserted != 2 {
+		t.Errorf("Inserted = %d, want 2 (whole file reread)", stats.Inserted)
+	}
+	pos, err := readShimPosition(filepath.Join(dir, "usage.offset"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos.inode != fileInode(info) || pos.offset != info.Size() {
+		t.Errorf("position = %+v, want offset %d inode %d", pos, info.Size(), fileInode(info))
+	}
+}
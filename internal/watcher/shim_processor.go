@@ -7,10 +7,12 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/blackwell-systems/brewprune/internal/shim"
 	"github.com/blackwell-systems/brewprune/internal/store"
 )
 
@@ -29,51 +31,151 @@ type ProcessingStats struct {
 // processed offset, resolves binary names to package names, and batch-inserts
 // usage events into the store in a single transaction.
 //
-// Log format (one entry per line, written by cmd/brewprune-shim):
-//
-//	<unix_nano>,<argv0_path>
-//
-// Example:
+// Log format (one entry per line, written by cmd/brewprune-shim; see
+// shimLogRecord for the v2 fields). v1 and v2 lines may be mixed:
 //
 //	1709012345678901234,/Users/alice/.brewprune/bin/git
+//	2	1709012345678901234	4242	4100	1		/Users/alice/.brewprune/bin/git	/Users/alice/src/app
 //
-// This is designed to be called on the watcher's 30-second ticker. It returns
+// The PID, parent PID, TTY flag, exit code and working directory of v2
+// lines are stored with each event.
+//
+// This is designed to be called by the watcher whenever the log changes. It returns
 // nil (no error) when the log file does not yet exist.
 func ProcessUsageLog(st *store.Store) (ProcessingStats, error) {
-	var stats ProcessingStats
+	return processUsageLog(st, nil)
+}
 
-	homeDir, err := os.UserHomeDir()
+// packageIndex resolves shim log entries to package names. Building it reads
+// every package from the store, so the watcher caches one between runs.
+type packageIndex struct {
+	binaryMap  map[string]string // basename → package (fallback)
+	optPathMap map[string]string // full linked path → package (preferred)
+	binPrefix  map[string]string // basename → prefix the shim runs it from
+	prefixes   []string          // scanned Homebrew prefixes
+	builtAt    time.Time
+}
+
+// buildPackageIndex builds the shim log lookup tables from the store.
+func buildPackageIndex(st *store.Store) (*packageIndex, error) {
+	// Build basename → package name lookup from stored packages (fallback).
+	binaryMap, err := buildBasenameMap(st)
 	if err != nil {
-		return stats, fmt.Errorf("shim_processor: get home dir: %w", err)
+		return nil, fmt.Errorf("shim_processor: build binary map: %w", err)
 	}
 
-	logPath := filepath.Join(homeDir, ".brewprune", "usage.log")
-	offsetPath := filepath.Join(homeDir, ".brewprune", "usage.offset")
+	// Build full opt path → package name lookup (preferred, avoids basename collisions).
+	optPathMap, err := buildOptPathMap(st)
+	if err != nil {
+		return nil, fmt.Errorf("shim_processor: build opt path map: %w", err)
+	}
 
-	// No-op: shim has not been set up yet.
-	if _, err := os.Stat(logPath); os.IsNotExist(err) {
-		return stats, nil
+	// The prefix manifest names the prefix each shim execs into, which
+	// settles commands provided by several prefixes. Best-effort.
+	manifest, _ := shim.ReadPrefixManifest()
+
+	prefixes, err := scannedPrefixes(st, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("shim_processor: %w", err)
 	}
 
-	// Read byte offset from last run (0 if first run).
-	offset, err := readShimOffset(offsetPath)
+	return &packageIndex{
+		binaryMap:  binaryMap,
+		optPathMap: optPathMap,
+		binPrefix:  manifest.Binaries,
+		prefixes:   prefixes,
+		builtAt:    time.Now(),
+	}, nil
+}
+
+// scannedPrefixes returns the Homebrew prefixes brewprune scanned: those in
+// the prefix manifest and those recorded for installed packages, sorted.
+func scannedPrefixes(st *store.Store, manifest *shim.PrefixManifest) ([]string, error) {
+	seen := make(map[string]bool)
+	for _, prefix := range manifest.Prefixes {
+		seen[filepath.Clean(prefix)] = true
+	}
+	packages, err := st.ListPackages()
 	if err != nil {
-		return stats, fmt.Errorf("shim_processor: read offset: %w", err)
+		return nil, fmt.Errorf("list packages: %w", err)
+	}
+	for _, pkg := range packages {
+		if pkg.Prefix != "" {
+			seen[filepath.Clean(pkg.Prefix)] = true
+		}
 	}
 
-	// Build basename → package name lookup from stored packages (fallback).
-	binaryMap, err := buildBasenameMap(st)
+	prefixes := make([]string, 0, len(seen))
+	for prefix := range seen {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes, nil
+}
+
+// empty reports whether no packages were indexed (scan has not run yet).
+func (idx *packageIndex) empty() bool {
+	return len(idx.binaryMap) == 0 && len(idx.optPathMap) == 0
+}
+
+// resolve maps a shimmed command's basename to its package.
+func (idx *packageIndex) resolve(basename string) (string, bool) {
+	// The binary the shim actually runs, per the prefix manifest.
+	if prefix, ok := idx.binPrefix[basename]; ok {
+		if pkg, found := idx.optPathMap[filepath.Join(prefix, "bin", basename)]; found {
+			return pkg, true
+		}
+	}
+
+	// Try full opt path first to avoid basename collisions between formulae.
+	// Apple Silicon Homebrew installs to /opt/homebrew/bin; Intel to /usr/local/bin.
+	pkg, found := idx.optPathMap["/opt/homebrew/bin/"+basename]
+	if !found {
+		pkg, found = idx.optPathMap["/usr/local/bin/"+basename]
+	}
+	if !found {
+		// Fall back to basename-only match for any remaining cases.
+		pkg, found = idx.binaryMap[basename]
+	}
+	if !found {
+		// Also try Linuxbrew prefix.
+		pkg, found = idx.optPathMap["/home/linuxbrew/.linuxbrew/bin/"+basename]
+	}
+	return pkg, found
+}
+
+// processUsageLog implements ProcessUsageLog. idx is a prebuilt package
+// index; when nil, one is built from the store.
+func processUsageLog(st *store.Store, idx *packageIndex) (ProcessingStats, error) {
+	var stats ProcessingStats
+
+	logPath, offsetPath, err := usageLogPaths()
 	if err != nil {
-		return stats, fmt.Errorf("shim_processor: build binary map: %w", err)
+		return stats, fmt.Errorf("shim_processor: %w", err)
 	}
 
-	// Build full opt path → package name lookup (preferred, avoids basename collisions).
-	optPathMap, err := buildOptPathMap(st)
+	// Read the position reached by the last run (zero if first run).
+	pos, err := readShimPosition(offsetPath)
 	if err != nil {
-		return stats, fmt.Errorf("shim_processor: build opt path map: %w", err)
+		return stats, fmt.Errorf("shim_processor: read offset: %w", err)
+	}
+
+	// A rotated segment is drained before the live log is touched.
+	logPath = selectUsageLog(logPath, pos)
+
+	// No-op: shim has not been set up yet.
+	if _, err := os.Stat(logPath); os.IsNotExist(err) {
+		return stats, nil
 	}
+	offset := pos.offset
 
-	noPackagesIndexed := len(binaryMap) == 0 && len(optPathMap) == 0
+	if idx == nil {
+		if idx, err = buildPackageIndex(st); err != nil {
+			return stats, err
+		}
+	}
+
+	noPackagesIndexed := idx.empty()
 	if noPackagesIndexed {
 		log.Printf("shim_processor: warning: no packages indexed yet — run 'brewprune scan' first")
 	}
@@ -91,8 +193,13 @@ func ProcessUsageLog(st *store.Store) (ProcessingStats, error) {
 		return stats, fmt.Errorf("shim_processor: stat log: %w", err)
 	}
 	fileSize := fileInfo.Size()
+	inode := fileInode(fileInfo)
 
-	if offset > fileSize {
+	if pos.inode != 0 && inode != 0 && pos.inode != inode {
+		// The offset belongs to another file: the log was rotated, or this is
+		// the live log after its rotated segment was drained.
+		offset = 0
+	} else if offset > fileSize {
 		// Offset is stale (file was truncated/deleted and recreated)
 		log.Printf("shim_processor: offset %d exceeds file size %d, resetting to 0", offset, fileSize)
 		offset = 0
@@ -120,9 +227,14 @@ func ProcessUsageLog(st *store.Store) (ProcessingStats, error) {
 	type pendingEvent struct {
 		pkg        string
 		binaryPath string
+		binaryName string
 		timestamp  time.Time
+		rec        shimLogRecord
 	}
 	var events []pendingEvent
+	// unresolved holds entries no package claims (pkg is empty); they are
+	// kept for replay once a scan adds the mapping.
+	var unresolved []pendingEvent
 
 	reader := bufio.NewReader(f)
 	// newOffset tracks the byte position in the file after each fully-read line.
@@ -155,7 +267,7 @@ func ProcessUsageLog(st *store.Store) (ProcessingStats, error) {
 			continue
 		}
 
-		tsNano, argv0, ok := parseShimLogLine(line)
+		rec, ok := parseShimLogRecord(line)
 		if !ok {
 			log.Printf("shim_processor: skipping malformed line: %q", line)
 			continue
@@ -163,61 +275,42 @@ func ProcessUsageLog(st *store.Store) (ProcessingStats, error) {
 
 		stats.LinesRead++
 
+		argv0 := rec.argv0
 		basename := filepath.Base(argv0)
 
-		// Try full opt path first to avoid basename collisions between formulae.
-		// Apple Silicon Homebrew installs to /opt/homebrew/bin; Intel to /usr/local/bin.
-		pkg, found := optPathMap["/opt/homebrew/bin/"+basename]
-		if !found {
-			pkg, found = optPathMap["/usr/local/bin/"+basename]
-		}
-		if !found {
-			// Fall back to basename-only match for any remaining cases.
-			pkg, found = binaryMap[basename]
-		}
-		if !found {
-			// Also try Linuxbrew prefix.
-			pkg, found = optPathMap["/home/linuxbrew/.linuxbrew/bin/"+basename]
-		}
+		pkg, found := idx.resolve(basename)
 		if !found {
 			log.Printf("shim_processor: no package found for binary %q (tried opt paths and basename map)", basename)
 			stats.Skipped++
-			continue // Not a managed Homebrew binary.
+			unresolved = append(unresolved, pendingEvent{
+				binaryPath: argv0,
+				binaryName: basename,
+				timestamp:  shimTimestamp(rec.tsNano),
+				rec:        rec,
+			})
+			continue // Not mapped to a package (yet).
 		}
 
 		stats.Resolved++
 
-		// Handle mixed timestamp formats: old self-test entries use seconds,
-		// new shim entries use nanoseconds. Detect by magnitude:
-		// - Unix seconds for 2026: ~1.77 × 10^9 (10 digits)
-		// - Unix nanos for 2026:   ~1.77 × 10^18 (19 digits)
-		// Threshold: 10^15 (year 2001 in seconds, year 1970 in nanos)
-		var ts time.Time
-		if tsNano < 1_000_000_000_000_000 {
-			// Legacy format: seconds since epoch
-			ts = time.Unix(tsNano, 0)
-		} else {
-			// Current format: nanoseconds since epoch
-			ts = time.Unix(0, tsNano)
-		}
-
 		events = append(events, pendingEvent{
 			pkg:        pkg,
 			binaryPath: argv0,
-			timestamp:  ts,
+			binaryName: basename,
+			timestamp:  shimTimestamp(rec.tsNano),
+			rec:        rec,
 		})
 	}
 
-	if len(events) == 0 {
-		if noPackagesIndexed {
-			// No packages indexed — retain offset so entries are retried next tick
-			// after 'brewprune scan' has populated the database.
-			stats.SkippedNoIndex = stats.LinesRead
-			return stats, nil
-		}
-		// Packages are indexed but nothing matched — advance offset to skip unknowns.
-		if newOffset != offset {
-			return stats, writeShimOffsetAtomic(offsetPath, newOffset)
+	if noPackagesIndexed {
+		// No packages indexed — retain offset so entries are retried next tick
+		// after 'brewprune scan' has populated the database.
+		stats.SkippedNoIndex = stats.LinesRead
+		return stats, nil
+	}
+	if len(events) == 0 && len(unresolved) == 0 {
+		if newOffset != offset || inode != pos.inode {
+			return stats, writeShimPositionAtomic(offsetPath, shimPosition{offset: newOffset, inode: inode})
 		}
 		return stats, nil
 	}
@@ -228,7 +321,7 @@ func ProcessUsageLog(st *store.Store) (ProcessingStats, error) {
 		return stats, fmt.Errorf("shim_processor: begin transaction: %w", err)
 	}
 
-	stmt, err := tx.Prepare(`INSERT INTO usage_events (package, event_type, binary_path, timestamp) VALUES (?, ?, ?, ?)`)
+	stmt, err := tx.Prepare(`INSERT INTO usage_events (package, event_type, binary_path, binary_name, timestamp, pid, ppid, tty, exit_code, cwd) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		tx.Rollback() //nolint:errcheck
 		return stats, fmt.Errorf("shim_processor: prepare statement: %w", err)
@@ -240,19 +333,88 @@ func ProcessUsageLog(st *store.Store) (ProcessingStats, error) {
 		if isConfigProbe(filepath.Base(e.binaryPath)) {
 			eventType = "probe"
 		}
-		if _, err := stmt.Exec(e.pkg, eventType, e.binaryPath, e.timestamp.Format("2006-01-02T15:04:05Z07:00")); err != nil {
+		args := append([]any{e.pkg, eventType, e.binaryPath, e.binaryName, e.timestamp.Format("2006-01-02T15:04:05Z07:00")}, e.rec.columns()...)
+		if _, err := stmt.Exec(args...); err != nil {
 			tx.Rollback() //nolint:errcheck
 			return stats, fmt.Errorf("shim_processor: insert event for %s: %w", e.pkg, err)
 		}
 		stats.Inserted++
 	}
 
+	if len(unresolved) > 0 {
+		unresolvedStmt, err := tx.Prepare(`INSERT INTO unresolved_events (binary_name, binary_path, timestamp, pid, ppid, tty, exit_code, cwd) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+		if err != nil {
+			tx.Rollback() //nolint:errcheck
+			return stats, fmt.Errorf("shim_processor: prepare unresolved statement: %w", err)
+		}
+		defer unresolvedStmt.Close()
+
+		for _, e := range unresolved {
+			args := append([]any{e.binaryName, e.binaryPath, e.timestamp.Format("2006-01-02T15:04:05Z07:00")}, e.rec.columns()...)
+			if _, err := unresolvedStmt.Exec(args...); err != nil {
+				tx.Rollback() //nolint:errcheck
+				return stats, fmt.Errorf("shim_processor: record unresolved %s: %w", e.binaryName, err)
+			}
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return stats, fmt.Errorf("shim_processor: commit: %w", err)
 	}
 
 	// Only advance the offset after successful commit — crash-safe.
-	return stats, writeShimOffsetAtomic(offsetPath, newOffset)
+	return stats, writeShimPositionAtomic(offsetPath, shimPosition{offset: newOffset, inode: inode})
+}
+
+// usageLogPaths returns the paths of the shim usage log and its offset file.
+func usageLogPaths() (logPath, offsetPath string, err error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", fmt.Errorf("get home dir: %w", err)
+	}
+	dir := filepath.Join(homeDir, ".brewprune")
+	return filepath.Join(dir, "usage.log"), filepath.Join(dir, "usage.offset"), nil
+}
+
+// PendingLogBytes returns how many bytes of the shim usage log, including a
+// rotated segment still being drained, have not been processed yet. It
+// returns 0 when the log does not exist.
+func PendingLogBytes() (int64, error) {
+	logPath, offsetPath, err := usageLogPaths()
+	if err != nil {
+		return 0, err
+	}
+	pos, err := readShimPosition(offsetPath)
+	if err != nil {
+		return 0, fmt.Errorf("read offset: %w", err)
+	}
+
+	var pending int64
+	current := selectUsageLog(logPath, pos)
+	for _, path := range []string{rotatedLogPath(logPath), logPath} {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("stat usage log: %w", err)
+		}
+		switch {
+		case path != current && path != logPath:
+			// Rotated segment already drained.
+		case path != current:
+			// Live log waiting behind the rotated segment.
+			pending += info.Size()
+		case pos.inode != 0 && fileInode(info) != 0 && pos.inode != fileInode(info):
+			pending += info.Size()
+		case pos.offset > info.Size():
+			// Stale offset after truncation: the whole file will be reread.
+			pending += info.Size()
+		default:
+			pending += info.Size() - pos.offset
+		}
+	}
+	return pending, nil
 }
 
 // buildBasenameMap builds a map of binary basename → package name from
@@ -319,47 +481,75 @@ func buildOptPathMap(st *store.Store) (map[string]string, error) {
 	return m, nil
 }
 
-// parseShimLogLine parses a line of the form "<unix_nano>,<argv0_path>".
-// Returns (0, "", false) on any parse error.
+// parseShimLogLine parses a v1 or v2 shim log line and returns its timestamp
+// and argv0. Returns (0, "", false) on any parse error.
 func parseShimLogLine(line string) (int64, string, bool) {
-	idx := strings.IndexByte(line, ',')
-	if idx <= 0 || idx >= len(line)-1 {
+	rec, ok := parseShimLogRecord(line)
+	if !ok {
 		return 0, "", false
 	}
+	return rec.tsNano, rec.argv0, true
+}
 
-	tsStr := line[:idx]
-	argv0 := line[idx+1:]
-
-	ts, err := strconv.ParseInt(tsStr, 10, 64)
-	if err != nil || ts <= 0 {
-		return 0, "", false
-	}
-	if argv0 == "" {
-		return 0, "", false
+// shimTimestamp converts a shim log timestamp to a time. Old self-test
+// entries use seconds, shim entries use nanoseconds. Detect by magnitude:
+//   - Unix seconds for 2026: ~1.77 × 10^9 (10 digits)
+//   - Unix nanos for 2026:   ~1.77 × 10^18 (19 digits)
+//
+// Threshold: 10^15 (year 2001 in seconds, year 1970 in nanos).
+func shimTimestamp(ts int64) time.Time {
+	if ts < 1_000_000_000_000_000 {
+		// Legacy format: seconds since epoch
+		return time.Unix(ts, 0)
 	}
+	// Current format: nanoseconds since epoch
+	return time.Unix(0, ts)
+}
 
-	return ts, argv0, true
+// shimPosition is the processing position stored in the offset file: a byte
+// offset into the log file with the given inode. The inode tells whether
+// the offset still applies after the log is rotated; it is 0 in offset files
+// written before rotation existed and on platforms without inodes.
+type shimPosition struct {
+	offset int64
+	inode  uint64
 }
 
-// readShimOffset reads the byte offset from the offset tracking file.
-// Returns 0 if the file does not exist.
-func readShimOffset(offsetPath string) (int64, error) {
+// readShimPosition reads the position from the offset tracking file, in the
+// form "<offset> <inode>" or the legacy "<offset>". Returns the zero position
+// if the file does not exist.
+func readShimPosition(offsetPath string) (shimPosition, error) {
+	var pos shimPosition
 	data, err := os.ReadFile(offsetPath)
 	if os.IsNotExist(err) {
-		return 0, nil
+		return pos, nil
 	}
 	if err != nil {
-		return 0, err
+		return pos, err
 	}
-	s := strings.TrimSpace(string(data))
-	if s == "" {
-		return 0, nil
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return pos, nil
 	}
-	offset, err := strconv.ParseInt(s, 10, 64)
-	if err != nil {
-		return 0, fmt.Errorf("parse offset %q: %w", s, err)
+	if len(fields) > 2 {
+		return pos, fmt.Errorf("parse offset %q: too many fields", strings.TrimSpace(string(data)))
+	}
+	if pos.offset, err = strconv.ParseInt(fields[0], 10, 64); err != nil {
+		return pos, fmt.Errorf("parse offset %q: %w", fields[0], err)
+	}
+	if len(fields) == 2 {
+		if pos.inode, err = strconv.ParseUint(fields[1], 10, 64); err != nil {
+			return pos, fmt.Errorf("parse offset inode %q: %w", fields[1], err)
+		}
 	}
-	return offset, nil
+	return pos, nil
+}
+
+// readShimOffset reads the byte offset from the offset tracking file.
+// Returns 0 if the file does not exist.
+func readShimOffset(offsetPath string) (int64, error) {
+	pos, err := readShimPosition(offsetPath)
+	return pos.offset, err
 }
 
 // isConfigProbe reports whether name is a build-system compiler-flag probe
@@ -369,13 +559,23 @@ func isConfigProbe(name string) bool {
 	return name == "pkg-config" || strings.HasSuffix(name, "-config")
 }
 
-// writeShimOffsetAtomic writes newOffset to offsetPath via a temp-file rename,
-// ensuring the update is atomic and crash-safe.
+// writeShimOffsetAtomic writes newOffset to offsetPath without an inode, as
+// offset files predating log rotation did.
 func writeShimOffsetAtomic(offsetPath string, newOffset int64) error {
+	return writeShimPositionAtomic(offsetPath, shimPosition{offset: newOffset})
+}
+
+// writeShimPositionAtomic writes pos to offsetPath via a temp-file rename,
+// ensuring the update is atomic and crash-safe.
+func writeShimPositionAtomic(offsetPath string, pos shimPosition) error {
 	dir := filepath.Dir(offsetPath)
 	tmpPath := filepath.Join(dir, ".offset.tmp")
 
-	if err := os.WriteFile(tmpPath, []byte(strconv.FormatInt(newOffset, 10)), 0600); err != nil {
+	data := strconv.FormatInt(pos.offset, 10)
+	if pos.inode != 0 {
+		data += " " + strconv.FormatUint(pos.inode, 10)
+	}
+	if err := os.WriteFile(tmpPath, []byte(data), 0600); err != nil {
 		return fmt.Errorf("write temp offset file: %w", err)
 	}
 	if err := os.Rename(tmpPath, offsetPath); err != nil {
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/blackwell-systems/brewprune/internal/brew"
+	"github.com/blackwell-systems/brewprune/internal/shim"
 	"github.com/blackwell-systems/brewprune/internal/store"
 )
 
@@ -122,6 +123,41 @@ func TestWriteAndReadShimOffset(t *testing.T) {
 	}
 }
 
+func TestReadShimPosition_Formats(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "usage.offset")
+
+	// Legacy offset files hold only the offset.
+	if err := os.WriteFile(path, []byte("1234\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	pos, err := readShimPosition(path)
+	if err != nil {
+		t.Fatalf("readShimPosition(legacy): %v", err)
+	}
+	if pos != (shimPosition{offset: 1234}) {
+		t.Errorf("legacy position = %+v, want offset 1234 and no inode", pos)
+	}
+
+	if err := writeShimPositionAtomic(path, shimPosition{offset: 42, inode: 9001}); err != nil {
+		t.Fatalf("writeShimPositionAtomic: %v", err)
+	}
+	pos, err = readShimPosition(path)
+	if err != nil {
+		t.Fatalf("readShimPosition: %v", err)
+	}
+	if pos != (shimPosition{offset: 42, inode: 9001}) {
+		t.Errorf("position = %+v, want offset 42 inode 9001", pos)
+	}
+
+	if err := os.WriteFile(path, []byte("1 2 3"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readShimPosition(path); err == nil {
+		t.Error("readShimPosition accepted three fields, want error")
+	}
+}
+
 func TestWriteShimOffsetAtomic_IsCrashSafe(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "usage.offset")
@@ -593,6 +629,43 @@ func TestProcessUsageLog_LinuxbrewPathResolution(t *testing.T) {
 	}
 }
 
+func TestProcessUsageLog_PrefixManifestResolution(t *testing.T) {
+	st := newTestStore(t)
+
+	// Both prefixes provide gcc-14; the Intel one is what the shim runs.
+	insertPkg(t, st, "gcc", []string{"/opt/homebrew/bin/gcc-14"})
+	insertPkg(t, st, "gcc@14", []string{"/usr/local/bin/gcc-14"})
+
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+	dir := filepath.Join(tmpHome, ".brewprune")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	manifest := "prefix\t/opt/homebrew\nprefix\t/usr/local\nbin\tgcc-14\t/usr/local\n"
+	if err := os.WriteFile(filepath.Join(dir, "prefixes"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "usage.log"), []byte("1709012345678901234,/home/u/.brewprune/bin/gcc-14\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ProcessUsageLog(st); err != nil {
+		t.Fatalf("ProcessUsageLog: %v", err)
+	}
+
+	since := time.Unix(0, 0)
+	for pkg, want := range map[string]int{"gcc@14": 1, "gcc": 0} {
+		count, err := st.GetUsageEventCountSince(pkg, since)
+		if err != nil {
+			t.Fatalf("GetUsageEventCountSince(%s): %v", pkg, err)
+		}
+		if count != want {
+			t.Errorf("GetUsageEventCountSince(%s) = %d, want %d", pkg, count, want)
+		}
+	}
+}
+
 // ── ProcessUsageLog — offset not advanced on insert failure ───────────────────
 
 // TestProcessUsageLog_OffsetAdvancesAfterInsert verifies that the file offset is
@@ -1011,3 +1084,19 @@ func TestProcessUsageLogStats(t *testing.T) {
 		t.Errorf("stats.Resolved = %d, want >= 0", stats.Resolved)
 	}
 }
+
+func TestScannedPrefixes(t *testing.T) {
+	st := newTestStore(t)
+	if err := st.InsertPackage(&brew.Package{Name: "jq", InstalledAt: time.Now(), Prefix: "/srv/brew/"}); err != nil {
+		t.Fatalf("InsertPackage: %v", err)
+	}
+	insertPkg(t, st, "rg", nil)
+
+	got, err := scannedPrefixes(st, &shim.PrefixManifest{Prefixes: []string{"/opt/homebrew", "/srv/brew"}})
+	if err != nil {
+		t.Fatalf("scannedPrefixes: %v", err)
+	}
+	if strings.Join(got, ",") != "/opt/homebrew,/srv/brew" {
+		t.Errorf("scannedPrefixes() = %v, want [/opt/homebrew /srv/brew]", got)
+	}
+}
@@ -0,0 +1,179 @@
+package watcher
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// shimLogRecord is one parsed line of the shim usage log.
+//
+// Two line formats are written by cmd/brewprune-shim and may be mixed in one
+// file, since a log outlives shim upgrades:
+//
+//	v1: <unix_nano>,<argv0>
+//	v2: 2<TAB><unix_nano><TAB><pid><TAB><ppid><TAB><tty><TAB><exit><TAB><argv0><TAB><cwd>
+//
+// A v2 line starts with its version byte and a tab, which no v1 line can
+// (v1 timestamps are all digits up to the comma). In v2, tty is 1 or 0 for
+// whether stdin is a terminal and empty when unknown; exit is the exit code
+// and is empty when unknown, which it always is for the shim itself since
+// it execs the real binary; cwd is empty when not recorded. argv0 and cwd
+// escape backslash, tab, newline and carriage return as \\, \t, \n and \r.
+// Fields after cwd are ignored, so later additions stay readable by this
+// parser.
+type shimLogRecord struct {
+	version  int
+	tsNano   int64
+	argv0    string
+	pid      int
+	ppid     int
+	tty      *bool
+	exitCode *int
+	cwd      string
+}
+
+// shimLogV2Fields is the number of fields in a v2 line, version included.
+const shimLogV2Fields = 8
+
+// parseShimLogRecord parses a v1 or v2 shim log line without its trailing
+// newline. It reports false for malformed lines.
+func parseShimLogRecord(line string) (shimLogRecord, bool) {
+	if strings.HasPrefix(line, "2\t") {
+		return parseShimLogV2(line)
+	}
+	return parseShimLogV1(line)
+}
+
+// parseShimLogV1 parses a line of the form "<unix_nano>,<argv0_path>".
+func parseShimLogV1(line string) (shimLogRecord, bool) {
+	idx := strings.IndexByte(line, ',')
+	if idx <= 0 || idx >= len(line)-1 {
+		return shimLogRecord{}, false
+	}
+
+	ts, ok := parseShimTimestamp(line[:idx])
+	if !ok {
+		return shimLogRecord{}, false
+	}
+	return shimLogRecord{version: 1, tsNano: ts, argv0: line[idx+1:]}, true
+}
+
+// parseShimLogV2 parses a tab-separated v2 line.
+func parseShimLogV2(line string) (shimLogRecord, bool) {
+	fields := strings.Split(line, "\t")
+	if len(fields) < shimLogV2Fields {
+		return shimLogRecord{}, false
+	}
+
+	rec := shimLogRecord{version: 2}
+	var ok bool
+	if rec.tsNano, ok = parseShimTimestamp(fields[1]); !ok {
+		return shimLogRecord{}, false
+	}
+	if rec.pid, ok = parseShimID(fields[2]); !ok {
+		return shimLogRecord{}, false
+	}
+	if rec.ppid, ok = parseShimID(fields[3]); !ok {
+		return shimLogRecord{}, false
+	}
+	switch fields[4] {
+	case "":
+	case "0", "1":
+		tty := fields[4] == "1"
+		rec.tty = &tty
+	default:
+		return shimLogRecord{}, false
+	}
+	if fields[5] != "" {
+		code, err := strconv.Atoi(fields[5])
+		if err != nil || code < 0 || code > 255 {
+			return shimLogRecord{}, false
+		}
+		rec.exitCode = &code
+	}
+	if rec.argv0, ok = unescapeShimLogField(fields[6]); !ok || rec.argv0 == "" {
+		return shimLogRecord{}, false
+	}
+	if rec.cwd, ok = unescapeShimLogField(fields[7]); !ok {
+		return shimLogRecord{}, false
+	}
+	return rec, true
+}
+
+// columns returns the pid, ppid, tty, exit_code and cwd column values for
+// the record, NULL where the line did not carry them.
+func (r shimLogRecord) columns() []any {
+	var tty, exitCode sql.NullInt64
+	if r.tty != nil {
+		tty = sql.NullInt64{Valid: true}
+		if *r.tty {
+			tty.Int64 = 1
+		}
+	}
+	if r.exitCode != nil {
+		exitCode = sql.NullInt64{Int64: int64(*r.exitCode), Valid: true}
+	}
+	return []any{
+		sql.NullInt64{Int64: int64(r.pid), Valid: r.pid > 0},
+		sql.NullInt64{Int64: int64(r.ppid), Valid: r.ppid > 0},
+		tty,
+		exitCode,
+		sql.NullString{String: r.cwd, Valid: r.cwd != ""},
+	}
+}
+
+// parseShimTimestamp parses a positive decimal timestamp.
+func parseShimTimestamp(s string) (int64, bool) {
+	ts, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || ts <= 0 {
+		return 0, false
+	}
+	return ts, true
+}
+
+// parseShimID parses a process ID field, which is empty (0) when unknown.
+func parseShimID(s string) (int, bool) {
+	if s == "" {
+		return 0, true
+	}
+	id, err := strconv.Atoi(s)
+	if err != nil || id < 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// unescapeShimLogField reverses the escaping the shim applies to v2 path
+// fields. It reports false for an unknown escape or a trailing backslash.
+func unescapeShimLogField(s string) (string, bool) {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s, true
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", false
+		}
+		switch s[i] {
+		case '\\':
+			b.WriteByte('\\')
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
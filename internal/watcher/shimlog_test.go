@@ -0,0 +1,229 @@
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// formatShimLogV2 builds a v2 line the way cmd/brewprune-shim writes it.
+func formatShimLogV2(rec shimLogRecord) string {
+	id := func(n int) string {
+		if n == 0 {
+			return ""
+		}
+		return fmt.Sprint(n)
+	}
+	tty := ""
+	if rec.tty != nil {
+		tty = "0"
+		if *rec.tty {
+			tty = "1"
+		}
+	}
+	exit := ""
+	if rec.exitCode != nil {
+		exit = fmt.Sprint(*rec.exitCode)
+	}
+	esc := strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+	return strings.Join([]string{"2", fmt.Sprint(rec.tsNano), id(rec.pid), id(rec.ppid),
+		tty, exit, esc.Replace(rec.argv0), esc.Replace(rec.cwd)}, "\t")
+}
+
+func TestParseShimLogRecord_V1(t *testing.T) {
+	rec, ok := parseShimLogRecord("1709012345678901234,/Users/alice/.brewprune/bin/git")
+	if !ok {
+		t.Fatal("expected ok=true")
+	}
+	if rec.version != 1 || rec.tsNano != 1709012345678901234 || rec.argv0 != "/Users/alice/.brewprune/bin/git" {
+		t.Errorf("record = %+v", rec)
+	}
+	if rec.pid != 0 || rec.ppid != 0 || rec.tty != nil || rec.exitCode != nil || rec.cwd != "" {
+		t.Errorf("v1 record has v2 fields set: %+v", rec)
+	}
+}
+
+func TestParseShimLogRecord_V2(t *testing.T) {
+	rec, ok := parseShimLogRecord("2\t1709012345678901234\t4242\t4100\t1\t3\t/Users/alice/.brewprune/bin/git\t/Users/alice/src/my\\tapp")
+	if !ok {
+		t.Fatal("expected ok=true")
+	}
+	if rec.version != 2 || rec.tsNano != 1709012345678901234 || rec.argv0 != "/Users/alice/.brewprune/bin/git" {
+		t.Errorf("record = %+v", rec)
+	}
+	if rec.pid != 4242 || rec.ppid != 4100 {
+		t.Errorf("pid, ppid = %d, %d; want 4242, 4100", rec.pid, rec.ppid)
+	}
+	if rec.tty == nil || !*rec.tty {
+		t.Errorf("tty = %v, want true", rec.tty)
+	}
+	if rec.exitCode == nil || *rec.exitCode != 3 {
+		t.Errorf("exitCode = %v, want 3", rec.exitCode)
+	}
+	if rec.cwd != "/Users/alice/src/my\tapp" {
+		t.Errorf("cwd = %q, want unescaped tab", rec.cwd)
+	}
+}
+
+func TestParseShimLogRecord_V2OptionalFields(t *testing.T) {
+	rec, ok := parseShimLogRecord("2\t1709012345678901234\t\t\t\t\t/home/u/.brewprune/bin/jq\t\tfuture\tfields")
+	if !ok {
+		t.Fatal("expected ok=true for empty optional fields and trailing extras")
+	}
+	if rec.pid != 0 || rec.ppid != 0 || rec.tty != nil || rec.exitCode != nil || rec.cwd != "" {
+		t.Errorf("record = %+v, want optional fields unset", rec)
+	}
+}
+
+func TestParseShimLogRecord_Malformed(t *testing.T) {
+	for _, line := range []string{
+		"2\t1709012345678901234\t1\t1\t0\t\t/bin/git", // too few fields
+		"2\t\t1\t1\t0\t\t/bin/git\t",                  // no timestamp
+		"2\t-5\t1\t1\t0\t\t/bin/git\t",
+		"2\t1709012345678901234\tx\t1\t0\t\t/bin/git\t",
+		"2\t1709012345678901234\t1\t-1\t0\t\t/bin/git\t",
+		"2\t1709012345678901234\t1\t1\tyes\t\t/bin/git\t",
+		"2\t1709012345678901234\t1\t1\t0\t256\t/bin/git\t",
+		"2\t1709012345678901234\t1\t1\t0\t\t\t/tmp", // empty argv0
+		"2\t1709012345678901234\t1\t1\t0\t\t/bin/gi\\t\\\t/tmp",
+		"2\t1709012345678901234\t1\t1\t0\t\t/bin/git\t/tmp\\x",
+		"3\t1709012345678901234\t1\t1\t0\t\t/bin/git\t", // unknown version, not v1 either
+	} {
+		if rec, ok := parseShimLogRecord(line); ok {
+			t.Errorf("parseShimLogRecord(%q) = %+v, want malformed", line, rec)
+		}
+	}
+}
+
+// TestProcessUsageLog_MixedVersions verifies that v1 and v2 lines in one log
+// are all recorded, and that v2 process fields reach the stored events,
+// including events replayed from unresolved_events.
+func TestProcessUsageLog_MixedVersions(t *testing.T) {
+	st := newTestStore(t)
+	insertPkg(t, st, "git", []string{"/opt/homebrew/bin/git"})
+
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+	if err := os.MkdirAll(filepath.Join(tmpHome, ".brewprune"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	tty := false
+	lines := fmt.Sprintf("%d,/home/u/.brewprune/bin/git\n", now.Add(-2*time.Minute).UnixNano()) +
+		formatShimLogV2(shimLogRecord{tsNano: now.Add(-time.Minute).UnixNano(), argv0: "/home/u/.brewprune/bin/git",
+			pid: 4242, ppid: 4100, tty: &tty, cwd: "/home/u/src/app"}) + "\n" +
+		formatShimLogV2(shimLogRecord{tsNano: now.UnixNano(), argv0: "/home/u/.brewprune/bin/jq",
+			pid: 4343, ppid: 4100, cwd: "/home/u/src/app"}) + "\n"
+	if err := os.WriteFile(filepath.Join(tmpHome, ".brewprune", "usage.log"), []byte(lines), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := ProcessUsageLog(st)
+	if err != nil {
+		t.Fatalf("ProcessUsageLog: %v", err)
+	}
+	if stats.LinesRead != 3 || stats.Inserted != 2 || stats.Skipped != 1 {
+		t.Errorf("stats = %+v, want 3 read, 2 inserted, 1 skipped", stats)
+	}
+
+	events, err := st.GetUsageEvents("git", time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("GetUsageEvents: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("git events = %d, want 2", len(events))
+	}
+	v2, v1 := events[0], events[1] // newest first
+	if v2.PID != 4242 || v2.PPID != 4100 || v2.Cwd != "/home/u/src/app" || v2.TTY == nil || *v2.TTY || v2.ExitCode != nil {
+		t.Errorf("v2 event = %+v, want pid 4242, ppid 4100, cwd, tty false, no exit code", v2)
+	}
+	if v1.PID != 0 || v1.Cwd != "" || v1.TTY != nil {
+		t.Errorf("v1 event = %+v, want process fields unset", v1)
+	}
+
+	// The unresolved jq entry keeps its fields when a scan maps it later.
+	insertPkg(t, st, "jq", []string{"/opt/homebrew/bin/jq"})
+	if _, err := st.ReplayUnresolvedEvents("jq", "jq", "exec"); err != nil {
+		t.Fatalf("ReplayUnresolvedEvents: %v", err)
+	}
+	events, err = st.GetUsageEvents("jq", time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("GetUsageEvents: %v", err)
+	}
+	if len(events) != 1 || events[0].PID != 4343 || events[0].Cwd != "/home/u/src/app" {
+		t.Errorf("replayed jq events = %+v, want pid 4343 with cwd", events)
+	}
+}
+
+// FuzzParseShimLogRecord checks that the parser never panics and that every
+// line it accepts yields a usable entry.
+func FuzzParseShimLogRecord(f *testing.F) {
+	for _, seed := range []string{
+		"1709012345678901234,/Users/alice/.brewprune/bin/git",
+		"1234567890,/bin/git",
+		",/bin/git",
+		"2\t1709012345678901234\t4242\t4100\t1\t\t/Users/alice/.brewprune/bin/git\t/Users/alice/src",
+		"2\t1709012345678901234\t\t\t\t\t/bin/jq\t",
+		"2\t1709012345678901234\t1\t1\t0\t0\t/bin/a\\\\b\\tc\t/x\\ny\textra",
+		"2\t1\t1\t1\t1\t1\t\\\t\\",
+		"",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		rec, ok := parseShimLogRecord(line)
+		if !ok {
+			return
+		}
+		if rec.tsNano <= 0 || rec.argv0 == "" || rec.pid < 0 || rec.ppid < 0 {
+			t.Fatalf("accepted %q as unusable record %+v", line, rec)
+		}
+		if rec.version != 1 && rec.version != 2 {
+			t.Fatalf("accepted %q with version %d", line, rec.version)
+		}
+		if rec.exitCode != nil && (*rec.exitCode < 0 || *rec.exitCode > 255) {
+			t.Fatalf("accepted %q with exit code %d", line, *rec.exitCode)
+		}
+		// The legacy accessor agrees with the record parser.
+		ts, argv0, ok := parseShimLogLine(line)
+		if !ok || ts != rec.tsNano || argv0 != rec.argv0 {
+			t.Fatalf("parseShimLogLine(%q) = %d, %q, %v; record %+v", line, ts, argv0, ok, rec)
+		}
+	})
+}
+
+// FuzzShimLogV2RoundTrip checks that any argv0 and cwd the shim escapes into
+// a v2 line parse back unchanged, whatever bytes they contain.
+func FuzzShimLogV2RoundTrip(f *testing.F) {
+	f.Add("/Users/alice/.brewprune/bin/git", "/Users/alice/src", 4242, 4100, true, 0)
+	f.Add("/tmp/we\tird\\bin\n", "C:\\dir\r\n", 1, 0, false, -1)
+	f.Add("x", "", 0, 0, false, 255)
+	f.Fuzz(func(t *testing.T, argv0, cwd string, pid, ppid int, tty bool, exit int) {
+		if argv0 == "" || pid < 0 || ppid < 0 {
+			return
+		}
+		want := shimLogRecord{version: 2, tsNano: 1709012345678901234, argv0: argv0, cwd: cwd,
+			pid: pid, ppid: ppid, tty: &tty}
+		if exit >= 0 && exit <= 255 {
+			want.exitCode = &exit
+		}
+
+		line := formatShimLogV2(want)
+		if strings.ContainsAny(line, "\n\r") {
+			t.Fatalf("v2 line %q contains a line break", line)
+		}
+		got, ok := parseShimLogRecord(line)
+		if !ok {
+			t.Fatalf("parseShimLogRecord(%q) failed", line)
+		}
+		if got.argv0 != want.argv0 || got.cwd != want.cwd || got.pid != want.pid || got.ppid != want.ppid ||
+			got.tty == nil || *got.tty != tty || (got.exitCode == nil) != (want.exitCode == nil) ||
+			(got.exitCode != nil && *got.exitCode != *want.exitCode) {
+			t.Fatalf("round trip of %+v via %q = %+v", want, line, got)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package watcher
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blackwell-systems/brewprune/internal/store"
+)
+
+// unresolvedRetention is how long shim log entries that no package claims
+// are kept for replay.
+const unresolvedRetention = 90 * 24 * time.Hour
+
+// ReplayStats summarises a ReplayUnresolvedEvents run.
+type ReplayStats struct {
+	Commands int // commands that now resolve to a package
+	Replayed int // events moved into usage_events
+	Pruned   int // events dropped after unresolvedRetention
+}
+
+// ReplayUnresolvedEvents resolves stored unresolved shim log entries against
+// the current package index and moves those that now map to a package into
+// usage_events, e.g. after a scan picked up a new alias or a renamed
+// formula. Entries older than unresolvedRetention are dropped.
+func ReplayUnresolvedEvents(st *store.Store) (ReplayStats, error) {
+	var stats ReplayStats
+
+	pruned, err := st.PruneUnresolvedEvents(time.Now().Add(-unresolvedRetention))
+	if err != nil {
+		return stats, err
+	}
+	stats.Pruned = pruned
+
+	commands, err := st.GetUnresolvedCommands(0)
+	if err != nil {
+		return stats, err
+	}
+	if len(commands) == 0 {
+		return stats, nil
+	}
+
+	idx, err := buildPackageIndex(st)
+	if err != nil {
+		return stats, err
+	}
+	if idx.empty() {
+		return stats, nil
+	}
+
+	for _, c := range commands {
+		pkg, found := idx.resolve(c.BinaryName)
+		if !found {
+			continue
+		}
+		eventType := "exec"
+		if isConfigProbe(c.BinaryName) {
+			eventType = "probe"
+		}
+		n, err := st.ReplayUnresolvedEvents(c.BinaryName, pkg, eventType)
+		if err != nil {
+			return stats, fmt.Errorf("replay %s: %w", c.BinaryName, err)
+		}
+		stats.Commands++
+		stats.Replayed += n
+	}
+	return stats, nil
+}
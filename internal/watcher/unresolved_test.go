@@ -0,0 +1,105 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProcessUsageLog_RecordsUnresolvedAndReplays(t *testing.T) {
+	home := setTestHome(t)
+	dir := filepath.Join(home, ".brewprune")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	st := newTestStore(t)
+	insertPkg(t, st, "jq", []string{"/opt/homebrew/bin/jq"})
+
+	appendShimLine(t, home, "jq")
+	appendShimLine(t, home, "ll")
+	appendShimLine(t, home, "ll")
+
+	stats, err := ProcessUsageLog(st)
+	if err != nil {
+		t.Fatalf("ProcessUsageLog: %v", err)
+	}
+	if stats.Inserted != 1 || stats.Skipped != 2 {
+		t.Fatalf("stats = %+v, want 1 inserted and 2 skipped", stats)
+	}
+
+	commands, err := st.GetUnresolvedCommands(0)
+	if err != nil {
+		t.Fatalf("GetUnresolvedCommands: %v", err)
+	}
+	if len(commands) != 1 || commands[0].BinaryName != "ll" || commands[0].Count != 2 {
+		t.Fatalf("unresolved = %+v, want ll x2", commands)
+	}
+	if want := filepath.Join(dir, "bin", "ll"); commands[0].BinaryPath != want {
+		t.Errorf("BinaryPath = %q, want %q", commands[0].BinaryPath, want)
+	}
+
+	// Nothing maps ll yet, so a replay leaves it alone.
+	replay, err := ReplayUnresolvedEvents(st)
+	if err != nil {
+		t.Fatalf("ReplayUnresolvedEvents: %v", err)
+	}
+	if replay.Replayed != 0 {
+		t.Errorf("Replayed = %d before the mapping exists, want 0", replay.Replayed)
+	}
+
+	// A scan registers the alias as a binary of eza.
+	insertPkg(t, st, "eza", []string{"/opt/homebrew/bin/eza", "/opt/homebrew/bin/ll"})
+	replay, err = ReplayUnresolvedEvents(st)
+	if err != nil {
+		t.Fatalf("ReplayUnresolvedEvents: %v", err)
+	}
+	if replay.Commands != 1 || replay.Replayed != 2 {
+		t.Errorf("replay = %+v, want 2 events of 1 command", replay)
+	}
+	waitForEvents(t, st, "eza", 2, 0)
+	if n, err := st.GetUnresolvedEventCount(); err != nil || n != 0 {
+		t.Errorf("GetUnresolvedEventCount = %d, %v; want 0", n, err)
+	}
+}
+
+func TestProcessUsageLog_NoIndexKeepsEntriesInLog(t *testing.T) {
+	home := setTestHome(t)
+	if err := os.MkdirAll(filepath.Join(home, ".brewprune"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	st := newTestStore(t)
+	appendShimLine(t, home, "jq")
+
+	if _, err := ProcessUsageLog(st); err != nil {
+		t.Fatalf("ProcessUsageLog: %v", err)
+	}
+	// Before the first scan entries stay in the log rather than being
+	// recorded as unresolved.
+	if n, err := st.GetUnresolvedEventCount(); err != nil || n != 0 {
+		t.Errorf("GetUnresolvedEventCount = %d, %v; want 0", n, err)
+	}
+}
+
+func TestReplayUnresolvedEvents_PrunesOldEntries(t *testing.T) {
+	st := newTestStore(t)
+	insertPkg(t, st, "jq", []string{"/opt/homebrew/bin/jq"})
+
+	old := time.Now().Add(-unresolvedRetention - 24*time.Hour)
+	for _, ts := range []time.Time{old, time.Now()} {
+		if err := st.InsertUnresolvedEvent("mystery", "/home/u/.brewprune/bin/mystery", ts); err != nil {
+			t.Fatalf("InsertUnresolvedEvent: %v", err)
+		}
+	}
+
+	replay, err := ReplayUnresolvedEvents(st)
+	if err != nil {
+		t.Fatalf("ReplayUnresolvedEvents: %v", err)
+	}
+	if replay.Pruned != 1 {
+		t.Errorf("Pruned = %d, want 1", replay.Pruned)
+	}
+	if n, err := st.GetUnresolvedEventCount(); err != nil || n != 1 {
+		t.Errorf("GetUnresolvedEventCount = %d, %v; want 1", n, err)
+	}
+}